@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	grpcclient "github.com/SabinGhost19/go-micro-payment/api/gateway/rest/grpcClient"
+	"github.com/SabinGhost19/go-micro-payment/api/gateway/rest/helper"
+	"github.com/SabinGhost19/go-micro-payment/api/gateway/rest/middleware"
+	paymentpb "github.com/SabinGhost19/go-micro-payment/proto/payment"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ListPayments pages through payments. Admins may filter by any user; everyone
+// else only sees their own payments.
+func ListPayments(c *gin.Context) {
+	req := &paymentpb.ListPaymentsRequest{
+		OrderId:     c.Query("order_id"),
+		UserId:      c.Query("user_id"),
+		Status:      c.Query("status"),
+		Provider:    c.Query("provider"),
+		CreatedFrom: c.Query("created_from"),
+		CreatedTo:   c.Query("created_to"),
+		PageToken:   c.Query("page_token"),
+	}
+	if size := c.Query("page_size"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 0 {
+			helper.SendError(c, http.StatusBadRequest, "Invalid page_size", size)
+			return
+		}
+		req.PageSize = int32(n)
+	}
+	if !middleware.IsAdmin(c) {
+		caller := middleware.CallerID(c)
+		if req.UserId != "" && req.UserId != caller {
+			helper.SendError(c, http.StatusForbidden, "Forbidden", "payments of other users are not visible")
+			return
+		}
+		req.UserId = caller
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := grpcclient.PaymentClient.ListPayments(ctx, req)
+	if err != nil {
+		helper.HandleGrpcError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, res)
+}
+
+// GetPaymentsForOrder returns every payment of an order with its attempts and
+// refunds, to admins and to the user who owns the order's payments
+func GetPaymentsForOrder(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := grpcclient.PaymentClient.GetPaymentsForOrder(ctx, &paymentpb.GetPaymentsForOrderRequest{OrderId: c.Param("id")})
+	if err != nil {
+		helper.HandleGrpcError(c, err)
+		return
+	}
+	if !middleware.IsAdmin(c) {
+		caller := middleware.CallerID(c)
+		for _, p := range res.Payments {
+			if p.Payment.GetUserId() != caller {
+				helper.SendError(c, http.StatusForbidden, "Forbidden", "order belongs to another user")
+				return
+			}
+		}
+	}
+
+	helper.SendSuccess(c, http.StatusOK, res)
+}
+
+// GetPayment returns one payment to an admin or to its owner
+func GetPayment(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := grpcclient.PaymentClient.CheckPaymentStatus(ctx, &paymentpb.CheckPaymentStatusRequest{PaymentId: c.Param("id")})
+	if err != nil {
+		helper.HandleGrpcError(c, err)
+		return
+	}
+	if !middleware.IsAdmin(c) && res.UserId != middleware.CallerID(c) {
+		helper.SendError(c, http.StatusForbidden, "Forbidden", "payment belongs to another user")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, res)
+}
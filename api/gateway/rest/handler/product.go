@@ -26,5 +26,5 @@ func GetProduct(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, resp.Product)
+	c.JSON(http.StatusOK, resp)
 }
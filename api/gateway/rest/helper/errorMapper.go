@@ -2,6 +2,7 @@ package helper
 
 import (
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"net/http"
 	"strings"
@@ -15,7 +16,9 @@ func HandleGrpcError(c *gin.Context, err error) {
 	}
 
 	switch {
-	case strings.Contains(strings.ToLower(st.Message()), "not found"):
+	case st.Code() == codes.InvalidArgument:
+		SendError(c, http.StatusBadRequest, "Invalid argument", st.Message())
+	case st.Code() == codes.NotFound, strings.Contains(strings.ToLower(st.Message()), "not found"):
 		SendError(c, http.StatusNotFound, "Resource not found", st.Message())
 	case strings.Contains(strings.ToLower(st.Message()), "invalid credentials"):
 		SendError(c, http.StatusUnauthorized, "Invalid credentials", st.Message())
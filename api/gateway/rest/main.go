@@ -2,6 +2,7 @@ package main
 
 import (
 	grpcclient "github.com/SabinGhost19/go-micro-payment/api/gateway/rest/grpcClient"
+	"github.com/SabinGhost19/go-micro-payment/api/gateway/rest/middleware"
 	"github.com/SabinGhost19/go-micro-payment/api/gateway/rest/routes"
 	"log"
 	"os"
@@ -17,9 +18,14 @@ func main() {
 		"inventory":    "localhost:50054",
 		"notification": "localhost:50053",
 	})
-	
+
+	auth := middleware.NewAuthConfig(
+		os.Getenv("JWT_SECRET"),             // same secret as the user service
+		os.Getenv("GATEWAY_ADMIN_USER_IDS"), // e.g., "user-1,user-2"
+	)
+
 	//get gin router
-	r := routes.NewRouter()
+	r := routes.NewRouter(auth)
 	port := os.Getenv("GATEWAY_PORT")
 	if port == "" {
 		port = "8080"
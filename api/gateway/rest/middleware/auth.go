@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/SabinGhost19/go-micro-payment/api/gateway/rest/helper"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"strings"
+)
+
+// context keys set by RequireAuth
+const (
+	UserIDKey  = "user_id"
+	IsAdminKey = "is_admin"
+)
+
+// AuthConfig verifies the access tokens issued by the user service
+type AuthConfig struct {
+	Secret   []byte          // JWT_SECRET shared with the user service
+	AdminIDs map[string]bool // users allowed to see every user's data
+}
+
+// NewAuthConfig builds a config from the shared secret and a comma-separated
+// list of admin user IDs
+func NewAuthConfig(secret, adminIDs string) AuthConfig {
+	cfg := AuthConfig{Secret: []byte(secret), AdminIDs: map[string]bool{}}
+	for _, id := range strings.Split(adminIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			cfg.AdminIDs[id] = true
+		}
+	}
+	return cfg
+}
+
+// RequireAuth rejects requests without a valid Bearer token and stores the
+// caller's user ID and admin flag on the context
+func RequireAuth(cfg AuthConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := cfg.verify(c.GetHeader("Authorization"))
+		if err != nil {
+			helper.SendError(c, http.StatusUnauthorized, "Unauthorized", err.Error())
+			c.Abort()
+			return
+		}
+		c.Set(UserIDKey, userID)
+		c.Set(IsAdminKey, cfg.AdminIDs[userID])
+		c.Next()
+	}
+}
+
+// verify returns the user ID of a valid "Bearer <token>" header
+func (cfg AuthConfig) verify(header string) (string, error) {
+	if len(cfg.Secret) == 0 {
+		return "", errors.New("authentication is not configured")
+	}
+	raw, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || raw == "" {
+		return "", errors.New("missing bearer token")
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		return cfg.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", errors.New("invalid token")
+	}
+	userID, _ := claims["user_id"].(string)
+	if userID == "" {
+		return "", errors.New("token has no user_id")
+	}
+	return userID, nil
+}
+
+// CallerID returns the authenticated user's ID
+func CallerID(c *gin.Context) string {
+	return c.GetString(UserIDKey)
+}
+
+// IsAdmin reports whether the authenticated user is an admin
+func IsAdmin(c *gin.Context) bool {
+	return c.GetBool(IsAdminKey)
+}
@@ -2,17 +2,24 @@ package routes
 
 import (
 	"github.com/SabinGhost19/go-micro-payment/api/gateway/rest/handler"
+	"github.com/SabinGhost19/go-micro-payment/api/gateway/rest/middleware"
 	"github.com/gin-gonic/gin"
 )
 
 // router config
-func NewRouter() *gin.Engine {
+func NewRouter(auth middleware.AuthConfig) *gin.Engine {
 	r := gin.Default()
 
 	// USER endpoints
 	r.POST("/users", handler.RegisterUser)
 	r.GET("/users/:id", handler.GetUser)
 	r.POST("user/auth", handler.AuthenticateUser)
+
+	// PAYMENT lookups, for admins and the owning user
+	authed := r.Group("/", middleware.RequireAuth(auth))
+	authed.GET("/payments", handler.ListPayments)
+	authed.GET("/payments/:id", handler.GetPayment)
+	authed.GET("/orders/:id/payments", handler.GetPaymentsForOrder)
 	//
 	//// PRODUCT endpoints
 	//r.GET("/products", handler.ListProducts)
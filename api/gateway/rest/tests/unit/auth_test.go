@@ -0,0 +1,182 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	grpcclient "github.com/SabinGhost19/go-micro-payment/api/gateway/rest/grpcClient"
+	"github.com/SabinGhost19/go-micro-payment/api/gateway/rest/handler"
+	"github.com/SabinGhost19/go-micro-payment/api/gateway/rest/middleware"
+	paymentpb "github.com/SabinGhost19/go-micro-payment/proto/payment"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+const testSecret = "test-secret"
+
+// fakePaymentClient serves the payment calls behind the gateway's payment
+// routes; the other methods are left nil and panic if called
+type fakePaymentClient struct {
+	paymentpb.PaymentServiceClient
+	payments   map[string]*paymentpb.PaymentResponse
+	listedUser string
+}
+
+func (c *fakePaymentClient) CheckPaymentStatus(_ context.Context, in *paymentpb.CheckPaymentStatusRequest, _ ...grpc.CallOption) (*paymentpb.PaymentResponse, error) {
+	return c.payments[in.PaymentId], nil
+}
+
+func (c *fakePaymentClient) ListPayments(_ context.Context, in *paymentpb.ListPaymentsRequest, _ ...grpc.CallOption) (*paymentpb.ListPaymentsResponse, error) {
+	c.listedUser = in.UserId
+	return &paymentpb.ListPaymentsResponse{}, nil
+}
+
+func (c *fakePaymentClient) GetPaymentsForOrder(_ context.Context, in *paymentpb.GetPaymentsForOrderRequest, _ ...grpc.CallOption) (*paymentpb.OrderPaymentsResponse, error) {
+	res := &paymentpb.OrderPaymentsResponse{OrderId: in.OrderId}
+	for _, p := range c.payments {
+		if p.OrderId == in.OrderId {
+			res.Payments = append(res.Payments, &paymentpb.OrderPayment{Payment: p})
+		}
+	}
+	return res, nil
+}
+
+func newRouter(t *testing.T) (*gin.Engine, *fakePaymentClient) {
+	gin.SetMode(gin.TestMode)
+	client := &fakePaymentClient{payments: map[string]*paymentpb.PaymentResponse{
+		"pay-1": {PaymentId: "pay-1", OrderId: "order-1", UserId: "user-1"},
+		"pay-2": {PaymentId: "pay-2", OrderId: "order-2", UserId: "user-2"},
+	}}
+	previous := grpcclient.PaymentClient
+	grpcclient.PaymentClient = client
+	t.Cleanup(func() { grpcclient.PaymentClient = previous })
+
+	r := gin.New()
+	auth := r.Group("/", middleware.RequireAuth(middleware.NewAuthConfig(testSecret, " admin-1 ,")))
+	auth.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": middleware.CallerID(c), "is_admin": middleware.IsAdmin(c)})
+	})
+	auth.GET("/payments", handler.ListPayments)
+	auth.GET("/payments/:id", handler.GetPayment)
+	auth.GET("/orders/:id/payments", handler.GetPaymentsForOrder)
+	return r, client
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func tokenFor(t *testing.T, userID string) string {
+	return signToken(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{
+		"user_id": userID,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+}
+
+func get(r *gin.Engine, path, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireAuth(t *testing.T) {
+	r, _ := newRouter(t)
+	expired := time.Now().Add(-time.Minute).Unix()
+	later := time.Now().Add(time.Hour).Unix()
+
+	rejected := []struct {
+		name   string
+		header string
+	}{
+		{"missing header", ""},
+		{"not a bearer token", "Basic " + tokenFor(t, "user-1")},
+		{"empty bearer token", "Bearer "},
+		{"malformed token", "Bearer not.a.jwt"},
+		{"wrong secret", "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte("other-secret"), jwt.MapClaims{"user_id": "user-1", "exp": later})},
+		{"wrong algorithm", "Bearer " + signToken(t, jwt.SigningMethodHS512, []byte(testSecret), jwt.MapClaims{"user_id": "user-1", "exp": later})},
+		{"unsigned token", "Bearer " + signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.MapClaims{"user_id": "user-1", "exp": later})},
+		{"expired token", "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"user_id": "user-1", "exp": expired})},
+		{"token without expiry", "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"user_id": "user-1"})},
+		{"missing user_id", "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"exp": later})},
+		{"non-string user_id", "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"user_id": 42, "exp": later})},
+	}
+	for _, tc := range rejected {
+		t.Run(tc.name, func(t *testing.T) {
+			w := get(r, "/whoami", tc.header)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		w := get(r, "/whoami", "Bearer "+tokenFor(t, "user-1"))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"user_id":"user-1","is_admin":false}`, w.Body.String())
+	})
+
+	t.Run("admin token", func(t *testing.T) {
+		w := get(r, "/whoami", "Bearer "+tokenFor(t, "admin-1"))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"user_id":"admin-1","is_admin":true}`, w.Body.String())
+	})
+
+	t.Run("unconfigured secret rejects every token", func(t *testing.T) {
+		r := gin.New()
+		r.GET("/whoami", middleware.RequireAuth(middleware.NewAuthConfig("", "")), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		w := get(r, "/whoami", "Bearer "+tokenFor(t, "user-1"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestPaymentAccess(t *testing.T) {
+	r, client := newRouter(t)
+	owner := "Bearer " + tokenFor(t, "user-1")
+	admin := "Bearer " + tokenFor(t, "admin-1")
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		status int
+	}{
+		{"owner reads their payment", "/payments/pay-1", owner, http.StatusOK},
+		{"owner cannot read another user's payment", "/payments/pay-2", owner, http.StatusForbidden},
+		{"admin reads any payment", "/payments/pay-2", admin, http.StatusOK},
+		{"owner reads their order's payments", "/orders/order-1/payments", owner, http.StatusOK},
+		{"owner cannot read another user's order", "/orders/order-2/payments", owner, http.StatusForbidden},
+		{"admin reads any order's payments", "/orders/order-2/payments", admin, http.StatusOK},
+		{"owner cannot list another user's payments", "/payments?user_id=user-2", owner, http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := get(r, tc.path, tc.header)
+			assert.Equal(t, tc.status, w.Code, w.Body.String())
+		})
+	}
+
+	t.Run("owner listing is limited to themselves", func(t *testing.T) {
+		w := get(r, "/payments", owner)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user-1", client.listedUser)
+	})
+
+	t.Run("admin lists any user's payments", func(t *testing.T) {
+		w := get(r, "/payments?user_id=user-2", admin)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "user-2", client.listedUser)
+	})
+}
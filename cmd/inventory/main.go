@@ -5,6 +5,7 @@ import (
 	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
 	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
 	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/allocation"
 	"github.com/SabinGhost19/go-micro-payment/services/inventory/handler"
 	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
 	"github.com/SabinGhost19/go-micro-payment/services/inventory/repository"
@@ -15,6 +16,7 @@ import (
 	"log"
 	"net"
 	"os"
+	"time"
 )
 
 // productGrpcClient implements the ProductGrpcClient interface
@@ -30,10 +32,13 @@ func (c *productGrpcClient) GetProduct(ctx context.Context, productID string) (*
 // main initializes and runs the Inventory Service
 func main() {
 	// load environment variables
-	dbDSN := os.Getenv("DB_DSN")                            // e.g., "host=postgres user=admin password=secret dbname=inventory port=5432 sslmode=disable"
-	kafkaBrokers := []string{os.Getenv("KAFKA_BROKERS")}    // e.g., ["kafka:9092"]
-	grpcPort := os.Getenv("INVENTORY_SERVICE_GRPC_PORT")    // e.g., ":50054"
-	productServiceAddr := os.Getenv("PRODUCT_SERVICE_ADDR") // e.g., "product-service:50055"
+	dbDSN := os.Getenv("DB_DSN")                             // e.g., "host=postgres user=admin password=secret dbname=inventory port=5432 sslmode=disable"
+	kafkaBrokers := []string{os.Getenv("KAFKA_BROKERS")}     // e.g., ["kafka:9092"]
+	grpcPort := os.Getenv("INVENTORY_SERVICE_GRPC_PORT")     // e.g., ":50054"
+	productServiceAddr := os.Getenv("PRODUCT_SERVICE_ADDR")  // e.g., "product-service:50055"
+	reservationTTL := os.Getenv("RESERVATION_TTL")           // e.g., "15m" (default), how long stock is held for an unpaid order
+	sweepInterval := os.Getenv("RESERVATION_SWEEP_INTERVAL") // e.g., "1m" (default), how often expired reservations are released
+	strategy := os.Getenv("ALLOCATION_STRATEGY")             // e.g., "priority" (default), "nearest" or "split"
 
 	// initialize database
 	db, err := gorm.Open(postgres.Open(dbDSN), &gorm.Config{})
@@ -41,7 +46,8 @@ func main() {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
 	// auto-migrate schema
-	if err := db.AutoMigrate(&model.Product{}); err != nil {
+	if err := db.AutoMigrate(&model.Product{}, &model.Reservation{}, &model.ReservationItem{},
+		&model.Location{}, &model.LocationStock{}, &model.StockTransfer{}, &model.StockMovement{}, &model.Backorder{}); err != nil {
 		log.Fatalf("failed to migrate database: %v", err)
 	}
 
@@ -62,7 +68,19 @@ func main() {
 
 	// initialize repository, service, and handler
 	repo := repository.NewPostgresInventoryRepository(db)
+	// stock kept before locations existed lives in the default location
+	if err := repo.EnsureDefaultLocation(context.Background()); err != nil {
+		log.Fatalf("failed to create default location: %v", err)
+	}
 	svc := service.NewInventoryService(repo, kafkaProducer, productClient)
+	if svc.DefaultStrategy, err = allocation.ParseStrategy(strategy); err != nil {
+		log.Fatalf("invalid ALLOCATION_STRATEGY: %v", err)
+	}
+	if reservationTTL != "" {
+		if svc.ReservationTTL, err = time.ParseDuration(reservationTTL); err != nil {
+			log.Fatalf("invalid RESERVATION_TTL: %v", err)
+		}
+	}
 	h := handler.NewInventoryHandler(svc)
 
 	// start gRPC server
@@ -74,9 +92,19 @@ func main() {
 	inventorypb.RegisterInventoryServiceServer(grpcServer, h)
 	log.Printf("Inventory Service gRPC server running on %s", grpcPort)
 
-	// start Kafka consumer for product events
+	// start sweeper for expired reservations
+	interval := time.Minute
+	if sweepInterval != "" {
+		if interval, err = time.ParseDuration(sweepInterval); err != nil {
+			log.Fatalf("invalid RESERVATION_SWEEP_INTERVAL: %v", err)
+		}
+	}
+	go svc.RunReservationSweeper(context.Background(), interval)
+	log.Printf("Stock is reserved for %s; expired reservations are swept every %s", svc.ReservationTTL, interval)
+
+	// start Kafka consumer for product and payment events
 	go func() {
-		if err := svc.ConsumeProductEvents(context.Background()); err != nil {
+		if err := svc.ConsumeEvents(context.Background()); err != nil {
 			log.Fatalf("failed to start Kafka consumer: %v", err)
 		}
 	}()
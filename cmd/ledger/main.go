@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/proto/ledger"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/handler"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/model"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/service"
+	"google.golang.org/grpc"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"log"
+	"net"
+	"os"
+)
+
+// main initializes and runs the Ledger Service
+func main() {
+	// load environment variables
+	dbDSN := os.Getenv("DB_DSN")                         // e.g., "host=postgres user=admin password=secret dbname=ledger port=5432 sslmode=disable"
+	kafkaBrokers := []string{os.Getenv("KAFKA_BROKERS")} // e.g., ["kafka:9092"]
+	grpcPort := os.Getenv("LEDGER_SERVICE_GRPC_PORT")    // e.g., ":50057"
+
+	// initialize database
+	db, err := gorm.Open(postgres.Open(dbDSN), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	// auto-migrate schema
+	if err := db.AutoMigrate(&model.Account{}, &model.JournalEntry{}, &model.JournalLine{}); err != nil {
+		log.Fatalf("failed to migrate database: %v", err)
+	}
+	// journal rows are append-only
+	if err := repository.InstallImmutabilityGuards(db); err != nil {
+		log.Fatalf("failed to install ledger immutability guards: %v", err)
+	}
+
+	// initialize Kafka producer for dead letters
+	kafkaProducer, err := kafka.NewProducer(kafkaBrokers)
+	if err != nil {
+		log.Fatalf("failed to initialize Kafka producer: %v", err)
+	}
+	defer kafkaProducer.Close()
+
+	// initialize repository, service, and handler
+	repo := repository.NewPostgresLedgerRepository(db)
+	svc := service.New(repo, kafkaProducer)
+	if err := svc.Setup(context.Background()); err != nil {
+		log.Fatalf("failed to create ledger accounts: %v", err)
+	}
+	h := handler.NewLedgerHandler(svc)
+
+	// start gRPC server
+	lis, err := net.Listen("tcp", grpcPort)
+	if err != nil {
+		log.Fatalf("failed to listen on %s: %v", grpcPort, err)
+	}
+	grpcServer := grpc.NewServer()
+	ledgerpb.RegisterLedgerServiceServer(grpcServer, h)
+	log.Printf("Ledger Service gRPC server running on %s", grpcPort)
+
+	// start Kafka consumer for payment, refund, dispute and wallet events
+	go func() {
+		if err := svc.ConsumeEvents(context.Background()); err != nil {
+			log.Fatalf("failed to start Kafka consumer: %v", err)
+		}
+	}()
+
+	// serve gRPC
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Fatalf("failed to serve gRPC: %v", err)
+	}
+}
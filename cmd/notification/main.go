@@ -14,6 +14,7 @@ import (
 	"log"
 	"net"
 	"os"
+	"strings"
 )
 
 // mockEmailSender is a placeholder for an email sending implementation
@@ -52,6 +53,12 @@ func main() {
 	repo := repository.NewPostgresNotificationRepository(db)
 	emailSender := &mockEmailSender{} // replace with real implementation
 	svc := service.New(repo, emailSender, kafkaProducer)
+	// e.g., "ops@example.com,warehouse@example.com"
+	for _, recipient := range strings.Split(os.Getenv("OPS_ALERT_RECIPIENTS"), ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			svc.OpsRecipients = append(svc.OpsRecipients, recipient)
+		}
+	}
 	h := handler.NewNotificationHandler(svc)
 
 	// start gRPC server
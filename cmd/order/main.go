@@ -7,9 +7,11 @@ import (
 	orderpb "github.com/SabinGhost19/go-micro-payment/proto/order"
 	paymentpb "github.com/SabinGhost19/go-micro-payment/proto/payment"
 	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
+	userpb "github.com/SabinGhost19/go-micro-payment/proto/user"
 	"github.com/SabinGhost19/go-micro-payment/services/order/handler"
 	"github.com/SabinGhost19/go-micro-payment/services/order/model"
 	"github.com/SabinGhost19/go-micro-payment/services/order/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/order/risk"
 	"github.com/SabinGhost19/go-micro-payment/services/order/service"
 	"google.golang.org/grpc"
 	"gorm.io/driver/postgres"
@@ -17,6 +19,7 @@ import (
 	"log"
 	"net"
 	"os"
+	"time"
 )
 
 // paymentGrpcClient implements the PaymentGrpcClient interface
@@ -25,12 +28,13 @@ type paymentGrpcClient struct {
 }
 
 // InitiatePayment calls the Payment Service's gRPC endpoint
-func (c *paymentGrpcClient) InitiatePayment(ctx context.Context, orderID, userID string, amount float64, currency string) (string, string, error) {
+func (c *paymentGrpcClient) InitiatePayment(ctx context.Context, orderID, userID string, amount float64, currency, captureMethod string) (string, string, error) {
 	resp, err := c.client.InitiatePayment(ctx, &paymentpb.InitiatePaymentRequest{
-		OrderId:  orderID,
-		UserId:   userID,
-		Amount:   amount,
-		Currency: currency,
+		OrderId:       orderID,
+		UserId:        userID,
+		Amount:        amount,
+		Currency:      currency,
+		CaptureMethod: captureMethod,
 	})
 	if err != nil {
 		return "", "", err
@@ -38,6 +42,37 @@ func (c *paymentGrpcClient) InitiatePayment(ctx context.Context, orderID, userID
 	return resp.PaymentId, resp.Status, nil
 }
 
+// CapturePayment calls the Payment Service's gRPC endpoint to capture the full authorized amount
+func (c *paymentGrpcClient) CapturePayment(ctx context.Context, paymentID string) (string, error) {
+	resp, err := c.client.CapturePayment(ctx, &paymentpb.CapturePaymentRequest{PaymentId: paymentID})
+	if err != nil {
+		return "", err
+	}
+	return resp.Status, nil
+}
+
+// VoidPayment calls the Payment Service's gRPC endpoint to release an uncaptured payment
+func (c *paymentGrpcClient) VoidPayment(ctx context.Context, paymentID, reason string) (string, error) {
+	resp, err := c.client.VoidPayment(ctx, &paymentpb.VoidPaymentRequest{PaymentId: paymentID, Reason: reason})
+	if err != nil {
+		return "", err
+	}
+	return resp.Status, nil
+}
+
+// RefundPayment calls the Payment Service's gRPC endpoint to refund the remaining amount
+func (c *paymentGrpcClient) RefundPayment(ctx context.Context, paymentID, reason, idempotencyKey string) (string, error) {
+	resp, err := c.client.RefundPayment(ctx, &paymentpb.RefundPaymentRequest{
+		PaymentId:      paymentID,
+		Reason:         reason,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Status, nil
+}
+
 // inventoryGrpcClient implements the InventoryGrpcClient interface
 type inventoryGrpcClient struct {
 	client inventorypb.InventoryServiceClient
@@ -49,11 +84,12 @@ func (c *inventoryGrpcClient) CheckStock(ctx context.Context, productID string)
 	if err != nil {
 		return 0, err
 	}
-	return resp.Available, nil
+	// backorderable units count too; the reservation decides what is backordered
+	return resp.Orderable, nil
 }
 
 // ReserveStock calls the Inventory Service's gRPC endpoint
-func (c *inventoryGrpcClient) ReserveStock(ctx context.Context, orderID string, items []inventorypb.StockItem) (bool, string, error) {
+func (c *inventoryGrpcClient) ReserveStock(ctx context.Context, orderID string, items []inventorypb.StockItem) (*inventorypb.ReserveStockResponse, error) {
 
 	// convert []inventorypb.StockItem -> []*inventorypb.StockItem
 	pbItems := make([]*inventorypb.StockItem, len(items))
@@ -61,14 +97,25 @@ func (c *inventoryGrpcClient) ReserveStock(ctx context.Context, orderID string,
 		pbItems[i] = &items[i]
 	}
 
-	resp, err := c.client.ReserveStock(ctx, &inventorypb.ReserveStockRequest{
+	return c.client.ReserveStock(ctx, &inventorypb.ReserveStockRequest{
 		OrderId: orderID,
 		Items:   pbItems,
 	})
-	if err != nil {
-		return false, "", err
-	}
-	return resp.Success, resp.Message, nil
+}
+
+// ReleaseReservation calls the Inventory Service's gRPC endpoint
+func (c *inventoryGrpcClient) ReleaseReservation(ctx context.Context, orderID, reason string) error {
+	_, err := c.client.ReleaseReservation(ctx, &inventorypb.ReleaseReservationRequest{OrderId: orderID, Reason: reason})
+	return err
+}
+
+// ExtendReservation calls the Inventory Service's gRPC endpoint
+func (c *inventoryGrpcClient) ExtendReservation(ctx context.Context, orderID string, expiresAt time.Time) error {
+	_, err := c.client.ExtendReservation(ctx, &inventorypb.ExtendReservationRequest{
+		OrderId:   orderID,
+		ExpiresAt: expiresAt.Format(time.RFC3339),
+	})
+	return err
 }
 
 // productGrpcClient implements the ProductGrpcClient interface
@@ -81,6 +128,16 @@ func (c *productGrpcClient) GetProduct(ctx context.Context, productID string) (*
 	return c.client.GetProduct(ctx, &productpb.GetProductRequest{ProductId: productID})
 }
 
+// userGrpcClient implements the UserGrpcClient interface
+type userGrpcClient struct {
+	client userpb.UserServiceClient
+}
+
+// GetUser calls the User Service's gRPC endpoint
+func (c *userGrpcClient) GetUser(ctx context.Context, userID string) (*userpb.UserResponse, error) {
+	return c.client.GetUser(ctx, &userpb.GetUserRequest{UserId: userID})
+}
+
 // main initializes and runs the Order Service
 func main() {
 	// load environment variables
@@ -90,6 +147,10 @@ func main() {
 	paymentServiceAddr := os.Getenv("PAYMENT_SERVICE_ADDR")     // e.g., "payment-service:50052"
 	inventoryServiceAddr := os.Getenv("INVENTORY_SERVICE_ADDR") // e.g., "inventory-service:50054"
 	productServiceAddr := os.Getenv("PRODUCT_SERVICE_ADDR")     // e.g., "product-service:50055"
+	userServiceAddr := os.Getenv("USER_SERVICE_ADDR")           // e.g., "user-service:50056"
+	reviewHold := os.Getenv("REVIEW_HOLD")                      // e.g., "72h" (default), how long stock is held for an order in review
+	paymentHold := os.Getenv("PAYMENT_HOLD")                    // e.g., "24h" (default), how long stock is held for a payment attempt
+	captureMethod := os.Getenv("CAPTURE_METHOD")                // e.g., "manual" (default, captured by ShipOrder) or "automatic"
 
 	// initialize database
 	db, err := gorm.Open(postgres.Open(dbDSN), &gorm.Config{})
@@ -132,9 +193,35 @@ func main() {
 	defer conn.Close()
 	productClient := &productGrpcClient{client: productpb.NewProductServiceClient(conn)}
 
-	// initialize repository, service, and handler
+	// initialize gRPC client for User Service
+	conn, err = grpc.Dial(userServiceAddr, grpc.WithInsecure())
+	if err != nil {
+		log.Fatalf("failed to connect to User Service: %v", err)
+	}
+	defer conn.Close()
+	userClient := &userGrpcClient{client: userpb.NewUserServiceClient(conn)}
+
+	// initialize repository, risk engine, service, and handler
 	repo := repository.NewPostgresOrderRepository(db)
-	svc := service.New(repo, kafkaProducer, paymentClient, inventoryClient, productClient)
+	riskEngine := risk.NewRuleEngine(risk.DefaultConfig(), repo)
+	svc := service.New(repo, kafkaProducer, paymentClient, inventoryClient, productClient, userClient, riskEngine)
+	if reviewHold != "" {
+		if svc.ReviewHold, err = time.ParseDuration(reviewHold); err != nil {
+			log.Fatalf("invalid REVIEW_HOLD: %v", err)
+		}
+	}
+	if paymentHold != "" {
+		if svc.PaymentHold, err = time.ParseDuration(paymentHold); err != nil {
+			log.Fatalf("invalid PAYMENT_HOLD: %v", err)
+		}
+	}
+	switch captureMethod {
+	case "":
+	case "manual", "automatic":
+		svc.CaptureMethod = captureMethod
+	default:
+		log.Fatalf("invalid CAPTURE_METHOD: %q", captureMethod)
+	}
 	h := handler.NewOrderHandler(svc)
 
 	// start gRPC server
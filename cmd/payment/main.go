@@ -1,31 +1,69 @@
 package main
 
 import (
+	"context"
 	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
 	"github.com/SabinGhost19/go-micro-payment/proto/payment"
 	"github.com/SabinGhost19/go-micro-payment/services/payment/handler"
 	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
 	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
 	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
-	"github.com/stripe/stripe-go/v74"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/wallet"
 	"google.golang.org/grpc"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"time"
 )
 
 // main initializes and runs the Payment Service
 func main() {
 	// load environment variables
-	dbDSN := os.Getenv("DB_DSN")                         // e.g., "host=postgres user=admin password=secret dbname=payments port=5432 sslmode=disable"
-	kafkaBrokers := []string{os.Getenv("KAFKA_BROKERS")} // e.g., ["kafka:9092"]
-	grpcPort := os.Getenv("PAYMENT_SERVICE_GRPC_PORT")   // e.g., ":50052"
-	stripeKey := os.Getenv("STRIPE_API_KEY")             // Stripe API key
+	dbDSN := os.Getenv("DB_DSN")                                // e.g., "host=postgres user=admin password=secret dbname=payments port=5432 sslmode=disable"
+	kafkaBrokers := []string{os.Getenv("KAFKA_BROKERS")}        // e.g., ["kafka:9092"]
+	grpcPort := os.Getenv("PAYMENT_SERVICE_GRPC_PORT")          // e.g., ":50052"
+	httpPort := os.Getenv("PAYMENT_SERVICE_HTTP_PORT")          // e.g., ":8082", serves provider webhooks
+	defaultProvider := os.Getenv("PAYMENT_PROVIDER")            // e.g., "stripe" or "simulator" (default)
+	stripeKey := os.Getenv("STRIPE_API_KEY")                    // only required when Stripe is selected
+	stripeSuccessURL := os.Getenv("STRIPE_SUCCESS_URL")         // e.g., "https://your-app.com/payment/success"
+	stripeCancelURL := os.Getenv("STRIPE_CANCEL_URL")           // e.g., "https://your-app.com/payment/cancel"
+	stripeWebhookSecret := os.Getenv("STRIPE_WEBHOOK_SECRET")   // e.g., "whsec_..."
+	reconcileInterval := os.Getenv("RECONCILE_INTERVAL")        // e.g., "1h"; empty disables the reconciler
+	reconcileWindow := os.Getenv("RECONCILE_WINDOW")            // e.g., "48h" (default)
+	retrySchedule := os.Getenv("PAYMENT_RETRY_SCHEDULE")        // e.g., "1h,24h,72h" (default)
+	retryMaxAttempts := os.Getenv("PAYMENT_RETRY_MAX_ATTEMPTS") // e.g., "4"; "1" disables retries
+	retryInterval := os.Getenv("PAYMENT_RETRY_INTERVAL")        // e.g., "1m" (default), how often due retries are made
+	pendingTTL := os.Getenv("PAYMENT_PENDING_TTL")              // e.g., "24h"; empty disables the pending sweeper
+	sweepInterval := os.Getenv("PAYMENT_SWEEP_INTERVAL")        // e.g., "5m" (default), how often stale payments are swept
+	refundSyncInterval := os.Getenv("REFUND_SYNC_INTERVAL")     // e.g., "5m" (default), how often pending refunds are checked
+	evidenceDir := os.Getenv("DISPUTE_EVIDENCE_DIR")            // e.g., "/var/lib/payment/evidence"; "dispute-evidence" by default
+	simulatorEvents := os.Getenv("SIMULATOR_EVENTS_ENABLED")    // "true" serves /webhooks/simulator for triggering disputes; never in production
+	feeSchedule := os.Getenv("PAYMENT_FEE_SCHEDULE")            // e.g., "stripe:capture:*:USD=2.9%+0.30,stripe:capture:amex:*=3.5%+0.30"; empty records no fees
+	if defaultProvider == "" {
+		defaultProvider = provider.SimulatorName
+	}
+	if evidenceDir == "" {
+		evidenceDir = "dispute-evidence"
+	}
 
-	// set Stripe API key
-	stripe.Key = stripeKey
+	// register payment providers; Stripe is only available when a key is configured
+	providers := provider.NewRegistry(defaultProvider)
+	simulator := provider.NewSimulator()
+	providers.Register(simulator)
+	if stripeKey != "" {
+		stripeProvider, err := provider.NewStripe(stripeKey, stripeSuccessURL, stripeCancelURL)
+		if err != nil {
+			log.Fatalf("failed to initialize Stripe provider: %v", err)
+		}
+		providers.Register(stripeProvider)
+	}
+	if _, err := providers.Get(defaultProvider); err != nil {
+		log.Fatalf("invalid PAYMENT_PROVIDER: %v", err)
+	}
 
 	// initialize database
 	db, err := gorm.Open(postgres.Open(dbDSN), &gorm.Config{})
@@ -33,7 +71,9 @@ func main() {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
 	// auto-migrate schema
-	if err := db.AutoMigrate(&model.Payment{}); err != nil {
+	if err := db.AutoMigrate(&model.Payment{}, &model.PaymentTransition{}, &model.PaymentAttempt{}, &model.Refund{}, &model.WebhookEvent{},
+		&model.ReconciliationReport{}, &model.ReconciliationItem{}, &model.PaymentMethod{},
+		&model.Dispute{}, &model.DisputeEvidence{}, &model.Wallet{}, &model.WalletTransaction{}); err != nil {
 		log.Fatalf("failed to migrate database: %v", err)
 	}
 
@@ -46,9 +86,89 @@ func main() {
 
 	// initialize repository, service, and handler
 	repo := repository.NewPostgresPaymentRepository(db)
-	svc := service.New(repo, kafkaProducer)
+	// store credit is always available as the "wallet" provider
+	providers.Register(wallet.NewProvider(repo))
+	svc := service.New(repo, kafkaProducer, providers)
+	if svc.RetryPolicy, err = service.ParseRetryPolicy(retrySchedule, retryMaxAttempts); err != nil {
+		log.Fatalf("invalid payment retry policy: %v", err)
+	}
+	if pendingTTL != "" {
+		if svc.PendingTTL, err = time.ParseDuration(pendingTTL); err != nil {
+			log.Fatalf("invalid PAYMENT_PENDING_TTL: %v", err)
+		}
+	}
+	svc.EvidenceDir = evidenceDir
+	if svc.Fees, err = service.ParseFeeSchedule(feeSchedule); err != nil {
+		log.Fatalf("invalid PAYMENT_FEE_SCHEDULE: %v", err)
+	}
 	h := handler.NewPaymentHandler(svc)
 
+	// start webhook HTTP server; Stripe webhooks are only accepted when a signing secret is configured
+	if httpPort != "" {
+		mux := http.NewServeMux()
+		if stripeWebhookSecret != "" {
+			mux.Handle("/webhooks/stripe", handler.NewStripeWebhookHandler(svc, stripeWebhookSecret))
+		}
+		if simulatorEvents == "true" {
+			mux.Handle("/webhooks/simulator", handler.NewSimulatorEventHandler(svc, simulator))
+		}
+		go func() {
+			log.Printf("Payment Service webhook server running on %s", httpPort)
+			if err := http.ListenAndServe(httpPort, mux); err != nil {
+				log.Fatalf("failed to serve webhooks: %v", err)
+			}
+		}()
+	}
+
+	// start reconciliation worker
+	if reconcileInterval != "" {
+		interval, err := time.ParseDuration(reconcileInterval)
+		if err != nil {
+			log.Fatalf("invalid RECONCILE_INTERVAL: %v", err)
+		}
+		window := 48 * time.Hour
+		if reconcileWindow != "" {
+			if window, err = time.ParseDuration(reconcileWindow); err != nil {
+				log.Fatalf("invalid RECONCILE_WINDOW: %v", err)
+			}
+		}
+		go svc.RunReconciler(context.Background(), interval, window)
+		log.Printf("Payment reconciliation running every %s over the last %s", interval, window)
+	}
+
+	// start retry worker for declined payments
+	if svc.RetryPolicy.MaxAttempts > 1 {
+		interval := time.Minute
+		if retryInterval != "" {
+			if interval, err = time.ParseDuration(retryInterval); err != nil {
+				log.Fatalf("invalid PAYMENT_RETRY_INTERVAL: %v", err)
+			}
+		}
+		go svc.RunRetryWorker(context.Background(), interval)
+		log.Printf("Payment retries after %v, at most %d attempts", svc.RetryPolicy.Schedule, svc.RetryPolicy.MaxAttempts)
+	}
+
+	// start sweeper for payments stuck in PENDING
+	if svc.PendingTTL > 0 {
+		interval := 5 * time.Minute
+		if sweepInterval != "" {
+			if interval, err = time.ParseDuration(sweepInterval); err != nil {
+				log.Fatalf("invalid PAYMENT_SWEEP_INTERVAL: %v", err)
+			}
+		}
+		go svc.RunPendingSweeper(context.Background(), interval)
+		log.Printf("Payments PENDING for longer than %s are swept every %s", svc.PendingTTL, interval)
+	}
+
+	// start sync of refunds the provider has not settled yet
+	refundInterval := 5 * time.Minute
+	if refundSyncInterval != "" {
+		if refundInterval, err = time.ParseDuration(refundSyncInterval); err != nil {
+			log.Fatalf("invalid REFUND_SYNC_INTERVAL: %v", err)
+		}
+	}
+	go svc.RunRefundSync(context.Background(), refundInterval)
+
 	// start gRPC server
 	lis, err := net.Listen("tcp", grpcPort)
 	if err != nil {
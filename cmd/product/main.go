@@ -22,16 +22,9 @@ type inventoryGrpcClient struct {
 	client inventorypb.InventoryServiceClient
 }
 
-// UpdateStock calls the Inventory Service's gRPC endpoint
-func (c *inventoryGrpcClient) UpdateStock(ctx context.Context, productID string, stockDelta int32) (int32, error) {
-	resp, err := c.client.UpdateStock(ctx, &inventorypb.UpdateStockRequest{
-		ProductId:  productID,
-		StockDelta: stockDelta,
-	})
-	if err != nil {
-		return 0, err
-	}
-	return resp.NewStock, nil
+// CheckStock calls the Inventory Service's gRPC endpoint
+func (c *inventoryGrpcClient) CheckStock(ctx context.Context, productID string) (*inventorypb.CheckStockResponse, error) {
+	return c.client.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: productID})
 }
 
 // main initializes and runs the Product Service
@@ -81,6 +74,13 @@ func main() {
 	productpb.RegisterProductServiceServer(grpcServer, h)
 	log.Printf("Product Service gRPC server running on %s", grpcPort)
 
+	// start Kafka consumer for stock events
+	go func() {
+		if err := svc.ConsumeEvents(context.Background()); err != nil {
+			log.Fatalf("failed to start Kafka consumer: %v", err)
+		}
+	}()
+
 	// serve gRPC
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("failed to serve gRPC: %v", err)
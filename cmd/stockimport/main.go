@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/stockcount"
+	"google.golang.org/grpc"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// main imports a stock count file into the Inventory Service: every counted
+// quantity that differs from the system quantity is applied as a cycle count
+func main() {
+	addr := flag.String("addr", os.Getenv("INVENTORY_SERVICE_ADDR"), "Inventory Service address, e.g. inventory-service:50054")
+	format := flag.String("format", "", "csv or json; taken from the file extension when empty")
+	dryRun := flag.Bool("dry-run", false, "only report the differences, change nothing")
+	reference := flag.String("reference", "", "recorded on the movements, e.g. the count sheet ID")
+	actor := flag.String("actor", os.Getenv("USER"), "who counted")
+	timeout := flag.Duration("timeout", 5*time.Minute, "how long the import may take")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: stockimport [flags] <file|->\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *addr == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// open the count file; - reads standard input
+	path := flag.Arg(0)
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("failed to open %s: %v", path, err)
+		}
+		defer f.Close()
+		in = f
+	}
+	if *format == "" {
+		*format = filepath.Ext(path)
+	}
+	countFormat, err := stockcount.ParseFormat(*format)
+	if err != nil {
+		log.Fatalf("invalid format: %v", err)
+	}
+	rows, err := stockcount.NewReader(in, countFormat)
+	if err != nil {
+		log.Fatalf("failed to read %s: %v", path, err)
+	}
+
+	// initialize gRPC client for Inventory Service
+	conn, err := grpc.Dial(*addr, grpc.WithInsecure())
+	if err != nil {
+		log.Fatalf("failed to connect to Inventory Service: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	stream, err := inventorypb.NewInventoryServiceClient(conn).ImportStockCounts(ctx)
+	if err != nil {
+		log.Fatalf("failed to start import: %v", err)
+	}
+
+	// stream the rows; rows that cannot be read are rejected here
+	var rejected []*inventorypb.RejectedStockCount
+	for {
+		row, err := rows.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *stockcount.RowError
+		if errors.As(err, &rowErr) {
+			rejected = append(rejected, &inventorypb.RejectedStockCount{Line: rowErr.Line, Reason: rowErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			log.Fatalf("failed to read %s: %v", path, err)
+		}
+		if err := stream.Send(&inventorypb.StockCountRow{
+			ProductId:  row.ProductID,
+			LocationId: row.LocationID,
+			Counted:    row.Counted,
+			Line:       row.Line,
+			DryRun:     *dryRun,
+			Reference:  *reference,
+			Actor:      *actor,
+		}); err != nil {
+			// the server ended the stream; CloseAndRecv returns why
+			break
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		log.Fatalf("failed to import stock counts: %v", err)
+	}
+	rejected = append(rejected, resp.Rejected...)
+	sort.SliceStable(rejected, func(i, j int) bool { return rejected[i].Line < rejected[j].Line })
+	report(resp, rejected, len(rejected)-len(resp.Rejected))
+}
+
+// report prints the differences of an import and a summary of its rejected rows
+func report(resp *inventorypb.ImportStockCountsResponse, rejected []*inventorypb.RejectedStockCount, unread int) {
+	verb := "applied"
+	if resp.DryRun {
+		verb = "found (dry run, nothing changed)"
+	}
+	fmt.Printf("%d of %d rows differ, %s; %d unchanged, %d rejected\n",
+		len(resp.Differences), int(resp.Rows)+unread, verb, resp.Unchanged, len(rejected))
+
+	if len(resp.Differences) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "line\tproduct\tlocation\tsystem\tcounted\tdelta\t")
+		for _, d := range resp.Differences {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%+d\t\n", d.Line, d.ProductId, d.LocationId, d.System, d.Counted, d.Delta)
+		}
+		w.Flush()
+	}
+	if len(rejected) > 0 {
+		fmt.Println("rejected:")
+		for _, r := range rejected {
+			if r.ProductId == "" {
+				fmt.Printf("  line %d: %s\n", r.Line, r.Reason)
+				continue
+			}
+			fmt.Printf("  line %d (%s at %s): %s\n", r.Line, r.ProductId, r.LocationId, r.Reason)
+		}
+	}
+}
@@ -22,6 +22,11 @@ func NewProducer(brokers []string) (*Producer, error) {
 	return &Producer{producer: producer}, nil
 }
 
+// NewProducerWithClient wraps an existing SyncProducer, e.g. a sarama mock in tests
+func NewProducerWithClient(producer sarama.SyncProducer) *Producer {
+	return &Producer{producer: producer}
+}
+
 // SendMessage sends a message to the specified topic
 func (p *Producer) SendMessage(ctx context.Context, topic, key string, value interface{}) error {
 	data, err := json.Marshal(value)
@@ -7,12 +7,11 @@
 package inventorypb
 
 import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
-
-	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
-	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 )
 
 const (
@@ -67,13 +66,15 @@ func (x *CheckStockRequest) GetProductId() string {
 	return ""
 }
 
-// Reserve stock for an model
+// Reserve stock for an order
 type ReserveStockRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	Items         []*StockItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	OrderId         string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Items           []*StockItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	Strategy        string                 `protobuf:"bytes,3,opt,name=strategy,proto3" json:"strategy,omitempty"`                                      // priority (default), nearest or split
+	ShippingCountry string                 `protobuf:"bytes,4,opt,name=shipping_country,json=shippingCountry,proto3" json:"shipping_country,omitempty"` // ISO 3166-1 alpha-2; used by nearest
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ReserveStockRequest) Reset() {
@@ -120,11 +121,29 @@ func (x *ReserveStockRequest) GetItems() []*StockItem {
 	return nil
 }
 
+func (x *ReserveStockRequest) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *ReserveStockRequest) GetShippingCountry() string {
+	if x != nil {
+		return x.ShippingCountry
+	}
+	return ""
+}
+
 // Update stock for a product
 type UpdateStockRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
 	StockDelta    int32                  `protobuf:"varint,2,opt,name=stock_delta,json=stockDelta,proto3" json:"stock_delta,omitempty"` // positive or negative delta
+	LocationId    string                 `protobuf:"bytes,3,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"`  // the default location when empty
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`                            // restock, adjustment or return; adjustment when empty
+	Reference     string                 `protobuf:"bytes,5,opt,name=reference,proto3" json:"reference,omitempty"`                      // e.g. the purchase order or return ID
+	Actor         string                 `protobuf:"bytes,6,opt,name=actor,proto3" json:"actor,omitempty"`                              // who made the change
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -173,29 +192,63 @@ func (x *UpdateStockRequest) GetStockDelta() int32 {
 	return 0
 }
 
-// Used for model reservation
-type StockItem struct {
+func (x *UpdateStockRequest) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *UpdateStockRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *UpdateStockRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *UpdateStockRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+// One counted row of a stock count import. dry_run, reference and actor are
+// read from the first row of the stream.
+type StockCountRow struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	LocationId    string                 `protobuf:"bytes,2,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"` // the default location when empty
+	Counted       int32                  `protobuf:"varint,3,opt,name=counted,proto3" json:"counted,omitempty"`
+	Line          int32                  `protobuf:"varint,4,opt,name=line,proto3" json:"line,omitempty"`                   // where the row came from, e.g. its line in a spreadsheet
+	DryRun        bool                   `protobuf:"varint,5,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"` // only report the differences
+	Reference     string                 `protobuf:"bytes,6,opt,name=reference,proto3" json:"reference,omitempty"`          // e.g. the count sheet ID
+	Actor         string                 `protobuf:"bytes,7,opt,name=actor,proto3" json:"actor,omitempty"`                  // who counted
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StockItem) Reset() {
-	*x = StockItem{}
+func (x *StockCountRow) Reset() {
+	*x = StockCountRow{}
 	mi := &file_inventory_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StockItem) String() string {
+func (x *StockCountRow) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StockItem) ProtoMessage() {}
+func (*StockCountRow) ProtoMessage() {}
 
-func (x *StockItem) ProtoReflect() protoreflect.Message {
+func (x *StockCountRow) ProtoReflect() protoreflect.Message {
 	mi := &file_inventory_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -207,48 +260,86 @@ func (x *StockItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StockItem.ProtoReflect.Descriptor instead.
-func (*StockItem) Descriptor() ([]byte, []int) {
+// Deprecated: Use StockCountRow.ProtoReflect.Descriptor instead.
+func (*StockCountRow) Descriptor() ([]byte, []int) {
 	return file_inventory_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *StockItem) GetProductId() string {
+func (x *StockCountRow) GetProductId() string {
 	if x != nil {
 		return x.ProductId
 	}
 	return ""
 }
 
-func (x *StockItem) GetQuantity() int32 {
+func (x *StockCountRow) GetLocationId() string {
 	if x != nil {
-		return x.Quantity
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *StockCountRow) GetCounted() int32 {
+	if x != nil {
+		return x.Counted
 	}
 	return 0
 }
 
-// Stock check response
-type CheckStockResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	Available     int32                  `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *StockCountRow) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
 }
 
-func (x *CheckStockResponse) Reset() {
-	*x = CheckStockResponse{}
+func (x *StockCountRow) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *StockCountRow) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *StockCountRow) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+// Move stock of a product between locations
+type TransferStockRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ProductId      string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	FromLocationId string                 `protobuf:"bytes,2,opt,name=from_location_id,json=fromLocationId,proto3" json:"from_location_id,omitempty"`
+	ToLocationId   string                 `protobuf:"bytes,3,opt,name=to_location_id,json=toLocationId,proto3" json:"to_location_id,omitempty"`
+	Quantity       int32                  `protobuf:"varint,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Actor          string                 `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"` // who made the change
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TransferStockRequest) Reset() {
+	*x = TransferStockRequest{}
 	mi := &file_inventory_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CheckStockResponse) String() string {
+func (x *TransferStockRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CheckStockResponse) ProtoMessage() {}
+func (*TransferStockRequest) ProtoMessage() {}
 
-func (x *CheckStockResponse) ProtoReflect() protoreflect.Message {
+func (x *TransferStockRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_inventory_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -260,49 +351,71 @@ func (x *CheckStockResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CheckStockResponse.ProtoReflect.Descriptor instead.
-func (*CheckStockResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use TransferStockRequest.ProtoReflect.Descriptor instead.
+func (*TransferStockRequest) Descriptor() ([]byte, []int) {
 	return file_inventory_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *CheckStockResponse) GetProductId() string {
+func (x *TransferStockRequest) GetProductId() string {
 	if x != nil {
 		return x.ProductId
 	}
 	return ""
 }
 
-func (x *CheckStockResponse) GetAvailable() int32 {
+func (x *TransferStockRequest) GetFromLocationId() string {
 	if x != nil {
-		return x.Available
+		return x.FromLocationId
+	}
+	return ""
+}
+
+func (x *TransferStockRequest) GetToLocationId() string {
+	if x != nil {
+		return x.ToLocationId
+	}
+	return ""
+}
+
+func (x *TransferStockRequest) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
 	}
 	return 0
 }
 
-// Stock reservation response
-type ReserveStockResponse struct {
+func (x *TransferStockRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+// Create or update a location
+type SaveLocationRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	LocationId    string                 `protobuf:"bytes,1,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Country       string                 `protobuf:"bytes,3,opt,name=country,proto3" json:"country,omitempty"`    // ISO 3166-1 alpha-2
+	Priority      int32                  `protobuf:"varint,4,opt,name=priority,proto3" json:"priority,omitempty"` // lower ships first
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ReserveStockResponse) Reset() {
-	*x = ReserveStockResponse{}
+func (x *SaveLocationRequest) Reset() {
+	*x = SaveLocationRequest{}
 	mi := &file_inventory_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ReserveStockResponse) String() string {
+func (x *SaveLocationRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReserveStockResponse) ProtoMessage() {}
+func (*SaveLocationRequest) ProtoMessage() {}
 
-func (x *ReserveStockResponse) ProtoReflect() protoreflect.Message {
+func (x *SaveLocationRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_inventory_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -314,55 +427,59 @@ func (x *ReserveStockResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReserveStockResponse.ProtoReflect.Descriptor instead.
-func (*ReserveStockResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SaveLocationRequest.ProtoReflect.Descriptor instead.
+func (*SaveLocationRequest) Descriptor() ([]byte, []int) {
 	return file_inventory_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *ReserveStockResponse) GetOrderId() string {
+func (x *SaveLocationRequest) GetLocationId() string {
 	if x != nil {
-		return x.OrderId
+		return x.LocationId
 	}
 	return ""
 }
 
-func (x *ReserveStockResponse) GetSuccess() bool {
+func (x *SaveLocationRequest) GetName() string {
 	if x != nil {
-		return x.Success
+		return x.Name
 	}
-	return false
+	return ""
 }
 
-func (x *ReserveStockResponse) GetMessage() string {
+func (x *SaveLocationRequest) GetCountry() string {
 	if x != nil {
-		return x.Message
+		return x.Country
 	}
 	return ""
 }
 
-// Stock update response
-type UpdateStockResponse struct {
+func (x *SaveLocationRequest) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+type ListLocationsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	NewStock      int32                  `protobuf:"varint,2,opt,name=new_stock,json=newStock,proto3" json:"new_stock,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateStockResponse) Reset() {
-	*x = UpdateStockResponse{}
+func (x *ListLocationsRequest) Reset() {
+	*x = ListLocationsRequest{}
 	mi := &file_inventory_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateStockResponse) String() string {
+func (x *ListLocationsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateStockResponse) ProtoMessage() {}
+func (*ListLocationsRequest) ProtoMessage() {}
 
-func (x *UpdateStockResponse) ProtoReflect() protoreflect.Message {
+func (x *ListLocationsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_inventory_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -374,62 +491,2063 @@ func (x *UpdateStockResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateStockResponse.ProtoReflect.Descriptor instead.
-func (*UpdateStockResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListLocationsRequest.ProtoReflect.Descriptor instead.
+func (*ListLocationsRequest) Descriptor() ([]byte, []int) {
 	return file_inventory_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *UpdateStockResponse) GetProductId() string {
+// List the stock ledger, newest first; empty filters match every movement
+type ListStockMovementsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	LocationId    string                 `protobuf:"bytes,2,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Reference     string                 `protobuf:"bytes,4,opt,name=reference,proto3" json:"reference,omitempty"`
+	PageSize      int32                  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // default 50, at most 200
+	PageToken     string                 `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token of the previous page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStockMovementsRequest) Reset() {
+	*x = ListStockMovementsRequest{}
+	mi := &file_inventory_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStockMovementsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStockMovementsRequest) ProtoMessage() {}
+
+func (x *ListStockMovementsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStockMovementsRequest.ProtoReflect.Descriptor instead.
+func (*ListStockMovementsRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListStockMovementsRequest) GetProductId() string {
 	if x != nil {
 		return x.ProductId
 	}
 	return ""
 }
 
-func (x *UpdateStockResponse) GetNewStock() int32 {
+func (x *ListStockMovementsRequest) GetLocationId() string {
 	if x != nil {
-		return x.NewStock
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *ListStockMovementsRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ListStockMovementsRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *ListStockMovementsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
 	}
 	return 0
 }
 
-var File_inventory_proto protoreflect.FileDescriptor
+func (x *ListStockMovementsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
 
-const file_inventory_proto_rawDesc = "" +
-	"\n" +
-	"\x0finventory.proto\x12\tinventory\"2\n" +
-	"\x11CheckStockRequest\x12\x1d\n" +
-	"\n" +
-	"product_id\x18\x01 \x01(\tR\tproductId\"\\\n" +
-	"\x13ReserveStockRequest\x12\x19\n" +
-	"\border_id\x18\x01 \x01(\tR\aorderId\x12*\n" +
-	"\x05items\x18\x02 \x03(\v2\x14.inventory.StockItemR\x05items\"T\n" +
-	"\x12UpdateStockRequest\x12\x1d\n" +
-	"\n" +
-	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1f\n" +
-	"\vstock_delta\x18\x02 \x01(\x05R\n" +
-	"stockDelta\"F\n" +
-	"\tStockItem\x12\x1d\n" +
-	"\n" +
-	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1a\n" +
-	"\bquantity\x18\x02 \x01(\x05R\bquantity\"Q\n" +
-	"\x12CheckStockResponse\x12\x1d\n" +
-	"\n" +
-	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1c\n" +
-	"\tavailable\x18\x02 \x01(\x05R\tavailable\"e\n" +
-	"\x14ReserveStockResponse\x12\x19\n" +
-	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x18\n" +
-	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\"Q\n" +
-	"\x13UpdateStockResponse\x12\x1d\n" +
-	"\n" +
-	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1b\n" +
-	"\tnew_stock\x18\x02 \x01(\x05R\bnewStock2\x82\x02\n" +
+// Set when a product alerts and should be reordered
+type SetStockThresholdsRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ProductId         string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	LowStockThreshold int32                  `protobuf:"varint,2,opt,name=low_stock_threshold,json=lowStockThreshold,proto3" json:"low_stock_threshold,omitempty"` // stock.low fires when available drops to it; 0 only alerts on stock.out
+	ReorderPoint      int32                  `protobuf:"varint,3,opt,name=reorder_point,json=reorderPoint,proto3" json:"reorder_point,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *SetStockThresholdsRequest) Reset() {
+	*x = SetStockThresholdsRequest{}
+	mi := &file_inventory_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetStockThresholdsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetStockThresholdsRequest) ProtoMessage() {}
+
+func (x *SetStockThresholdsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetStockThresholdsRequest.ProtoReflect.Descriptor instead.
+func (*SetStockThresholdsRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SetStockThresholdsRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *SetStockThresholdsRequest) GetLowStockThreshold() int32 {
+	if x != nil {
+		return x.LowStockThreshold
+	}
+	return 0
+}
+
+func (x *SetStockThresholdsRequest) GetReorderPoint() int32 {
+	if x != nil {
+		return x.ReorderPoint
+	}
+	return 0
+}
+
+// Set whether a product can be ordered beyond its stock
+type SetBackorderPolicyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Policy        string                 `protobuf:"bytes,2,opt,name=policy,proto3" json:"policy,omitempty"`                           // disallow, limited or preorder
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`                            // units that can be backordered at once under the limited policy
+	ExpectedAt    string                 `protobuf:"bytes,4,opt,name=expected_at,json=expectedAt,proto3" json:"expected_at,omitempty"` // RFC3339; when backordered stock is expected, optional
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetBackorderPolicyRequest) Reset() {
+	*x = SetBackorderPolicyRequest{}
+	mi := &file_inventory_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetBackorderPolicyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetBackorderPolicyRequest) ProtoMessage() {}
+
+func (x *SetBackorderPolicyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetBackorderPolicyRequest.ProtoReflect.Descriptor instead.
+func (*SetBackorderPolicyRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SetBackorderPolicyRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *SetBackorderPolicyRequest) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *SetBackorderPolicyRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SetBackorderPolicyRequest) GetExpectedAt() string {
+	if x != nil {
+		return x.ExpectedAt
+	}
+	return ""
+}
+
+// Check that the stock ledger sums to the stock balances
+type CheckStockConsistencyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"` // every product when empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckStockConsistencyRequest) Reset() {
+	*x = CheckStockConsistencyRequest{}
+	mi := &file_inventory_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckStockConsistencyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckStockConsistencyRequest) ProtoMessage() {}
+
+func (x *CheckStockConsistencyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckStockConsistencyRequest.ProtoReflect.Descriptor instead.
+func (*CheckStockConsistencyRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CheckStockConsistencyRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+// Take the reserved stock of a paid order off on-hand
+type CommitReservationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitReservationRequest) Reset() {
+	*x = CommitReservationRequest{}
+	mi := &file_inventory_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitReservationRequest) ProtoMessage() {}
+
+func (x *CommitReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitReservationRequest.ProtoReflect.Descriptor instead.
+func (*CommitReservationRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CommitReservationRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+// Give the reserved stock of an order back
+type ReleaseReservationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseReservationRequest) Reset() {
+	*x = ReleaseReservationRequest{}
+	mi := &file_inventory_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseReservationRequest) ProtoMessage() {}
+
+func (x *ReleaseReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseReservationRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseReservationRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ReleaseReservationRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *ReleaseReservationRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Keep the reserved stock of an order held longer, e.g. while it waits for
+// review or payment; an expired reservation is held again if the stock is
+// still available
+type ExtendReservationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	ExpiresAt     string                 `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // RFC3339; a later expiry is kept
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExtendReservationRequest) Reset() {
+	*x = ExtendReservationRequest{}
+	mi := &file_inventory_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtendReservationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtendReservationRequest) ProtoMessage() {}
+
+func (x *ExtendReservationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtendReservationRequest.ProtoReflect.Descriptor instead.
+func (*ExtendReservationRequest) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ExtendReservationRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *ExtendReservationRequest) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+// Used for order reservation
+type StockItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	LocationId    string                 `protobuf:"bytes,3,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"` // where the stock is held; set in responses
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockItem) Reset() {
+	*x = StockItem{}
+	mi := &file_inventory_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockItem) ProtoMessage() {}
+
+func (x *StockItem) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockItem.ProtoReflect.Descriptor instead.
+func (*StockItem) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StockItem) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *StockItem) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *StockItem) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+// Stock check response
+type CheckStockResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ProductId           string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Available           int32                  `protobuf:"varint,2,opt,name=available,proto3" json:"available,omitempty"` // on_hand - held
+	OnHand              int32                  `protobuf:"varint,3,opt,name=on_hand,json=onHand,proto3" json:"on_hand,omitempty"`
+	Held                int32                  `protobuf:"varint,4,opt,name=held,proto3" json:"held,omitempty"` // held by active reservations
+	Locations           []*LocationStock       `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
+	LowStockThreshold   int32                  `protobuf:"varint,6,opt,name=low_stock_threshold,json=lowStockThreshold,proto3" json:"low_stock_threshold,omitempty"`
+	ReorderPoint        int32                  `protobuf:"varint,7,opt,name=reorder_point,json=reorderPoint,proto3" json:"reorder_point,omitempty"`
+	AlertLevel          string                 `protobuf:"bytes,8,opt,name=alert_level,json=alertLevel,proto3" json:"alert_level,omitempty"`                 // ok, low or out
+	Archived            bool                   `protobuf:"varint,9,opt,name=archived,proto3" json:"archived,omitempty"`                                      // deleted from the catalog; cannot be reserved
+	BackorderPolicy     string                 `protobuf:"bytes,10,opt,name=backorder_policy,json=backorderPolicy,proto3" json:"backorder_policy,omitempty"` // disallow, limited or preorder
+	BackorderLimit      int32                  `protobuf:"varint,11,opt,name=backorder_limit,json=backorderLimit,proto3" json:"backorder_limit,omitempty"`
+	BackorderExpectedAt string                 `protobuf:"bytes,12,opt,name=backorder_expected_at,json=backorderExpectedAt,proto3" json:"backorder_expected_at,omitempty"` // RFC3339
+	Backordered         int32                  `protobuf:"varint,13,opt,name=backordered,proto3" json:"backordered,omitempty"`                                             // units waiting in open backorders
+	Orderable           int32                  `protobuf:"varint,14,opt,name=orderable,proto3" json:"orderable,omitempty"`                                                 // available plus what can still be backordered
+	Version             int64                  `protobuf:"varint,15,opt,name=version,proto3" json:"version,omitempty"`                                                     // incremented by every change to on_hand
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *CheckStockResponse) Reset() {
+	*x = CheckStockResponse{}
+	mi := &file_inventory_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckStockResponse) ProtoMessage() {}
+
+func (x *CheckStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckStockResponse.ProtoReflect.Descriptor instead.
+func (*CheckStockResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CheckStockResponse) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *CheckStockResponse) GetAvailable() int32 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+func (x *CheckStockResponse) GetOnHand() int32 {
+	if x != nil {
+		return x.OnHand
+	}
+	return 0
+}
+
+func (x *CheckStockResponse) GetHeld() int32 {
+	if x != nil {
+		return x.Held
+	}
+	return 0
+}
+
+func (x *CheckStockResponse) GetLocations() []*LocationStock {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+func (x *CheckStockResponse) GetLowStockThreshold() int32 {
+	if x != nil {
+		return x.LowStockThreshold
+	}
+	return 0
+}
+
+func (x *CheckStockResponse) GetReorderPoint() int32 {
+	if x != nil {
+		return x.ReorderPoint
+	}
+	return 0
+}
+
+func (x *CheckStockResponse) GetAlertLevel() string {
+	if x != nil {
+		return x.AlertLevel
+	}
+	return ""
+}
+
+func (x *CheckStockResponse) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+func (x *CheckStockResponse) GetBackorderPolicy() string {
+	if x != nil {
+		return x.BackorderPolicy
+	}
+	return ""
+}
+
+func (x *CheckStockResponse) GetBackorderLimit() int32 {
+	if x != nil {
+		return x.BackorderLimit
+	}
+	return 0
+}
+
+func (x *CheckStockResponse) GetBackorderExpectedAt() string {
+	if x != nil {
+		return x.BackorderExpectedAt
+	}
+	return ""
+}
+
+func (x *CheckStockResponse) GetBackordered() int32 {
+	if x != nil {
+		return x.Backordered
+	}
+	return 0
+}
+
+func (x *CheckStockResponse) GetOrderable() int32 {
+	if x != nil {
+		return x.Orderable
+	}
+	return 0
+}
+
+func (x *CheckStockResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// Stock of a product at one location
+type LocationStock struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LocationId    string                 `protobuf:"bytes,1,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	OnHand        int32                  `protobuf:"varint,3,opt,name=on_hand,json=onHand,proto3" json:"on_hand,omitempty"`
+	Held          int32                  `protobuf:"varint,4,opt,name=held,proto3" json:"held,omitempty"`
+	Available     int32                  `protobuf:"varint,5,opt,name=available,proto3" json:"available,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LocationStock) Reset() {
+	*x = LocationStock{}
+	mi := &file_inventory_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LocationStock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LocationStock) ProtoMessage() {}
+
+func (x *LocationStock) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LocationStock.ProtoReflect.Descriptor instead.
+func (*LocationStock) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *LocationStock) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *LocationStock) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LocationStock) GetOnHand() int32 {
+	if x != nil {
+		return x.OnHand
+	}
+	return 0
+}
+
+func (x *LocationStock) GetHeld() int32 {
+	if x != nil {
+		return x.Held
+	}
+	return 0
+}
+
+func (x *LocationStock) GetAvailable() int32 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+// Stock reservation response
+type ReserveStockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	ExpiresAt     string                 `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // RFC3339; the reservation is released after it
+	Shortages     []*StockShortage       `protobuf:"bytes,5,rep,name=shortages,proto3" json:"shortages,omitempty"`                  // every product that fell short when success is false
+	Items         []*StockItem           `protobuf:"bytes,6,rep,name=items,proto3" json:"items,omitempty"`                          // the reserved items with the locations holding them
+	Backorders    []*Backorder           `protobuf:"bytes,7,rep,name=backorders,proto3" json:"backorders,omitempty"`                // lines waiting for stock
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReserveStockResponse) Reset() {
+	*x = ReserveStockResponse{}
+	mi := &file_inventory_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReserveStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReserveStockResponse) ProtoMessage() {}
+
+func (x *ReserveStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReserveStockResponse.ProtoReflect.Descriptor instead.
+func (*ReserveStockResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ReserveStockResponse) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *ReserveStockResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ReserveStockResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ReserveStockResponse) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *ReserveStockResponse) GetShortages() []*StockShortage {
+	if x != nil {
+		return x.Shortages
+	}
+	return nil
+}
+
+func (x *ReserveStockResponse) GetItems() []*StockItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ReserveStockResponse) GetBackorders() []*Backorder {
+	if x != nil {
+		return x.Backorders
+	}
+	return nil
+}
+
+// A line of an order waiting for stock
+type Backorder struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	ProductId     string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity      int32                  `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	State         string                 `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`                             // open, allocated or cancelled
+	ExpectedAt    string                 `protobuf:"bytes,5,opt,name=expected_at,json=expectedAt,proto3" json:"expected_at,omitempty"` // RFC3339; empty when unknown
+	LocationId    string                 `protobuf:"bytes,6,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"` // where it was allocated
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Backorder) Reset() {
+	*x = Backorder{}
+	mi := &file_inventory_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Backorder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Backorder) ProtoMessage() {}
+
+func (x *Backorder) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Backorder.ProtoReflect.Descriptor instead.
+func (*Backorder) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Backorder) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Backorder) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *Backorder) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Backorder) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Backorder) GetExpectedAt() string {
+	if x != nil {
+		return x.ExpectedAt
+	}
+	return ""
+}
+
+func (x *Backorder) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+// A product a reservation asked more of than is available
+type StockShortage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Requested     int32                  `protobuf:"varint,2,opt,name=requested,proto3" json:"requested,omitempty"`
+	Available     int32                  `protobuf:"varint,3,opt,name=available,proto3" json:"available,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockShortage) Reset() {
+	*x = StockShortage{}
+	mi := &file_inventory_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockShortage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockShortage) ProtoMessage() {}
+
+func (x *StockShortage) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockShortage.ProtoReflect.Descriptor instead.
+func (*StockShortage) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StockShortage) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *StockShortage) GetRequested() int32 {
+	if x != nil {
+		return x.Requested
+	}
+	return 0
+}
+
+func (x *StockShortage) GetAvailable() int32 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+// Reservation state after a commit or release
+type ReservationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	State         string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"` // held, committed, released or expired
+	ExpiresAt     string                 `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Items         []*StockItem           `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	Backorders    []*Backorder           `protobuf:"bytes,6,rep,name=backorders,proto3" json:"backorders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReservationResponse) Reset() {
+	*x = ReservationResponse{}
+	mi := &file_inventory_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReservationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReservationResponse) ProtoMessage() {}
+
+func (x *ReservationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReservationResponse.ProtoReflect.Descriptor instead.
+func (*ReservationResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ReservationResponse) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *ReservationResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ReservationResponse) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *ReservationResponse) GetItems() []*StockItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ReservationResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ReservationResponse) GetBackorders() []*Backorder {
+	if x != nil {
+		return x.Backorders
+	}
+	return nil
+}
+
+// Stock update response
+type UpdateStockResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ProductId           string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	NewStock            int32                  `protobuf:"varint,2,opt,name=new_stock,json=newStock,proto3" json:"new_stock,omitempty"` // total over all locations
+	Location            *LocationStock         `protobuf:"bytes,3,opt,name=location,proto3" json:"location,omitempty"`
+	AllocatedBackorders []*Backorder           `protobuf:"bytes,4,rep,name=allocated_backorders,json=allocatedBackorders,proto3" json:"allocated_backorders,omitempty"` // backorders the added stock went to
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *UpdateStockResponse) Reset() {
+	*x = UpdateStockResponse{}
+	mi := &file_inventory_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStockResponse) ProtoMessage() {}
+
+func (x *UpdateStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStockResponse.ProtoReflect.Descriptor instead.
+func (*UpdateStockResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UpdateStockResponse) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *UpdateStockResponse) GetNewStock() int32 {
+	if x != nil {
+		return x.NewStock
+	}
+	return 0
+}
+
+func (x *UpdateStockResponse) GetLocation() *LocationStock {
+	if x != nil {
+		return x.Location
+	}
+	return nil
+}
+
+func (x *UpdateStockResponse) GetAllocatedBackorders() []*Backorder {
+	if x != nil {
+		return x.AllocatedBackorders
+	}
+	return nil
+}
+
+// A counted quantity that differs from the system quantity
+type StockCountDifference struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          int32                  `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	ProductId     string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	LocationId    string                 `protobuf:"bytes,3,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"`
+	System        int32                  `protobuf:"varint,4,opt,name=system,proto3" json:"system,omitempty"` // on-hand before the count
+	Counted       int32                  `protobuf:"varint,5,opt,name=counted,proto3" json:"counted,omitempty"`
+	Delta         int32                  `protobuf:"varint,6,opt,name=delta,proto3" json:"delta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockCountDifference) Reset() {
+	*x = StockCountDifference{}
+	mi := &file_inventory_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockCountDifference) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockCountDifference) ProtoMessage() {}
+
+func (x *StockCountDifference) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockCountDifference.ProtoReflect.Descriptor instead.
+func (*StockCountDifference) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StockCountDifference) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *StockCountDifference) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *StockCountDifference) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *StockCountDifference) GetSystem() int32 {
+	if x != nil {
+		return x.System
+	}
+	return 0
+}
+
+func (x *StockCountDifference) GetCounted() int32 {
+	if x != nil {
+		return x.Counted
+	}
+	return 0
+}
+
+func (x *StockCountDifference) GetDelta() int32 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+// A row of a stock count import that was not applied
+type RejectedStockCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          int32                  `protobuf:"varint,1,opt,name=line,proto3" json:"line,omitempty"`
+	ProductId     string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	LocationId    string                 `protobuf:"bytes,3,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RejectedStockCount) Reset() {
+	*x = RejectedStockCount{}
+	mi := &file_inventory_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RejectedStockCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RejectedStockCount) ProtoMessage() {}
+
+func (x *RejectedStockCount) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RejectedStockCount.ProtoReflect.Descriptor instead.
+func (*RejectedStockCount) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *RejectedStockCount) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *RejectedStockCount) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *RejectedStockCount) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *RejectedStockCount) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Result of a stock count import
+type ImportStockCountsResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	DryRun        bool                    `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Rows          int32                   `protobuf:"varint,2,opt,name=rows,proto3" json:"rows,omitempty"`
+	Unchanged     int32                   `protobuf:"varint,3,opt,name=unchanged,proto3" json:"unchanged,omitempty"` // rows that matched the system quantity
+	Differences   []*StockCountDifference `protobuf:"bytes,4,rep,name=differences,proto3" json:"differences,omitempty"`
+	Rejected      []*RejectedStockCount   `protobuf:"bytes,5,rep,name=rejected,proto3" json:"rejected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportStockCountsResponse) Reset() {
+	*x = ImportStockCountsResponse{}
+	mi := &file_inventory_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportStockCountsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportStockCountsResponse) ProtoMessage() {}
+
+func (x *ImportStockCountsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportStockCountsResponse.ProtoReflect.Descriptor instead.
+func (*ImportStockCountsResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ImportStockCountsResponse) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *ImportStockCountsResponse) GetRows() int32 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *ImportStockCountsResponse) GetUnchanged() int32 {
+	if x != nil {
+		return x.Unchanged
+	}
+	return 0
+}
+
+func (x *ImportStockCountsResponse) GetDifferences() []*StockCountDifference {
+	if x != nil {
+		return x.Differences
+	}
+	return nil
+}
+
+func (x *ImportStockCountsResponse) GetRejected() []*RejectedStockCount {
+	if x != nil {
+		return x.Rejected
+	}
+	return nil
+}
+
+// Stock transfer response
+type TransferStockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransferId    string                 `protobuf:"bytes,1,opt,name=transfer_id,json=transferId,proto3" json:"transfer_id,omitempty"`
+	ProductId     string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity      int32                  `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	From          *LocationStock         `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"`
+	To            *LocationStock         `protobuf:"bytes,5,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransferStockResponse) Reset() {
+	*x = TransferStockResponse{}
+	mi := &file_inventory_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransferStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransferStockResponse) ProtoMessage() {}
+
+func (x *TransferStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransferStockResponse.ProtoReflect.Descriptor instead.
+func (*TransferStockResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *TransferStockResponse) GetTransferId() string {
+	if x != nil {
+		return x.TransferId
+	}
+	return ""
+}
+
+func (x *TransferStockResponse) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *TransferStockResponse) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *TransferStockResponse) GetFrom() *LocationStock {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *TransferStockResponse) GetTo() *LocationStock {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type LocationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LocationId    string                 `protobuf:"bytes,1,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Country       string                 `protobuf:"bytes,3,opt,name=country,proto3" json:"country,omitempty"`
+	Priority      int32                  `protobuf:"varint,4,opt,name=priority,proto3" json:"priority,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LocationResponse) Reset() {
+	*x = LocationResponse{}
+	mi := &file_inventory_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LocationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LocationResponse) ProtoMessage() {}
+
+func (x *LocationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LocationResponse.ProtoReflect.Descriptor instead.
+func (*LocationResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *LocationResponse) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *LocationResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *LocationResponse) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *LocationResponse) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+type ListLocationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Locations     []*LocationResponse    `protobuf:"bytes,1,rep,name=locations,proto3" json:"locations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLocationsResponse) Reset() {
+	*x = ListLocationsResponse{}
+	mi := &file_inventory_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLocationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLocationsResponse) ProtoMessage() {}
+
+func (x *ListLocationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLocationsResponse.ProtoReflect.Descriptor instead.
+func (*ListLocationsResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListLocationsResponse) GetLocations() []*LocationResponse {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+// One entry of the stock ledger
+type StockMovement struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovementId    string                 `protobuf:"bytes,1,opt,name=movement_id,json=movementId,proto3" json:"movement_id,omitempty"`
+	ProductId     string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	LocationId    string                 `protobuf:"bytes,3,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"`
+	Quantity      int32                  `protobuf:"varint,4,opt,name=quantity,proto3" json:"quantity,omitempty"`                             // signed change to on-hand
+	HeldQuantity  int32                  `protobuf:"varint,5,opt,name=held_quantity,json=heldQuantity,proto3" json:"held_quantity,omitempty"` // signed change to held
+	BalanceAfter  int32                  `protobuf:"varint,6,opt,name=balance_after,json=balanceAfter,proto3" json:"balance_after,omitempty"` // on-hand at the location afterwards
+	Reason        string                 `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	Reference     string                 `protobuf:"bytes,8,opt,name=reference,proto3" json:"reference,omitempty"`
+	Actor         string                 `protobuf:"bytes,9,opt,name=actor,proto3" json:"actor,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // RFC3339
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockMovement) Reset() {
+	*x = StockMovement{}
+	mi := &file_inventory_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockMovement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockMovement) ProtoMessage() {}
+
+func (x *StockMovement) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockMovement.ProtoReflect.Descriptor instead.
+func (*StockMovement) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *StockMovement) GetMovementId() string {
+	if x != nil {
+		return x.MovementId
+	}
+	return ""
+}
+
+func (x *StockMovement) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *StockMovement) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *StockMovement) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *StockMovement) GetHeldQuantity() int32 {
+	if x != nil {
+		return x.HeldQuantity
+	}
+	return 0
+}
+
+func (x *StockMovement) GetBalanceAfter() int32 {
+	if x != nil {
+		return x.BalanceAfter
+	}
+	return 0
+}
+
+func (x *StockMovement) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *StockMovement) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+func (x *StockMovement) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *StockMovement) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+// One page of stock movements
+type ListStockMovementsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movements     []*StockMovement       `protobuf:"bytes,1,rep,name=movements,proto3" json:"movements,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStockMovementsResponse) Reset() {
+	*x = ListStockMovementsResponse{}
+	mi := &file_inventory_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStockMovementsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStockMovementsResponse) ProtoMessage() {}
+
+func (x *ListStockMovementsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStockMovementsResponse.ProtoReflect.Descriptor instead.
+func (*ListStockMovementsResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListStockMovementsResponse) GetMovements() []*StockMovement {
+	if x != nil {
+		return x.Movements
+	}
+	return nil
+}
+
+func (x *ListStockMovementsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// A balance that does not match the sum of its movements
+type StockDiscrepancy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	LocationId    string                 `protobuf:"bytes,2,opt,name=location_id,json=locationId,proto3" json:"location_id,omitempty"` // empty when the product total does not match its locations
+	Balance       int32                  `protobuf:"varint,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	Movements     int32                  `protobuf:"varint,4,opt,name=movements,proto3" json:"movements,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockDiscrepancy) Reset() {
+	*x = StockDiscrepancy{}
+	mi := &file_inventory_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockDiscrepancy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockDiscrepancy) ProtoMessage() {}
+
+func (x *StockDiscrepancy) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockDiscrepancy.ProtoReflect.Descriptor instead.
+func (*StockDiscrepancy) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *StockDiscrepancy) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *StockDiscrepancy) GetLocationId() string {
+	if x != nil {
+		return x.LocationId
+	}
+	return ""
+}
+
+func (x *StockDiscrepancy) GetBalance() int32 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *StockDiscrepancy) GetMovements() int32 {
+	if x != nil {
+		return x.Movements
+	}
+	return 0
+}
+
+type CheckStockConsistencyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Consistent    bool                   `protobuf:"varint,1,opt,name=consistent,proto3" json:"consistent,omitempty"`
+	Discrepancies []*StockDiscrepancy    `protobuf:"bytes,2,rep,name=discrepancies,proto3" json:"discrepancies,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckStockConsistencyResponse) Reset() {
+	*x = CheckStockConsistencyResponse{}
+	mi := &file_inventory_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckStockConsistencyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckStockConsistencyResponse) ProtoMessage() {}
+
+func (x *CheckStockConsistencyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckStockConsistencyResponse.ProtoReflect.Descriptor instead.
+func (*CheckStockConsistencyResponse) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *CheckStockConsistencyResponse) GetConsistent() bool {
+	if x != nil {
+		return x.Consistent
+	}
+	return false
+}
+
+func (x *CheckStockConsistencyResponse) GetDiscrepancies() []*StockDiscrepancy {
+	if x != nil {
+		return x.Discrepancies
+	}
+	return nil
+}
+
+var File_inventory_proto protoreflect.FileDescriptor
+
+const file_inventory_proto_rawDesc = "" +
+	"\n" +
+	"\x0finventory.proto\x12\tinventory\"2\n" +
+	"\x11CheckStockRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\"\xa3\x01\n" +
+	"\x13ReserveStockRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12*\n" +
+	"\x05items\x18\x02 \x03(\v2\x14.inventory.StockItemR\x05items\x12\x1a\n" +
+	"\bstrategy\x18\x03 \x01(\tR\bstrategy\x12)\n" +
+	"\x10shipping_country\x18\x04 \x01(\tR\x0fshippingCountry\"\xc1\x01\n" +
+	"\x12UpdateStockRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1f\n" +
+	"\vstock_delta\x18\x02 \x01(\x05R\n" +
+	"stockDelta\x12\x1f\n" +
+	"\vlocation_id\x18\x03 \x01(\tR\n" +
+	"locationId\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x1c\n" +
+	"\treference\x18\x05 \x01(\tR\treference\x12\x14\n" +
+	"\x05actor\x18\x06 \x01(\tR\x05actor\"\xca\x01\n" +
+	"\rStockCountRow\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1f\n" +
+	"\vlocation_id\x18\x02 \x01(\tR\n" +
+	"locationId\x12\x18\n" +
+	"\acounted\x18\x03 \x01(\x05R\acounted\x12\x12\n" +
+	"\x04line\x18\x04 \x01(\x05R\x04line\x12\x17\n" +
+	"\adry_run\x18\x05 \x01(\bR\x06dryRun\x12\x1c\n" +
+	"\treference\x18\x06 \x01(\tR\treference\x12\x14\n" +
+	"\x05actor\x18\a \x01(\tR\x05actor\"\xb7\x01\n" +
+	"\x14TransferStockRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12(\n" +
+	"\x10from_location_id\x18\x02 \x01(\tR\x0efromLocationId\x12$\n" +
+	"\x0eto_location_id\x18\x03 \x01(\tR\ftoLocationId\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x05R\bquantity\x12\x14\n" +
+	"\x05actor\x18\x05 \x01(\tR\x05actor\"\x80\x01\n" +
+	"\x13SaveLocationRequest\x12\x1f\n" +
+	"\vlocation_id\x18\x01 \x01(\tR\n" +
+	"locationId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\acountry\x18\x03 \x01(\tR\acountry\x12\x1a\n" +
+	"\bpriority\x18\x04 \x01(\x05R\bpriority\"\x16\n" +
+	"\x14ListLocationsRequest\"\xcd\x01\n" +
+	"\x19ListStockMovementsRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1f\n" +
+	"\vlocation_id\x18\x02 \x01(\tR\n" +
+	"locationId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x1c\n" +
+	"\treference\x18\x04 \x01(\tR\treference\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x06 \x01(\tR\tpageToken\"\x8f\x01\n" +
+	"\x19SetStockThresholdsRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12.\n" +
+	"\x13low_stock_threshold\x18\x02 \x01(\x05R\x11lowStockThreshold\x12#\n" +
+	"\rreorder_point\x18\x03 \x01(\x05R\freorderPoint\"\x89\x01\n" +
+	"\x19SetBackorderPolicyRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x16\n" +
+	"\x06policy\x18\x02 \x01(\tR\x06policy\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x1f\n" +
+	"\vexpected_at\x18\x04 \x01(\tR\n" +
+	"expectedAt\"=\n" +
+	"\x1cCheckStockConsistencyRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\"5\n" +
+	"\x18CommitReservationRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"N\n" +
+	"\x19ReleaseReservationRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"T\n" +
+	"\x18ExtendReservationRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\tR\texpiresAt\"g\n" +
+	"\tStockItem\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x05R\bquantity\x12\x1f\n" +
+	"\vlocation_id\x18\x03 \x01(\tR\n" +
+	"locationId\"\xaa\x04\n" +
+	"\x12CheckStockResponse\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1c\n" +
+	"\tavailable\x18\x02 \x01(\x05R\tavailable\x12\x17\n" +
+	"\aon_hand\x18\x03 \x01(\x05R\x06onHand\x12\x12\n" +
+	"\x04held\x18\x04 \x01(\x05R\x04held\x126\n" +
+	"\tlocations\x18\x05 \x03(\v2\x18.inventory.LocationStockR\tlocations\x12.\n" +
+	"\x13low_stock_threshold\x18\x06 \x01(\x05R\x11lowStockThreshold\x12#\n" +
+	"\rreorder_point\x18\a \x01(\x05R\freorderPoint\x12\x1f\n" +
+	"\valert_level\x18\b \x01(\tR\n" +
+	"alertLevel\x12\x1a\n" +
+	"\barchived\x18\t \x01(\bR\barchived\x12)\n" +
+	"\x10backorder_policy\x18\n" +
+	" \x01(\tR\x0fbackorderPolicy\x12'\n" +
+	"\x0fbackorder_limit\x18\v \x01(\x05R\x0ebackorderLimit\x122\n" +
+	"\x15backorder_expected_at\x18\f \x01(\tR\x13backorderExpectedAt\x12 \n" +
+	"\vbackordered\x18\r \x01(\x05R\vbackordered\x12\x1c\n" +
+	"\torderable\x18\x0e \x01(\x05R\torderable\x12\x18\n" +
+	"\aversion\x18\x0f \x01(\x03R\aversion\"\x8f\x01\n" +
+	"\rLocationStock\x12\x1f\n" +
+	"\vlocation_id\x18\x01 \x01(\tR\n" +
+	"locationId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x17\n" +
+	"\aon_hand\x18\x03 \x01(\x05R\x06onHand\x12\x12\n" +
+	"\x04held\x18\x04 \x01(\x05R\x04held\x12\x1c\n" +
+	"\tavailable\x18\x05 \x01(\x05R\tavailable\"\x9e\x02\n" +
+	"\x14ReserveStockResponse\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\tR\texpiresAt\x126\n" +
+	"\tshortages\x18\x05 \x03(\v2\x18.inventory.StockShortageR\tshortages\x12*\n" +
+	"\x05items\x18\x06 \x03(\v2\x14.inventory.StockItemR\x05items\x124\n" +
+	"\n" +
+	"backorders\x18\a \x03(\v2\x14.inventory.BackorderR\n" +
+	"backorders\"\xb9\x01\n" +
+	"\tBackorder\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\tR\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x05R\bquantity\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\x12\x1f\n" +
+	"\vexpected_at\x18\x05 \x01(\tR\n" +
+	"expectedAt\x12\x1f\n" +
+	"\vlocation_id\x18\x06 \x01(\tR\n" +
+	"locationId\"j\n" +
+	"\rStockShortage\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1c\n" +
+	"\trequested\x18\x02 \x01(\x05R\trequested\x12\x1c\n" +
+	"\tavailable\x18\x03 \x01(\x05R\tavailable\"\xe1\x01\n" +
+	"\x13ReservationResponse\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\tR\texpiresAt\x12*\n" +
+	"\x05items\x18\x04 \x03(\v2\x14.inventory.StockItemR\x05items\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\x124\n" +
+	"\n" +
+	"backorders\x18\x06 \x03(\v2\x14.inventory.BackorderR\n" +
+	"backorders\"\xd0\x01\n" +
+	"\x13UpdateStockResponse\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1b\n" +
+	"\tnew_stock\x18\x02 \x01(\x05R\bnewStock\x124\n" +
+	"\blocation\x18\x03 \x01(\v2\x18.inventory.LocationStockR\blocation\x12G\n" +
+	"\x14allocated_backorders\x18\x04 \x03(\v2\x14.inventory.BackorderR\x13allocatedBackorders\"\xb2\x01\n" +
+	"\x14StockCountDifference\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\x05R\x04line\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\tR\tproductId\x12\x1f\n" +
+	"\vlocation_id\x18\x03 \x01(\tR\n" +
+	"locationId\x12\x16\n" +
+	"\x06system\x18\x04 \x01(\x05R\x06system\x12\x18\n" +
+	"\acounted\x18\x05 \x01(\x05R\acounted\x12\x14\n" +
+	"\x05delta\x18\x06 \x01(\x05R\x05delta\"\x80\x01\n" +
+	"\x12RejectedStockCount\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\x05R\x04line\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\tR\tproductId\x12\x1f\n" +
+	"\vlocation_id\x18\x03 \x01(\tR\n" +
+	"locationId\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\xe4\x01\n" +
+	"\x19ImportStockCountsResponse\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\x12\x12\n" +
+	"\x04rows\x18\x02 \x01(\x05R\x04rows\x12\x1c\n" +
+	"\tunchanged\x18\x03 \x01(\x05R\tunchanged\x12A\n" +
+	"\vdifferences\x18\x04 \x03(\v2\x1f.inventory.StockCountDifferenceR\vdifferences\x129\n" +
+	"\brejected\x18\x05 \x03(\v2\x1d.inventory.RejectedStockCountR\brejected\"\xcb\x01\n" +
+	"\x15TransferStockResponse\x12\x1f\n" +
+	"\vtransfer_id\x18\x01 \x01(\tR\n" +
+	"transferId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\tR\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x05R\bquantity\x12,\n" +
+	"\x04from\x18\x04 \x01(\v2\x18.inventory.LocationStockR\x04from\x12(\n" +
+	"\x02to\x18\x05 \x01(\v2\x18.inventory.LocationStockR\x02to\"}\n" +
+	"\x10LocationResponse\x12\x1f\n" +
+	"\vlocation_id\x18\x01 \x01(\tR\n" +
+	"locationId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\acountry\x18\x03 \x01(\tR\acountry\x12\x1a\n" +
+	"\bpriority\x18\x04 \x01(\x05R\bpriority\"R\n" +
+	"\x15ListLocationsResponse\x129\n" +
+	"\tlocations\x18\x01 \x03(\v2\x1b.inventory.LocationResponseR\tlocations\"\xc1\x02\n" +
+	"\rStockMovement\x12\x1f\n" +
+	"\vmovement_id\x18\x01 \x01(\tR\n" +
+	"movementId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\tR\tproductId\x12\x1f\n" +
+	"\vlocation_id\x18\x03 \x01(\tR\n" +
+	"locationId\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x05R\bquantity\x12#\n" +
+	"\rheld_quantity\x18\x05 \x01(\x05R\fheldQuantity\x12#\n" +
+	"\rbalance_after\x18\x06 \x01(\x05R\fbalanceAfter\x12\x16\n" +
+	"\x06reason\x18\a \x01(\tR\x06reason\x12\x1c\n" +
+	"\treference\x18\b \x01(\tR\treference\x12\x14\n" +
+	"\x05actor\x18\t \x01(\tR\x05actor\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\tR\tcreatedAt\"|\n" +
+	"\x1aListStockMovementsResponse\x126\n" +
+	"\tmovements\x18\x01 \x03(\v2\x18.inventory.StockMovementR\tmovements\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x8a\x01\n" +
+	"\x10StockDiscrepancy\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1f\n" +
+	"\vlocation_id\x18\x02 \x01(\tR\n" +
+	"locationId\x12\x18\n" +
+	"\abalance\x18\x03 \x01(\x05R\abalance\x12\x1c\n" +
+	"\tmovements\x18\x04 \x01(\x05R\tmovements\"\x82\x01\n" +
+	"\x1dCheckStockConsistencyResponse\x12\x1e\n" +
+	"\n" +
+	"consistent\x18\x01 \x01(\bR\n" +
+	"consistent\x12A\n" +
+	"\rdiscrepancies\x18\x02 \x03(\v2\x1b.inventory.StockDiscrepancyR\rdiscrepancies2\xf9\t\n" +
 	"\x10InventoryService\x12K\n" +
 	"\n" +
 	"CheckStock\x12\x1c.inventory.CheckStockRequest\x1a\x1d.inventory.CheckStockResponse\"\x00\x12Q\n" +
 	"\fReserveStock\x12\x1e.inventory.ReserveStockRequest\x1a\x1f.inventory.ReserveStockResponse\"\x00\x12N\n" +
-	"\vUpdateStock\x12\x1d.inventory.UpdateStockRequest\x1a\x1e.inventory.UpdateStockResponse\"\x00B<Z:github.com/SabinGhost19/go-micro-payment/proto/inventorypbb\x06proto3"
+	"\vUpdateStock\x12\x1d.inventory.UpdateStockRequest\x1a\x1e.inventory.UpdateStockResponse\"\x00\x12Z\n" +
+	"\x11CommitReservation\x12#.inventory.CommitReservationRequest\x1a\x1e.inventory.ReservationResponse\"\x00\x12\\\n" +
+	"\x12ReleaseReservation\x12$.inventory.ReleaseReservationRequest\x1a\x1e.inventory.ReservationResponse\"\x00\x12Z\n" +
+	"\x11ExtendReservation\x12#.inventory.ExtendReservationRequest\x1a\x1e.inventory.ReservationResponse\"\x00\x12T\n" +
+	"\rTransferStock\x12\x1f.inventory.TransferStockRequest\x1a .inventory.TransferStockResponse\"\x00\x12M\n" +
+	"\fSaveLocation\x12\x1e.inventory.SaveLocationRequest\x1a\x1b.inventory.LocationResponse\"\x00\x12T\n" +
+	"\rListLocations\x12\x1f.inventory.ListLocationsRequest\x1a .inventory.ListLocationsResponse\"\x00\x12c\n" +
+	"\x12ListStockMovements\x12$.inventory.ListStockMovementsRequest\x1a%.inventory.ListStockMovementsResponse\"\x00\x12l\n" +
+	"\x15CheckStockConsistency\x12'.inventory.CheckStockConsistencyRequest\x1a(.inventory.CheckStockConsistencyResponse\"\x00\x12[\n" +
+	"\x12SetStockThresholds\x12$.inventory.SetStockThresholdsRequest\x1a\x1d.inventory.CheckStockResponse\"\x00\x12[\n" +
+	"\x12SetBackorderPolicy\x12$.inventory.SetBackorderPolicyRequest\x1a\x1d.inventory.CheckStockResponse\"\x00\x12W\n" +
+	"\x11ImportStockCounts\x12\x18.inventory.StockCountRow\x1a$.inventory.ImportStockCountsResponse\"\x00(\x01B<Z:github.com/SabinGhost19/go-micro-payment/proto/inventorypbb\x06proto3"
 
 var (
 	file_inventory_proto_rawDescOnce sync.Once
@@ -443,29 +2561,91 @@ func file_inventory_proto_rawDescGZIP() []byte {
 	return file_inventory_proto_rawDescData
 }
 
-var file_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
 var file_inventory_proto_goTypes = []any{
-	(*CheckStockRequest)(nil),    // 0: inventory.CheckStockRequest
-	(*ReserveStockRequest)(nil),  // 1: inventory.ReserveStockRequest
-	(*UpdateStockRequest)(nil),   // 2: inventory.UpdateStockRequest
-	(*StockItem)(nil),            // 3: inventory.StockItem
-	(*CheckStockResponse)(nil),   // 4: inventory.CheckStockResponse
-	(*ReserveStockResponse)(nil), // 5: inventory.ReserveStockResponse
-	(*UpdateStockResponse)(nil),  // 6: inventory.UpdateStockResponse
+	(*CheckStockRequest)(nil),             // 0: inventory.CheckStockRequest
+	(*ReserveStockRequest)(nil),           // 1: inventory.ReserveStockRequest
+	(*UpdateStockRequest)(nil),            // 2: inventory.UpdateStockRequest
+	(*StockCountRow)(nil),                 // 3: inventory.StockCountRow
+	(*TransferStockRequest)(nil),          // 4: inventory.TransferStockRequest
+	(*SaveLocationRequest)(nil),           // 5: inventory.SaveLocationRequest
+	(*ListLocationsRequest)(nil),          // 6: inventory.ListLocationsRequest
+	(*ListStockMovementsRequest)(nil),     // 7: inventory.ListStockMovementsRequest
+	(*SetStockThresholdsRequest)(nil),     // 8: inventory.SetStockThresholdsRequest
+	(*SetBackorderPolicyRequest)(nil),     // 9: inventory.SetBackorderPolicyRequest
+	(*CheckStockConsistencyRequest)(nil),  // 10: inventory.CheckStockConsistencyRequest
+	(*CommitReservationRequest)(nil),      // 11: inventory.CommitReservationRequest
+	(*ReleaseReservationRequest)(nil),     // 12: inventory.ReleaseReservationRequest
+	(*ExtendReservationRequest)(nil),      // 13: inventory.ExtendReservationRequest
+	(*StockItem)(nil),                     // 14: inventory.StockItem
+	(*CheckStockResponse)(nil),            // 15: inventory.CheckStockResponse
+	(*LocationStock)(nil),                 // 16: inventory.LocationStock
+	(*ReserveStockResponse)(nil),          // 17: inventory.ReserveStockResponse
+	(*Backorder)(nil),                     // 18: inventory.Backorder
+	(*StockShortage)(nil),                 // 19: inventory.StockShortage
+	(*ReservationResponse)(nil),           // 20: inventory.ReservationResponse
+	(*UpdateStockResponse)(nil),           // 21: inventory.UpdateStockResponse
+	(*StockCountDifference)(nil),          // 22: inventory.StockCountDifference
+	(*RejectedStockCount)(nil),            // 23: inventory.RejectedStockCount
+	(*ImportStockCountsResponse)(nil),     // 24: inventory.ImportStockCountsResponse
+	(*TransferStockResponse)(nil),         // 25: inventory.TransferStockResponse
+	(*LocationResponse)(nil),              // 26: inventory.LocationResponse
+	(*ListLocationsResponse)(nil),         // 27: inventory.ListLocationsResponse
+	(*StockMovement)(nil),                 // 28: inventory.StockMovement
+	(*ListStockMovementsResponse)(nil),    // 29: inventory.ListStockMovementsResponse
+	(*StockDiscrepancy)(nil),              // 30: inventory.StockDiscrepancy
+	(*CheckStockConsistencyResponse)(nil), // 31: inventory.CheckStockConsistencyResponse
 }
 var file_inventory_proto_depIdxs = []int32{
-	3, // 0: inventory.ReserveStockRequest.items:type_name -> inventory.StockItem
-	0, // 1: inventory.InventoryService.CheckStock:input_type -> inventory.CheckStockRequest
-	1, // 2: inventory.InventoryService.ReserveStock:input_type -> inventory.ReserveStockRequest
-	2, // 3: inventory.InventoryService.UpdateStock:input_type -> inventory.UpdateStockRequest
-	4, // 4: inventory.InventoryService.CheckStock:output_type -> inventory.CheckStockResponse
-	5, // 5: inventory.InventoryService.ReserveStock:output_type -> inventory.ReserveStockResponse
-	6, // 6: inventory.InventoryService.UpdateStock:output_type -> inventory.UpdateStockResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	14, // 0: inventory.ReserveStockRequest.items:type_name -> inventory.StockItem
+	16, // 1: inventory.CheckStockResponse.locations:type_name -> inventory.LocationStock
+	19, // 2: inventory.ReserveStockResponse.shortages:type_name -> inventory.StockShortage
+	14, // 3: inventory.ReserveStockResponse.items:type_name -> inventory.StockItem
+	18, // 4: inventory.ReserveStockResponse.backorders:type_name -> inventory.Backorder
+	14, // 5: inventory.ReservationResponse.items:type_name -> inventory.StockItem
+	18, // 6: inventory.ReservationResponse.backorders:type_name -> inventory.Backorder
+	16, // 7: inventory.UpdateStockResponse.location:type_name -> inventory.LocationStock
+	18, // 8: inventory.UpdateStockResponse.allocated_backorders:type_name -> inventory.Backorder
+	22, // 9: inventory.ImportStockCountsResponse.differences:type_name -> inventory.StockCountDifference
+	23, // 10: inventory.ImportStockCountsResponse.rejected:type_name -> inventory.RejectedStockCount
+	16, // 11: inventory.TransferStockResponse.from:type_name -> inventory.LocationStock
+	16, // 12: inventory.TransferStockResponse.to:type_name -> inventory.LocationStock
+	26, // 13: inventory.ListLocationsResponse.locations:type_name -> inventory.LocationResponse
+	28, // 14: inventory.ListStockMovementsResponse.movements:type_name -> inventory.StockMovement
+	30, // 15: inventory.CheckStockConsistencyResponse.discrepancies:type_name -> inventory.StockDiscrepancy
+	0,  // 16: inventory.InventoryService.CheckStock:input_type -> inventory.CheckStockRequest
+	1,  // 17: inventory.InventoryService.ReserveStock:input_type -> inventory.ReserveStockRequest
+	2,  // 18: inventory.InventoryService.UpdateStock:input_type -> inventory.UpdateStockRequest
+	11, // 19: inventory.InventoryService.CommitReservation:input_type -> inventory.CommitReservationRequest
+	12, // 20: inventory.InventoryService.ReleaseReservation:input_type -> inventory.ReleaseReservationRequest
+	13, // 21: inventory.InventoryService.ExtendReservation:input_type -> inventory.ExtendReservationRequest
+	4,  // 22: inventory.InventoryService.TransferStock:input_type -> inventory.TransferStockRequest
+	5,  // 23: inventory.InventoryService.SaveLocation:input_type -> inventory.SaveLocationRequest
+	6,  // 24: inventory.InventoryService.ListLocations:input_type -> inventory.ListLocationsRequest
+	7,  // 25: inventory.InventoryService.ListStockMovements:input_type -> inventory.ListStockMovementsRequest
+	10, // 26: inventory.InventoryService.CheckStockConsistency:input_type -> inventory.CheckStockConsistencyRequest
+	8,  // 27: inventory.InventoryService.SetStockThresholds:input_type -> inventory.SetStockThresholdsRequest
+	9,  // 28: inventory.InventoryService.SetBackorderPolicy:input_type -> inventory.SetBackorderPolicyRequest
+	3,  // 29: inventory.InventoryService.ImportStockCounts:input_type -> inventory.StockCountRow
+	15, // 30: inventory.InventoryService.CheckStock:output_type -> inventory.CheckStockResponse
+	17, // 31: inventory.InventoryService.ReserveStock:output_type -> inventory.ReserveStockResponse
+	21, // 32: inventory.InventoryService.UpdateStock:output_type -> inventory.UpdateStockResponse
+	20, // 33: inventory.InventoryService.CommitReservation:output_type -> inventory.ReservationResponse
+	20, // 34: inventory.InventoryService.ReleaseReservation:output_type -> inventory.ReservationResponse
+	20, // 35: inventory.InventoryService.ExtendReservation:output_type -> inventory.ReservationResponse
+	25, // 36: inventory.InventoryService.TransferStock:output_type -> inventory.TransferStockResponse
+	26, // 37: inventory.InventoryService.SaveLocation:output_type -> inventory.LocationResponse
+	27, // 38: inventory.InventoryService.ListLocations:output_type -> inventory.ListLocationsResponse
+	29, // 39: inventory.InventoryService.ListStockMovements:output_type -> inventory.ListStockMovementsResponse
+	31, // 40: inventory.InventoryService.CheckStockConsistency:output_type -> inventory.CheckStockConsistencyResponse
+	15, // 41: inventory.InventoryService.SetStockThresholds:output_type -> inventory.CheckStockResponse
+	15, // 42: inventory.InventoryService.SetBackorderPolicy:output_type -> inventory.CheckStockResponse
+	24, // 43: inventory.InventoryService.ImportStockCounts:output_type -> inventory.ImportStockCountsResponse
+	30, // [30:44] is the sub-list for method output_type
+	16, // [16:30] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_inventory_proto_init() }
@@ -479,7 +2659,7 @@ func file_inventory_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_inventory_proto_rawDesc), len(file_inventory_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   32,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -23,13 +23,16 @@ const (
 
 // Message for creating a new order
 type CreateOrderRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Items         []*OrderItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
-	Address       string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
-	Currency      string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Items           []*OrderItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	Address         string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Currency        string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	BillingCountry  string                 `protobuf:"bytes,5,opt,name=billing_country,json=billingCountry,proto3" json:"billing_country,omitempty"`    // ISO 3166-1 alpha-2
+	ShippingCountry string                 `protobuf:"bytes,6,opt,name=shipping_country,json=shippingCountry,proto3" json:"shipping_country,omitempty"` // ISO 3166-1 alpha-2
+	ClientIp        string                 `protobuf:"bytes,7,opt,name=client_ip,json=clientIp,proto3" json:"client_ip,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *CreateOrderRequest) Reset() {
@@ -90,6 +93,27 @@ func (x *CreateOrderRequest) GetCurrency() string {
 	return ""
 }
 
+func (x *CreateOrderRequest) GetBillingCountry() string {
+	if x != nil {
+		return x.BillingCountry
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetShippingCountry() string {
+	if x != nil {
+		return x.ShippingCountry
+	}
+	return ""
+}
+
+func (x *CreateOrderRequest) GetClientIp() string {
+	if x != nil {
+		return x.ClientIp
+	}
+	return ""
+}
+
 // Retrieve an order by ID
 type GetOrderRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -196,18 +220,134 @@ func (x *ListOrdersRequest) GetPageSize() int32 {
 	return 0
 }
 
-// Order item details
-type OrderItem struct {
+// Approve or reject an order held in REVIEW
+type ReviewOrderRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Approve       bool                   `protobuf:"varint,2,opt,name=approve,proto3" json:"approve,omitempty"`
+	Reviewer      string                 `protobuf:"bytes,3,opt,name=reviewer,proto3" json:"reviewer,omitempty"`
+	Note          string                 `protobuf:"bytes,4,opt,name=note,proto3" json:"note,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReviewOrderRequest) Reset() {
+	*x = ReviewOrderRequest{}
+	mi := &file_proto_order_order_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReviewOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewOrderRequest) ProtoMessage() {}
+
+func (x *ReviewOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_order_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewOrderRequest.ProtoReflect.Descriptor instead.
+func (*ReviewOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_order_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReviewOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *ReviewOrderRequest) GetApprove() bool {
+	if x != nil {
+		return x.Approve
+	}
+	return false
+}
+
+func (x *ReviewOrderRequest) GetReviewer() string {
+	if x != nil {
+		return x.Reviewer
+	}
+	return ""
+}
+
+func (x *ReviewOrderRequest) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+// Ship an AUTHORIZED order
+type ShipOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *ShipOrderRequest) Reset() {
+	*x = ShipOrderRequest{}
+	mi := &file_proto_order_order_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShipOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShipOrderRequest) ProtoMessage() {}
+
+func (x *ShipOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_order_order_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShipOrderRequest.ProtoReflect.Descriptor instead.
+func (*ShipOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_order_order_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ShipOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+// Order item details
+type OrderItem struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	ProductId           string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity            int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	BackorderedQuantity int32                  `protobuf:"varint,3,opt,name=backordered_quantity,json=backorderedQuantity,proto3" json:"backordered_quantity,omitempty"` // part of quantity waiting for stock
+	ExpectedAt          string                 `protobuf:"bytes,4,opt,name=expected_at,json=expectedAt,proto3" json:"expected_at,omitempty"`                             // RFC3339; when backordered stock is expected, if known
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
 func (x *OrderItem) Reset() {
 	*x = OrderItem{}
-	mi := &file_proto_order_order_proto_msgTypes[3]
+	mi := &file_proto_order_order_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -219,7 +359,7 @@ func (x *OrderItem) String() string {
 func (*OrderItem) ProtoMessage() {}
 
 func (x *OrderItem) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_order_proto_msgTypes[3]
+	mi := &file_proto_order_order_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -232,7 +372,7 @@ func (x *OrderItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderItem.ProtoReflect.Descriptor instead.
 func (*OrderItem) Descriptor() ([]byte, []int) {
-	return file_proto_order_order_proto_rawDescGZIP(), []int{3}
+	return file_proto_order_order_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *OrderItem) GetProductId() string {
@@ -249,6 +389,20 @@ func (x *OrderItem) GetQuantity() int32 {
 	return 0
 }
 
+func (x *OrderItem) GetBackorderedQuantity() int32 {
+	if x != nil {
+		return x.BackorderedQuantity
+	}
+	return 0
+}
+
+func (x *OrderItem) GetExpectedAt() string {
+	if x != nil {
+		return x.ExpectedAt
+	}
+	return ""
+}
+
 // Order response
 type OrderResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -260,13 +414,16 @@ type OrderResponse struct {
 	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
 	CreatedAt     string                 `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     string                 `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	RiskDecision  string                 `protobuf:"bytes,9,opt,name=risk_decision,json=riskDecision,proto3" json:"risk_decision,omitempty"` // ALLOW, REVIEW, DENY
+	RiskReasons   []string               `protobuf:"bytes,10,rep,name=risk_reasons,json=riskReasons,proto3" json:"risk_reasons,omitempty"`
+	PaymentId     string                 `protobuf:"bytes,11,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *OrderResponse) Reset() {
 	*x = OrderResponse{}
-	mi := &file_proto_order_order_proto_msgTypes[4]
+	mi := &file_proto_order_order_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -278,7 +435,7 @@ func (x *OrderResponse) String() string {
 func (*OrderResponse) ProtoMessage() {}
 
 func (x *OrderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_order_proto_msgTypes[4]
+	mi := &file_proto_order_order_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -291,7 +448,7 @@ func (x *OrderResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OrderResponse.ProtoReflect.Descriptor instead.
 func (*OrderResponse) Descriptor() ([]byte, []int) {
-	return file_proto_order_order_proto_rawDescGZIP(), []int{4}
+	return file_proto_order_order_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *OrderResponse) GetOrderId() string {
@@ -350,6 +507,27 @@ func (x *OrderResponse) GetUpdatedAt() string {
 	return ""
 }
 
+func (x *OrderResponse) GetRiskDecision() string {
+	if x != nil {
+		return x.RiskDecision
+	}
+	return ""
+}
+
+func (x *OrderResponse) GetRiskReasons() []string {
+	if x != nil {
+		return x.RiskReasons
+	}
+	return nil
+}
+
+func (x *OrderResponse) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
 // List orders response
 type ListOrdersResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -360,7 +538,7 @@ type ListOrdersResponse struct {
 
 func (x *ListOrdersResponse) Reset() {
 	*x = ListOrdersResponse{}
-	mi := &file_proto_order_order_proto_msgTypes[5]
+	mi := &file_proto_order_order_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -372,7 +550,7 @@ func (x *ListOrdersResponse) String() string {
 func (*ListOrdersResponse) ProtoMessage() {}
 
 func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_order_order_proto_msgTypes[5]
+	mi := &file_proto_order_order_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -385,7 +563,7 @@ func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
 func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
-	return file_proto_order_order_proto_rawDescGZIP(), []int{5}
+	return file_proto_order_order_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ListOrdersResponse) GetOrders() []*OrderResponse {
@@ -399,22 +577,35 @@ var File_proto_order_order_proto protoreflect.FileDescriptor
 
 const file_proto_order_order_proto_rawDesc = "" +
 	"\n" +
-	"\x17proto/order/order.proto\x12\x05order\"\x8b\x01\n" +
+	"\x17proto/order/order.proto\x12\x05order\"\xfc\x01\n" +
 	"\x12CreateOrderRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12&\n" +
 	"\x05items\x18\x02 \x03(\v2\x10.order.OrderItemR\x05items\x12\x18\n" +
 	"\aaddress\x18\x03 \x01(\tR\aaddress\x12\x1a\n" +
-	"\bcurrency\x18\x04 \x01(\tR\bcurrency\",\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12'\n" +
+	"\x0fbilling_country\x18\x05 \x01(\tR\x0ebillingCountry\x12)\n" +
+	"\x10shipping_country\x18\x06 \x01(\tR\x0fshippingCountry\x12\x1b\n" +
+	"\tclient_ip\x18\a \x01(\tR\bclientIp\",\n" +
 	"\x0fGetOrderRequest\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\"]\n" +
 	"\x11ListOrdersRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
 	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
-	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"F\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"y\n" +
+	"\x12ReviewOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x18\n" +
+	"\aapprove\x18\x02 \x01(\bR\aapprove\x12\x1a\n" +
+	"\breviewer\x18\x03 \x01(\tR\breviewer\x12\x12\n" +
+	"\x04note\x18\x04 \x01(\tR\x04note\"-\n" +
+	"\x10ShipOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"\x9a\x01\n" +
 	"\tOrderItem\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1a\n" +
-	"\bquantity\x18\x02 \x01(\x05R\bquantity\"\xf3\x01\n" +
+	"\bquantity\x18\x02 \x01(\x05R\bquantity\x121\n" +
+	"\x14backordered_quantity\x18\x03 \x01(\x05R\x13backorderedQuantity\x12\x1f\n" +
+	"\vexpected_at\x18\x04 \x01(\tR\n" +
+	"expectedAt\"\xda\x02\n" +
 	"\rOrderResponse\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12&\n" +
@@ -425,14 +616,21 @@ const file_proto_order_order_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\a \x01(\tR\tcreatedAt\x12\x1d\n" +
 	"\n" +
-	"updated_at\x18\b \x01(\tR\tupdatedAt\"B\n" +
+	"updated_at\x18\b \x01(\tR\tupdatedAt\x12#\n" +
+	"\rrisk_decision\x18\t \x01(\tR\friskDecision\x12!\n" +
+	"\frisk_reasons\x18\n" +
+	" \x03(\tR\vriskReasons\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\v \x01(\tR\tpaymentId\"B\n" +
 	"\x12ListOrdersResponse\x12,\n" +
-	"\x06orders\x18\x01 \x03(\v2\x14.order.OrderResponseR\x06orders2\xd1\x01\n" +
+	"\x06orders\x18\x01 \x03(\v2\x14.order.OrderResponseR\x06orders2\xd1\x02\n" +
 	"\fOrderService\x12@\n" +
 	"\vCreateOrder\x12\x19.order.CreateOrderRequest\x1a\x14.order.OrderResponse\"\x00\x12:\n" +
 	"\bGetOrder\x12\x16.order.GetOrderRequest\x1a\x14.order.OrderResponse\"\x00\x12C\n" +
 	"\n" +
-	"ListOrders\x12\x18.order.ListOrdersRequest\x1a\x19.order.ListOrdersResponse\"\x00B8Z6github.com/SabinGhost19/go-micro-payment/proto/orderpbb\x06proto3"
+	"ListOrders\x12\x18.order.ListOrdersRequest\x1a\x19.order.ListOrdersResponse\"\x00\x12@\n" +
+	"\vReviewOrder\x12\x19.order.ReviewOrderRequest\x1a\x14.order.OrderResponse\"\x00\x12<\n" +
+	"\tShipOrder\x12\x17.order.ShipOrderRequest\x1a\x14.order.OrderResponse\"\x00B8Z6github.com/SabinGhost19/go-micro-payment/proto/orderpbb\x06proto3"
 
 var (
 	file_proto_order_order_proto_rawDescOnce sync.Once
@@ -446,27 +644,33 @@ func file_proto_order_order_proto_rawDescGZIP() []byte {
 	return file_proto_order_order_proto_rawDescData
 }
 
-var file_proto_order_order_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_order_order_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_proto_order_order_proto_goTypes = []any{
 	(*CreateOrderRequest)(nil), // 0: order.CreateOrderRequest
 	(*GetOrderRequest)(nil),    // 1: order.GetOrderRequest
 	(*ListOrdersRequest)(nil),  // 2: order.ListOrdersRequest
-	(*OrderItem)(nil),          // 3: order.OrderItem
-	(*OrderResponse)(nil),      // 4: order.OrderResponse
-	(*ListOrdersResponse)(nil), // 5: order.ListOrdersResponse
+	(*ReviewOrderRequest)(nil), // 3: order.ReviewOrderRequest
+	(*ShipOrderRequest)(nil),   // 4: order.ShipOrderRequest
+	(*OrderItem)(nil),          // 5: order.OrderItem
+	(*OrderResponse)(nil),      // 6: order.OrderResponse
+	(*ListOrdersResponse)(nil), // 7: order.ListOrdersResponse
 }
 var file_proto_order_order_proto_depIdxs = []int32{
-	3, // 0: order.CreateOrderRequest.items:type_name -> order.OrderItem
-	3, // 1: order.OrderResponse.items:type_name -> order.OrderItem
-	4, // 2: order.ListOrdersResponse.orders:type_name -> order.OrderResponse
+	5, // 0: order.CreateOrderRequest.items:type_name -> order.OrderItem
+	5, // 1: order.OrderResponse.items:type_name -> order.OrderItem
+	6, // 2: order.ListOrdersResponse.orders:type_name -> order.OrderResponse
 	0, // 3: order.OrderService.CreateOrder:input_type -> order.CreateOrderRequest
 	1, // 4: order.OrderService.GetOrder:input_type -> order.GetOrderRequest
 	2, // 5: order.OrderService.ListOrders:input_type -> order.ListOrdersRequest
-	4, // 6: order.OrderService.CreateOrder:output_type -> order.OrderResponse
-	4, // 7: order.OrderService.GetOrder:output_type -> order.OrderResponse
-	5, // 8: order.OrderService.ListOrders:output_type -> order.ListOrdersResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
+	3, // 6: order.OrderService.ReviewOrder:input_type -> order.ReviewOrderRequest
+	4, // 7: order.OrderService.ShipOrder:input_type -> order.ShipOrderRequest
+	6, // 8: order.OrderService.CreateOrder:output_type -> order.OrderResponse
+	6, // 9: order.OrderService.GetOrder:output_type -> order.OrderResponse
+	7, // 10: order.OrderService.ListOrders:output_type -> order.ListOrdersResponse
+	6, // 11: order.OrderService.ReviewOrder:output_type -> order.OrderResponse
+	6, // 12: order.OrderService.ShipOrder:output_type -> order.OrderResponse
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
 	3, // [3:3] is the sub-list for extension type_name
 	3, // [3:3] is the sub-list for extension extendee
 	0, // [0:3] is the sub-list for field type_name
@@ -483,7 +687,7 @@ func file_proto_order_order_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_order_order_proto_rawDesc), len(file_proto_order_order_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -28,7 +28,10 @@ type InitiatePaymentRequest struct {
 	Amount          float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
 	Currency        string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
 	UserId          string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	PaymentMethodId string                 `protobuf:"bytes,5,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"` // Stripe PaymentMethod ID, if known
+	PaymentMethodId string                 `protobuf:"bytes,5,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"` // saved method of the user (see AttachPaymentMethod) to charge off-session
+	Provider        string                 `protobuf:"bytes,6,opt,name=provider,proto3" json:"provider,omitempty"`                                        // e.g., "stripe" or "simulator"; empty selects the configured default
+	CaptureMethod   string                 `protobuf:"bytes,7,opt,name=capture_method,json=captureMethod,proto3" json:"capture_method,omitempty"`         // "automatic" (default) or "manual" to authorize now and capture later
+	WalletAmount    float64                `protobuf:"fixed64,8,opt,name=wallet_amount,json=walletAmount,proto3" json:"wallet_amount,omitempty"`          // store credit to apply, less than amount; provider "wallet" pays the whole amount with credit
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -98,29 +101,2627 @@ func (x *InitiatePaymentRequest) GetPaymentMethodId() string {
 	return ""
 }
 
+func (x *InitiatePaymentRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *InitiatePaymentRequest) GetCaptureMethod() string {
+	if x != nil {
+		return x.CaptureMethod
+	}
+	return ""
+}
+
+func (x *InitiatePaymentRequest) GetWalletAmount() float64 {
+	if x != nil {
+		return x.WalletAmount
+	}
+	return 0
+}
+
 // Check payment status by payment ID
 type CheckPaymentStatusRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckPaymentStatusRequest) Reset() {
+	*x = CheckPaymentStatusRequest{}
+	mi := &file_payment_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckPaymentStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckPaymentStatusRequest) ProtoMessage() {}
+
+func (x *CheckPaymentStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckPaymentStatusRequest.ProtoReflect.Descriptor instead.
+func (*CheckPaymentStatusRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckPaymentStatusRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+// List payments, newest first; empty filters match every payment
+type ListPaymentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`                              // e.g., "PAID"
+	Provider      string                 `protobuf:"bytes,4,opt,name=provider,proto3" json:"provider,omitempty"`                          // e.g., "stripe"
+	CreatedFrom   string                 `protobuf:"bytes,5,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"` // RFC3339, inclusive
+	CreatedTo     string                 `protobuf:"bytes,6,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`       // RFC3339, exclusive
+	PageSize      int32                  `protobuf:"varint,7,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`         // default 50, at most 200
+	PageToken     string                 `protobuf:"bytes,8,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`       // next_page_token of the previous page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsRequest) Reset() {
+	*x = ListPaymentsRequest{}
+	mi := &file_payment_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsRequest) ProtoMessage() {}
+
+func (x *ListPaymentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsRequest.ProtoReflect.Descriptor instead.
+func (*ListPaymentsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListPaymentsRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *ListPaymentsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListPaymentsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListPaymentsRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ListPaymentsRequest) GetCreatedFrom() string {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return ""
+}
+
+func (x *ListPaymentsRequest) GetCreatedTo() string {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return ""
+}
+
+func (x *ListPaymentsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPaymentsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// Fetch every payment of an order with its attempts and refunds
+type GetPaymentsForOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPaymentsForOrderRequest) Reset() {
+	*x = GetPaymentsForOrderRequest{}
+	mi := &file_payment_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPaymentsForOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPaymentsForOrderRequest) ProtoMessage() {}
+
+func (x *GetPaymentsForOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPaymentsForOrderRequest.ProtoReflect.Descriptor instead.
+func (*GetPaymentsForOrderRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetPaymentsForOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+// Save a provider token as a payment method of the user. Raw card numbers are rejected.
+type AttachPaymentMethodRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Provider      string                 `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`                           // empty selects the configured default
+	Token         string                 `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`                                 // e.g., a Stripe PaymentMethod ID "pm_..."
+	MakeDefault   bool                   `protobuf:"varint,4,opt,name=make_default,json=makeDefault,proto3" json:"make_default,omitempty"` // the first method of a user is always the default
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachPaymentMethodRequest) Reset() {
+	*x = AttachPaymentMethodRequest{}
+	mi := &file_payment_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachPaymentMethodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachPaymentMethodRequest) ProtoMessage() {}
+
+func (x *AttachPaymentMethodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachPaymentMethodRequest.ProtoReflect.Descriptor instead.
+func (*AttachPaymentMethodRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AttachPaymentMethodRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *AttachPaymentMethodRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *AttachPaymentMethodRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *AttachPaymentMethodRequest) GetMakeDefault() bool {
+	if x != nil {
+		return x.MakeDefault
+	}
+	return false
+}
+
+// List the saved payment methods of a user
+type ListPaymentMethodsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentMethodsRequest) Reset() {
+	*x = ListPaymentMethodsRequest{}
+	mi := &file_payment_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentMethodsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentMethodsRequest) ProtoMessage() {}
+
+func (x *ListPaymentMethodsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentMethodsRequest.ProtoReflect.Descriptor instead.
+func (*ListPaymentMethodsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListPaymentMethodsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// Remove a saved payment method of the user
+type DetachPaymentMethodRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PaymentMethodId string                 `protobuf:"bytes,2,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DetachPaymentMethodRequest) Reset() {
+	*x = DetachPaymentMethodRequest{}
+	mi := &file_payment_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DetachPaymentMethodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetachPaymentMethodRequest) ProtoMessage() {}
+
+func (x *DetachPaymentMethodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetachPaymentMethodRequest.ProtoReflect.Descriptor instead.
+func (*DetachPaymentMethodRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DetachPaymentMethodRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DetachPaymentMethodRequest) GetPaymentMethodId() string {
+	if x != nil {
+		return x.PaymentMethodId
+	}
+	return ""
+}
+
+// A saved payment method; only display data, never the card number
+type PaymentMethodResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PaymentMethodId string                 `protobuf:"bytes,1,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"`
+	UserId          string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Provider        string                 `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"`
+	Brand           string                 `protobuf:"bytes,4,opt,name=brand,proto3" json:"brand,omitempty"` // e.g., "visa"
+	Last4           string                 `protobuf:"bytes,5,opt,name=last4,proto3" json:"last4,omitempty"`
+	ExpMonth        int32                  `protobuf:"varint,6,opt,name=exp_month,json=expMonth,proto3" json:"exp_month,omitempty"`
+	ExpYear         int32                  `protobuf:"varint,7,opt,name=exp_year,json=expYear,proto3" json:"exp_year,omitempty"`
+	IsDefault       bool                   `protobuf:"varint,8,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
+	CreatedAt       string                 `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PaymentMethodResponse) Reset() {
+	*x = PaymentMethodResponse{}
+	mi := &file_payment_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentMethodResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentMethodResponse) ProtoMessage() {}
+
+func (x *PaymentMethodResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentMethodResponse.ProtoReflect.Descriptor instead.
+func (*PaymentMethodResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PaymentMethodResponse) GetPaymentMethodId() string {
+	if x != nil {
+		return x.PaymentMethodId
+	}
+	return ""
+}
+
+func (x *PaymentMethodResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PaymentMethodResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *PaymentMethodResponse) GetBrand() string {
+	if x != nil {
+		return x.Brand
+	}
+	return ""
+}
+
+func (x *PaymentMethodResponse) GetLast4() string {
+	if x != nil {
+		return x.Last4
+	}
+	return ""
+}
+
+func (x *PaymentMethodResponse) GetExpMonth() int32 {
+	if x != nil {
+		return x.ExpMonth
+	}
+	return 0
+}
+
+func (x *PaymentMethodResponse) GetExpYear() int32 {
+	if x != nil {
+		return x.ExpYear
+	}
+	return 0
+}
+
+func (x *PaymentMethodResponse) GetIsDefault() bool {
+	if x != nil {
+		return x.IsDefault
+	}
+	return false
+}
+
+func (x *PaymentMethodResponse) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+// Saved payment methods, the default one first
+type ListPaymentMethodsResponse struct {
+	state          protoimpl.MessageState   `protogen:"open.v1"`
+	PaymentMethods []*PaymentMethodResponse `protobuf:"bytes,1,rep,name=payment_methods,json=paymentMethods,proto3" json:"payment_methods,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListPaymentMethodsResponse) Reset() {
+	*x = ListPaymentMethodsResponse{}
+	mi := &file_payment_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentMethodsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentMethodsResponse) ProtoMessage() {}
+
+func (x *ListPaymentMethodsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentMethodsResponse.ProtoReflect.Descriptor instead.
+func (*ListPaymentMethodsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListPaymentMethodsResponse) GetPaymentMethods() []*PaymentMethodResponse {
+	if x != nil {
+		return x.PaymentMethods
+	}
+	return nil
+}
+
+// Result of removing a payment method
+type DetachPaymentMethodResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PaymentMethodId string                 `protobuf:"bytes,1,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DetachPaymentMethodResponse) Reset() {
+	*x = DetachPaymentMethodResponse{}
+	mi := &file_payment_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DetachPaymentMethodResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetachPaymentMethodResponse) ProtoMessage() {}
+
+func (x *DetachPaymentMethodResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetachPaymentMethodResponse.ProtoReflect.Descriptor instead.
+func (*DetachPaymentMethodResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DetachPaymentMethodResponse) GetPaymentMethodId() string {
+	if x != nil {
+		return x.PaymentMethodId
+	}
+	return ""
+}
+
+// Request to capture an authorized payment
+type CapturePaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"` // 0 captures the full authorized amount
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CapturePaymentRequest) Reset() {
+	*x = CapturePaymentRequest{}
+	mi := &file_payment_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CapturePaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CapturePaymentRequest) ProtoMessage() {}
+
+func (x *CapturePaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CapturePaymentRequest.ProtoReflect.Descriptor instead.
+func (*CapturePaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CapturePaymentRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *CapturePaymentRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+// Request to release an authorization or abandon an unpaid payment
+type VoidPaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VoidPaymentRequest) Reset() {
+	*x = VoidPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VoidPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoidPaymentRequest) ProtoMessage() {}
+
+func (x *VoidPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoidPaymentRequest.ProtoReflect.Descriptor instead.
+func (*VoidPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *VoidPaymentRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *VoidPaymentRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Resume a REQUIRES_ACTION payment after the customer completed the next action
+type ConfirmPaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmPaymentRequest) Reset() {
+	*x = ConfirmPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmPaymentRequest) ProtoMessage() {}
+
+func (x *ConfirmPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmPaymentRequest.ProtoReflect.Descriptor instead.
+func (*ConfirmPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ConfirmPaymentRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+// Request to refund all or part of a paid payment
+type RefundPaymentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId      string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	Amount         float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"` // 0 refunds the remaining refundable amount
+	Reason         string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // required; retrying with the same key returns the original refund
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RefundPaymentRequest) Reset() {
+	*x = RefundPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundPaymentRequest) ProtoMessage() {}
+
+func (x *RefundPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundPaymentRequest.ProtoReflect.Descriptor instead.
+func (*RefundPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RefundPaymentRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *RefundPaymentRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *RefundPaymentRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RefundPaymentRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+// Payment response
+type PaymentResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId       string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	OrderId         string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Status          string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`     // PENDING, REQUIRES_ACTION, AUTHORIZED, CAPTURED, PAID, RETRY_SCHEDULED, FAILED, VOIDED, EXPIRED, PARTIALLY_REFUNDED, REFUNDED
+	Provider        string                 `protobuf:"bytes,4,opt,name=provider,proto3" json:"provider,omitempty"` // e.g., "stripe"
+	CreatedAt       string                 `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt       string                 `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Message         string                 `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`
+	CheckoutUrl     string                 `protobuf:"bytes,8,opt,name=checkout_url,json=checkoutUrl,proto3" json:"checkout_url,omitempty"` // hosted payment page, when the provider has one
+	RefundedAmount  float64                `protobuf:"fixed64,9,opt,name=refunded_amount,json=refundedAmount,proto3" json:"refunded_amount,omitempty"`
+	Amount          float64                `protobuf:"fixed64,10,opt,name=amount,proto3" json:"amount,omitempty"`
+	CapturedAmount  float64                `protobuf:"fixed64,11,opt,name=captured_amount,json=capturedAmount,proto3" json:"captured_amount,omitempty"`
+	CaptureMethod   string                 `protobuf:"bytes,12,opt,name=capture_method,json=captureMethod,proto3" json:"capture_method,omitempty"`
+	Attempts        int32                  `protobuf:"varint,13,opt,name=attempts,proto3" json:"attempts,omitempty"`                           // charge attempts made, including retries
+	NextRetryAt     string                 `protobuf:"bytes,14,opt,name=next_retry_at,json=nextRetryAt,proto3" json:"next_retry_at,omitempty"` // RFC3339, set while RETRY_SCHEDULED
+	DeclineCode     string                 `protobuf:"bytes,15,opt,name=decline_code,json=declineCode,proto3" json:"decline_code,omitempty"`   // of the last declined attempt, e.g. "insufficient_funds"
+	UserId          string                 `protobuf:"bytes,16,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Currency        string                 `protobuf:"bytes,17,opt,name=currency,proto3" json:"currency,omitempty"`
+	PaymentMethodId string                 `protobuf:"bytes,18,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"` // saved method charged off-session, if any
+	NextAction      *NextAction            `protobuf:"bytes,19,opt,name=next_action,json=nextAction,proto3" json:"next_action,omitempty"`                  // set while REQUIRES_ACTION
+	FeeAmount       float64                `protobuf:"fixed64,20,opt,name=fee_amount,json=feeAmount,proto3" json:"fee_amount,omitempty"`                   // provider fee of the capture
+	NetAmount       float64                `protobuf:"fixed64,21,opt,name=net_amount,json=netAmount,proto3" json:"net_amount,omitempty"`                   // captured less refunds and all fees
+	WalletAmount    float64                `protobuf:"fixed64,22,opt,name=wallet_amount,json=walletAmount,proto3" json:"wallet_amount,omitempty"`          // store credit applied; the provider charged the rest
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PaymentResponse) Reset() {
+	*x = PaymentResponse{}
+	mi := &file_payment_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentResponse) ProtoMessage() {}
+
+func (x *PaymentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentResponse.ProtoReflect.Descriptor instead.
+func (*PaymentResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *PaymentResponse) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetCheckoutUrl() string {
+	if x != nil {
+		return x.CheckoutUrl
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetRefundedAmount() float64 {
+	if x != nil {
+		return x.RefundedAmount
+	}
+	return 0
+}
+
+func (x *PaymentResponse) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *PaymentResponse) GetCapturedAmount() float64 {
+	if x != nil {
+		return x.CapturedAmount
+	}
+	return 0
+}
+
+func (x *PaymentResponse) GetCaptureMethod() string {
+	if x != nil {
+		return x.CaptureMethod
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *PaymentResponse) GetNextRetryAt() string {
+	if x != nil {
+		return x.NextRetryAt
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetDeclineCode() string {
+	if x != nil {
+		return x.DeclineCode
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetPaymentMethodId() string {
+	if x != nil {
+		return x.PaymentMethodId
+	}
+	return ""
+}
+
+func (x *PaymentResponse) GetNextAction() *NextAction {
+	if x != nil {
+		return x.NextAction
+	}
+	return nil
+}
+
+func (x *PaymentResponse) GetFeeAmount() float64 {
+	if x != nil {
+		return x.FeeAmount
+	}
+	return 0
+}
+
+func (x *PaymentResponse) GetNetAmount() float64 {
+	if x != nil {
+		return x.NetAmount
+	}
+	return 0
+}
+
+func (x *PaymentResponse) GetWalletAmount() float64 {
+	if x != nil {
+		return x.WalletAmount
+	}
+	return 0
+}
+
+// How the customer completes authentication, e.g. a 3-D Secure challenge.
+// Afterwards the client calls ConfirmPayment.
+type NextAction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`                                     // "redirect_to_url" or "use_client_secret"
+	RedirectUrl   string                 `protobuf:"bytes,2,opt,name=redirect_url,json=redirectUrl,proto3" json:"redirect_url,omitempty"`    // page to send the customer to, for redirect_to_url
+	ClientSecret  string                 `protobuf:"bytes,3,opt,name=client_secret,json=clientSecret,proto3" json:"client_secret,omitempty"` // for completing the challenge with the provider SDK, e.g. Stripe.js
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NextAction) Reset() {
+	*x = NextAction{}
+	mi := &file_payment_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NextAction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NextAction) ProtoMessage() {}
+
+func (x *NextAction) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NextAction.ProtoReflect.Descriptor instead.
+func (*NextAction) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *NextAction) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *NextAction) GetRedirectUrl() string {
+	if x != nil {
+		return x.RedirectUrl
+	}
+	return ""
+}
+
+func (x *NextAction) GetClientSecret() string {
+	if x != nil {
+		return x.ClientSecret
+	}
+	return ""
+}
+
+// Answer a dispute that needs a response; text, files or both are required
+type SubmitDisputeEvidenceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DisputeId     string                 `protobuf:"bytes,1,opt,name=dispute_id,json=disputeId,proto3" json:"dispute_id,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`   // sent to the provider, e.g. shipping details and customer communication
+	Files         []*EvidenceFile        `protobuf:"bytes,3,rep,name=files,proto3" json:"files,omitempty"` // stored by the payment service, at most 10 files of 5 MiB each
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitDisputeEvidenceRequest) Reset() {
+	*x = SubmitDisputeEvidenceRequest{}
+	mi := &file_payment_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitDisputeEvidenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitDisputeEvidenceRequest) ProtoMessage() {}
+
+func (x *SubmitDisputeEvidenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitDisputeEvidenceRequest.ProtoReflect.Descriptor instead.
+func (*SubmitDisputeEvidenceRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SubmitDisputeEvidenceRequest) GetDisputeId() string {
+	if x != nil {
+		return x.DisputeId
+	}
+	return ""
+}
+
+func (x *SubmitDisputeEvidenceRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SubmitDisputeEvidenceRequest) GetFiles() []*EvidenceFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+// A file submitted as dispute evidence
+type EvidenceFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileName      string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`          // a plain file name without directories
+	ContentType   string                 `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"` // e.g., "application/pdf"
+	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvidenceFile) Reset() {
+	*x = EvidenceFile{}
+	mi := &file_payment_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvidenceFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvidenceFile) ProtoMessage() {}
+
+func (x *EvidenceFile) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvidenceFile.ProtoReflect.Descriptor instead.
+func (*EvidenceFile) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *EvidenceFile) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *EvidenceFile) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *EvidenceFile) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+// Request to get a dispute
+type GetDisputeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DisputeId     string                 `protobuf:"bytes,1,opt,name=dispute_id,json=disputeId,proto3" json:"dispute_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDisputeRequest) Reset() {
+	*x = GetDisputeRequest{}
+	mi := &file_payment_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDisputeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDisputeRequest) ProtoMessage() {}
+
+func (x *GetDisputeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDisputeRequest.ProtoReflect.Descriptor instead.
+func (*GetDisputeRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetDisputeRequest) GetDisputeId() string {
+	if x != nil {
+		return x.DisputeId
+	}
+	return ""
+}
+
+// Request to list disputes; empty fields match every dispute
+type ListDisputesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // needs_response, under_review, won, lost
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDisputesRequest) Reset() {
+	*x = ListDisputesRequest{}
+	mi := &file_payment_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDisputesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDisputesRequest) ProtoMessage() {}
+
+func (x *ListDisputesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDisputesRequest.ProtoReflect.Descriptor instead.
+func (*ListDisputesRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListDisputesRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *ListDisputesRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// Dispute response
+type DisputeResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	DisputeId           string                 `protobuf:"bytes,1,opt,name=dispute_id,json=disputeId,proto3" json:"dispute_id,omitempty"`
+	PaymentId           string                 `protobuf:"bytes,2,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	Provider            string                 `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"`
+	ProviderDisputeId   string                 `protobuf:"bytes,4,opt,name=provider_dispute_id,json=providerDisputeId,proto3" json:"provider_dispute_id,omitempty"`
+	Reason              string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"` // e.g., "fraudulent"
+	Amount              float64                `protobuf:"fixed64,6,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency            string                 `protobuf:"bytes,7,opt,name=currency,proto3" json:"currency,omitempty"`
+	Status              string                 `protobuf:"bytes,8,opt,name=status,proto3" json:"status,omitempty"`                                      // needs_response, under_review, won, lost
+	EvidenceDueBy       string                 `protobuf:"bytes,9,opt,name=evidence_due_by,json=evidenceDueBy,proto3" json:"evidence_due_by,omitempty"` // RFC3339, when the provider gave a deadline
+	EvidenceText        string                 `protobuf:"bytes,10,opt,name=evidence_text,json=evidenceText,proto3" json:"evidence_text,omitempty"`
+	EvidenceSubmittedAt string                 `protobuf:"bytes,11,opt,name=evidence_submitted_at,json=evidenceSubmittedAt,proto3" json:"evidence_submitted_at,omitempty"`
+	EvidenceFiles       []*EvidenceFileInfo    `protobuf:"bytes,12,rep,name=evidence_files,json=evidenceFiles,proto3" json:"evidence_files,omitempty"`
+	CreatedAt           string                 `protobuf:"bytes,13,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ClosedAt            string                 `protobuf:"bytes,14,opt,name=closed_at,json=closedAt,proto3" json:"closed_at,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *DisputeResponse) Reset() {
+	*x = DisputeResponse{}
+	mi := &file_payment_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisputeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisputeResponse) ProtoMessage() {}
+
+func (x *DisputeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisputeResponse.ProtoReflect.Descriptor instead.
+func (*DisputeResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DisputeResponse) GetDisputeId() string {
+	if x != nil {
+		return x.DisputeId
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetProviderDisputeId() string {
+	if x != nil {
+		return x.ProviderDisputeId
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *DisputeResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetEvidenceDueBy() string {
+	if x != nil {
+		return x.EvidenceDueBy
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetEvidenceText() string {
+	if x != nil {
+		return x.EvidenceText
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetEvidenceSubmittedAt() string {
+	if x != nil {
+		return x.EvidenceSubmittedAt
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetEvidenceFiles() []*EvidenceFileInfo {
+	if x != nil {
+		return x.EvidenceFiles
+	}
+	return nil
+}
+
+func (x *DisputeResponse) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *DisputeResponse) GetClosedAt() string {
+	if x != nil {
+		return x.ClosedAt
+	}
+	return ""
+}
+
+// A stored evidence file, without its content
+type EvidenceFileInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileName      string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	ContentType   string                 `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvidenceFileInfo) Reset() {
+	*x = EvidenceFileInfo{}
+	mi := &file_payment_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvidenceFileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvidenceFileInfo) ProtoMessage() {}
+
+func (x *EvidenceFileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvidenceFileInfo.ProtoReflect.Descriptor instead.
+func (*EvidenceFileInfo) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *EvidenceFileInfo) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *EvidenceFileInfo) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *EvidenceFileInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *EvidenceFileInfo) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+// Disputes, newest first
+type ListDisputesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Disputes      []*DisputeResponse     `protobuf:"bytes,1,rep,name=disputes,proto3" json:"disputes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDisputesResponse) Reset() {
+	*x = ListDisputesResponse{}
+	mi := &file_payment_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDisputesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDisputesResponse) ProtoMessage() {}
+
+func (x *ListDisputesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDisputesResponse.ProtoReflect.Descriptor instead.
+func (*ListDisputesResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListDisputesResponse) GetDisputes() []*DisputeResponse {
+	if x != nil {
+		return x.Disputes
+	}
+	return nil
+}
+
+// One page of payments
+type ListPaymentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payments      []*PaymentResponse     `protobuf:"bytes,1,rep,name=payments,proto3" json:"payments,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsResponse) Reset() {
+	*x = ListPaymentsResponse{}
+	mi := &file_payment_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsResponse) ProtoMessage() {}
+
+func (x *ListPaymentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsResponse.ProtoReflect.Descriptor instead.
+func (*ListPaymentsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListPaymentsResponse) GetPayments() []*PaymentResponse {
+	if x != nil {
+		return x.Payments
+	}
+	return nil
+}
+
+func (x *ListPaymentsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// One try at charging a payment
+type PaymentAttemptResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        int32                  `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	SessionId     string                 `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // PENDING, SUCCEEDED, FAILED
+	DeclineCode   string                 `protobuf:"bytes,4,opt,name=decline_code,json=declineCode,proto3" json:"decline_code,omitempty"`
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	FinishedAt    string                 `protobuf:"bytes,7,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PaymentAttemptResponse) Reset() {
+	*x = PaymentAttemptResponse{}
+	mi := &file_payment_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentAttemptResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentAttemptResponse) ProtoMessage() {}
+
+func (x *PaymentAttemptResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentAttemptResponse.ProtoReflect.Descriptor instead.
+func (*PaymentAttemptResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *PaymentAttemptResponse) GetNumber() int32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *PaymentAttemptResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *PaymentAttemptResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PaymentAttemptResponse) GetDeclineCode() string {
+	if x != nil {
+		return x.DeclineCode
+	}
+	return ""
+}
+
+func (x *PaymentAttemptResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *PaymentAttemptResponse) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *PaymentAttemptResponse) GetFinishedAt() string {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return ""
+}
+
+// A payment of an order with its history
+type OrderPayment struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	Payment       *PaymentResponse          `protobuf:"bytes,1,opt,name=payment,proto3" json:"payment,omitempty"`
+	Attempts      []*PaymentAttemptResponse `protobuf:"bytes,2,rep,name=attempts,proto3" json:"attempts,omitempty"`
+	Refunds       []*RefundResponse         `protobuf:"bytes,3,rep,name=refunds,proto3" json:"refunds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderPayment) Reset() {
+	*x = OrderPayment{}
+	mi := &file_payment_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderPayment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderPayment) ProtoMessage() {}
+
+func (x *OrderPayment) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderPayment.ProtoReflect.Descriptor instead.
+func (*OrderPayment) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *OrderPayment) GetPayment() *PaymentResponse {
+	if x != nil {
+		return x.Payment
+	}
+	return nil
+}
+
+func (x *OrderPayment) GetAttempts() []*PaymentAttemptResponse {
+	if x != nil {
+		return x.Attempts
+	}
+	return nil
+}
+
+func (x *OrderPayment) GetRefunds() []*RefundResponse {
+	if x != nil {
+		return x.Refunds
+	}
+	return nil
+}
+
+// Every payment of an order, oldest first
+type OrderPaymentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Payments      []*OrderPayment        `protobuf:"bytes,2,rep,name=payments,proto3" json:"payments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderPaymentsResponse) Reset() {
+	*x = OrderPaymentsResponse{}
+	mi := &file_payment_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderPaymentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderPaymentsResponse) ProtoMessage() {}
+
+func (x *OrderPaymentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderPaymentsResponse.ProtoReflect.Descriptor instead.
+func (*OrderPaymentsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *OrderPaymentsResponse) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *OrderPaymentsResponse) GetPayments() []*OrderPayment {
+	if x != nil {
+		return x.Payments
+	}
+	return nil
+}
+
+// Refund response
+type RefundResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RefundId       string                 `protobuf:"bytes,1,opt,name=refund_id,json=refundId,proto3" json:"refund_id,omitempty"`
+	PaymentId      string                 `protobuf:"bytes,2,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	Amount         float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency       string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	Status         string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"` // PENDING, SUCCEEDED, FAILED
+	Reason         string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message        string                 `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`
+	CreatedAt      string                 `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	PaymentStatus  string                 `protobuf:"bytes,9,opt,name=payment_status,json=paymentStatus,proto3" json:"payment_status,omitempty"`
+	RefundedAmount float64                `protobuf:"fixed64,10,opt,name=refunded_amount,json=refundedAmount,proto3" json:"refunded_amount,omitempty"` // total refunded on the payment so far
+	FeeAmount      float64                `protobuf:"fixed64,11,opt,name=fee_amount,json=feeAmount,proto3" json:"fee_amount,omitempty"`                // provider fee of the refund
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RefundResponse) Reset() {
+	*x = RefundResponse{}
+	mi := &file_payment_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefundResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefundResponse) ProtoMessage() {}
+
+func (x *RefundResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefundResponse.ProtoReflect.Descriptor instead.
+func (*RefundResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *RefundResponse) GetRefundId() string {
+	if x != nil {
+		return x.RefundId
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *RefundResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetPaymentStatus() string {
+	if x != nil {
+		return x.PaymentStatus
+	}
+	return ""
+}
+
+func (x *RefundResponse) GetRefundedAmount() float64 {
+	if x != nil {
+		return x.RefundedAmount
+	}
+	return 0
+}
+
+func (x *RefundResponse) GetFeeAmount() float64 {
+	if x != nil {
+		return x.FeeAmount
+	}
+	return 0
+}
+
+// Fetch a reconciliation report
+type GetReconciliationReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ReportId      string                 `protobuf:"bytes,1,opt,name=report_id,json=reportId,proto3" json:"report_id,omitempty"` // empty returns the latest report
+	Provider      string                 `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`                 // with an empty report_id, limits the lookup to one provider
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReconciliationReportRequest) Reset() {
+	*x = GetReconciliationReportRequest{}
+	mi := &file_payment_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReconciliationReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReconciliationReportRequest) ProtoMessage() {}
+
+func (x *GetReconciliationReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReconciliationReportRequest.ProtoReflect.Descriptor instead.
+func (*GetReconciliationReportRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetReconciliationReportRequest) GetReportId() string {
+	if x != nil {
+		return x.ReportId
+	}
+	return ""
+}
+
+func (x *GetReconciliationReportRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+// A discrepancy between a local payment and a provider transaction
+type ReconciliationItem struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Kind           string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"` // MISSING_LOCALLY, MISSING_AT_PROVIDER, AMOUNT_MISMATCH, STATUS_MISMATCH
+	PaymentId      string                 `protobuf:"bytes,2,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	SessionId      string                 `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	LocalStatus    string                 `protobuf:"bytes,4,opt,name=local_status,json=localStatus,proto3" json:"local_status,omitempty"`
+	ProviderStatus string                 `protobuf:"bytes,5,opt,name=provider_status,json=providerStatus,proto3" json:"provider_status,omitempty"`
+	LocalAmount    float64                `protobuf:"fixed64,6,opt,name=local_amount,json=localAmount,proto3" json:"local_amount,omitempty"`
+	ProviderAmount float64                `protobuf:"fixed64,7,opt,name=provider_amount,json=providerAmount,proto3" json:"provider_amount,omitempty"`
+	Fixed          bool                   `protobuf:"varint,8,opt,name=fixed,proto3" json:"fixed,omitempty"` // corrected automatically
+	Note           string                 `protobuf:"bytes,9,opt,name=note,proto3" json:"note,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReconciliationItem) Reset() {
+	*x = ReconciliationItem{}
+	mi := &file_payment_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconciliationItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconciliationItem) ProtoMessage() {}
+
+func (x *ReconciliationItem) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconciliationItem.ProtoReflect.Descriptor instead.
+func (*ReconciliationItem) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ReconciliationItem) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *ReconciliationItem) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *ReconciliationItem) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ReconciliationItem) GetLocalStatus() string {
+	if x != nil {
+		return x.LocalStatus
+	}
+	return ""
+}
+
+func (x *ReconciliationItem) GetProviderStatus() string {
+	if x != nil {
+		return x.ProviderStatus
+	}
+	return ""
+}
+
+func (x *ReconciliationItem) GetLocalAmount() float64 {
+	if x != nil {
+		return x.LocalAmount
+	}
+	return 0
+}
+
+func (x *ReconciliationItem) GetProviderAmount() float64 {
+	if x != nil {
+		return x.ProviderAmount
+	}
+	return 0
+}
+
+func (x *ReconciliationItem) GetFixed() bool {
+	if x != nil {
+		return x.Fixed
+	}
+	return false
+}
+
+func (x *ReconciliationItem) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+// Reconciliation report
+type ReconciliationReportResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	ReportId          string                 `protobuf:"bytes,1,opt,name=report_id,json=reportId,proto3" json:"report_id,omitempty"`
+	Provider          string                 `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	WindowStart       string                 `protobuf:"bytes,3,opt,name=window_start,json=windowStart,proto3" json:"window_start,omitempty"`
+	WindowEnd         string                 `protobuf:"bytes,4,opt,name=window_end,json=windowEnd,proto3" json:"window_end,omitempty"`
+	StartedAt         string                 `protobuf:"bytes,5,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt        string                 `protobuf:"bytes,6,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Matched           int32                  `protobuf:"varint,7,opt,name=matched,proto3" json:"matched,omitempty"`
+	MissingLocally    int32                  `protobuf:"varint,8,opt,name=missing_locally,json=missingLocally,proto3" json:"missing_locally,omitempty"`
+	MissingAtProvider int32                  `protobuf:"varint,9,opt,name=missing_at_provider,json=missingAtProvider,proto3" json:"missing_at_provider,omitempty"`
+	AmountMismatches  int32                  `protobuf:"varint,10,opt,name=amount_mismatches,json=amountMismatches,proto3" json:"amount_mismatches,omitempty"`
+	StatusMismatches  int32                  `protobuf:"varint,11,opt,name=status_mismatches,json=statusMismatches,proto3" json:"status_mismatches,omitempty"`
+	Fixed             int32                  `protobuf:"varint,12,opt,name=fixed,proto3" json:"fixed,omitempty"`
+	Items             []*ReconciliationItem  `protobuf:"bytes,13,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ReconciliationReportResponse) Reset() {
+	*x = ReconciliationReportResponse{}
+	mi := &file_payment_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconciliationReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconciliationReportResponse) ProtoMessage() {}
+
+func (x *ReconciliationReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconciliationReportResponse.ProtoReflect.Descriptor instead.
+func (*ReconciliationReportResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ReconciliationReportResponse) GetReportId() string {
+	if x != nil {
+		return x.ReportId
+	}
+	return ""
+}
+
+func (x *ReconciliationReportResponse) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ReconciliationReportResponse) GetWindowStart() string {
+	if x != nil {
+		return x.WindowStart
+	}
+	return ""
+}
+
+func (x *ReconciliationReportResponse) GetWindowEnd() string {
+	if x != nil {
+		return x.WindowEnd
+	}
+	return ""
+}
+
+func (x *ReconciliationReportResponse) GetStartedAt() string {
+	if x != nil {
+		return x.StartedAt
+	}
+	return ""
+}
+
+func (x *ReconciliationReportResponse) GetFinishedAt() string {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return ""
+}
+
+func (x *ReconciliationReportResponse) GetMatched() int32 {
+	if x != nil {
+		return x.Matched
+	}
+	return 0
+}
+
+func (x *ReconciliationReportResponse) GetMissingLocally() int32 {
+	if x != nil {
+		return x.MissingLocally
+	}
+	return 0
+}
+
+func (x *ReconciliationReportResponse) GetMissingAtProvider() int32 {
+	if x != nil {
+		return x.MissingAtProvider
+	}
+	return 0
+}
+
+func (x *ReconciliationReportResponse) GetAmountMismatches() int32 {
+	if x != nil {
+		return x.AmountMismatches
+	}
+	return 0
+}
+
+func (x *ReconciliationReportResponse) GetStatusMismatches() int32 {
+	if x != nil {
+		return x.StatusMismatches
+	}
+	return 0
+}
+
+func (x *ReconciliationReportResponse) GetFixed() int32 {
+	if x != nil {
+		return x.Fixed
+	}
+	return 0
+}
+
+func (x *ReconciliationReportResponse) GetItems() []*ReconciliationItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// Request for the settlement report of a period of UTC days
+type GetSettlementReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`         // first day, e.g. "2024-05-01"
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`             // last day, inclusive; at most 366 days after from
+	Provider      string                 `protobuf:"bytes,3,opt,name=provider,proto3" json:"provider,omitempty"` // empty includes every provider
+	Currency      string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"` // empty includes every currency
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSettlementReportRequest) Reset() {
+	*x = GetSettlementReportRequest{}
+	mi := &file_payment_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSettlementReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSettlementReportRequest) ProtoMessage() {}
+
+func (x *GetSettlementReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSettlementReportRequest.ProtoReflect.Descriptor instead.
+func (*GetSettlementReportRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *GetSettlementReportRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *GetSettlementReportRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *GetSettlementReportRequest) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *GetSettlementReportRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+// Money moved on one day, or over the whole period, in one currency
+type SettlementLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Date          string                 `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"` // empty for period totals
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	Gross         float64                `protobuf:"fixed64,3,opt,name=gross,proto3" json:"gross,omitempty"` // captured
+	Fees          float64                `protobuf:"fixed64,4,opt,name=fees,proto3" json:"fees,omitempty"`   // provider fees of captures and refunds
+	Refunds       float64                `protobuf:"fixed64,5,opt,name=refunds,proto3" json:"refunds,omitempty"`
+	Net           float64                `protobuf:"fixed64,6,opt,name=net,proto3" json:"net,omitempty"` // gross less refunds and fees, what the provider pays out
+	Captures      int32                  `protobuf:"varint,7,opt,name=captures,proto3" json:"captures,omitempty"`
+	RefundCount   int32                  `protobuf:"varint,8,opt,name=refund_count,json=refundCount,proto3" json:"refund_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SettlementLine) Reset() {
+	*x = SettlementLine{}
+	mi := &file_payment_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SettlementLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SettlementLine) ProtoMessage() {}
+
+func (x *SettlementLine) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SettlementLine.ProtoReflect.Descriptor instead.
+func (*SettlementLine) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *SettlementLine) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *SettlementLine) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *SettlementLine) GetGross() float64 {
+	if x != nil {
+		return x.Gross
+	}
+	return 0
+}
+
+func (x *SettlementLine) GetFees() float64 {
+	if x != nil {
+		return x.Fees
+	}
+	return 0
+}
+
+func (x *SettlementLine) GetRefunds() float64 {
+	if x != nil {
+		return x.Refunds
+	}
+	return 0
+}
+
+func (x *SettlementLine) GetNet() float64 {
+	if x != nil {
+		return x.Net
+	}
+	return 0
+}
+
+func (x *SettlementLine) GetCaptures() int32 {
+	if x != nil {
+		return x.Captures
+	}
+	return 0
+}
+
+func (x *SettlementLine) GetRefundCount() int32 {
+	if x != nil {
+		return x.RefundCount
+	}
+	return 0
+}
+
+// Settlement report
+type SettlementReportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Days          []*SettlementLine      `protobuf:"bytes,3,rep,name=days,proto3" json:"days,omitempty"`     // by date, then currency
+	Totals        []*SettlementLine      `protobuf:"bytes,4,rep,name=totals,proto3" json:"totals,omitempty"` // one per currency
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SettlementReportResponse) Reset() {
+	*x = SettlementReportResponse{}
+	mi := &file_payment_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SettlementReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SettlementReportResponse) ProtoMessage() {}
+
+func (x *SettlementReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SettlementReportResponse.ProtoReflect.Descriptor instead.
+func (*SettlementReportResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *SettlementReportResponse) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *SettlementReportResponse) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *SettlementReportResponse) GetDays() []*SettlementLine {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+func (x *SettlementReportResponse) GetTotals() []*SettlementLine {
+	if x != nil {
+		return x.Totals
+	}
+	return nil
+}
+
+// Request for a user's store credit; an empty currency returns every wallet
+type GetWalletBalanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWalletBalanceRequest) Reset() {
+	*x = GetWalletBalanceRequest{}
+	mi := &file_payment_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWalletBalanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWalletBalanceRequest) ProtoMessage() {}
+
+func (x *GetWalletBalanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWalletBalanceRequest.ProtoReflect.Descriptor instead.
+func (*GetWalletBalanceRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *GetWalletBalanceRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetWalletBalanceRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+// Store credit of a user in one currency
+type WalletBalance struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Currency      string                 `protobuf:"bytes,1,opt,name=currency,proto3" json:"currency,omitempty"`
+	Balance       float64                `protobuf:"fixed64,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	UpdatedAt     string                 `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletBalance) Reset() {
+	*x = WalletBalance{}
+	mi := &file_payment_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletBalance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletBalance) ProtoMessage() {}
+
+func (x *WalletBalance) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletBalance.ProtoReflect.Descriptor instead.
+func (*WalletBalance) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *WalletBalance) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *WalletBalance) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *WalletBalance) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+// Store credit of a user, one balance per currency
+type WalletBalanceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Balances      []*WalletBalance       `protobuf:"bytes,2,rep,name=balances,proto3" json:"balances,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CheckPaymentStatusRequest) Reset() {
-	*x = CheckPaymentStatusRequest{}
-	mi := &file_payment_proto_msgTypes[1]
+func (x *WalletBalanceResponse) Reset() {
+	*x = WalletBalanceResponse{}
+	mi := &file_payment_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CheckPaymentStatusRequest) String() string {
+func (x *WalletBalanceResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CheckPaymentStatusRequest) ProtoMessage() {}
+func (*WalletBalanceResponse) ProtoMessage() {}
 
-func (x *CheckPaymentStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_payment_proto_msgTypes[1]
+func (x *WalletBalanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -131,47 +2732,51 @@ func (x *CheckPaymentStatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CheckPaymentStatusRequest.ProtoReflect.Descriptor instead.
-func (*CheckPaymentStatusRequest) Descriptor() ([]byte, []int) {
-	return file_payment_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use WalletBalanceResponse.ProtoReflect.Descriptor instead.
+func (*WalletBalanceResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *CheckPaymentStatusRequest) GetPaymentId() string {
+func (x *WalletBalanceResponse) GetUserId() string {
 	if x != nil {
-		return x.PaymentId
+		return x.UserId
 	}
 	return ""
 }
 
-// Payment response
-type PaymentResponse struct {
+func (x *WalletBalanceResponse) GetBalances() []*WalletBalance {
+	if x != nil {
+		return x.Balances
+	}
+	return nil
+}
+
+// Request for a page of a user's wallet history, newest first
+type ListWalletTransactionsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	PaymentId     string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
-	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`     // PENDING, PAID, FAILED
-	Provider      string                 `protobuf:"bytes,4,opt,name=provider,proto3" json:"provider,omitempty"` // e.g., "stripe"
-	CreatedAt     string                 `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     string                 `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	Message       string                 `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`                    // empty includes every currency
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // default 50, at most 200
+	PageToken     string                 `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // next_page_token of the previous page
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PaymentResponse) Reset() {
-	*x = PaymentResponse{}
-	mi := &file_payment_proto_msgTypes[2]
+func (x *ListWalletTransactionsRequest) Reset() {
+	*x = ListWalletTransactionsRequest{}
+	mi := &file_payment_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PaymentResponse) String() string {
+func (x *ListWalletTransactionsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PaymentResponse) ProtoMessage() {}
+func (*ListWalletTransactionsRequest) ProtoMessage() {}
 
-func (x *PaymentResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_payment_proto_msgTypes[2]
+func (x *ListWalletTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -182,56 +2787,298 @@ func (x *PaymentResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PaymentResponse.ProtoReflect.Descriptor instead.
-func (*PaymentResponse) Descriptor() ([]byte, []int) {
-	return file_payment_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use ListWalletTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ListWalletTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{37}
 }
 
-func (x *PaymentResponse) GetPaymentId() string {
+func (x *ListWalletTransactionsRequest) GetUserId() string {
 	if x != nil {
-		return x.PaymentId
+		return x.UserId
 	}
 	return ""
 }
 
-func (x *PaymentResponse) GetOrderId() string {
+func (x *ListWalletTransactionsRequest) GetCurrency() string {
 	if x != nil {
-		return x.OrderId
+		return x.Currency
 	}
 	return ""
 }
 
-func (x *PaymentResponse) GetStatus() string {
+func (x *ListWalletTransactionsRequest) GetPageSize() int32 {
 	if x != nil {
-		return x.Status
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListWalletTransactionsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
 	}
 	return ""
 }
 
-func (x *PaymentResponse) GetProvider() string {
+// Admin request to add store credit to a user's wallet
+type GrantWalletCreditRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Amount         float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency       string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	Reason         string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`                                       // "return" or "goodwill"
+	Note           string                 `protobuf:"bytes,5,opt,name=note,proto3" json:"note,omitempty"`                                           // e.g. the return or ticket it is for
+	GrantedBy      string                 `protobuf:"bytes,6,opt,name=granted_by,json=grantedBy,proto3" json:"granted_by,omitempty"`                // the admin granting it
+	IdempotencyKey string                 `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // required; retries with the same key grant the credit once
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GrantWalletCreditRequest) Reset() {
+	*x = GrantWalletCreditRequest{}
+	mi := &file_payment_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GrantWalletCreditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GrantWalletCreditRequest) ProtoMessage() {}
+
+func (x *GrantWalletCreditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[38]
 	if x != nil {
-		return x.Provider
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GrantWalletCreditRequest.ProtoReflect.Descriptor instead.
+func (*GrantWalletCreditRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *GrantWalletCreditRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
 	}
 	return ""
 }
 
-func (x *PaymentResponse) GetCreatedAt() string {
+func (x *GrantWalletCreditRequest) GetAmount() float64 {
 	if x != nil {
-		return x.CreatedAt
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *GrantWalletCreditRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
 	}
 	return ""
 }
 
-func (x *PaymentResponse) GetUpdatedAt() string {
+func (x *GrantWalletCreditRequest) GetReason() string {
 	if x != nil {
-		return x.UpdatedAt
+		return x.Reason
 	}
 	return ""
 }
 
-func (x *PaymentResponse) GetMessage() string {
+func (x *GrantWalletCreditRequest) GetNote() string {
 	if x != nil {
-		return x.Message
+		return x.Note
+	}
+	return ""
+}
+
+func (x *GrantWalletCreditRequest) GetGrantedBy() string {
+	if x != nil {
+		return x.GrantedBy
+	}
+	return ""
+}
+
+func (x *GrantWalletCreditRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+// One movement of store credit
+type WalletTransactionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Currency      string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	Amount        float64                `protobuf:"fixed64,4,opt,name=amount,proto3" json:"amount,omitempty"` // positive for credits, negative for debits
+	BalanceAfter  float64                `protobuf:"fixed64,5,opt,name=balance_after,json=balanceAfter,proto3" json:"balance_after,omitempty"`
+	Reason        string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"` // return, goodwill, payment, refund, release
+	Note          string                 `protobuf:"bytes,7,opt,name=note,proto3" json:"note,omitempty"`
+	PaymentId     string                 `protobuf:"bytes,8,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"` // payment the credit was spent on or returned from
+	CreatedBy     string                 `protobuf:"bytes,9,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletTransactionResponse) Reset() {
+	*x = WalletTransactionResponse{}
+	mi := &file_payment_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletTransactionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletTransactionResponse) ProtoMessage() {}
+
+func (x *WalletTransactionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletTransactionResponse.ProtoReflect.Descriptor instead.
+func (*WalletTransactionResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *WalletTransactionResponse) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+func (x *WalletTransactionResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *WalletTransactionResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *WalletTransactionResponse) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *WalletTransactionResponse) GetBalanceAfter() float64 {
+	if x != nil {
+		return x.BalanceAfter
+	}
+	return 0
+}
+
+func (x *WalletTransactionResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *WalletTransactionResponse) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *WalletTransactionResponse) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *WalletTransactionResponse) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *WalletTransactionResponse) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+// One page of wallet history
+type ListWalletTransactionsResponse struct {
+	state         protoimpl.MessageState       `protogen:"open.v1"`
+	Transactions  []*WalletTransactionResponse `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	NextPageToken string                       `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWalletTransactionsResponse) Reset() {
+	*x = ListWalletTransactionsResponse{}
+	mi := &file_payment_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWalletTransactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWalletTransactionsResponse) ProtoMessage() {}
+
+func (x *ListWalletTransactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWalletTransactionsResponse.ProtoReflect.Descriptor instead.
+func (*ListWalletTransactionsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ListWalletTransactionsResponse) GetTransactions() []*WalletTransactionResponse {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *ListWalletTransactionsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
 	}
 	return ""
 }
@@ -240,16 +3087,75 @@ var File_payment_proto protoreflect.FileDescriptor
 
 const file_payment_proto_rawDesc = "" +
 	"\n" +
-	"\rpayment.proto\x12\apayment\"\xac\x01\n" +
+	"\rpayment.proto\x12\apayment\"\x94\x02\n" +
 	"\x16InitiatePaymentRequest\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x16\n" +
 	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12\x1a\n" +
 	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12\x17\n" +
 	"\auser_id\x18\x04 \x01(\tR\x06userId\x12*\n" +
-	"\x11payment_method_id\x18\x05 \x01(\tR\x0fpaymentMethodId\":\n" +
+	"\x11payment_method_id\x18\x05 \x01(\tR\x0fpaymentMethodId\x12\x1a\n" +
+	"\bprovider\x18\x06 \x01(\tR\bprovider\x12%\n" +
+	"\x0ecapture_method\x18\a \x01(\tR\rcaptureMethod\x12#\n" +
+	"\rwallet_amount\x18\b \x01(\x01R\fwalletAmount\":\n" +
 	"\x19CheckPaymentStatusRequest\x12\x1d\n" +
 	"\n" +
-	"payment_id\x18\x01 \x01(\tR\tpaymentId\"\xd7\x01\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\"\xfb\x01\n" +
+	"\x13ListPaymentsRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1a\n" +
+	"\bprovider\x18\x04 \x01(\tR\bprovider\x12!\n" +
+	"\fcreated_from\x18\x05 \x01(\tR\vcreatedFrom\x12\x1d\n" +
+	"\n" +
+	"created_to\x18\x06 \x01(\tR\tcreatedTo\x12\x1b\n" +
+	"\tpage_size\x18\a \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\b \x01(\tR\tpageToken\"7\n" +
+	"\x1aGetPaymentsForOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"\x8a\x01\n" +
+	"\x1aAttachPaymentMethodRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
+	"\bprovider\x18\x02 \x01(\tR\bprovider\x12\x14\n" +
+	"\x05token\x18\x03 \x01(\tR\x05token\x12!\n" +
+	"\fmake_default\x18\x04 \x01(\bR\vmakeDefault\"4\n" +
+	"\x19ListPaymentMethodsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"a\n" +
+	"\x1aDetachPaymentMethodRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12*\n" +
+	"\x11payment_method_id\x18\x02 \x01(\tR\x0fpaymentMethodId\"\x9a\x02\n" +
+	"\x15PaymentMethodResponse\x12*\n" +
+	"\x11payment_method_id\x18\x01 \x01(\tR\x0fpaymentMethodId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1a\n" +
+	"\bprovider\x18\x03 \x01(\tR\bprovider\x12\x14\n" +
+	"\x05brand\x18\x04 \x01(\tR\x05brand\x12\x14\n" +
+	"\x05last4\x18\x05 \x01(\tR\x05last4\x12\x1b\n" +
+	"\texp_month\x18\x06 \x01(\x05R\bexpMonth\x12\x19\n" +
+	"\bexp_year\x18\a \x01(\x05R\aexpYear\x12\x1d\n" +
+	"\n" +
+	"is_default\x18\b \x01(\bR\tisDefault\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\t \x01(\tR\tcreatedAt\"e\n" +
+	"\x1aListPaymentMethodsResponse\x12G\n" +
+	"\x0fpayment_methods\x18\x01 \x03(\v2\x1e.payment.PaymentMethodResponseR\x0epaymentMethods\"I\n" +
+	"\x1bDetachPaymentMethodResponse\x12*\n" +
+	"\x11payment_method_id\x18\x01 \x01(\tR\x0fpaymentMethodId\"N\n" +
+	"\x15CapturePaymentRequest\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\"K\n" +
+	"\x12VoidPaymentRequest\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"6\n" +
+	"\x15ConfirmPaymentRequest\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\"\x8e\x01\n" +
+	"\x14RefundPaymentRequest\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\"\xe8\x05\n" +
 	"\x0fPaymentResponse\x12\x1d\n" +
 	"\n" +
 	"payment_id\x18\x01 \x01(\tR\tpaymentId\x12\x19\n" +
@@ -260,10 +3166,228 @@ const file_payment_proto_rawDesc = "" +
 	"created_at\x18\x05 \x01(\tR\tcreatedAt\x12\x1d\n" +
 	"\n" +
 	"updated_at\x18\x06 \x01(\tR\tupdatedAt\x12\x18\n" +
-	"\amessage\x18\a \x01(\tR\amessage2\xb6\x01\n" +
+	"\amessage\x18\a \x01(\tR\amessage\x12!\n" +
+	"\fcheckout_url\x18\b \x01(\tR\vcheckoutUrl\x12'\n" +
+	"\x0frefunded_amount\x18\t \x01(\x01R\x0erefundedAmount\x12\x16\n" +
+	"\x06amount\x18\n" +
+	" \x01(\x01R\x06amount\x12'\n" +
+	"\x0fcaptured_amount\x18\v \x01(\x01R\x0ecapturedAmount\x12%\n" +
+	"\x0ecapture_method\x18\f \x01(\tR\rcaptureMethod\x12\x1a\n" +
+	"\battempts\x18\r \x01(\x05R\battempts\x12\"\n" +
+	"\rnext_retry_at\x18\x0e \x01(\tR\vnextRetryAt\x12!\n" +
+	"\fdecline_code\x18\x0f \x01(\tR\vdeclineCode\x12\x17\n" +
+	"\auser_id\x18\x10 \x01(\tR\x06userId\x12\x1a\n" +
+	"\bcurrency\x18\x11 \x01(\tR\bcurrency\x12*\n" +
+	"\x11payment_method_id\x18\x12 \x01(\tR\x0fpaymentMethodId\x124\n" +
+	"\vnext_action\x18\x13 \x01(\v2\x13.payment.NextActionR\n" +
+	"nextAction\x12\x1d\n" +
+	"\n" +
+	"fee_amount\x18\x14 \x01(\x01R\tfeeAmount\x12\x1d\n" +
+	"\n" +
+	"net_amount\x18\x15 \x01(\x01R\tnetAmount\x12#\n" +
+	"\rwallet_amount\x18\x16 \x01(\x01R\fwalletAmount\"h\n" +
+	"\n" +
+	"NextAction\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12!\n" +
+	"\fredirect_url\x18\x02 \x01(\tR\vredirectUrl\x12#\n" +
+	"\rclient_secret\x18\x03 \x01(\tR\fclientSecret\"~\n" +
+	"\x1cSubmitDisputeEvidenceRequest\x12\x1d\n" +
+	"\n" +
+	"dispute_id\x18\x01 \x01(\tR\tdisputeId\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12+\n" +
+	"\x05files\x18\x03 \x03(\v2\x15.payment.EvidenceFileR\x05files\"h\n" +
+	"\fEvidenceFile\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\"2\n" +
+	"\x11GetDisputeRequest\x12\x1d\n" +
+	"\n" +
+	"dispute_id\x18\x01 \x01(\tR\tdisputeId\"L\n" +
+	"\x13ListDisputesRequest\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"\xfe\x03\n" +
+	"\x0fDisputeResponse\x12\x1d\n" +
+	"\n" +
+	"dispute_id\x18\x01 \x01(\tR\tdisputeId\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x02 \x01(\tR\tpaymentId\x12\x1a\n" +
+	"\bprovider\x18\x03 \x01(\tR\bprovider\x12.\n" +
+	"\x13provider_dispute_id\x18\x04 \x01(\tR\x11providerDisputeId\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x12\x16\n" +
+	"\x06amount\x18\x06 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\a \x01(\tR\bcurrency\x12\x16\n" +
+	"\x06status\x18\b \x01(\tR\x06status\x12&\n" +
+	"\x0fevidence_due_by\x18\t \x01(\tR\revidenceDueBy\x12#\n" +
+	"\revidence_text\x18\n" +
+	" \x01(\tR\fevidenceText\x122\n" +
+	"\x15evidence_submitted_at\x18\v \x01(\tR\x13evidenceSubmittedAt\x12@\n" +
+	"\x0eevidence_files\x18\f \x03(\v2\x19.payment.EvidenceFileInfoR\revidenceFiles\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\r \x01(\tR\tcreatedAt\x12\x1b\n" +
+	"\tclosed_at\x18\x0e \x01(\tR\bclosedAt\"\x85\x01\n" +
+	"\x10EvidenceFileInfo\x12\x1b\n" +
+	"\tfile_name\x18\x01 \x01(\tR\bfileName\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\tR\tcreatedAt\"L\n" +
+	"\x14ListDisputesResponse\x124\n" +
+	"\bdisputes\x18\x01 \x03(\v2\x18.payment.DisputeResponseR\bdisputes\"t\n" +
+	"\x14ListPaymentsResponse\x124\n" +
+	"\bpayments\x18\x01 \x03(\v2\x18.payment.PaymentResponseR\bpayments\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xe4\x01\n" +
+	"\x16PaymentAttemptResponse\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x05R\x06number\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12!\n" +
+	"\fdecline_code\x18\x04 \x01(\tR\vdeclineCode\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\tR\tcreatedAt\x12\x1f\n" +
+	"\vfinished_at\x18\a \x01(\tR\n" +
+	"finishedAt\"\xb2\x01\n" +
+	"\fOrderPayment\x122\n" +
+	"\apayment\x18\x01 \x01(\v2\x18.payment.PaymentResponseR\apayment\x12;\n" +
+	"\battempts\x18\x02 \x03(\v2\x1f.payment.PaymentAttemptResponseR\battempts\x121\n" +
+	"\arefunds\x18\x03 \x03(\v2\x17.payment.RefundResponseR\arefunds\"e\n" +
+	"\x15OrderPaymentsResponse\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x121\n" +
+	"\bpayments\x18\x02 \x03(\v2\x15.payment.OrderPaymentR\bpayments\"\xd8\x02\n" +
+	"\x0eRefundResponse\x12\x1b\n" +
+	"\trefund_id\x18\x01 \x01(\tR\brefundId\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x02 \x01(\tR\tpaymentId\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\a \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\b \x01(\tR\tcreatedAt\x12%\n" +
+	"\x0epayment_status\x18\t \x01(\tR\rpaymentStatus\x12'\n" +
+	"\x0frefunded_amount\x18\n" +
+	" \x01(\x01R\x0erefundedAmount\x12\x1d\n" +
+	"\n" +
+	"fee_amount\x18\v \x01(\x01R\tfeeAmount\"Y\n" +
+	"\x1eGetReconciliationReportRequest\x12\x1b\n" +
+	"\treport_id\x18\x01 \x01(\tR\breportId\x12\x1a\n" +
+	"\bprovider\x18\x02 \x01(\tR\bprovider\"\xa8\x02\n" +
+	"\x12ReconciliationItem\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x02 \x01(\tR\tpaymentId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\tR\tsessionId\x12!\n" +
+	"\flocal_status\x18\x04 \x01(\tR\vlocalStatus\x12'\n" +
+	"\x0fprovider_status\x18\x05 \x01(\tR\x0eproviderStatus\x12!\n" +
+	"\flocal_amount\x18\x06 \x01(\x01R\vlocalAmount\x12'\n" +
+	"\x0fprovider_amount\x18\a \x01(\x01R\x0eproviderAmount\x12\x14\n" +
+	"\x05fixed\x18\b \x01(\bR\x05fixed\x12\x12\n" +
+	"\x04note\x18\t \x01(\tR\x04note\"\xef\x03\n" +
+	"\x1cReconciliationReportResponse\x12\x1b\n" +
+	"\treport_id\x18\x01 \x01(\tR\breportId\x12\x1a\n" +
+	"\bprovider\x18\x02 \x01(\tR\bprovider\x12!\n" +
+	"\fwindow_start\x18\x03 \x01(\tR\vwindowStart\x12\x1d\n" +
+	"\n" +
+	"window_end\x18\x04 \x01(\tR\twindowEnd\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x05 \x01(\tR\tstartedAt\x12\x1f\n" +
+	"\vfinished_at\x18\x06 \x01(\tR\n" +
+	"finishedAt\x12\x18\n" +
+	"\amatched\x18\a \x01(\x05R\amatched\x12'\n" +
+	"\x0fmissing_locally\x18\b \x01(\x05R\x0emissingLocally\x12.\n" +
+	"\x13missing_at_provider\x18\t \x01(\x05R\x11missingAtProvider\x12+\n" +
+	"\x11amount_mismatches\x18\n" +
+	" \x01(\x05R\x10amountMismatches\x12+\n" +
+	"\x11status_mismatches\x18\v \x01(\x05R\x10statusMismatches\x12\x14\n" +
+	"\x05fixed\x18\f \x01(\x05R\x05fixed\x121\n" +
+	"\x05items\x18\r \x03(\v2\x1b.payment.ReconciliationItemR\x05items\"x\n" +
+	"\x1aGetSettlementReportRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x1a\n" +
+	"\bprovider\x18\x03 \x01(\tR\bprovider\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\"\xd5\x01\n" +
+	"\x0eSettlementLine\x12\x12\n" +
+	"\x04date\x18\x01 \x01(\tR\x04date\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\x12\x14\n" +
+	"\x05gross\x18\x03 \x01(\x01R\x05gross\x12\x12\n" +
+	"\x04fees\x18\x04 \x01(\x01R\x04fees\x12\x18\n" +
+	"\arefunds\x18\x05 \x01(\x01R\arefunds\x12\x10\n" +
+	"\x03net\x18\x06 \x01(\x01R\x03net\x12\x1a\n" +
+	"\bcaptures\x18\a \x01(\x05R\bcaptures\x12!\n" +
+	"\frefund_count\x18\b \x01(\x05R\vrefundCount\"\x9c\x01\n" +
+	"\x18SettlementReportResponse\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12+\n" +
+	"\x04days\x18\x03 \x03(\v2\x17.payment.SettlementLineR\x04days\x12/\n" +
+	"\x06totals\x18\x04 \x03(\v2\x17.payment.SettlementLineR\x06totals\"N\n" +
+	"\x17GetWalletBalanceRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\"d\n" +
+	"\rWalletBalance\x12\x1a\n" +
+	"\bcurrency\x18\x01 \x01(\tR\bcurrency\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\x01R\abalance\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x03 \x01(\tR\tupdatedAt\"d\n" +
+	"\x15WalletBalanceResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x122\n" +
+	"\bbalances\x18\x02 \x03(\v2\x16.payment.WalletBalanceR\bbalances\"\x90\x01\n" +
+	"\x1dListWalletTransactionsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"\xdb\x01\n" +
+	"\x18GrantWalletCreditRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x12\n" +
+	"\x04note\x18\x05 \x01(\tR\x04note\x12\x1d\n" +
+	"\n" +
+	"granted_by\x18\x06 \x01(\tR\tgrantedBy\x12'\n" +
+	"\x0fidempotency_key\x18\a \x01(\tR\x0eidempotencyKey\"\xbd\x02\n" +
+	"\x19WalletTransactionResponse\x12%\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\x01R\x06amount\x12#\n" +
+	"\rbalance_after\x18\x05 \x01(\x01R\fbalanceAfter\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\x12\x12\n" +
+	"\x04note\x18\a \x01(\tR\x04note\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\b \x01(\tR\tpaymentId\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\t \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\tR\tcreatedAt\"\x90\x01\n" +
+	"\x1eListWalletTransactionsResponse\x12F\n" +
+	"\ftransactions\x18\x01 \x03(\v2\".payment.WalletTransactionResponseR\ftransactions\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken2\x97\r\n" +
 	"\x0ePaymentService\x12N\n" +
 	"\x0fInitiatePayment\x12\x1f.payment.InitiatePaymentRequest\x1a\x18.payment.PaymentResponse\"\x00\x12T\n" +
-	"\x12CheckPaymentStatus\x12\".payment.CheckPaymentStatusRequest\x1a\x18.payment.PaymentResponse\"\x00B:Z8github.com/SabinGhost19/go-micro-payment/proto/paymentpbb\x06proto3"
+	"\x12CheckPaymentStatus\x12\".payment.CheckPaymentStatusRequest\x1a\x18.payment.PaymentResponse\"\x00\x12I\n" +
+	"\rRefundPayment\x12\x1d.payment.RefundPaymentRequest\x1a\x17.payment.RefundResponse\"\x00\x12L\n" +
+	"\x0eCapturePayment\x12\x1e.payment.CapturePaymentRequest\x1a\x18.payment.PaymentResponse\"\x00\x12F\n" +
+	"\vVoidPayment\x12\x1b.payment.VoidPaymentRequest\x1a\x18.payment.PaymentResponse\"\x00\x12L\n" +
+	"\x0eConfirmPayment\x12\x1e.payment.ConfirmPaymentRequest\x1a\x18.payment.PaymentResponse\"\x00\x12k\n" +
+	"\x17GetReconciliationReport\x12'.payment.GetReconciliationReportRequest\x1a%.payment.ReconciliationReportResponse\"\x00\x12_\n" +
+	"\x13GetSettlementReport\x12#.payment.GetSettlementReportRequest\x1a!.payment.SettlementReportResponse\"\x00\x12M\n" +
+	"\fListPayments\x12\x1c.payment.ListPaymentsRequest\x1a\x1d.payment.ListPaymentsResponse\"\x00\x12\\\n" +
+	"\x13GetPaymentsForOrder\x12#.payment.GetPaymentsForOrderRequest\x1a\x1e.payment.OrderPaymentsResponse\"\x00\x12\\\n" +
+	"\x13AttachPaymentMethod\x12#.payment.AttachPaymentMethodRequest\x1a\x1e.payment.PaymentMethodResponse\"\x00\x12_\n" +
+	"\x12ListPaymentMethods\x12\".payment.ListPaymentMethodsRequest\x1a#.payment.ListPaymentMethodsResponse\"\x00\x12b\n" +
+	"\x13DetachPaymentMethod\x12#.payment.DetachPaymentMethodRequest\x1a$.payment.DetachPaymentMethodResponse\"\x00\x12Z\n" +
+	"\x15SubmitDisputeEvidence\x12%.payment.SubmitDisputeEvidenceRequest\x1a\x18.payment.DisputeResponse\"\x00\x12D\n" +
+	"\n" +
+	"GetDispute\x12\x1a.payment.GetDisputeRequest\x1a\x18.payment.DisputeResponse\"\x00\x12M\n" +
+	"\fListDisputes\x12\x1c.payment.ListDisputesRequest\x1a\x1d.payment.ListDisputesResponse\"\x00\x12V\n" +
+	"\x10GetWalletBalance\x12 .payment.GetWalletBalanceRequest\x1a\x1e.payment.WalletBalanceResponse\"\x00\x12k\n" +
+	"\x16ListWalletTransactions\x12&.payment.ListWalletTransactionsRequest\x1a'.payment.ListWalletTransactionsResponse\"\x00\x12\\\n" +
+	"\x11GrantWalletCredit\x12!.payment.GrantWalletCreditRequest\x1a\".payment.WalletTransactionResponse\"\x00B:Z8github.com/SabinGhost19/go-micro-payment/proto/paymentpbb\x06proto3"
 
 var (
 	file_payment_proto_rawDescOnce sync.Once
@@ -277,22 +3401,109 @@ func file_payment_proto_rawDescGZIP() []byte {
 	return file_payment_proto_rawDescData
 }
 
-var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 41)
 var file_payment_proto_goTypes = []any{
-	(*InitiatePaymentRequest)(nil),    // 0: payment.InitiatePaymentRequest
-	(*CheckPaymentStatusRequest)(nil), // 1: payment.CheckPaymentStatusRequest
-	(*PaymentResponse)(nil),           // 2: payment.PaymentResponse
+	(*InitiatePaymentRequest)(nil),         // 0: payment.InitiatePaymentRequest
+	(*CheckPaymentStatusRequest)(nil),      // 1: payment.CheckPaymentStatusRequest
+	(*ListPaymentsRequest)(nil),            // 2: payment.ListPaymentsRequest
+	(*GetPaymentsForOrderRequest)(nil),     // 3: payment.GetPaymentsForOrderRequest
+	(*AttachPaymentMethodRequest)(nil),     // 4: payment.AttachPaymentMethodRequest
+	(*ListPaymentMethodsRequest)(nil),      // 5: payment.ListPaymentMethodsRequest
+	(*DetachPaymentMethodRequest)(nil),     // 6: payment.DetachPaymentMethodRequest
+	(*PaymentMethodResponse)(nil),          // 7: payment.PaymentMethodResponse
+	(*ListPaymentMethodsResponse)(nil),     // 8: payment.ListPaymentMethodsResponse
+	(*DetachPaymentMethodResponse)(nil),    // 9: payment.DetachPaymentMethodResponse
+	(*CapturePaymentRequest)(nil),          // 10: payment.CapturePaymentRequest
+	(*VoidPaymentRequest)(nil),             // 11: payment.VoidPaymentRequest
+	(*ConfirmPaymentRequest)(nil),          // 12: payment.ConfirmPaymentRequest
+	(*RefundPaymentRequest)(nil),           // 13: payment.RefundPaymentRequest
+	(*PaymentResponse)(nil),                // 14: payment.PaymentResponse
+	(*NextAction)(nil),                     // 15: payment.NextAction
+	(*SubmitDisputeEvidenceRequest)(nil),   // 16: payment.SubmitDisputeEvidenceRequest
+	(*EvidenceFile)(nil),                   // 17: payment.EvidenceFile
+	(*GetDisputeRequest)(nil),              // 18: payment.GetDisputeRequest
+	(*ListDisputesRequest)(nil),            // 19: payment.ListDisputesRequest
+	(*DisputeResponse)(nil),                // 20: payment.DisputeResponse
+	(*EvidenceFileInfo)(nil),               // 21: payment.EvidenceFileInfo
+	(*ListDisputesResponse)(nil),           // 22: payment.ListDisputesResponse
+	(*ListPaymentsResponse)(nil),           // 23: payment.ListPaymentsResponse
+	(*PaymentAttemptResponse)(nil),         // 24: payment.PaymentAttemptResponse
+	(*OrderPayment)(nil),                   // 25: payment.OrderPayment
+	(*OrderPaymentsResponse)(nil),          // 26: payment.OrderPaymentsResponse
+	(*RefundResponse)(nil),                 // 27: payment.RefundResponse
+	(*GetReconciliationReportRequest)(nil), // 28: payment.GetReconciliationReportRequest
+	(*ReconciliationItem)(nil),             // 29: payment.ReconciliationItem
+	(*ReconciliationReportResponse)(nil),   // 30: payment.ReconciliationReportResponse
+	(*GetSettlementReportRequest)(nil),     // 31: payment.GetSettlementReportRequest
+	(*SettlementLine)(nil),                 // 32: payment.SettlementLine
+	(*SettlementReportResponse)(nil),       // 33: payment.SettlementReportResponse
+	(*GetWalletBalanceRequest)(nil),        // 34: payment.GetWalletBalanceRequest
+	(*WalletBalance)(nil),                  // 35: payment.WalletBalance
+	(*WalletBalanceResponse)(nil),          // 36: payment.WalletBalanceResponse
+	(*ListWalletTransactionsRequest)(nil),  // 37: payment.ListWalletTransactionsRequest
+	(*GrantWalletCreditRequest)(nil),       // 38: payment.GrantWalletCreditRequest
+	(*WalletTransactionResponse)(nil),      // 39: payment.WalletTransactionResponse
+	(*ListWalletTransactionsResponse)(nil), // 40: payment.ListWalletTransactionsResponse
 }
 var file_payment_proto_depIdxs = []int32{
-	0, // 0: payment.PaymentService.InitiatePayment:input_type -> payment.InitiatePaymentRequest
-	1, // 1: payment.PaymentService.CheckPaymentStatus:input_type -> payment.CheckPaymentStatusRequest
-	2, // 2: payment.PaymentService.InitiatePayment:output_type -> payment.PaymentResponse
-	2, // 3: payment.PaymentService.CheckPaymentStatus:output_type -> payment.PaymentResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	7,  // 0: payment.ListPaymentMethodsResponse.payment_methods:type_name -> payment.PaymentMethodResponse
+	15, // 1: payment.PaymentResponse.next_action:type_name -> payment.NextAction
+	17, // 2: payment.SubmitDisputeEvidenceRequest.files:type_name -> payment.EvidenceFile
+	21, // 3: payment.DisputeResponse.evidence_files:type_name -> payment.EvidenceFileInfo
+	20, // 4: payment.ListDisputesResponse.disputes:type_name -> payment.DisputeResponse
+	14, // 5: payment.ListPaymentsResponse.payments:type_name -> payment.PaymentResponse
+	14, // 6: payment.OrderPayment.payment:type_name -> payment.PaymentResponse
+	24, // 7: payment.OrderPayment.attempts:type_name -> payment.PaymentAttemptResponse
+	27, // 8: payment.OrderPayment.refunds:type_name -> payment.RefundResponse
+	25, // 9: payment.OrderPaymentsResponse.payments:type_name -> payment.OrderPayment
+	29, // 10: payment.ReconciliationReportResponse.items:type_name -> payment.ReconciliationItem
+	32, // 11: payment.SettlementReportResponse.days:type_name -> payment.SettlementLine
+	32, // 12: payment.SettlementReportResponse.totals:type_name -> payment.SettlementLine
+	35, // 13: payment.WalletBalanceResponse.balances:type_name -> payment.WalletBalance
+	39, // 14: payment.ListWalletTransactionsResponse.transactions:type_name -> payment.WalletTransactionResponse
+	0,  // 15: payment.PaymentService.InitiatePayment:input_type -> payment.InitiatePaymentRequest
+	1,  // 16: payment.PaymentService.CheckPaymentStatus:input_type -> payment.CheckPaymentStatusRequest
+	13, // 17: payment.PaymentService.RefundPayment:input_type -> payment.RefundPaymentRequest
+	10, // 18: payment.PaymentService.CapturePayment:input_type -> payment.CapturePaymentRequest
+	11, // 19: payment.PaymentService.VoidPayment:input_type -> payment.VoidPaymentRequest
+	12, // 20: payment.PaymentService.ConfirmPayment:input_type -> payment.ConfirmPaymentRequest
+	28, // 21: payment.PaymentService.GetReconciliationReport:input_type -> payment.GetReconciliationReportRequest
+	31, // 22: payment.PaymentService.GetSettlementReport:input_type -> payment.GetSettlementReportRequest
+	2,  // 23: payment.PaymentService.ListPayments:input_type -> payment.ListPaymentsRequest
+	3,  // 24: payment.PaymentService.GetPaymentsForOrder:input_type -> payment.GetPaymentsForOrderRequest
+	4,  // 25: payment.PaymentService.AttachPaymentMethod:input_type -> payment.AttachPaymentMethodRequest
+	5,  // 26: payment.PaymentService.ListPaymentMethods:input_type -> payment.ListPaymentMethodsRequest
+	6,  // 27: payment.PaymentService.DetachPaymentMethod:input_type -> payment.DetachPaymentMethodRequest
+	16, // 28: payment.PaymentService.SubmitDisputeEvidence:input_type -> payment.SubmitDisputeEvidenceRequest
+	18, // 29: payment.PaymentService.GetDispute:input_type -> payment.GetDisputeRequest
+	19, // 30: payment.PaymentService.ListDisputes:input_type -> payment.ListDisputesRequest
+	34, // 31: payment.PaymentService.GetWalletBalance:input_type -> payment.GetWalletBalanceRequest
+	37, // 32: payment.PaymentService.ListWalletTransactions:input_type -> payment.ListWalletTransactionsRequest
+	38, // 33: payment.PaymentService.GrantWalletCredit:input_type -> payment.GrantWalletCreditRequest
+	14, // 34: payment.PaymentService.InitiatePayment:output_type -> payment.PaymentResponse
+	14, // 35: payment.PaymentService.CheckPaymentStatus:output_type -> payment.PaymentResponse
+	27, // 36: payment.PaymentService.RefundPayment:output_type -> payment.RefundResponse
+	14, // 37: payment.PaymentService.CapturePayment:output_type -> payment.PaymentResponse
+	14, // 38: payment.PaymentService.VoidPayment:output_type -> payment.PaymentResponse
+	14, // 39: payment.PaymentService.ConfirmPayment:output_type -> payment.PaymentResponse
+	30, // 40: payment.PaymentService.GetReconciliationReport:output_type -> payment.ReconciliationReportResponse
+	33, // 41: payment.PaymentService.GetSettlementReport:output_type -> payment.SettlementReportResponse
+	23, // 42: payment.PaymentService.ListPayments:output_type -> payment.ListPaymentsResponse
+	26, // 43: payment.PaymentService.GetPaymentsForOrder:output_type -> payment.OrderPaymentsResponse
+	7,  // 44: payment.PaymentService.AttachPaymentMethod:output_type -> payment.PaymentMethodResponse
+	8,  // 45: payment.PaymentService.ListPaymentMethods:output_type -> payment.ListPaymentMethodsResponse
+	9,  // 46: payment.PaymentService.DetachPaymentMethod:output_type -> payment.DetachPaymentMethodResponse
+	20, // 47: payment.PaymentService.SubmitDisputeEvidence:output_type -> payment.DisputeResponse
+	20, // 48: payment.PaymentService.GetDispute:output_type -> payment.DisputeResponse
+	22, // 49: payment.PaymentService.ListDisputes:output_type -> payment.ListDisputesResponse
+	36, // 50: payment.PaymentService.GetWalletBalance:output_type -> payment.WalletBalanceResponse
+	40, // 51: payment.PaymentService.ListWalletTransactions:output_type -> payment.ListWalletTransactionsResponse
+	39, // 52: payment.PaymentService.GrantWalletCredit:output_type -> payment.WalletTransactionResponse
+	34, // [34:53] is the sub-list for method output_type
+	15, // [15:34] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_payment_proto_init() }
@@ -306,7 +3517,7 @@ func file_payment_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payment_proto_rawDesc), len(file_payment_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   41,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -27,7 +27,7 @@ type CreateProductRequest struct {
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
 	Price         float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
-	Stock         int32                  `protobuf:"varint,4,opt,name=stock,proto3" json:"stock,omitempty"`
+	Stock         int32                  `protobuf:"varint,4,opt,name=stock,proto3" json:"stock,omitempty"` // initial stock, handed to the Inventory Service
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -195,7 +195,7 @@ type UpdateProductRequest struct {
 	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
-	Stock         int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"`
+	Stock         int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"` // read-only: 0 or the current stock; change stock with the Inventory Service's UpdateStock
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -317,7 +317,7 @@ type ProductResponse struct {
 	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
 	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
-	Stock         int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"`
+	Stock         int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"` // on-hand stock cached from the Inventory Service
 	CreatedAt     string                 `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     string                 `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
@@ -493,6 +493,189 @@ func (x *DeleteProductResponse) GetSuccess() bool {
 	return false
 }
 
+// Compare the cached stock of products with the Inventory Service
+type ReconcileStockRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"` // every product when empty
+	DryRun        bool                   `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`         // only report divergences
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReconcileStockRequest) Reset() {
+	*x = ReconcileStockRequest{}
+	mi := &file_product_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconcileStockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconcileStockRequest) ProtoMessage() {}
+
+func (x *ReconcileStockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconcileStockRequest.ProtoReflect.Descriptor instead.
+func (*ReconcileStockRequest) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReconcileStockRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *ReconcileStockRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+// A product whose catalog and inventory records disagree
+type StockDivergence struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ProductId      string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Kind           string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"` // stock, missing (unknown to the inventory) or archived (deleted in the inventory only)
+	CachedStock    int32                  `protobuf:"varint,3,opt,name=cached_stock,json=cachedStock,proto3" json:"cached_stock,omitempty"`
+	InventoryStock int32                  `protobuf:"varint,4,opt,name=inventory_stock,json=inventoryStock,proto3" json:"inventory_stock,omitempty"`
+	Repaired       bool                   `protobuf:"varint,5,opt,name=repaired,proto3" json:"repaired,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StockDivergence) Reset() {
+	*x = StockDivergence{}
+	mi := &file_product_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockDivergence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockDivergence) ProtoMessage() {}
+
+func (x *StockDivergence) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockDivergence.ProtoReflect.Descriptor instead.
+func (*StockDivergence) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StockDivergence) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *StockDivergence) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *StockDivergence) GetCachedStock() int32 {
+	if x != nil {
+		return x.CachedStock
+	}
+	return 0
+}
+
+func (x *StockDivergence) GetInventoryStock() int32 {
+	if x != nil {
+		return x.InventoryStock
+	}
+	return 0
+}
+
+func (x *StockDivergence) GetRepaired() bool {
+	if x != nil {
+		return x.Repaired
+	}
+	return false
+}
+
+// Result of a reconciliation
+type ReconcileStockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Checked       int32                  `protobuf:"varint,1,opt,name=checked,proto3" json:"checked,omitempty"`
+	Divergences   []*StockDivergence     `protobuf:"bytes,2,rep,name=divergences,proto3" json:"divergences,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReconcileStockResponse) Reset() {
+	*x = ReconcileStockResponse{}
+	mi := &file_product_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReconcileStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReconcileStockResponse) ProtoMessage() {}
+
+func (x *ReconcileStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReconcileStockResponse.ProtoReflect.Descriptor instead.
+func (*ReconcileStockResponse) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReconcileStockResponse) GetChecked() int32 {
+	if x != nil {
+		return x.Checked
+	}
+	return 0
+}
+
+func (x *ReconcileStockResponse) GetDivergences() []*StockDivergence {
+	if x != nil {
+		return x.Divergences
+	}
+	return nil
+}
+
 var File_product_proto protoreflect.FileDescriptor
 
 const file_product_proto_rawDesc = "" +
@@ -533,14 +716,29 @@ const file_product_proto_rawDesc = "" +
 	"\x14ListProductsResponse\x124\n" +
 	"\bproducts\x18\x01 \x03(\v2\x18.product.ProductResponseR\bproducts\"1\n" +
 	"\x15DeleteProductResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess2\x8f\x03\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"O\n" +
+	"\x15ReconcileStockRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x17\n" +
+	"\adry_run\x18\x02 \x01(\bR\x06dryRun\"\xac\x01\n" +
+	"\x0fStockDivergence\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x12!\n" +
+	"\fcached_stock\x18\x03 \x01(\x05R\vcachedStock\x12'\n" +
+	"\x0finventory_stock\x18\x04 \x01(\x05R\x0einventoryStock\x12\x1a\n" +
+	"\brepaired\x18\x05 \x01(\bR\brepaired\"n\n" +
+	"\x16ReconcileStockResponse\x12\x18\n" +
+	"\achecked\x18\x01 \x01(\x05R\achecked\x12:\n" +
+	"\vdivergences\x18\x02 \x03(\v2\x18.product.StockDivergenceR\vdivergences2\xe4\x03\n" +
 	"\x0eProductService\x12J\n" +
 	"\rCreateProduct\x12\x1d.product.CreateProductRequest\x1a\x18.product.ProductResponse\"\x00\x12D\n" +
 	"\n" +
 	"GetProduct\x12\x1a.product.GetProductRequest\x1a\x18.product.ProductResponse\"\x00\x12M\n" +
 	"\fListProducts\x12\x1c.product.ListProductsRequest\x1a\x1d.product.ListProductsResponse\"\x00\x12J\n" +
 	"\rUpdateProduct\x12\x1d.product.UpdateProductRequest\x1a\x18.product.ProductResponse\"\x00\x12P\n" +
-	"\rDeleteProduct\x12\x1d.product.DeleteProductRequest\x1a\x1e.product.DeleteProductResponse\"\x00B9Z7github.com/SabinGhost19/go-micro-payment/proto/productbb\x06proto3"
+	"\rDeleteProduct\x12\x1d.product.DeleteProductRequest\x1a\x1e.product.DeleteProductResponse\"\x00\x12S\n" +
+	"\x0eReconcileStock\x12\x1e.product.ReconcileStockRequest\x1a\x1f.product.ReconcileStockResponse\"\x00B9Z7github.com/SabinGhost19/go-micro-payment/proto/productbb\x06proto3"
 
 var (
 	file_product_proto_rawDescOnce sync.Once
@@ -554,34 +752,40 @@ func file_product_proto_rawDescGZIP() []byte {
 	return file_product_proto_rawDescData
 }
 
-var file_product_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_product_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_product_proto_goTypes = []any{
-	(*CreateProductRequest)(nil),  // 0: product.CreateProductRequest
-	(*GetProductRequest)(nil),     // 1: product.GetProductRequest
-	(*ListProductsRequest)(nil),   // 2: product.ListProductsRequest
-	(*UpdateProductRequest)(nil),  // 3: product.UpdateProductRequest
-	(*DeleteProductRequest)(nil),  // 4: product.DeleteProductRequest
-	(*ProductResponse)(nil),       // 5: product.ProductResponse
-	(*ListProductsResponse)(nil),  // 6: product.ListProductsResponse
-	(*DeleteProductResponse)(nil), // 7: product.DeleteProductResponse
+	(*CreateProductRequest)(nil),   // 0: product.CreateProductRequest
+	(*GetProductRequest)(nil),      // 1: product.GetProductRequest
+	(*ListProductsRequest)(nil),    // 2: product.ListProductsRequest
+	(*UpdateProductRequest)(nil),   // 3: product.UpdateProductRequest
+	(*DeleteProductRequest)(nil),   // 4: product.DeleteProductRequest
+	(*ProductResponse)(nil),        // 5: product.ProductResponse
+	(*ListProductsResponse)(nil),   // 6: product.ListProductsResponse
+	(*DeleteProductResponse)(nil),  // 7: product.DeleteProductResponse
+	(*ReconcileStockRequest)(nil),  // 8: product.ReconcileStockRequest
+	(*StockDivergence)(nil),        // 9: product.StockDivergence
+	(*ReconcileStockResponse)(nil), // 10: product.ReconcileStockResponse
 }
 var file_product_proto_depIdxs = []int32{
-	5, // 0: product.ListProductsResponse.products:type_name -> product.ProductResponse
-	0, // 1: product.ProductService.CreateProduct:input_type -> product.CreateProductRequest
-	1, // 2: product.ProductService.GetProduct:input_type -> product.GetProductRequest
-	2, // 3: product.ProductService.ListProducts:input_type -> product.ListProductsRequest
-	3, // 4: product.ProductService.UpdateProduct:input_type -> product.UpdateProductRequest
-	4, // 5: product.ProductService.DeleteProduct:input_type -> product.DeleteProductRequest
-	5, // 6: product.ProductService.CreateProduct:output_type -> product.ProductResponse
-	5, // 7: product.ProductService.GetProduct:output_type -> product.ProductResponse
-	6, // 8: product.ProductService.ListProducts:output_type -> product.ListProductsResponse
-	5, // 9: product.ProductService.UpdateProduct:output_type -> product.ProductResponse
-	7, // 10: product.ProductService.DeleteProduct:output_type -> product.DeleteProductResponse
-	6, // [6:11] is the sub-list for method output_type
-	1, // [1:6] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	5,  // 0: product.ListProductsResponse.products:type_name -> product.ProductResponse
+	9,  // 1: product.ReconcileStockResponse.divergences:type_name -> product.StockDivergence
+	0,  // 2: product.ProductService.CreateProduct:input_type -> product.CreateProductRequest
+	1,  // 3: product.ProductService.GetProduct:input_type -> product.GetProductRequest
+	2,  // 4: product.ProductService.ListProducts:input_type -> product.ListProductsRequest
+	3,  // 5: product.ProductService.UpdateProduct:input_type -> product.UpdateProductRequest
+	4,  // 6: product.ProductService.DeleteProduct:input_type -> product.DeleteProductRequest
+	8,  // 7: product.ProductService.ReconcileStock:input_type -> product.ReconcileStockRequest
+	5,  // 8: product.ProductService.CreateProduct:output_type -> product.ProductResponse
+	5,  // 9: product.ProductService.GetProduct:output_type -> product.ProductResponse
+	6,  // 10: product.ProductService.ListProducts:output_type -> product.ListProductsResponse
+	5,  // 11: product.ProductService.UpdateProduct:output_type -> product.ProductResponse
+	7,  // 12: product.ProductService.DeleteProduct:output_type -> product.DeleteProductResponse
+	10, // 13: product.ProductService.ReconcileStock:output_type -> product.ReconcileStockResponse
+	8,  // [8:14] is the sub-list for method output_type
+	2,  // [2:8] is the sub-list for method input_type
+	2,  // [2:2] is the sub-list for extension type_name
+	2,  // [2:2] is the sub-list for extension extendee
+	0,  // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_product_proto_init() }
@@ -595,7 +799,7 @@ func file_product_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_product_proto_rawDesc), len(file_product_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
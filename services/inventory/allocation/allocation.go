@@ -0,0 +1,166 @@
+// Package allocation decides which locations the stock of a reservation is
+// held at
+package allocation
+
+import (
+	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"sort"
+	"strings"
+)
+
+// Strategy picks the locations a reservation is served from
+type Strategy string
+
+const (
+	// Priority serves the whole order from the highest priority location that holds all of it
+	Priority Strategy = "priority"
+	// Nearest is Priority, but locations in the shipping country come first
+	Nearest Strategy = "nearest"
+	// Split fills each product from locations in priority order, across as many as it takes
+	Split Strategy = "split"
+)
+
+// ErrUnknownStrategy is returned for a strategy that is not one of the above
+var ErrUnknownStrategy = errors.New("unknown allocation strategy")
+
+// ParseStrategy parses a strategy name; empty is Priority
+func ParseStrategy(s string) (Strategy, error) {
+	switch strategy := Strategy(strings.ToLower(strings.TrimSpace(s))); strategy {
+	case "":
+		return Priority, nil
+	case Priority, Nearest, Split:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("%w %q: use %q, %q or %q", ErrUnknownStrategy, s, Priority, Nearest, Split)
+}
+
+// Request is the stock to allocate: quantities per product
+type Request struct {
+	Quantities      map[string]int32
+	Strategy        Strategy
+	ShippingCountry string // used by Nearest
+}
+
+// Allocate returns the reservation items serving req from locations, given the
+// stock levels of its products. When the stock does not suffice it returns the
+// shortages instead: for Split against all locations together, otherwise
+// against the one location that could serve the most.
+func Allocate(req Request, locations []model.Location, levels []model.StockLevel) ([]model.ReservationItem, []model.Shortage) {
+	ranked := rank(locations, req.Strategy, req.ShippingCountry)
+	byProduct := make(map[string]*model.StockLevel, len(levels))
+	for i := range levels {
+		byProduct[levels[i].ProductID] = &levels[i]
+	}
+	available := func(productID, locationID string) int32 {
+		level, ok := byProduct[productID]
+		if !ok {
+			return 0
+		}
+		for _, l := range level.Locations {
+			if l.LocationID == locationID {
+				return l.Available()
+			}
+		}
+		return 0
+	}
+	products := make([]string, 0, len(req.Quantities))
+	for id := range req.Quantities {
+		products = append(products, id)
+	}
+	sort.Strings(products)
+
+	if req.Strategy == Split {
+		var items []model.ReservationItem
+		var shortages []model.Shortage
+		for _, productID := range products {
+			remaining := req.Quantities[productID]
+			var total int32
+			for _, loc := range ranked {
+				take := available(productID, loc.ID)
+				if take <= 0 {
+					continue
+				}
+				total += take
+				if take > remaining {
+					take = remaining
+				}
+				if take > 0 {
+					items = append(items, model.ReservationItem{ProductID: productID, LocationID: loc.ID, Quantity: take})
+					remaining -= take
+				}
+			}
+			if remaining > 0 {
+				shortages = append(shortages, model.Shortage{ProductID: productID, Requested: req.Quantities[productID], Available: total})
+			}
+		}
+		if len(shortages) > 0 {
+			return nil, shortages
+		}
+		return items, nil
+	}
+
+	// serve everything from one location: the first that holds it all, or
+	// report what the one that could serve the most is missing
+	best, bestUnits := -1, int32(-1)
+	for i, loc := range ranked {
+		var units int32
+		complete := true
+		for _, productID := range products {
+			n := available(productID, loc.ID)
+			if n < req.Quantities[productID] {
+				complete = false
+			} else {
+				n = req.Quantities[productID]
+			}
+			if n > 0 {
+				units += n
+			}
+		}
+		if complete {
+			items := make([]model.ReservationItem, len(products))
+			for j, productID := range products {
+				items[j] = model.ReservationItem{ProductID: productID, LocationID: loc.ID, Quantity: req.Quantities[productID]}
+			}
+			return items, nil
+		}
+		if units > bestUnits {
+			best, bestUnits = i, units
+		}
+	}
+	var shortages []model.Shortage
+	for _, productID := range products {
+		var n int32
+		if best >= 0 {
+			n = available(productID, ranked[best].ID)
+		}
+		if n < 0 {
+			n = 0
+		}
+		if n < req.Quantities[productID] {
+			shortages = append(shortages, model.Shortage{ProductID: productID, Requested: req.Quantities[productID], Available: n})
+		}
+	}
+	return nil, shortages
+}
+
+// rank orders locations by priority, then ID; Nearest puts the locations in
+// the shipping country first
+func rank(locations []model.Location, strategy Strategy, country string) []model.Location {
+	ranked := append([]model.Location(nil), locations...)
+	country = strings.ToUpper(country)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if strategy == Nearest && country != "" {
+			ni, nj := ranked[i].Country == country, ranked[j].Country == country
+			if ni != nj {
+				return ni
+			}
+		}
+		if ranked[i].Priority != ranked[j].Priority {
+			return ranked[i].Priority < ranked[j].Priority
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	return ranked
+}
@@ -26,3 +26,47 @@ func (h *InventoryHandler) ReserveStock(ctx context.Context, req *inventorypb.Re
 func (h *InventoryHandler) UpdateStock(ctx context.Context, req *inventorypb.UpdateStockRequest) (*inventorypb.UpdateStockResponse, error) {
 	return h.svc.UpdateStock(ctx, req)
 }
+
+func (h *InventoryHandler) CommitReservation(ctx context.Context, req *inventorypb.CommitReservationRequest) (*inventorypb.ReservationResponse, error) {
+	return h.svc.CommitReservation(ctx, req)
+}
+
+func (h *InventoryHandler) ReleaseReservation(ctx context.Context, req *inventorypb.ReleaseReservationRequest) (*inventorypb.ReservationResponse, error) {
+	return h.svc.ReleaseReservation(ctx, req)
+}
+
+func (h *InventoryHandler) ExtendReservation(ctx context.Context, req *inventorypb.ExtendReservationRequest) (*inventorypb.ReservationResponse, error) {
+	return h.svc.ExtendReservation(ctx, req)
+}
+
+func (h *InventoryHandler) TransferStock(ctx context.Context, req *inventorypb.TransferStockRequest) (*inventorypb.TransferStockResponse, error) {
+	return h.svc.TransferStock(ctx, req)
+}
+
+func (h *InventoryHandler) SaveLocation(ctx context.Context, req *inventorypb.SaveLocationRequest) (*inventorypb.LocationResponse, error) {
+	return h.svc.SaveLocation(ctx, req)
+}
+
+func (h *InventoryHandler) ListLocations(ctx context.Context, req *inventorypb.ListLocationsRequest) (*inventorypb.ListLocationsResponse, error) {
+	return h.svc.ListLocations(ctx, req)
+}
+
+func (h *InventoryHandler) ListStockMovements(ctx context.Context, req *inventorypb.ListStockMovementsRequest) (*inventorypb.ListStockMovementsResponse, error) {
+	return h.svc.ListStockMovements(ctx, req)
+}
+
+func (h *InventoryHandler) CheckStockConsistency(ctx context.Context, req *inventorypb.CheckStockConsistencyRequest) (*inventorypb.CheckStockConsistencyResponse, error) {
+	return h.svc.CheckStockConsistency(ctx, req)
+}
+
+func (h *InventoryHandler) SetStockThresholds(ctx context.Context, req *inventorypb.SetStockThresholdsRequest) (*inventorypb.CheckStockResponse, error) {
+	return h.svc.SetStockThresholds(ctx, req)
+}
+
+func (h *InventoryHandler) SetBackorderPolicy(ctx context.Context, req *inventorypb.SetBackorderPolicyRequest) (*inventorypb.CheckStockResponse, error) {
+	return h.svc.SetBackorderPolicy(ctx, req)
+}
+
+func (h *InventoryHandler) ImportStockCounts(stream inventorypb.InventoryService_ImportStockCountsServer) error {
+	return h.svc.ImportStockCounts(stream)
+}
@@ -0,0 +1,23 @@
+package model
+
+// StockAlertLevel is how low the available stock of a product is; products
+// remember the level they last alerted at, so an alert fires once per crossing
+type StockAlertLevel string
+
+const (
+	StockOK  StockAlertLevel = "ok"
+	StockLow StockAlertLevel = "low" // available at or below the low-stock threshold
+	StockOut StockAlertLevel = "out" // nothing available
+)
+
+// AlertLevelFor returns the alert level of available stock; a threshold of
+// zero only alerts when stock runs out
+func AlertLevelFor(available, lowStockThreshold int32) StockAlertLevel {
+	switch {
+	case available <= 0:
+		return StockOut
+	case available <= lowStockThreshold:
+		return StockLow
+	}
+	return StockOK
+}
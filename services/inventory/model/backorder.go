@@ -0,0 +1,62 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// BackorderPolicy says whether a product can be ordered beyond its stock
+type BackorderPolicy string
+
+const (
+	BackorderDisallow BackorderPolicy = "disallow" // orders beyond the stock fail
+	BackorderLimited  BackorderPolicy = "limited"  // up to the product's backorder limit can wait for stock
+	BackorderPreorder BackorderPolicy = "preorder" // any quantity can wait for stock expected at a date
+)
+
+// BackorderState is the lifecycle of a backorder
+type BackorderState string
+
+const (
+	BackorderOpen      BackorderState = "open"      // waiting for stock
+	BackorderAllocated BackorderState = "allocated" // stock arrived and was given to the order
+	BackorderCancelled BackorderState = "cancelled" // the order's reservation ended first
+)
+
+// Backorder is a line of an order that waits for stock. Stock added to a
+// product goes to its open backorders first, oldest (lowest ID) first.
+type Backorder struct {
+	ID          uint           `gorm:"primaryKey;autoIncrement"`
+	OrderID     string         `gorm:"type:varchar(36);index;not null"`
+	ProductID   string         `gorm:"type:uuid;index:idx_backorder_queue;not null"`
+	Quantity    int32          `gorm:"type:integer;not null"`
+	State       BackorderState `gorm:"type:varchar(10);index:idx_backorder_queue;not null"`
+	LocationID  string         `gorm:"type:varchar(36)"` // where it was allocated
+	ExpectedAt  *time.Time     // when stock was expected when it was ordered
+	AllocatedAt *time.Time
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// Backorderable returns how many more units of a product can be backordered
+func (l StockLevel) Backorderable() int32 {
+	switch l.BackorderPolicy {
+	case BackorderLimited:
+		if l.Backordered >= l.BackorderLimit {
+			return 0
+		}
+		return l.BackorderLimit - l.Backordered
+	case BackorderPreorder:
+		return math.MaxInt32
+	}
+	return 0
+}
+
+// Orderable returns how many units of a product an order can ask for: what
+// is available plus what can be backordered
+func (l StockLevel) Orderable() int32 {
+	backorderable := l.Backorderable()
+	if backorderable > math.MaxInt32-l.Available() {
+		return math.MaxInt32
+	}
+	return l.Available() + backorderable
+}
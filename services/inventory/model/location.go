@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// DefaultLocationID is the location stock goes to when none is named, e.g.
+// stock synced from the product catalog
+const DefaultLocationID = "default"
+
+// Location is a warehouse stock is kept and shipped from
+type Location struct {
+	ID        string    `gorm:"primaryKey;type:varchar(36)"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	Country   string    `gorm:"type:char(2)"` // ISO 3166-1 alpha-2, e.g. "DE"
+	Priority  int       `gorm:"not null"`     // lower ships first
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// LocationStock is the on-hand stock of a product at one location; the
+// product's Stock is the sum over its locations
+type LocationStock struct {
+	ProductID  string    `gorm:"primaryKey;type:uuid"`
+	LocationID string    `gorm:"primaryKey;type:varchar(36);index"`
+	OnHand     int32     `gorm:"type:integer;not null;check:on_hand >= 0"`
+	Version    int64     `gorm:"not null;default:0"` // incremented by every stock change
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+}
+
+// StockTransfer records stock moved from one location to another
+type StockTransfer struct {
+	ID             string    `gorm:"primaryKey;type:uuid"`
+	ProductID      string    `gorm:"type:uuid;index;not null"`
+	FromLocationID string    `gorm:"type:varchar(36);not null"`
+	ToLocationID   string    `gorm:"type:varchar(36);not null"`
+	Quantity       int32     `gorm:"type:integer;not null"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+}
+
+// LocationLevel is the stock of a product at one location
+type LocationLevel struct {
+	LocationID string
+	OnHand     int32
+	Held       int32
+	Version    int64 // location stock version the level was read at
+}
+
+func (l LocationLevel) Available() int32 {
+	return l.OnHand - l.Held
+}
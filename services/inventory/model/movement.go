@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// MovementReason says why stock changed
+type MovementReason string
+
+const (
+	MovementReservation MovementReason = "reservation" // stock held for an order
+	MovementRelease     MovementReason = "release"     // a hold given back, by request or on expiry
+	MovementSale        MovementReason = "sale"        // a paid reservation taken off on-hand
+	MovementRestock     MovementReason = "restock"     // stock received
+	MovementAdjustment  MovementReason = "adjustment"  // a correction, e.g. after a count or a catalog sync
+	MovementReturn      MovementReason = "return"      // stock returned by a customer
+	MovementTransfer    MovementReason = "transfer"    // stock moved between locations
+	MovementCycleCount  MovementReason = "cycle_count" // on-hand set to what was counted
+)
+
+// Manual reports whether a reason may be given to UpdateStock; the others are
+// recorded by reservations, transfers and stock counts
+func (r MovementReason) Manual() bool {
+	return r == MovementRestock || r == MovementAdjustment || r == MovementReturn
+}
+
+// ActorSystem is the actor of movements the service makes on its own, e.g.
+// releasing expired reservations
+const ActorSystem = "system"
+
+// StockMovement is one immutable entry of the stock ledger. Quantity changes
+// on-hand and HeldQuantity changes what reservations hold, both at a location;
+// the on-hand of a location is the sum of its movements' quantities.
+type StockMovement struct {
+	ID           string         `gorm:"primaryKey;type:uuid"`
+	ProductID    string         `gorm:"type:uuid;not null;index:idx_movement_stock"`
+	LocationID   string         `gorm:"type:varchar(36);not null;index:idx_movement_stock"`
+	Quantity     int32          `gorm:"type:integer;not null"` // signed change to on-hand
+	HeldQuantity int32          `gorm:"type:integer;not null"` // signed change to held
+	BalanceAfter int32          `gorm:"type:integer;not null"` // on-hand at the location afterwards
+	Reason       MovementReason `gorm:"type:varchar(20);not null;index"`
+	Reference    string         `gorm:"type:varchar(64);index"` // e.g. the order, return or transfer ID
+	Actor        string         `gorm:"type:varchar(64)"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime;index"`
+}
+
+// StockDiscrepancy is a balance that does not match the sum of its movements.
+// LocationID is empty when a product's total does not match its locations.
+type StockDiscrepancy struct {
+	ProductID  string
+	LocationID string
+	Balance    int32
+	Movements  int32
+}
+
+// StockCount is a quantity of a product counted at a location, e.g. one row
+// of a cycle count. System is the on-hand the count was checked against.
+type StockCount struct {
+	ProductID  string
+	LocationID string
+	Counted    int32
+	System     int32
+	Reference  string
+	Actor      string
+}
+
+// Delta is the change to on-hand the count makes
+func (c StockCount) Delta() int32 {
+	return c.Counted - c.System
+}
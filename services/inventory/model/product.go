@@ -5,7 +5,20 @@ import "time"
 type Product struct {
 	ID        string    `gorm:"primaryKey;type:uuid"`
 	Name      string    `gorm:"type:varchar(255)"`
-	Stock     int32     `gorm:"type:integer;not null"`
+	Stock     int32     `gorm:"type:integer;not null;check:stock >= 0"`
+	Version   int64     `gorm:"not null;default:0"` // incremented by every stock change
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	LowStockThreshold int32           `gorm:"type:integer;not null;default:0"`        // alert when available stock drops to it
+	ReorderPoint      int32           `gorm:"type:integer;not null;default:0"`        // reorder when available stock drops to it
+	AlertLevel        StockAlertLevel `gorm:"type:varchar(10);not null;default:'ok'"` // the level last alerted at
+
+	// ArchivedAt is set when the product was deleted from the catalog; its
+	// stock and movements are kept, but it cannot be reserved
+	ArchivedAt *time.Time `gorm:"index"`
+
+	BackorderPolicy     BackorderPolicy `gorm:"type:varchar(10);not null;default:'disallow'"`
+	BackorderLimit      int32           `gorm:"type:integer;not null;default:0"` // for the limited policy
+	BackorderExpectedAt *time.Time      // when backordered stock is expected
 }
@@ -0,0 +1,86 @@
+package model
+
+import "time"
+
+// ReservationState is the lifecycle of a stock reservation
+type ReservationState string
+
+const (
+	ReservationHeld      ReservationState = "held"      // stock is set aside until ExpiresAt
+	ReservationCommitted ReservationState = "committed" // the order was paid; the stock left on-hand
+	ReservationReleased  ReservationState = "released"  // given back before it expired
+	ReservationExpired   ReservationState = "expired"   // given back by the sweeper
+)
+
+// Reservation holds stock for one order. While it is held, its items count
+// against the available stock of their products without changing on-hand.
+type Reservation struct {
+	OrderID   string            `gorm:"primaryKey;type:varchar(36)"`
+	State     ReservationState  `gorm:"type:varchar(20);index;not null"`
+	ExpiresAt time.Time         `gorm:"index;not null"`
+	Reason    string            `gorm:"type:text"` // why it was released
+	Items     []ReservationItem `gorm:"foreignKey:OrderID"`
+	CreatedAt time.Time         `gorm:"autoCreateTime"`
+	UpdatedAt time.Time         `gorm:"autoUpdateTime"`
+
+	Backorders []Backorder `gorm:"foreignKey:OrderID"` // lines waiting for stock
+}
+
+// ReservationItem is one line of a reservation: stock of a product held at a
+// location. A product split across locations has a line per location.
+type ReservationItem struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	OrderID    string `gorm:"type:varchar(36);index;not null"`
+	ProductID  string `gorm:"type:uuid;index;not null"`
+	LocationID string `gorm:"type:varchar(36);index;not null;default:'default'"`
+	Quantity   int32  `gorm:"type:integer;not null"`
+}
+
+// Active reports whether a reservation still holds its stock at now; a held
+// reservation past its expiry no longer does, even before it is swept
+func (r *Reservation) Active(now time.Time) bool {
+	return r.State == ReservationHeld && r.ExpiresAt.After(now)
+}
+
+// StockLevel is the stock of a product: on-hand less what active
+// reservations hold is available. The totals sum Locations.
+type StockLevel struct {
+	ProductID string
+	OnHand    int32
+	Held      int32
+	Version   int64 // product version the level was read at
+	Locations []LocationLevel
+
+	LowStockThreshold int32
+	ReorderPoint      int32
+	AlertLevel        StockAlertLevel
+	Archived          bool
+
+	BackorderPolicy     BackorderPolicy
+	BackorderLimit      int32
+	BackorderExpectedAt *time.Time
+	Backordered         int32 // held by open backorders
+}
+
+func (l StockLevel) Available() int32 {
+	return l.OnHand - l.Held
+}
+
+// At returns the level at a location; a location without stock of the
+// product has a zero level
+func (l *StockLevel) At(locationID string) *LocationLevel {
+	for i := range l.Locations {
+		if l.Locations[i].LocationID == locationID {
+			return &l.Locations[i]
+		}
+	}
+	l.Locations = append(l.Locations, LocationLevel{LocationID: locationID})
+	return &l.Locations[len(l.Locations)-1]
+}
+
+// Shortage is a product a reservation asked more of than is available
+type Shortage struct {
+	ProductID string
+	Requested int32
+	Available int32
+}
@@ -3,16 +3,89 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/configs/utils"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/allocation"
 	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+	"strings"
 	"time"
 )
 
+var (
+	// ErrProductNotFound is returned for products the inventory does not know
+	ErrProductNotFound = errors.New("product not found")
+	// ErrInsufficientStock is returned when less stock is available than requested
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidQuantity is returned for reservation items without a positive quantity
+	ErrInvalidQuantity = errors.New("quantity must be positive")
+	// ErrReservationNotFound is returned when an order has no reservation
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrReservationClosed is returned when a reservation's state does not allow the change
+	ErrReservationClosed = errors.New("reservation can no longer change")
+	// ErrLocationNotFound is returned for locations that do not exist
+	ErrLocationNotFound = errors.New("location not found")
+	// ErrStockConflict is returned when a product's stock changed between reading and writing it
+	ErrStockConflict = errors.New("stock was changed concurrently")
+	// ErrProductArchived is returned when reserving a product deleted from the catalog
+	ErrProductArchived = errors.New("product is archived")
+)
+
+// ShortageError is returned when a reservation asks for more of some products
+// than is available; it matches ErrInsufficientStock
+type ShortageError struct {
+	Shortages []model.Shortage
+}
+
+func (e *ShortageError) Error() string {
+	parts := make([]string, len(e.Shortages))
+	for i, s := range e.Shortages {
+		parts[i] = fmt.Sprintf("product %s has %d available, %d requested", s.ProductID, s.Available, s.Requested)
+	}
+	return fmt.Sprintf("%v: %s", ErrInsufficientStock, strings.Join(parts, "; "))
+}
+
+func (e *ShortageError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+// PageCursor is the position after the last row of a page, ordered by
+// creation time and ID
+type PageCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// MovementFilter selects stock movements; empty fields match every movement
+type MovementFilter struct {
+	ProductID  string
+	LocationID string
+	Reason     model.MovementReason
+	Reference  string
+}
+
 type InventoryRepository interface {
-	CheckStock(ctx context.Context, productID string) (int32, error)
-	ReserveStock(ctx context.Context, productID string, quantity int32) error
-	UpdateStock(ctx context.Context, productID string, quantity int32) (int32, error)
-	SyncProduct(ctx context.Context, productID, name string, stock int32) error
+	CheckStock(ctx context.Context, productID string) (*model.StockLevel, error)
+	ReserveStock(ctx context.Context, orderID string, items []model.ReservationItem, strategy allocation.Strategy, shippingCountry string, expiresAt time.Time) (*model.Reservation, error)
+	CommitReservation(ctx context.Context, orderID string, now time.Time) (*model.Reservation, bool, error)
+	ReleaseReservation(ctx context.Context, orderID, reason string, now time.Time) (*model.Reservation, bool, error)
+	ExtendReservation(ctx context.Context, orderID string, expiresAt, now time.Time) (*model.Reservation, bool, error)
+	ExpireReservations(ctx context.Context, now time.Time, limit int) ([]model.Reservation, error)
+	UpdateStock(ctx context.Context, movement *model.StockMovement) (*model.StockLevel, []model.Backorder, error)
+	TransferStock(ctx context.Context, productID, fromLocationID, toLocationID string, quantity int32, actor string) (*model.StockTransfer, *model.StockLevel, error)
+	CountStock(ctx context.Context, count *model.StockCount, dryRun bool) (*model.StockLevel, []model.Backorder, error)
+	ListStockMovements(ctx context.Context, filter MovementFilter, after *PageCursor, limit int) ([]model.StockMovement, error)
+	CheckConsistency(ctx context.Context, productID string) ([]model.StockDiscrepancy, error)
+	SetStockThresholds(ctx context.Context, productID string, lowStockThreshold, reorderPoint int32) (*model.StockLevel, error)
+	SwapAlertLevel(ctx context.Context, productID string, from, to model.StockAlertLevel) (bool, error)
+	SaveLocation(ctx context.Context, location *model.Location) error
+	ListLocations(ctx context.Context) ([]model.Location, error)
+	EnsureDefaultLocation(ctx context.Context) error
+	SyncProduct(ctx context.Context, productID, name string, initialStock int32) error
+	ArchiveProduct(ctx context.Context, productID string, now time.Time) (bool, error)
+	SetBackorderPolicy(ctx context.Context, productID string, policy model.BackorderPolicy, limit int32, expectedAt *time.Time) (*model.StockLevel, error)
 }
 
 type pgRepo struct {
@@ -23,78 +96,971 @@ func NewPostgresInventoryRepository(db *gorm.DB) InventoryRepository {
 	return &pgRepo{db: db}
 }
 
-func (r *pgRepo) CheckStock(ctx context.Context, productID string) (int32, error) {
-	var product model.Product
-	err := r.db.WithContext(ctx).Where("id = ?", productID).First(&product).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return 0, errors.New("product not found")
+// CheckStock returns the on-hand stock of a product and how much of it active
+// reservations hold, in total and per location
+func (r *pgRepo) CheckStock(ctx context.Context, productID string) (*model.StockLevel, error) {
+	return readStockLevel(r.db.WithContext(ctx), productID, false)
+}
+
+// ReserveStock holds all items for an order until expiresAt, or none of them,
+// at the locations strategy picks. The products are locked while their
+// availability is checked, so concurrent reservations cannot hold more than
+// is on hand. Products whose backorder policy allows it are backordered for
+// what falls short; when other stock falls short the error is a
+// *ShortageError listing every such product. Reserving again for an order
+// whose reservation is still held returns that reservation.
+func (r *pgRepo) ReserveStock(ctx context.Context, orderID string, items []model.ReservationItem, strategy allocation.Strategy, shippingCountry string, expiresAt time.Time) (*model.Reservation, error) {
+	reservation := &model.Reservation{OrderID: orderID, State: model.ReservationHeld, ExpiresAt: expiresAt}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing model.Reservation
+		err := lockReservation(tx, orderID, &existing)
+		if err == nil {
+			if !existing.Active(time.Now()) {
+				return fmt.Errorf("%w: reservation for order %s is %s", ErrReservationClosed, orderID, existing.State)
+			}
+			reservation = &existing
+			return nil
+		}
+		if !errors.Is(err, ErrReservationNotFound) {
+			return err
+		}
+
+		quantities, err := quantitiesByProduct(items)
+		if err != nil {
+			return err
+		}
+		levels, err := lockStockLevels(tx, quantities)
+		if err != nil {
+			return err
+		}
+		for _, level := range levels {
+			if level.Archived {
+				return fmt.Errorf("%w: %s", ErrProductArchived, level.ProductID)
+			}
+		}
+		var locations []model.Location
+		if err := tx.Find(&locations).Error; err != nil {
+			return err
+		}
+		request := allocation.Request{
+			Quantities:      quantities,
+			Strategy:        strategy,
+			ShippingCountry: shippingCountry,
+		}
+		allocated, shortages := allocation.Allocate(request, locations, levels)
+		if len(shortages) > 0 {
+			backorders, err := backorderShortages(levelsByProduct(levels), quantities, shortages)
+			if err != nil {
+				return err
+			}
+			// allocate what is in stock, leaving the rest to the backorders
+			for _, b := range backorders {
+				if quantities[b.ProductID] -= b.Quantity; quantities[b.ProductID] == 0 {
+					delete(quantities, b.ProductID)
+				}
+				b.OrderID = orderID
+				reservation.Backorders = append(reservation.Backorders, b)
+			}
+			if allocated, shortages = allocation.Allocate(request, locations, levels); len(shortages) > 0 {
+				return &ShortageError{Shortages: shortages}
+			}
+		}
+		for i := range allocated {
+			allocated[i].OrderID = orderID
+		}
+		reservation.Items = allocated
+		if err := tx.Create(reservation).Error; err != nil {
+			return err
+		}
+		return recordHolds(tx, levelsByProduct(levels), allocated, model.MovementReservation, 1)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return reservation, nil
+}
+
+// CommitReservation takes the reserved items off on-hand stock once the order
+// is paid. A reservation that expired is committed only if its stock is still
+// available. changed is false when it was committed before.
+func (r *pgRepo) CommitReservation(ctx context.Context, orderID string, now time.Time) (*model.Reservation, bool, error) {
+	var reservation model.Reservation
+	changed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockReservation(tx, orderID, &reservation); err != nil {
+			return err
+		}
+		switch reservation.State {
+		case model.ReservationCommitted:
+			return nil
+		case model.ReservationReleased:
+			return fmt.Errorf("%w: reservation for order %s was released", ErrReservationClosed, orderID)
+		}
+
+		byProduct, err := lockItemLevels(tx, reservation.Items)
+		if err != nil {
+			return err
+		}
+		for _, item := range reservation.Items {
+			level := byProduct[item.ProductID]
+			at := level.At(item.LocationID)
+			// an active reservation is part of Held; a lapsed one must fit into what is left
+			if !reservation.Active(now) && at.Available() < item.Quantity {
+				return fmt.Errorf("%w: reservation for order %s expired and product %s has %d available at %s", ErrInsufficientStock, orderID, item.ProductID, at.Available(), item.LocationID)
+			}
+			sale := &model.StockMovement{
+				LocationID: item.LocationID,
+				Quantity:   -item.Quantity,
+				Reason:     model.MovementSale,
+				Reference:  orderID,
+				Actor:      model.ActorSystem,
+			}
+			if reservation.State == model.ReservationHeld {
+				// an expired reservation's hold was already released
+				sale.HeldQuantity = -item.Quantity
+			}
+			if err := adjustStock(tx, level, sale); err != nil {
+				return err
+			}
+		}
+		changed = true
+		reservation.State = model.ReservationCommitted
+		return tx.Model(&reservation).Update("state", model.ReservationCommitted).Error
+	})
 	if err != nil {
-		return 0, err
+		return nil, false, err
 	}
-	return product.Stock, nil
+	return &reservation, changed, nil
 }
 
-func (r *pgRepo) ReserveStock(ctx context.Context, productID string, quantity int32) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var product model.Product
-		if err := tx.Where("id = ?", productID).First(&product).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("product not found")
+// ReleaseReservation gives the stock of a held reservation back. changed is
+// false when it was already released or expired.
+func (r *pgRepo) ReleaseReservation(ctx context.Context, orderID, reason string, now time.Time) (*model.Reservation, bool, error) {
+	var reservation model.Reservation
+	changed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockReservation(tx, orderID, &reservation); err != nil {
+			return err
+		}
+		switch reservation.State {
+		case model.ReservationCommitted:
+			return fmt.Errorf("%w: reservation for order %s was committed", ErrReservationClosed, orderID)
+		case model.ReservationReleased, model.ReservationExpired:
+			return nil
+		}
+		changed = true
+		reservation.State, reservation.Reason = model.ReservationReleased, reason
+		if err := tx.Model(&reservation).Updates(map[string]interface{}{"state": reservation.State, "reason": reason}).Error; err != nil {
+			return err
+		}
+		if err := cancelBackorders(tx, orderID); err != nil {
+			return err
+		}
+		for i := range reservation.Backorders {
+			if reservation.Backorders[i].State == model.BackorderOpen {
+				reservation.Backorders[i].State = model.BackorderCancelled
+			}
+		}
+		byProduct, err := lockItemLevels(tx, reservation.Items)
+		if err != nil {
+			return err
+		}
+		return recordHolds(tx, byProduct, reservation.Items, model.MovementRelease, -1)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &reservation, changed, nil
+}
+
+// ExtendReservation keeps a held reservation until expiresAt, unless it is
+// already held longer. A reservation that lapsed or expired is held again only
+// if its stock is still available; the backorders an expiry cancelled are not
+// reopened. A committed reservation is returned as it is. rehold is true when
+// the stock of an expired reservation was held again.
+func (r *pgRepo) ExtendReservation(ctx context.Context, orderID string, expiresAt, now time.Time) (*model.Reservation, bool, error) {
+	var reservation model.Reservation
+	rehold := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockReservation(tx, orderID, &reservation); err != nil {
+			return err
+		}
+		switch reservation.State {
+		case model.ReservationCommitted:
+			return nil
+		case model.ReservationReleased:
+			return fmt.Errorf("%w: reservation for order %s was released", ErrReservationClosed, orderID)
+		}
+		if reservation.Active(now) {
+			if !expiresAt.After(reservation.ExpiresAt) {
+				return nil
 			}
+			reservation.ExpiresAt = expiresAt
+			return tx.Model(&reservation).Update("expires_at", expiresAt).Error
+		}
+
+		byProduct, err := lockItemLevels(tx, reservation.Items)
+		if err != nil {
 			return err
 		}
-		if product.Stock < quantity {
-			return errors.New("insufficient stock")
+		for _, item := range reservation.Items {
+			// a lapsed reservation is no longer part of Held
+			if at := byProduct[item.ProductID].At(item.LocationID); at.Available() < item.Quantity {
+				return fmt.Errorf("%w: reservation for order %s expired and product %s has %d available at %s", ErrInsufficientStock, orderID, item.ProductID, at.Available(), item.LocationID)
+			}
 		}
-		return tx.Model(&model.Product{}).Where("id = ?", productID).Update("stock", product.Stock-quantity).Error
+		if reservation.State == model.ReservationExpired {
+			// the sweeper released the hold
+			rehold = true
+			if err := recordHolds(tx, byProduct, reservation.Items, model.MovementReservation, 1); err != nil {
+				return err
+			}
+		}
+		reservation.State, reservation.Reason, reservation.ExpiresAt = model.ReservationHeld, "", expiresAt
+		return tx.Model(&reservation).Updates(map[string]interface{}{
+			"state":      reservation.State,
+			"reason":     "",
+			"expires_at": expiresAt,
+		}).Error
 	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &reservation, rehold, nil
 }
 
-func (r *pgRepo) UpdateStock(ctx context.Context, productID string, delta int32) (int32, error) {
-	var newStock int32
+// ExpireReservations marks up to limit held reservations whose expiry passed
+// as expired and returns them. Rows another sweeper is expiring are skipped.
+func (r *pgRepo) ExpireReservations(ctx context.Context, now time.Time, limit int) ([]model.Reservation, error) {
+	var reservations []model.Reservation
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var product model.Product
-		if err := tx.Where("id = ?", productID).First(&product).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("product not found")
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("state = ? AND expires_at <= ?", model.ReservationHeld, now).
+			Order("expires_at").Limit(limit).Find(&reservations).Error; err != nil {
+			return err
+		}
+		if len(reservations) == 0 {
+			return nil
+		}
+		ids := make([]string, len(reservations))
+		for i := range reservations {
+			ids[i] = reservations[i].OrderID
+			reservations[i].State = model.ReservationExpired
+		}
+		if err := tx.Model(&model.Reservation{}).Where("order_id IN ?", ids).Updates(map[string]interface{}{
+			"state":  model.ReservationExpired,
+			"reason": "expired",
+		}).Error; err != nil {
+			return err
+		}
+		if err := cancelBackorders(tx, ids...); err != nil {
+			return err
+		}
+		var items []model.ReservationItem
+		if err := tx.Where("order_id IN ?", ids).Order("id").Find(&items).Error; err != nil {
+			return err
+		}
+		for i := range reservations {
+			for _, item := range items {
+				if item.OrderID == reservations[i].OrderID {
+					reservations[i].Items = append(reservations[i].Items, item)
+				}
 			}
+		}
+		byProduct, err := lockItemLevels(tx, items)
+		if err != nil {
 			return err
 		}
-		newStock = product.Stock + delta
+		return recordHolds(tx, byProduct, items, model.MovementRelease, -1)
+	})
+	return reservations, err
+}
+
+// UpdateStock applies a movement's quantity to the on-hand stock of its
+// product at its location and records it in the stock ledger. Stock cannot
+// drop below what active reservations hold there. Stock added goes to the
+// product's open backorders first. It returns the product's new stock level
+// and the backorders that were allocated.
+func (r *pgRepo) UpdateStock(ctx context.Context, movement *model.StockMovement) (*model.StockLevel, []model.Backorder, error) {
+	var (
+		level      *model.StockLevel
+		backorders []model.Backorder
+	)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findLocation(tx, movement.LocationID); err != nil {
+			return err
+		}
+		var err error
+		if level, err = lockStockLevel(tx, movement.ProductID); err != nil {
+			return err
+		}
+		at := level.At(movement.LocationID)
+		newStock := at.OnHand + movement.Quantity
 		if newStock < 0 {
 			return errors.New("stock cannot be negative")
 		}
-		return tx.Model(&model.Product{}).Where("id = ?", productID).Update("stock", newStock).Error
+		if newStock < at.Held {
+			return fmt.Errorf("%w: %d units are held by reservations at %s", ErrInsufficientStock, at.Held, movement.LocationID)
+		}
+		if err := adjustStock(tx, level, movement); err != nil {
+			return err
+		}
+		if movement.Quantity <= 0 {
+			return nil
+		}
+		backorders, err = allocateBackorders(tx, level, movement.LocationID, time.Now())
+		return err
 	})
 	if err != nil {
-		return 0, err
+		return nil, nil, err
 	}
-	return newStock, nil
+	return level, backorders, nil
 }
 
-func (r *pgRepo) SyncProduct(ctx context.Context, productID, name string, stock int32) error {
+// TransferStock moves available stock of a product from one location to
+// another and records the transfer
+func (r *pgRepo) TransferStock(ctx context.Context, productID, fromLocationID, toLocationID string, quantity int32, actor string) (*model.StockTransfer, *model.StockLevel, error) {
+	if quantity <= 0 {
+		return nil, nil, fmt.Errorf("%w: %d", ErrInvalidQuantity, quantity)
+	}
+	if fromLocationID == toLocationID {
+		return nil, nil, fmt.Errorf("%w: cannot transfer from %s to itself", ErrInvalidQuantity, fromLocationID)
+	}
+	transfer := &model.StockTransfer{
+		ID:             utils.GenerateUUID(),
+		ProductID:      productID,
+		FromLocationID: fromLocationID,
+		ToLocationID:   toLocationID,
+		Quantity:       quantity,
+	}
+	var level *model.StockLevel
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, id := range []string{fromLocationID, toLocationID} {
+			if err := findLocation(tx, id); err != nil {
+				return err
+			}
+		}
+		var err error
+		if level, err = lockStockLevel(tx, productID); err != nil {
+			return err
+		}
+		if available := level.At(fromLocationID).Available(); available < quantity {
+			return fmt.Errorf("%w: product %s has %d available at %s, %d requested", ErrInsufficientStock, productID, available, fromLocationID, quantity)
+		}
+		for _, leg := range []struct {
+			locationID string
+			quantity   int32
+		}{{fromLocationID, -quantity}, {toLocationID, quantity}} {
+			if err := adjustStock(tx, level, &model.StockMovement{
+				LocationID: leg.locationID,
+				Quantity:   leg.quantity,
+				Reason:     model.MovementTransfer,
+				Reference:  transfer.ID,
+				Actor:      actor,
+			}); err != nil {
+				return err
+			}
+		}
+		return tx.Create(transfer).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return transfer, level, nil
+}
+
+// CountStock sets the on-hand stock of a product at a location to what was
+// counted there, filling in the count's system quantity and recording the
+// difference as a cycle count movement. A count cannot be below what active
+// reservations hold at the location. Counted stock above the system quantity
+// goes to open backorders first, as in UpdateStock. A dry run only checks the
+// count and changes nothing.
+func (r *pgRepo) CountStock(ctx context.Context, count *model.StockCount, dryRun bool) (*model.StockLevel, []model.Backorder, error) {
+	if count.Counted < 0 {
+		return nil, nil, fmt.Errorf("%w: counted %d", ErrInvalidQuantity, count.Counted)
+	}
+	var (
+		level      *model.StockLevel
+		backorders []model.Backorder
+	)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := findLocation(tx, count.LocationID); err != nil {
+			return err
+		}
+		var err error
+		if level, err = readStockLevel(tx, count.ProductID, !dryRun); err != nil {
+			return err
+		}
+		at := level.At(count.LocationID)
+		count.System = at.OnHand
+		if count.Counted < at.Held {
+			return fmt.Errorf("%w: %d units are held by reservations at %s", ErrInsufficientStock, at.Held, count.LocationID)
+		}
+		if dryRun || count.Delta() == 0 {
+			return nil
+		}
+		if err := adjustStock(tx, level, &model.StockMovement{
+			LocationID: count.LocationID,
+			Quantity:   count.Delta(),
+			Reason:     model.MovementCycleCount,
+			Reference:  count.Reference,
+			Actor:      count.Actor,
+		}); err != nil {
+			return err
+		}
+		if count.Delta() < 0 {
+			return nil
+		}
+		backorders, err = allocateBackorders(tx, level, count.LocationID, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return level, backorders, nil
+}
+
+// SaveLocation creates a location or updates its name, country and priority
+func (r *pgRepo) SaveLocation(ctx context.Context, location *model.Location) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "country", "priority", "updated_at"}),
+	}).Create(location).Error
+}
+
+// ListLocations returns all locations by priority
+func (r *pgRepo) ListLocations(ctx context.Context) ([]model.Location, error) {
+	var locations []model.Location
+	err := r.db.WithContext(ctx).Order("priority, id").Find(&locations).Error
+	return locations, err
+}
+
+// EnsureDefaultLocation creates the default location if it is missing and
+// moves the stock of products without any location stock into it, so stock
+// kept before locations existed stays available. Location stock without any
+// movements gets an opening adjustment, so the ledger sums to it.
+func (r *pgRepo) EnsureDefaultLocation(ctx context.Context) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Location{
+			ID:       model.DefaultLocationID,
+			Name:     "Default",
+			Priority: 100,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`INSERT INTO location_stocks (product_id, location_id, on_hand, version, updated_at)
+			SELECT p.id, ?, p.stock, 0, NOW() FROM products p
+			WHERE NOT EXISTS (SELECT 1 FROM location_stocks s WHERE s.product_id = p.id)`, model.DefaultLocationID).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO stock_movements (id, product_id, location_id, quantity, held_quantity, balance_after, reason, reference, actor, created_at)
+			SELECT gen_random_uuid(), s.product_id, s.location_id, s.on_hand, 0, s.on_hand, ?, 'opening balance', ?, NOW()
+			FROM location_stocks s
+			WHERE s.on_hand <> 0 AND NOT EXISTS (
+				SELECT 1 FROM stock_movements m WHERE m.product_id = s.product_id AND m.location_id = s.location_id)`,
+			model.MovementAdjustment, model.ActorSystem).Error
+	})
+}
+
+// SyncProduct creates or renames a product from the catalog. The inventory
+// owns stock, so the catalog's initial stock only seeds a product it did not
+// know, in the default location; syncing a known product restores it if it
+// was archived but leaves its stock alone.
+func (r *pgRepo) SyncProduct(ctx context.Context, productID, name string, initialStock int32) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var product model.Product
 		err := tx.Where("id = ?", productID).First(&product).Error
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// create new product
-			return tx.Create(&model.Product{
+			if err := tx.Create(&model.Product{
 				ID:        productID,
 				Name:      name,
-				Stock:     stock,
+				Stock:     initialStock,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&model.LocationStock{
+				ProductID:  productID,
+				LocationID: model.DefaultLocationID,
+				OnHand:     initialStock,
+				Version:    1,
+			}).Error; err != nil {
+				return err
+			}
+			return tx.Create(&model.StockMovement{
+				ID:           utils.GenerateUUID(),
+				ProductID:    productID,
+				LocationID:   model.DefaultLocationID,
+				Quantity:     initialStock,
+				BalanceAfter: initialStock,
+				Reason:       model.MovementAdjustment,
+				Reference:    "catalog",
+				Actor:        model.ActorSystem,
 			}).Error
 		}
 		if err != nil {
 			return err
 		}
-		// update existing product
-		return tx.Model(&model.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
-			"name":       name,
-			"stock":      stock,
-			"updated_at": time.Now(),
-		}).Error
+		// update existing product, restoring it if it was archived
+		return tx.Model(&model.Product{}).Where("id = ?", productID).
+			Updates(map[string]interface{}{"name": name, "archived_at": nil}).Error
 	})
 }
+
+// ArchiveProduct archives a product deleted from the catalog, keeping its
+// stock and movements; syncing it again restores it. changed is false when
+// it was archived before.
+func (r *pgRepo) ArchiveProduct(ctx context.Context, productID string, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Product{}).
+		Where("id = ? AND archived_at IS NULL", productID).
+		Update("archived_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", productID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, fmt.Errorf("%w: %s", ErrProductNotFound, productID)
+	}
+	return false, nil
+}
+
+// SetStockThresholds sets the low-stock threshold and reorder point of a
+// product and returns its stock level
+func (r *pgRepo) SetStockThresholds(ctx context.Context, productID string, lowStockThreshold, reorderPoint int32) (*model.StockLevel, error) {
+	if lowStockThreshold < 0 || reorderPoint < 0 {
+		return nil, ErrInvalidQuantity
+	}
+	result := r.db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", productID).
+		Updates(map[string]interface{}{"low_stock_threshold": lowStockThreshold, "reorder_point": reorderPoint})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, productID)
+	}
+	return r.CheckStock(ctx, productID)
+}
+
+// SetBackorderPolicy sets whether and how far a product can be ordered beyond
+// its stock, and when backordered stock is expected
+func (r *pgRepo) SetBackorderPolicy(ctx context.Context, productID string, policy model.BackorderPolicy, limit int32, expectedAt *time.Time) (*model.StockLevel, error) {
+	if limit < 0 {
+		return nil, ErrInvalidQuantity
+	}
+	result := r.db.WithContext(ctx).Model(&model.Product{}).Where("id = ?", productID).
+		Updates(map[string]interface{}{"backorder_policy": policy, "backorder_limit": limit, "backorder_expected_at": expectedAt})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, productID)
+	}
+	return r.CheckStock(ctx, productID)
+}
+
+// SwapAlertLevel moves the alert level of a product from one level to
+// another and reports whether it did; only one of several callers that saw
+// the same crossing wins, so each alert is sent once
+func (r *pgRepo) SwapAlertLevel(ctx context.Context, productID string, from, to model.StockAlertLevel) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Product{}).
+		Where("id = ? AND alert_level = ?", productID, from).
+		Update("alert_level", to)
+	return result.RowsAffected == 1, result.Error
+}
+
+// ListStockMovements returns up to limit movements matching filter, newest
+// first, after the cursor when one is given
+func (r *pgRepo) ListStockMovements(ctx context.Context, filter MovementFilter, after *PageCursor, limit int) ([]model.StockMovement, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC, id DESC").Limit(limit)
+	if filter.ProductID != "" {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+	if filter.LocationID != "" {
+		query = query.Where("location_id = ?", filter.LocationID)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+	if filter.Reference != "" {
+		query = query.Where("reference = ?", filter.Reference)
+	}
+	if after != nil {
+		query = query.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+	var movements []model.StockMovement
+	err := query.Find(&movements).Error
+	return movements, err
+}
+
+// CheckConsistency compares every location balance with the sum of its
+// movements, and every product total with the sum of its locations, and
+// returns the ones that differ. An empty productID checks all products.
+func (r *pgRepo) CheckConsistency(ctx context.Context, productID string) ([]model.StockDiscrepancy, error) {
+	var discrepancies []model.StockDiscrepancy
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(s.product_id, m.product_id) AS product_id, COALESCE(s.location_id, m.location_id) AS location_id,
+			COALESCE(s.on_hand, 0) AS balance, COALESCE(m.total, 0) AS movements
+		FROM location_stocks s
+		FULL OUTER JOIN (
+			SELECT product_id, location_id, SUM(quantity) AS total FROM stock_movements GROUP BY product_id, location_id
+		) m ON m.product_id = s.product_id AND m.location_id = s.location_id
+		WHERE COALESCE(s.on_hand, 0) <> COALESCE(m.total, 0) AND (? = '' OR COALESCE(s.product_id, m.product_id)::text = ?)
+		UNION ALL
+		SELECT p.id, '', p.stock, COALESCE(SUM(s.on_hand), 0)
+		FROM products p LEFT JOIN location_stocks s ON s.product_id = p.id
+		WHERE (? = '' OR p.id::text = ?)
+		GROUP BY p.id, p.stock
+		HAVING p.stock <> COALESCE(SUM(s.on_hand), 0)
+		ORDER BY product_id, location_id`, productID, productID, productID, productID).Scan(&discrepancies).Error
+	return discrepancies, err
+}
+
+// lockReservation loads a reservation with its items and locks its row for
+// the rest of the transaction
+func lockReservation(tx *gorm.DB, orderID string, reservation *model.Reservation) error {
+	byID := func(db *gorm.DB) *gorm.DB { return db.Order("id") }
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items", byID).Preload("Backorders", byID).
+		Where("order_id = ?", orderID).First(reservation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrReservationNotFound
+	}
+	return err
+}
+
+// lockStockLevel locks a product row and its location stock and returns its
+// stock level
+func lockStockLevel(tx *gorm.DB, productID string) (*model.StockLevel, error) {
+	return readStockLevel(tx, productID, true)
+}
+
+// readStockLevel returns the stock level of a product, locking its rows when
+// lock is set
+func readStockLevel(db *gorm.DB, productID string, lock bool) (*model.StockLevel, error) {
+	query := func() *gorm.DB {
+		if lock {
+			return db.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		return db
+	}
+	var product model.Product
+	err := query().Where("id = ?", productID).First(&product).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, productID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stocks []model.LocationStock
+	if err := query().Where("product_id = ?", productID).Order("location_id").Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+	held, err := heldByLocation(db, productID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var backordered int32
+	if err := db.Model(&model.Backorder{}).Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND state = ?", productID, model.BackorderOpen).Scan(&backordered).Error; err != nil {
+		return nil, err
+	}
+
+	level := &model.StockLevel{
+		ProductID:         productID,
+		OnHand:            product.Stock,
+		Version:           product.Version,
+		LowStockThreshold: product.LowStockThreshold,
+		ReorderPoint:      product.ReorderPoint,
+		AlertLevel:        product.AlertLevel,
+		Archived:          product.ArchivedAt != nil,
+
+		BackorderPolicy:     product.BackorderPolicy,
+		BackorderLimit:      product.BackorderLimit,
+		BackorderExpectedAt: product.BackorderExpectedAt,
+		Backordered:         backordered,
+	}
+	for _, stock := range stocks {
+		level.Locations = append(level.Locations, model.LocationLevel{
+			LocationID: stock.LocationID,
+			OnHand:     stock.OnHand,
+			Version:    stock.Version,
+		})
+	}
+	for locationID, quantity := range held {
+		level.At(locationID).Held = quantity
+		level.Held += quantity
+	}
+	sort.Slice(level.Locations, func(i, j int) bool { return level.Locations[i].LocationID < level.Locations[j].LocationID })
+	return level, nil
+}
+
+// adjustStock adds the quantity of a movement to the on-hand stock of a
+// product at the movement's location, and to its total, after the level was
+// read under lock, and records the movement. The updates only apply to the
+// versions that were read and never take stock below zero, so a writer that
+// skipped the lock cannot be overwritten; it returns ErrStockConflict when
+// they did not apply.
+func adjustStock(tx *gorm.DB, level *model.StockLevel, movement *model.StockMovement) error {
+	locationID, delta := movement.LocationID, movement.Quantity
+	res := tx.Model(&model.Product{}).
+		Where("id = ? AND version = ? AND stock + ? >= 0", level.ProductID, level.Version, delta).
+		Updates(map[string]interface{}{
+			"stock":   gorm.Expr("stock + ?", delta),
+			"version": gorm.Expr("version + 1"),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: product %s", ErrStockConflict, level.ProductID)
+	}
+	level.OnHand += delta
+	level.Version++
+
+	at := level.At(locationID)
+	res = tx.Model(&model.LocationStock{}).
+		Where("product_id = ? AND location_id = ? AND version = ? AND on_hand + ? >= 0", level.ProductID, locationID, at.Version, delta).
+		Updates(map[string]interface{}{
+			"on_hand": gorm.Expr("on_hand + ?", delta),
+			"version": gorm.Expr("version + 1"),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 && delta > 0 && at.Version == 0 {
+		// first stock of the product at this location
+		res = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.LocationStock{
+			ProductID:  level.ProductID,
+			LocationID: locationID,
+			OnHand:     delta,
+			Version:    1,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: product %s at %s", ErrStockConflict, level.ProductID, locationID)
+	}
+	at.OnHand += delta
+	at.Version++
+	at.Held += movement.HeldQuantity
+	level.Held += movement.HeldQuantity
+
+	movement.ID = utils.GenerateUUID()
+	movement.ProductID = level.ProductID
+	movement.BalanceAfter = at.OnHand
+	return tx.Create(movement).Error
+}
+
+// recordHolds records a movement per item for the stock a reservation starts
+// (sign 1) or stops (sign -1) holding; levels are the locked stock levels of
+// the items' products
+func recordHolds(tx *gorm.DB, levels map[string]*model.StockLevel, items []model.ReservationItem, reason model.MovementReason, sign int32) error {
+	for _, item := range items {
+		at := levels[item.ProductID].At(item.LocationID)
+		at.Held += sign * item.Quantity
+		if err := tx.Create(&model.StockMovement{
+			ID:           utils.GenerateUUID(),
+			ProductID:    item.ProductID,
+			LocationID:   item.LocationID,
+			HeldQuantity: sign * item.Quantity,
+			BalanceAfter: at.OnHand,
+			Reason:       reason,
+			Reference:    item.OrderID,
+			Actor:        model.ActorSystem,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockItemLevels locks the stock levels of the products of items and returns
+// them by product
+func lockItemLevels(tx *gorm.DB, items []model.ReservationItem) (map[string]*model.StockLevel, error) {
+	quantities, err := quantitiesByProduct(items)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := lockStockLevels(tx, quantities)
+	if err != nil {
+		return nil, err
+	}
+	return levelsByProduct(levels), nil
+}
+
+// backorderShortages returns a backorder for what each short product is
+// missing, or a *ShortageError for the products whose policy does not allow
+// backordering all of it
+func backorderShortages(levels map[string]*model.StockLevel, quantities map[string]int32, shortages []model.Shortage) ([]model.Backorder, error) {
+	var (
+		backorders []model.Backorder
+		short      []model.Shortage
+	)
+	for _, shortage := range shortages {
+		level := levels[shortage.ProductID]
+		missing := shortage.Requested - max(shortage.Available, 0)
+		if level.Archived || level.Backorderable() < missing {
+			short = append(short, shortage)
+			continue
+		}
+		backorders = append(backorders, model.Backorder{
+			ProductID:  shortage.ProductID,
+			Quantity:   missing,
+			State:      model.BackorderOpen,
+			ExpectedAt: level.BackorderExpectedAt,
+		})
+	}
+	if len(short) > 0 {
+		return nil, &ShortageError{Shortages: short}
+	}
+	return backorders, nil
+}
+
+// allocateBackorders gives the available stock of a product at a location to
+// its open backorders, oldest first, until the oldest one no longer fits;
+// level is locked. A backorder of a held reservation becomes one of its items,
+// and one of a committed reservation is taken off on-hand as a sale.
+func allocateBackorders(tx *gorm.DB, level *model.StockLevel, locationID string, now time.Time) ([]model.Backorder, error) {
+	var open []model.Backorder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND state = ?", level.ProductID, model.BackorderOpen).
+		Order("id").Find(&open).Error; err != nil {
+		return nil, err
+	}
+	var allocated []model.Backorder
+	for _, backorder := range open {
+		if level.At(locationID).Available() < backorder.Quantity {
+			break
+		}
+		// the product is locked before the reservation here, the other way
+		// round from commits and releases, so a reservation they are changing
+		// is skipped rather than waited for; its backorder stays open
+		var reservation model.Reservation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("order_id = ?", backorder.OrderID).Limit(1).Find(&reservation).Error; err != nil {
+			return nil, err
+		}
+		// a lapsed reservation's backorders are cancelled when it is swept
+		if reservation.OrderID == "" || reservation.State == model.ReservationHeld && !reservation.Active(now) {
+			continue
+		}
+		item := model.ReservationItem{
+			OrderID:    backorder.OrderID,
+			ProductID:  backorder.ProductID,
+			LocationID: locationID,
+			Quantity:   backorder.Quantity,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return nil, err
+		}
+		var err error
+		if reservation.State == model.ReservationCommitted {
+			err = adjustStock(tx, level, &model.StockMovement{
+				LocationID: locationID,
+				Quantity:   -backorder.Quantity,
+				Reason:     model.MovementSale,
+				Reference:  backorder.OrderID,
+				Actor:      model.ActorSystem,
+			})
+		} else {
+			err = recordHolds(tx, map[string]*model.StockLevel{level.ProductID: level}, []model.ReservationItem{item}, model.MovementReservation, 1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		backorder.State, backorder.LocationID, backorder.AllocatedAt = model.BackorderAllocated, locationID, &now
+		if err := tx.Save(&backorder).Error; err != nil {
+			return nil, err
+		}
+		allocated = append(allocated, backorder)
+	}
+	return allocated, nil
+}
+
+// cancelBackorders cancels the open backorders of orders
+func cancelBackorders(tx *gorm.DB, orderIDs ...string) error {
+	return tx.Model(&model.Backorder{}).Where("order_id IN ? AND state = ?", orderIDs, model.BackorderOpen).
+		Update("state", model.BackorderCancelled).Error
+}
+
+func levelsByProduct(levels []model.StockLevel) map[string]*model.StockLevel {
+	byProduct := make(map[string]*model.StockLevel, len(levels))
+	for i := range levels {
+		byProduct[levels[i].ProductID] = &levels[i]
+	}
+	return byProduct
+}
+
+// lockStockLevels locks the rows of the products in quantities and returns
+// their stock levels. Rows are locked in product ID order, so transactions
+// locking overlapping products cannot deadlock.
+func lockStockLevels(tx *gorm.DB, quantities map[string]int32) ([]model.StockLevel, error) {
+	ids := make([]string, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	levels := make([]model.StockLevel, len(ids))
+	for i, id := range ids {
+		level, err := lockStockLevel(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		levels[i] = *level
+	}
+	return levels, nil
+}
+
+// heldByLocation sums what active reservations hold of a product per location
+func heldByLocation(db *gorm.DB, productID string, now time.Time) (map[string]int32, error) {
+	var rows []struct {
+		LocationID string
+		Held       int32
+	}
+	err := db.Model(&model.ReservationItem{}).
+		Joins("JOIN reservations ON reservations.order_id = reservation_items.order_id").
+		Where("reservation_items.product_id = ? AND reservations.state = ? AND reservations.expires_at > ?", productID, model.ReservationHeld, now).
+		Group("reservation_items.location_id").
+		Select("reservation_items.location_id, SUM(reservation_items.quantity) AS held").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[string]int32, len(rows))
+	for _, row := range rows {
+		held[row.LocationID] = row.Held
+	}
+	return held, nil
+}
+
+// findLocation returns ErrLocationNotFound unless the location exists
+func findLocation(tx *gorm.DB, locationID string) error {
+	var count int64
+	if err := tx.Model(&model.Location{}).Where("id = ?", locationID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s", ErrLocationNotFound, locationID)
+	}
+	return nil
+}
+
+// quantitiesByProduct adds up the quantities of items per product
+func quantitiesByProduct(items []model.ReservationItem) (map[string]int32, error) {
+	quantities := make(map[string]int32, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: %d of product %s", ErrInvalidQuantity, item.Quantity, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities, nil
+}
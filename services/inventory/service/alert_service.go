@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"log"
+)
+
+// SetStockThresholds sets the low-stock threshold and reorder point of a
+// product; a product already below its new threshold alerts at once
+func (s *InventoryService) SetStockThresholds(ctx context.Context, req *inventorypb.SetStockThresholdsRequest) (*inventorypb.CheckStockResponse, error) {
+	level, err := s.repo.SetStockThresholds(ctx, req.ProductId, req.LowStockThreshold, req.ReorderPoint)
+	if err != nil {
+		return nil, stockError("failed to set stock thresholds", err)
+	}
+	if alert := s.checkStockAlert(ctx, level); alert != "" {
+		level.AlertLevel = alert
+	}
+	resp, err := s.toCheckStockResponse(ctx, level)
+	if err != nil {
+		return nil, stockError("failed to set stock thresholds", err)
+	}
+	return resp, nil
+}
+
+// checkStockAlerts checks the alert level of each product
+func (s *InventoryService) checkStockAlerts(ctx context.Context, productIDs ...string) {
+	for _, productID := range productIDs {
+		level, err := s.repo.CheckStock(ctx, productID)
+		if err != nil {
+			log.Printf("failed to check stock alert of product %s: %v", productID, err)
+			continue
+		}
+		s.checkStockAlert(ctx, level)
+	}
+}
+
+// itemProducts returns the distinct products of items, in order
+func itemProducts(items []model.ReservationItem) []string {
+	var productIDs []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if !seen[item.ProductID] {
+			seen[item.ProductID] = true
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	return productIDs
+}
+
+// checkStockAlert compares the available stock of a level with the product's
+// threshold and publishes stock.low or stock.out when it dropped past it and
+// stock.restored when it came back. The level is swapped first, so while
+// stock stays low the alert is sent once, even by concurrent callers; going
+// from out to low is recorded without an alert. It returns the new level, or
+// an empty one when nothing changed.
+func (s *InventoryService) checkStockAlert(ctx context.Context, level *model.StockLevel) model.StockAlertLevel {
+	// archived products cannot be sold, so their stock needs no alerts
+	if level.Archived {
+		return ""
+	}
+	from := level.AlertLevel
+	if from == "" {
+		from = model.StockOK
+	}
+	to := model.AlertLevelFor(level.Available(), level.LowStockThreshold)
+	if to == from {
+		return ""
+	}
+	swapped, err := s.repo.SwapAlertLevel(ctx, level.ProductID, from, to)
+	if err != nil {
+		log.Printf("failed to update stock alert of product %s: %v", level.ProductID, err)
+		return ""
+	}
+	if !swapped {
+		return ""
+	}
+
+	var name string
+	switch {
+	case to == model.StockOut:
+		name = "stock.out"
+	case to == model.StockLow && from == model.StockOK:
+		name = "stock.low"
+	case to == model.StockOK:
+		name = "stock.restored"
+	default:
+		return to
+	}
+	event := map[string]interface{}{
+		"event":               name,
+		"product_id":          level.ProductID,
+		"available":           level.Available(),
+		"on_hand":             level.OnHand,
+		"low_stock_threshold": level.LowStockThreshold,
+		"reorder_point":       level.ReorderPoint,
+		"reorder":             level.Available() <= level.ReorderPoint,
+		"status":              string(to),
+	}
+	if err := s.kafka.SendMessage(ctx, "stock-events", level.ProductID, event); err != nil {
+		log.Printf("failed to publish %s event: %v", name, err)
+	}
+	return to
+}
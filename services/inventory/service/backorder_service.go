@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"strings"
+	"time"
+)
+
+// SetBackorderPolicy sets whether a product can be ordered beyond its stock:
+// never, up to a limit, or as a pre-order without one
+func (s *InventoryService) SetBackorderPolicy(ctx context.Context, req *inventorypb.SetBackorderPolicyRequest) (*inventorypb.CheckStockResponse, error) {
+	policy := model.BackorderPolicy(strings.ToLower(strings.TrimSpace(req.Policy)))
+	switch policy {
+	case model.BackorderDisallow, model.BackorderLimited, model.BackorderPreorder:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "policy must be disallow, limited or preorder, got %q", req.Policy)
+	}
+	if policy == model.BackorderLimited && req.Limit <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "the limited policy needs a positive limit")
+	}
+	var expectedAt *time.Time
+	if req.ExpectedAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpectedAt)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "expected_at must be RFC3339: %v", err)
+		}
+		expectedAt = &t
+	}
+
+	level, err := s.repo.SetBackorderPolicy(ctx, req.ProductId, policy, req.Limit, expectedAt)
+	if err != nil {
+		return nil, stockError("failed to set backorder policy", err)
+	}
+	resp, err := s.toCheckStockResponse(ctx, level)
+	if err != nil {
+		return nil, stockError("failed to set backorder policy", err)
+	}
+	return resp, nil
+}
+
+// publishBackorderAllocated tells the order service that a backordered line
+// got its stock and can be fulfilled
+func (s *InventoryService) publishBackorderAllocated(ctx context.Context, backorder model.Backorder) {
+	event := map[string]interface{}{
+		"event":       "stock.backorder_allocated",
+		"order_id":    backorder.OrderID,
+		"product_id":  backorder.ProductID,
+		"quantity":    backorder.Quantity,
+		"location_id": backorder.LocationID,
+		"status":      "fulfillable",
+	}
+	if err := s.kafka.SendMessage(ctx, "stock-events", backorder.OrderID, event); err != nil {
+		log.Printf("failed to publish stock.backorder_allocated event: %v", err)
+	}
+}
+
+func toBackorders(backorders []model.Backorder) []*inventorypb.Backorder {
+	var out []*inventorypb.Backorder
+	for _, b := range backorders {
+		backorder := &inventorypb.Backorder{
+			OrderId:    b.OrderID,
+			ProductId:  b.ProductID,
+			Quantity:   b.Quantity,
+			State:      string(b.State),
+			LocationId: b.LocationID,
+		}
+		if b.ExpectedAt != nil {
+			backorder.ExpectedAt = b.ExpectedAt.Format(time.RFC3339)
+		}
+		out = append(out, backorder)
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+)
+
+// ImportStockCounts checks a stream of counted rows against the system
+// quantities and sets on-hand to what was counted, recording each difference
+// as a cycle count movement. Rows that cannot be applied are rejected and the
+// rest of the import goes on; a dry run only reports the differences.
+func (s *InventoryService) ImportStockCounts(stream inventorypb.InventoryService_ImportStockCountsServer) error {
+	ctx := stream.Context()
+	resp := &inventorypb.ImportStockCountsResponse{}
+	var reference, actor string
+	type counted struct{ productID, locationID string }
+	seen := make(map[counted]int32) // the line each product was counted on, per location
+	for {
+		row, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		resp.Rows++
+		if resp.Rows == 1 {
+			resp.DryRun, reference, actor = row.DryRun, row.Reference, row.Actor
+		}
+		line := row.Line
+		if line == 0 {
+			line = resp.Rows
+		}
+		locationID := row.LocationId
+		if locationID == "" {
+			locationID = model.DefaultLocationID
+		}
+		reject := func(reason string) {
+			resp.Rejected = append(resp.Rejected, &inventorypb.RejectedStockCount{
+				Line: line, ProductId: row.ProductId, LocationId: locationID, Reason: reason,
+			})
+		}
+
+		if row.ProductId == "" {
+			reject("product_id is required")
+			continue
+		}
+		key := counted{row.ProductId, locationID}
+		if first, ok := seen[key]; ok {
+			reject(fmt.Sprintf("already counted on line %d", first))
+			continue
+		}
+		seen[key] = line
+
+		count := &model.StockCount{
+			ProductID:  row.ProductId,
+			LocationID: locationID,
+			Counted:    row.Counted,
+			Reference:  reference,
+			Actor:      actor,
+		}
+		level, backorders, err := s.repo.CountStock(ctx, count, resp.DryRun)
+		if err != nil {
+			if err := stockError("failed to import stock counts", err); status.Code(err) == codes.Internal {
+				return err
+			}
+			reject(err.Error())
+			continue
+		}
+		if count.Delta() == 0 {
+			resp.Unchanged++
+			continue
+		}
+		resp.Differences = append(resp.Differences, &inventorypb.StockCountDifference{
+			Line:       line,
+			ProductId:  count.ProductID,
+			LocationId: count.LocationID,
+			System:     count.System,
+			Counted:    count.Counted,
+			Delta:      count.Delta(),
+		})
+		if !resp.DryRun {
+			s.stockUpdated(ctx, level, locationID, model.MovementCycleCount, reference, backorders)
+		}
+	}
+	return stream.SendAndClose(resp)
+}
@@ -3,15 +3,26 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/IBM/sarama"
 	"github.com/SabinGhost19/go-micro-payment/configs/utils"
 	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
 	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
 	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/allocation"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
 	"github.com/SabinGhost19/go-micro-payment/services/inventory/repository"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultReservationTTL is how long stock is held for an order by default
+	DefaultReservationTTL = 15 * time.Minute
 )
 
 // ProductGrpcClient defines the gRPC client interface for Product Service
@@ -25,86 +36,195 @@ type InventoryService struct {
 	kafka       *kafka.Producer
 	productGrpc ProductGrpcClient
 	inventorypb.UnimplementedInventoryServiceServer
+
+	// ReservationTTL is how long reserved stock is held for an unpaid order
+	ReservationTTL time.Duration
+	// DefaultStrategy allocates reservations that do not name a strategy
+	DefaultStrategy allocation.Strategy
 }
 
 // NewInventoryService creates a new InventoryService
 func NewInventoryService(repo repository.InventoryRepository, kafka *kafka.Producer, productGrpc ProductGrpcClient) *InventoryService {
-	return &InventoryService{repo: repo, kafka: kafka, productGrpc: productGrpc}
+	return &InventoryService{
+		repo:            repo,
+		kafka:           kafka,
+		productGrpc:     productGrpc,
+		ReservationTTL:  DefaultReservationTTL,
+		DefaultStrategy: allocation.Priority,
+	}
 }
 
-// CheckStock checks the available stock for a product
+// CheckStock returns the stock available for a product, what is on hand less
+// what active reservations hold, in total and per location
 func (s *InventoryService) CheckStock(ctx context.Context, req *inventorypb.CheckStockRequest) (*inventorypb.CheckStockResponse, error) {
 	// validate product existence
 	if _, err := s.productGrpc.GetProduct(ctx, req.ProductId); err != nil {
 		return nil, status.Errorf(codes.NotFound, "product not found: %v", err)
 	}
 
-	stock, err := s.repo.CheckStock(ctx, req.ProductId)
+	level, err := s.repo.CheckStock(ctx, req.ProductId)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to check stock: %v", err)
+		return nil, stockError("failed to check stock", err)
 	}
-	return &inventorypb.CheckStockResponse{
-		ProductId: req.ProductId,
-		Available: stock,
-	}, nil
+	resp, err := s.toCheckStockResponse(ctx, level)
+	if err != nil {
+		return nil, stockError("failed to check stock", err)
+	}
+	return resp, nil
 }
 
-// ReserveStock reserves stock for an order
+// toCheckStockResponse describes a stock level with its locations
+func (s *InventoryService) toCheckStockResponse(ctx context.Context, level *model.StockLevel) (*inventorypb.CheckStockResponse, error) {
+	names, err := s.locationNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alert := level.AlertLevel
+	if alert == "" {
+		alert = model.StockOK
+	}
+	resp := &inventorypb.CheckStockResponse{
+		ProductId:         level.ProductID,
+		Available:         level.Available(),
+		OnHand:            level.OnHand,
+		Held:              level.Held,
+		Version:           level.Version,
+		LowStockThreshold: level.LowStockThreshold,
+		ReorderPoint:      level.ReorderPoint,
+		AlertLevel:        string(alert),
+		Archived:          level.Archived,
+
+		BackorderPolicy: string(level.BackorderPolicy),
+		BackorderLimit:  level.BackorderLimit,
+		Backordered:     level.Backordered,
+		Orderable:       level.Orderable(),
+	}
+	if resp.BackorderPolicy == "" {
+		resp.BackorderPolicy = string(model.BackorderDisallow)
+	}
+	if level.BackorderExpectedAt != nil {
+		resp.BackorderExpectedAt = level.BackorderExpectedAt.Format(time.RFC3339)
+	}
+	for _, l := range level.Locations {
+		resp.Locations = append(resp.Locations, toLocationStock(l, names))
+	}
+	return resp, nil
+}
+
+// ReserveStock holds stock for an order until ReservationTTL passes, at the
+// locations the request's strategy picks. Either every item is held or none is; when some fall short, the response lists each
+// shortage so the client can adjust the cart. The hold is committed once the
+// order is paid and released if it is not.
 func (s *InventoryService) ReserveStock(ctx context.Context, req *inventorypb.ReserveStockRequest) (*inventorypb.ReserveStockResponse, error) {
-	for _, item := range req.Items {
+	items := make([]model.ReservationItem, len(req.Items))
+	for i, item := range req.Items {
 		// validate product existence
 		if _, err := s.productGrpc.GetProduct(ctx, item.ProductId); err != nil {
 			return nil, status.Errorf(codes.NotFound, "product not found: %v", err)
 		}
-		if err := s.repo.ReserveStock(ctx, item.ProductId, item.Quantity); err != nil {
-			// publish stock reservation failure event
-			event := map[string]interface{}{
-				"order_id":   req.OrderId,
-				"product_id": item.ProductId,
-				"quantity":   item.Quantity,
-				"status":     "failed",
-				"message":    err.Error(),
-			}
-			if err := s.kafka.SendMessage(ctx, "stock-events", utils.GenerateUUID(), event); err != nil {
-				log.Printf("failed to publish stock.reserved event: %v", err)
-			}
-			return &inventorypb.ReserveStockResponse{
-				OrderId: req.OrderId,
-				Success: false,
-				Message: err.Error(),
-			}, err
+		items[i] = model.ReservationItem{ProductID: item.ProductId, Quantity: item.Quantity}
+	}
+	strategy := s.DefaultStrategy
+	if req.Strategy != "" {
+		var err error
+		if strategy, err = allocation.ParseStrategy(req.Strategy); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
 		}
 	}
 
+	reservation, err := s.repo.ReserveStock(ctx, req.OrderId, items, strategy, req.ShippingCountry, time.Now().Add(s.ReservationTTL))
+	var shortage *repository.ShortageError
+	if errors.As(err, &shortage) || errors.Is(err, repository.ErrReservationClosed) || errors.Is(err, repository.ErrProductArchived) {
+		var shortages []*inventorypb.StockShortage
+		if shortage != nil {
+			shortages = toStockShortages(shortage.Shortages)
+		}
+		// publish stock reservation failure event
+		event := map[string]interface{}{
+			"event":     "stock.reserved",
+			"order_id":  req.OrderId,
+			"items":     req.Items,
+			"shortages": shortages,
+			"status":    "failed",
+			"message":   err.Error(),
+		}
+		if err := s.kafka.SendMessage(ctx, "stock-events", req.OrderId, event); err != nil {
+			log.Printf("failed to publish stock.reserved event: %v", err)
+		}
+		return &inventorypb.ReserveStockResponse{
+			OrderId:   req.OrderId,
+			Success:   false,
+			Message:   err.Error(),
+			Shortages: shortages,
+		}, nil
+	}
+	if err != nil {
+		return nil, stockError("failed to reserve stock", err)
+	}
+
 	// publish stock reservation success event
 	event := map[string]interface{}{
-		"order_id": req.OrderId,
-		"items":    req.Items,
-		"status":   "reserved",
+		"event":      "stock.reserved",
+		"order_id":   req.OrderId,
+		"items":      toStockItems(reservation.Items),
+		"backorders": toBackorders(reservation.Backorders),
+		"status":     "reserved",
+		"expires_at": reservation.ExpiresAt.Format(time.RFC3339),
 	}
 	if err := s.kafka.SendMessage(ctx, "stock-events", req.OrderId, event); err != nil {
 		log.Printf("failed to publish stock.reserved event: %v", err)
 	}
+	s.checkStockAlerts(ctx, itemProducts(reservation.Items)...)
 
+	message := "Stock reserved successfully"
+	if len(reservation.Backorders) > 0 {
+		message = fmt.Sprintf("Stock reserved with %d backordered lines", len(reservation.Backorders))
+	}
 	return &inventorypb.ReserveStockResponse{
-		OrderId: req.OrderId,
-		Success: true,
-		Message: "Stock reserved successfully",
+		OrderId:    req.OrderId,
+		Success:    true,
+		Message:    message,
+		ExpiresAt:  reservation.ExpiresAt.Format(time.RFC3339),
+		Items:      toStockItems(reservation.Items),
+		Backorders: toBackorders(reservation.Backorders),
 	}, nil
 }
 
-// UpdateStock updates the stock level for a product
+// UpdateStock updates the stock level for a product at a location, the
+// default location when none is given
 func (s *InventoryService) UpdateStock(ctx context.Context, req *inventorypb.UpdateStockRequest) (*inventorypb.UpdateStockResponse, error) {
 	// validate product existence
 	if _, err := s.productGrpc.GetProduct(ctx, req.ProductId); err != nil {
 		return nil, status.Errorf(codes.NotFound, "product not found: %v", err)
 	}
+	locationID := req.LocationId
+	if locationID == "" {
+		locationID = model.DefaultLocationID
+	}
+	reason := model.MovementReason(strings.ToLower(strings.TrimSpace(req.Reason)))
+	if reason == "" {
+		reason = model.MovementAdjustment
+	}
+	if !reason.Manual() {
+		return nil, status.Errorf(codes.InvalidArgument, "reason must be restock, adjustment or return, got %q", req.Reason)
+	}
+	if reason != model.MovementAdjustment && req.StockDelta <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "a %s must add stock", reason)
+	}
 
-	newStock, err := s.repo.UpdateStock(ctx, req.ProductId, req.StockDelta)
+	level, backorders, err := s.repo.UpdateStock(ctx, &model.StockMovement{
+		ProductID:  req.ProductId,
+		LocationID: locationID,
+		Quantity:   req.StockDelta,
+		Reason:     reason,
+		Reference:  req.Reference,
+		Actor:      req.Actor,
+	})
 	if err != nil {
 		// publish stock update failure event
 		event := map[string]interface{}{
 			"product_id":  req.ProductId,
+			"location_id": locationID,
 			"stock_delta": req.StockDelta,
 			"status":      "failed",
 			"message":     err.Error(),
@@ -112,77 +232,121 @@ func (s *InventoryService) UpdateStock(ctx context.Context, req *inventorypb.Upd
 		if err := s.kafka.SendMessage(ctx, "stock-events", utils.GenerateUUID(), event); err != nil {
 			log.Printf("failed to publish stock.updated event: %v", err)
 		}
-		return nil, status.Errorf(codes.Internal, "failed to update stock: %v", err)
+		return nil, stockError("failed to update stock", err)
 	}
+	s.stockUpdated(ctx, level, locationID, reason, req.Reference, backorders)
 
+	names, err := s.locationNames(ctx)
+	if err != nil {
+		return nil, stockError("failed to update stock", err)
+	}
+	return &inventorypb.UpdateStockResponse{
+		ProductId:           req.ProductId,
+		NewStock:            level.OnHand,
+		Location:            toLocationStock(*level.At(locationID), names),
+		AllocatedBackorders: toBackorders(backorders),
+	}, nil
+}
+
+// stockUpdated publishes the changed on-hand stock of a product, and at a
+// location unless it is empty, and the backorders the change allocated, then
+// checks the product's alerts. The version orders the updates of a product
+// for services that cache its stock.
+func (s *InventoryService) stockUpdated(ctx context.Context, level *model.StockLevel, locationID string, reason model.MovementReason, reference string, backorders []model.Backorder) {
 	// publish stock update success event
 	event := map[string]interface{}{
-		"product_id": req.ProductId,
-		"new_stock":  newStock,
+		"event":      "stock.updated",
+		"product_id": level.ProductID,
+		"new_stock":  level.OnHand,
+		"version":    level.Version,
+		"reason":     string(reason),
+		"reference":  reference,
 		"status":     "updated",
 	}
-	if err := s.kafka.SendMessage(ctx, "stock-events", req.ProductId, event); err != nil {
+	if locationID != "" {
+		event["location_id"] = locationID
+		event["on_hand"] = level.At(locationID).OnHand
+	}
+	if err := s.kafka.SendMessage(ctx, "stock-events", level.ProductID, event); err != nil {
 		log.Printf("failed to publish stock.updated event: %v", err)
 	}
-
-	return &inventorypb.UpdateStockResponse{
-		ProductId: req.ProductId,
-		NewStock:  newStock,
-	}, nil
+	for _, backorder := range backorders {
+		s.publishBackorderAllocated(ctx, backorder)
+	}
+	s.checkStockAlert(ctx, level)
 }
 
-// ConsumeProductEvents listens for product events to sync inventory
-func (s *InventoryService) ConsumeProductEvents(ctx context.Context) error {
+// ConsumeEvents listens for product events to sync inventory and for payment
+// status updates to commit or release the stock reserved for orders
+func (s *InventoryService) ConsumeEvents(ctx context.Context) error {
 	consumer, err := kafka.NewConsumer([]string{"kafka:9092"}, "inventory-service-group")
 	if err != nil {
 		return err
 	}
 	defer consumer.Close()
 
-	handler := &productEventHandler{service: s}
-	return consumer.Consume(ctx, []string{"product-events"}, handler)
+	handler := &inventoryEventHandler{service: s}
+	return consumer.Consume(ctx, []string{"product-events", "payment-status-updates"}, handler)
 }
 
-// productEventHandler implements Sarama ConsumerGroupHandler for product events
-type productEventHandler struct {
+// inventoryEventHandler implements Sarama ConsumerGroupHandler for product and payment events
+type inventoryEventHandler struct {
 	service *InventoryService
 }
 
 // Setup is called when the consumer group session starts
-func (h *productEventHandler) Setup(_ sarama.ConsumerGroupSession) error {
+func (h *inventoryEventHandler) Setup(_ sarama.ConsumerGroupSession) error {
 	return nil
 }
 
 // Cleanup is called when the consumer group session ends
-func (h *productEventHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+func (h *inventoryEventHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
 	return nil
 }
 
-// ConsumeClaim processes product event messages
-func (h *productEventHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+// ConsumeClaim processes product and payment event messages
+func (h *inventoryEventHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	for msg := range claim.Messages() {
-		var event struct {
-			ProductID string `json:"product_id"`
-			Name      string `json:"name"`
-			Stock     int32  `json:"stock"`
-			Status    string `json:"status"`
-		}
-		if err := json.Unmarshal(msg.Value, &event); err != nil {
-			log.Printf("failed to unmarshal product event: %v", err)
-			continue
-		}
-
-		switch event.Status {
-		case "created", "updated":
-			if err := h.service.repo.SyncProduct(context.Background(), event.ProductID, event.Name, event.Stock); err != nil {
-				log.Printf("failed to sync product %s: %v", event.ProductID, err)
-			}
-		case "deleted":
-			//if err := h.service.repo.Delete(context.Background(), event.ProductID); err != nil {
-			//	log.Printf("failed to delete product %s: %v", event.ProductID, err)
-			//}
+		switch msg.Topic {
+		case "product-events":
+			h.service.HandleProductEvent(context.Background(), msg.Value)
+		case "payment-status-updates":
+			h.service.HandlePaymentStatus(context.Background(), msg.Value)
 		}
 		session.MarkMessage(msg, "")
 	}
 	return nil
 }
+
+// HandleProductEvent syncs a created or updated product, restoring it if it
+// was archived, and archives a deleted one. The stock of a created product
+// seeds it when the inventory does not know it yet; from then on the
+// inventory owns its stock.
+func (s *InventoryService) HandleProductEvent(ctx context.Context, value []byte) {
+	var event struct {
+		ProductID string `json:"product_id"`
+		Name      string `json:"name"`
+		Stock     int32  `json:"stock"` // initial stock
+		Status    string `json:"status"`
+	}
+	if err := json.Unmarshal(value, &event); err != nil {
+		log.Printf("failed to unmarshal product event: %v", err)
+		return
+	}
+
+	switch event.Status {
+	case "created", "updated":
+		if err := s.repo.SyncProduct(ctx, event.ProductID, event.Name, event.Stock); err != nil {
+			log.Printf("failed to sync product %s: %v", event.ProductID, err)
+			return
+		}
+		s.checkStockAlerts(ctx, event.ProductID)
+	case "deleted":
+		archived, err := s.repo.ArchiveProduct(ctx, event.ProductID, time.Now())
+		if err != nil {
+			log.Printf("failed to archive product %s: %v", event.ProductID, err)
+		} else if archived {
+			log.Printf("archived product %s", event.ProductID)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"strings"
+)
+
+// SaveLocation creates a location or updates its name, country and priority
+func (s *InventoryService) SaveLocation(ctx context.Context, req *inventorypb.SaveLocationRequest) (*inventorypb.LocationResponse, error) {
+	if req.LocationId == "" || len(req.LocationId) > 36 || req.Name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "a location needs an ID of at most 36 characters and a name")
+	}
+	if req.Country != "" && len(req.Country) != 2 {
+		return nil, status.Errorf(codes.InvalidArgument, "country must be an ISO 3166-1 alpha-2 code, got %q", req.Country)
+	}
+	location := &model.Location{
+		ID:       req.LocationId,
+		Name:     req.Name,
+		Country:  strings.ToUpper(req.Country),
+		Priority: int(req.Priority),
+	}
+	if err := s.repo.SaveLocation(ctx, location); err != nil {
+		return nil, stockError("failed to save location", err)
+	}
+	return toLocationResponse(location), nil
+}
+
+// ListLocations returns all locations, highest priority first
+func (s *InventoryService) ListLocations(ctx context.Context, _ *inventorypb.ListLocationsRequest) (*inventorypb.ListLocationsResponse, error) {
+	locations, err := s.repo.ListLocations(ctx)
+	if err != nil {
+		return nil, stockError("failed to list locations", err)
+	}
+	resp := &inventorypb.ListLocationsResponse{}
+	for i := range locations {
+		resp.Locations = append(resp.Locations, toLocationResponse(&locations[i]))
+	}
+	return resp, nil
+}
+
+// TransferStock moves available stock of a product from one location to another
+func (s *InventoryService) TransferStock(ctx context.Context, req *inventorypb.TransferStockRequest) (*inventorypb.TransferStockResponse, error) {
+	transfer, level, err := s.repo.TransferStock(ctx, req.ProductId, req.FromLocationId, req.ToLocationId, req.Quantity, req.Actor)
+	if err != nil {
+		return nil, stockError("failed to transfer stock", err)
+	}
+
+	event := map[string]interface{}{
+		"event":            "stock.transferred",
+		"transfer_id":      transfer.ID,
+		"product_id":       transfer.ProductID,
+		"from_location_id": transfer.FromLocationID,
+		"to_location_id":   transfer.ToLocationID,
+		"quantity":         transfer.Quantity,
+		"status":           "transferred",
+	}
+	if err := s.kafka.SendMessage(ctx, "stock-events", transfer.ProductID, event); err != nil {
+		log.Printf("failed to publish stock.transferred event: %v", err)
+	}
+
+	names, err := s.locationNames(ctx)
+	if err != nil {
+		return nil, stockError("failed to transfer stock", err)
+	}
+	return &inventorypb.TransferStockResponse{
+		TransferId: transfer.ID,
+		ProductId:  transfer.ProductID,
+		Quantity:   transfer.Quantity,
+		From:       toLocationStock(*level.At(transfer.FromLocationID), names),
+		To:         toLocationStock(*level.At(transfer.ToLocationID), names),
+	}, nil
+}
+
+// locationNames maps location IDs to names
+func (s *InventoryService) locationNames(ctx context.Context) (map[string]string, error) {
+	locations, err := s.repo.ListLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(locations))
+	for _, l := range locations {
+		names[l.ID] = l.Name
+	}
+	return names, nil
+}
+
+func toLocationStock(l model.LocationLevel, names map[string]string) *inventorypb.LocationStock {
+	return &inventorypb.LocationStock{
+		LocationId: l.LocationID,
+		Name:       names[l.LocationID],
+		OnHand:     l.OnHand,
+		Held:       l.Held,
+		Available:  l.Available(),
+	}
+}
+
+func toLocationResponse(l *model.Location) *inventorypb.LocationResponse {
+	return &inventorypb.LocationResponse{
+		LocationId: l.ID,
+		Name:       l.Name,
+		Country:    l.Country,
+		Priority:   int32(l.Priority),
+	}
+}
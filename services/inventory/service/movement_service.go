@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when ListStockMovements is called without a page size
+	DefaultPageSize = 50
+	// MaxPageSize bounds the page size of ListStockMovements
+	MaxPageSize = 200
+)
+
+// ErrInvalidPageToken is returned for page tokens ListStockMovements did not issue
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// ListStockMovements returns one page of the stock ledger, newest first
+func (s *InventoryService) ListStockMovements(ctx context.Context, req *inventorypb.ListStockMovementsRequest) (*inventorypb.ListStockMovementsResponse, error) {
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	var after *repository.PageCursor
+	if req.PageToken != "" {
+		cursor, err := decodePageToken(req.PageToken)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		after = cursor
+	}
+	filter := repository.MovementFilter{
+		ProductID:  req.ProductId,
+		LocationID: req.LocationId,
+		Reason:     model.MovementReason(strings.ToLower(req.Reason)),
+		Reference:  req.Reference,
+	}
+
+	// one extra row tells whether another page follows
+	movements, err := s.repo.ListStockMovements(ctx, filter, after, pageSize+1)
+	if err != nil {
+		return nil, stockError("failed to list stock movements", err)
+	}
+	resp := &inventorypb.ListStockMovementsResponse{}
+	if len(movements) > pageSize {
+		movements = movements[:pageSize]
+		last := movements[pageSize-1]
+		resp.NextPageToken = encodePageToken(repository.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	for _, m := range movements {
+		resp.Movements = append(resp.Movements, toStockMovement(m))
+	}
+	return resp, nil
+}
+
+// CheckStockConsistency confirms that the stock ledger sums to the stock at
+// every location and that the locations sum to each product's total
+func (s *InventoryService) CheckStockConsistency(ctx context.Context, req *inventorypb.CheckStockConsistencyRequest) (*inventorypb.CheckStockConsistencyResponse, error) {
+	discrepancies, err := s.repo.CheckConsistency(ctx, req.ProductId)
+	if err != nil {
+		return nil, stockError("failed to check stock consistency", err)
+	}
+	resp := &inventorypb.CheckStockConsistencyResponse{Consistent: len(discrepancies) == 0}
+	for _, d := range discrepancies {
+		resp.Discrepancies = append(resp.Discrepancies, &inventorypb.StockDiscrepancy{
+			ProductId:  d.ProductID,
+			LocationId: d.LocationID,
+			Balance:    d.Balance,
+			Movements:  d.Movements,
+		})
+	}
+	return resp, nil
+}
+
+func toStockMovement(m model.StockMovement) *inventorypb.StockMovement {
+	return &inventorypb.StockMovement{
+		MovementId:   m.ID,
+		ProductId:    m.ProductID,
+		LocationId:   m.LocationID,
+		Quantity:     m.Quantity,
+		HeldQuantity: m.HeldQuantity,
+		BalanceAfter: m.BalanceAfter,
+		Reason:       string(m.Reason),
+		Reference:    m.Reference,
+		Actor:        m.Actor,
+		CreatedAt:    m.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// encodePageToken turns a cursor into an opaque token
+func encodePageToken(c repository.PageCursor) string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePageToken is the inverse of encodePageToken
+func decodePageToken(token string) (*repository.PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	nanos, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidPageToken
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	return &repository.PageCursor{CreatedAt: time.Unix(0, n), ID: id}, nil
+}
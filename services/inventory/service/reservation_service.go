@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"time"
+)
+
+// expireBatchSize caps how many reservations one sweep expires
+const expireBatchSize = 100
+
+// CommitReservation takes the stock reserved for a paid order off on-hand,
+// publishing the new stock of each product as a sale. Committing twice is a
+// no-op.
+func (s *InventoryService) CommitReservation(ctx context.Context, req *inventorypb.CommitReservationRequest) (*inventorypb.ReservationResponse, error) {
+	reservation, changed, err := s.repo.CommitReservation(ctx, req.OrderId, time.Now())
+	if err != nil {
+		return nil, stockError("failed to commit reservation", err)
+	}
+	if changed {
+		s.publishReservation(ctx, "stock.committed", reservation)
+		for _, productID := range itemProducts(reservation.Items) {
+			level, err := s.repo.CheckStock(ctx, productID)
+			if err != nil {
+				log.Printf("failed to read stock of product %s: %v", productID, err)
+				continue
+			}
+			s.stockUpdated(ctx, level, "", model.MovementSale, reservation.OrderID, nil)
+		}
+	}
+	return toReservationResponse(reservation, "Reservation committed"), nil
+}
+
+// ReleaseReservation gives the stock reserved for an order back, e.g. when the
+// order is cancelled. Releasing a reservation that already ended is a no-op.
+func (s *InventoryService) ReleaseReservation(ctx context.Context, req *inventorypb.ReleaseReservationRequest) (*inventorypb.ReservationResponse, error) {
+	reason := req.Reason
+	if reason == "" {
+		reason = "released"
+	}
+	reservation, changed, err := s.repo.ReleaseReservation(ctx, req.OrderId, reason, time.Now())
+	if err != nil {
+		return nil, stockError("failed to release reservation", err)
+	}
+	if changed {
+		s.publishReservation(ctx, "stock.released", reservation)
+		s.checkStockAlerts(ctx, itemProducts(reservation.Items)...)
+	}
+	return toReservationResponse(reservation, "Reservation released"), nil
+}
+
+// ExtendReservation keeps the stock reserved for an order held until the
+// requested expiry, e.g. while the order waits for review or for a payment
+// retry. An expired reservation whose stock is still available is held again
+// and published as reserved; otherwise FailedPrecondition is returned.
+func (s *InventoryService) ExtendReservation(ctx context.Context, req *inventorypb.ExtendReservationRequest) (*inventorypb.ReservationResponse, error) {
+	now := time.Now()
+	expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid expires_at: %v", err)
+	}
+	if !expiresAt.After(now) {
+		return nil, status.Errorf(codes.InvalidArgument, "expires_at must be in the future")
+	}
+	reservation, rehold, err := s.repo.ExtendReservation(ctx, req.OrderId, expiresAt, now)
+	if err != nil {
+		return nil, stockError("failed to extend reservation", err)
+	}
+	if rehold {
+		event := map[string]interface{}{
+			"event":      "stock.reserved",
+			"order_id":   reservation.OrderID,
+			"items":      toStockItems(reservation.Items),
+			"status":     "reserved",
+			"expires_at": reservation.ExpiresAt.Format(time.RFC3339),
+		}
+		if err := s.kafka.SendMessage(ctx, "stock-events", reservation.OrderID, event); err != nil {
+			log.Printf("failed to publish stock.reserved event: %v", err)
+		}
+		s.checkStockAlerts(ctx, itemProducts(reservation.Items)...)
+	}
+	return toReservationResponse(reservation, "Reservation extended"), nil
+}
+
+// RunReservationSweeper expires lapsed reservations once per interval, until
+// ctx is cancelled
+func (s *InventoryService) RunReservationSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.ExpireReservations(ctx, time.Now())
+			if err != nil {
+				log.Printf("reservation sweep failed: %v", err)
+			} else if n > 0 {
+				log.Printf("expired %d reservations", n)
+			}
+		}
+	}
+}
+
+// ExpireReservations releases the stock of every reservation still held past
+// its expiry at now, publishing a stock.released event for each, and returns
+// how many were expired
+func (s *InventoryService) ExpireReservations(ctx context.Context, now time.Time) (int, error) {
+	total := 0
+	for {
+		reservations, err := s.repo.ExpireReservations(ctx, now, expireBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("expire reservations: %w", err)
+		}
+		for i := range reservations {
+			s.publishReservation(ctx, "stock.released", &reservations[i])
+			s.checkStockAlerts(ctx, itemProducts(reservations[i].Items)...)
+		}
+		total += len(reservations)
+		if len(reservations) < expireBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// HandlePaymentStatus commits the reservation of an order whose payment went
+// through and releases it when the payment did not. A reservation that
+// cannot be committed is published as stock.commit_failed, since the order
+// was paid for stock it does not have.
+func (s *InventoryService) HandlePaymentStatus(ctx context.Context, value []byte) {
+	var event struct {
+		OrderID   string `json:"order_id"`
+		PaymentID string `json:"payment_id"`
+		Status    string `json:"status"`
+	}
+	if err := json.Unmarshal(value, &event); err != nil {
+		log.Printf("failed to unmarshal payment event: %v", err)
+		return
+	}
+
+	var err error
+	switch event.Status {
+	case "AUTHORIZED", "PAID":
+		if _, err = s.CommitReservation(ctx, &inventorypb.CommitReservationRequest{OrderId: event.OrderID}); err != nil {
+			s.publishCommitFailed(ctx, event.OrderID, event.PaymentID, err)
+		}
+	case "FAILED", "VOIDED", "EXPIRED":
+		_, err = s.ReleaseReservation(ctx, &inventorypb.ReleaseReservationRequest{
+			OrderId: event.OrderID,
+			Reason:  "payment " + event.Status,
+		})
+	default:
+		return
+	}
+	if err != nil {
+		log.Printf("failed to settle reservation of order %s on payment %s: %v", event.OrderID, event.Status, err)
+	}
+}
+
+// publishCommitFailed reports a paid order whose reservation could not be
+// committed, e.g. because it expired and its stock was sold meanwhile
+func (s *InventoryService) publishCommitFailed(ctx context.Context, orderID, paymentID string, cause error) {
+	event := map[string]interface{}{
+		"event":      "stock.commit_failed",
+		"order_id":   orderID,
+		"payment_id": paymentID,
+		"status":     "commit_failed",
+		"message":    cause.Error(),
+	}
+	if err := s.kafka.SendMessage(ctx, "stock-events", orderID, event); err != nil {
+		log.Printf("failed to publish stock.commit_failed event: %v", err)
+	}
+}
+
+// publishReservation publishes a reservation state change on stock-events
+func (s *InventoryService) publishReservation(ctx context.Context, name string, reservation *model.Reservation) {
+	event := map[string]interface{}{
+		"event":    name,
+		"order_id": reservation.OrderID,
+		"items":    toStockItems(reservation.Items),
+		"status":   string(reservation.State),
+	}
+	if reservation.State != model.ReservationCommitted {
+		event["status"] = "released"
+		event["reason"] = reservation.Reason
+	}
+	if err := s.kafka.SendMessage(ctx, "stock-events", reservation.OrderID, event); err != nil {
+		log.Printf("failed to publish %s event: %v", name, err)
+	}
+}
+
+// stockError maps repository errors to gRPC status errors
+func stockError(msg string, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound), errors.Is(err, repository.ErrReservationNotFound),
+		errors.Is(err, repository.ErrLocationNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", msg, err)
+	case errors.Is(err, repository.ErrInvalidQuantity):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", msg, err)
+	case errors.Is(err, repository.ErrInsufficientStock), errors.Is(err, repository.ErrReservationClosed),
+		errors.Is(err, repository.ErrProductArchived):
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	case errors.Is(err, repository.ErrStockConflict):
+		return status.Errorf(codes.Aborted, "%s: %v", msg, err)
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
+
+func toStockItems(items []model.ReservationItem) []*inventorypb.StockItem {
+	out := make([]*inventorypb.StockItem, len(items))
+	for i, item := range items {
+		out[i] = &inventorypb.StockItem{ProductId: item.ProductID, Quantity: item.Quantity, LocationId: item.LocationID}
+	}
+	return out
+}
+
+func toStockShortages(shortages []model.Shortage) []*inventorypb.StockShortage {
+	out := make([]*inventorypb.StockShortage, len(shortages))
+	for i, s := range shortages {
+		out[i] = &inventorypb.StockShortage{ProductId: s.ProductID, Requested: s.Requested, Available: s.Available}
+	}
+	return out
+}
+
+func toReservationResponse(r *model.Reservation, message string) *inventorypb.ReservationResponse {
+	return &inventorypb.ReservationResponse{
+		OrderId:    r.OrderID,
+		State:      string(r.State),
+		ExpiresAt:  r.ExpiresAt.Format(time.RFC3339),
+		Items:      toStockItems(r.Items),
+		Message:    message,
+		Backorders: toBackorders(r.Backorders),
+	}
+}
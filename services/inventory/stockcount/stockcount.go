@@ -0,0 +1,230 @@
+// Package stockcount reads the rows of a stock count, as warehouse staff send
+// them, from CSV or JSON
+package stockcount
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is the encoding of a stock count file
+type Format string
+
+const (
+	// CSV has a header row naming the product_id, location and counted columns
+	CSV Format = "csv"
+	// JSON is an array of objects, or one object per line, with the same fields
+	JSON Format = "json"
+)
+
+// ErrUnknownFormat is returned for a format that is not one of the above
+var ErrUnknownFormat = errors.New("unknown stock count format")
+
+// ParseFormat parses a format name or file extension
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), ".")) {
+	case "csv":
+		return CSV, nil
+	case "json", "jsonl", "ndjson":
+		return JSON, nil
+	}
+	return "", fmt.Errorf("%w %q: use %q or %q", ErrUnknownFormat, s, CSV, JSON)
+}
+
+// Row is one counted quantity of a product at a location. Line is the line
+// of a CSV row or the position of a JSON object, counting from 1.
+type Row struct {
+	Line       int32
+	ProductID  string
+	LocationID string // the default location when empty
+	Counted    int32
+}
+
+// RowError is a row that could not be read; the rows after it still can
+type RowError struct {
+	Line int32
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// columns are the names a CSV header may give each field
+var columns = map[string][]string{
+	"product_id": {"product_id", "product"},
+	"location":   {"location", "location_id"},
+	"counted":    {"counted", "counted_quantity", "quantity"},
+}
+
+// Reader reads stock count rows one at a time
+type Reader struct {
+	csv     *csv.Reader
+	columns map[string]int // field to CSV column; location is -1 when missing
+
+	json    *json.Decoder
+	array   bool
+	objects int32
+}
+
+// NewReader returns a reader of rows in format. For CSV it reads the header.
+func NewReader(r io.Reader, format Format) (*Reader, error) {
+	br := bufio.NewReader(r)
+	// spreadsheets often start their exports with a byte order mark
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+	}
+	switch format {
+	case CSV:
+		return newCSVReader(br)
+	case JSON:
+		return newJSONReader(br)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+func newCSVReader(r io.Reader) (*Reader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	found := map[string]int{"location": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, names := range columns {
+			for _, n := range names {
+				if n == name {
+					found[field] = i
+				}
+			}
+		}
+	}
+	for _, field := range []string{"product_id", "counted"} {
+		if _, ok := found[field]; !ok {
+			return nil, fmt.Errorf("CSV header has no %s column", field)
+		}
+	}
+	return &Reader{csv: cr, columns: found}, nil
+}
+
+func newJSONReader(r *bufio.Reader) (*Reader, error) {
+	// an array is decoded element by element, so both forms stream
+	var array bool
+	for {
+		b, err := r.ReadByte()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+		r.UnreadByte()
+		array = b == '['
+		break
+	}
+	dec := json.NewDecoder(r)
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+	return &Reader{json: dec, array: array}, nil
+}
+
+// Read returns the next row, a *RowError for a row that could not be read,
+// or io.EOF after the last row. Other errors end the file.
+func (r *Reader) Read() (Row, error) {
+	if r.csv != nil {
+		return r.readCSV()
+	}
+	return r.readJSON()
+}
+
+func (r *Reader) readCSV() (Row, error) {
+	record, err := r.csv.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return Row{}, &RowError{Line: int32(parseErr.StartLine), Err: parseErr.Err}
+		}
+		return Row{}, err
+	}
+	line, _ := r.csv.FieldPos(0)
+	field := func(name string) string {
+		if i := r.columns[name]; i >= 0 && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	return parseRow(int32(line), field("product_id"), field("location"), field("counted"))
+}
+
+// jsonRow is a JSON stock count object; location_id may stand for location
+type jsonRow struct {
+	ProductID  string          `json:"product_id"`
+	Location   string          `json:"location"`
+	LocationID string          `json:"location_id"`
+	Counted    json.RawMessage `json:"counted"`
+}
+
+func (r *Reader) readJSON() (Row, error) {
+	if r.array && !r.json.More() {
+		if _, err := r.json.Token(); err != nil {
+			return Row{}, err
+		}
+		return Row{}, io.EOF
+	}
+	var raw json.RawMessage
+	if err := r.json.Decode(&raw); err != nil {
+		if errors.Is(err, io.EOF) && r.array {
+			return Row{}, io.ErrUnexpectedEOF
+		}
+		return Row{}, err
+	}
+	r.objects++
+	var obj jsonRow
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return Row{}, &RowError{Line: r.objects, Err: err}
+	}
+	location := obj.Location
+	if location == "" {
+		location = obj.LocationID
+	}
+	// counted may be a number or, from spreadsheet exports, a string
+	counted := strings.Trim(strings.TrimSpace(string(obj.Counted)), `"`)
+	return parseRow(r.objects, strings.TrimSpace(obj.ProductID), strings.TrimSpace(location), counted)
+}
+
+func parseRow(line int32, productID, locationID, counted string) (Row, error) {
+	if productID == "" {
+		return Row{}, &RowError{Line: line, Err: errors.New("product_id is required")}
+	}
+	if counted == "" || counted == "null" {
+		return Row{}, &RowError{Line: line, Err: errors.New("counted is required")}
+	}
+	n, err := strconv.ParseInt(counted, 10, 32)
+	if err != nil {
+		return Row{}, &RowError{Line: line, Err: fmt.Errorf("counted %q is not a whole number", counted)}
+	}
+	if n < 0 {
+		return Row{}, &RowError{Line: line, Err: fmt.Errorf("counted %d is negative", n)}
+	}
+	return Row{Line: line, ProductID: productID, LocationID: locationID, Counted: int32(n)}, nil
+}
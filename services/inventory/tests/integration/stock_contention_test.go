@@ -0,0 +1,143 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/configs/utils"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/allocation"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB connects to the database in INVENTORY_TEST_DSN, e.g.
+// "host=localhost user=admin password=secret dbname=inventory_test port=5432 sslmode=disable",
+// and skips the test when it is not set
+func openTestDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("INVENTORY_TEST_DSN")
+	if dsn == "" {
+		t.Skip("INVENTORY_TEST_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.Product{}, &model.Reservation{}, &model.ReservationItem{},
+		&model.Location{}, &model.LocationStock{}, &model.StockTransfer{}, &model.StockMovement{}, &model.Backorder{}))
+	require.NoError(t, repository.NewPostgresInventoryRepository(db).EnsureDefaultLocation(context.Background()))
+	return db
+}
+
+// TestStockIsNeverOversold hammers one product with concurrent reservations,
+// commits and stock decrements and checks that no more is sold than was on hand
+func TestStockIsNeverOversold(t *testing.T) {
+	db := openTestDB(t)
+	repo := repository.NewPostgresInventoryRepository(db)
+	ctx := context.Background()
+
+	const (
+		onHand  = 20
+		orders  = 60
+		workers = 30
+	)
+	productID := utils.GenerateUUID()
+	require.NoError(t, repo.SyncProduct(ctx, productID, "contended", onHand))
+	var orderIDs []string
+	t.Cleanup(func() {
+		db.Where("order_id IN ?", orderIDs).Delete(&model.ReservationItem{})
+		db.Where("order_id IN ?", orderIDs).Delete(&model.Reservation{})
+		db.Where("product_id = ?", productID).Delete(&model.StockMovement{})
+		db.Where("product_id = ?", productID).Delete(&model.LocationStock{})
+		db.Where("id = ?", productID).Delete(&model.Product{})
+	})
+	for i := 0; i < orders; i++ {
+		orderIDs = append(orderIDs, utils.GenerateUUID())
+	}
+
+	// every order asks for one unit at the same time
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved []string
+	)
+	start := make(chan struct{})
+	for _, orderID := range orderIDs {
+		wg.Add(1)
+		go func(orderID string) {
+			defer wg.Done()
+			<-start
+			items := []model.ReservationItem{{ProductID: productID, Quantity: 1}}
+			_, err := repo.ReserveStock(ctx, orderID, items, allocation.Priority, "", time.Now().Add(time.Hour))
+			if err == nil {
+				mu.Lock()
+				reserved = append(reserved, orderID)
+				mu.Unlock()
+				return
+			}
+			assert.ErrorIs(t, err, repository.ErrInsufficientStock)
+		}(orderID)
+	}
+	close(start)
+	wg.Wait()
+
+	require.Len(t, reserved, onHand, "exactly the stock on hand is reserved")
+	level, err := repo.CheckStock(ctx, productID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(onHand), level.OnHand)
+	assert.Equal(t, int32(onHand), level.Held)
+
+	// commit the reservations while other writers try to take stock off
+	var decremented int32
+	start = make(chan struct{})
+	for _, orderID := range reserved {
+		wg.Add(1)
+		go func(orderID string) {
+			defer wg.Done()
+			<-start
+			_, _, err := repo.CommitReservation(ctx, orderID, time.Now())
+			assert.NoError(t, err)
+		}(orderID)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, _, err := repo.UpdateStock(ctx, &model.StockMovement{
+				ProductID: productID, LocationID: model.DefaultLocationID, Quantity: -1, Reason: model.MovementAdjustment,
+			})
+			if err == nil {
+				atomic.AddInt32(&decremented, 1)
+				return
+			}
+			if !errors.Is(err, repository.ErrInsufficientStock) {
+				assert.Contains(t, err.Error(), "stock cannot be negative")
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Zero(t, decremented, "held stock cannot be taken off")
+	level, err = repo.CheckStock(ctx, productID)
+	require.NoError(t, err)
+	assert.Zero(t, level.OnHand)
+	assert.Zero(t, level.Held)
+
+	var product model.Product
+	require.NoError(t, db.Where("id = ?", productID).First(&product).Error)
+	assert.Equal(t, int64(onHand), product.Version, "every committed unit is one version")
+
+	discrepancies, err := repo.CheckConsistency(ctx, productID)
+	require.NoError(t, err)
+	assert.Empty(t, discrepancies, "the ledger sums to the stock")
+}
@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// alerts returns the stock alert events a recorder saw, in order
+func alerts(recorder *topicRecorder) []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, event := range recorder.events {
+		switch event["event"] {
+		case "stock.low", "stock.out", "stock.restored":
+			out = append(out, event)
+		}
+	}
+	return out
+}
+
+func TestStockAlerts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("crossing a threshold alerts once", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 8, model.Product{ID: laptop, Stock: 10})
+		resp, err := svc.SetStockThresholds(ctx, &inventorypb.SetStockThresholdsRequest{ProductId: laptop, LowStockThreshold: 3, ReorderPoint: 5})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp.AlertLevel)
+
+		reserve(t, svc, "order-1", 7)
+		reserve(t, svc, "order-2", 1)
+		_, err = svc.ReleaseReservation(ctx, &inventorypb.ReleaseReservationRequest{OrderId: "order-2"})
+		require.NoError(t, err)
+		_, err = svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: -3})
+		require.NoError(t, err)
+		_, err = svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: 10, Reason: "restock"})
+		require.NoError(t, err)
+
+		events := alerts(recorder)
+		var names []string
+		for _, event := range events {
+			names = append(names, event["event"].(string))
+		}
+		assert.Equal(t, []string{"stock.low", "stock.out", "stock.restored"}, names, "staying low does not alert again")
+		assert.Equal(t, float64(3), events[0]["available"])
+		assert.Equal(t, true, events[0]["reorder"])
+		assert.Equal(t, float64(10), events[2]["available"])
+		assert.Equal(t, "ok", events[2]["status"])
+	})
+
+	t.Run("coming back from out of stock to low does not alert", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 3, model.Product{ID: laptop, Stock: 4})
+		_, err := svc.SetStockThresholds(ctx, &inventorypb.SetStockThresholdsRequest{ProductId: laptop, LowStockThreshold: 2})
+		require.NoError(t, err)
+		reserve(t, svc, "order-1", 4)
+		_, err = svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: 1, Reason: "restock"})
+		require.NoError(t, err)
+
+		stock, err := svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.Equal(t, "low", stock.AlertLevel)
+		require.Len(t, alerts(recorder), 1)
+		assert.Equal(t, "stock.out", alerts(recorder)[0]["event"])
+	})
+
+	t.Run("a product already below a new threshold alerts at once", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 1, model.Product{ID: laptop, Stock: 10})
+		resp, err := svc.SetStockThresholds(ctx, &inventorypb.SetStockThresholdsRequest{ProductId: laptop, LowStockThreshold: 20, ReorderPoint: 25})
+		require.NoError(t, err)
+		assert.Equal(t, "low", resp.AlertLevel)
+		assert.Equal(t, int32(25), resp.ReorderPoint)
+		require.Len(t, recorder.events, 1)
+		assert.Equal(t, "stock.low", recorder.events[0]["event"])
+
+		_, err = svc.SetStockThresholds(ctx, &inventorypb.SetStockThresholdsRequest{ProductId: laptop, LowStockThreshold: -1})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
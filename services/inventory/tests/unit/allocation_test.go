@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/allocation"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// levels builds the stock levels of one product from its on-hand stock per location
+func levels(productID string, onHand map[string]int32) model.StockLevel {
+	level := model.StockLevel{ProductID: productID}
+	for locationID, n := range onHand {
+		level.At(locationID).OnHand = n
+		level.OnHand += n
+	}
+	return level
+}
+
+func TestAllocate(t *testing.T) {
+	const mouse = "22222222-2222-2222-2222-222222222222"
+	locations := []model.Location{
+		{ID: "berlin", Country: "DE", Priority: 2},
+		{ID: "dallas", Country: "US", Priority: 1},
+		{ID: "paris", Country: "FR", Priority: 3},
+	}
+
+	t.Run("priority serves the order from the first location holding all of it", func(t *testing.T) {
+		items, shortages := allocation.Allocate(allocation.Request{
+			Quantities: map[string]int32{laptop: 2, mouse: 1},
+			Strategy:   allocation.Priority,
+		}, locations, []model.StockLevel{
+			levels(laptop, map[string]int32{"dallas": 5, "berlin": 5}),
+			levels(mouse, map[string]int32{"berlin": 1}),
+		})
+		require.Empty(t, shortages)
+		assert.Equal(t, []model.ReservationItem{
+			{ProductID: laptop, LocationID: "berlin", Quantity: 2},
+			{ProductID: mouse, LocationID: "berlin", Quantity: 1},
+		}, items)
+	})
+
+	t.Run("nearest prefers the shipping country", func(t *testing.T) {
+		stock := []model.StockLevel{levels(laptop, map[string]int32{"dallas": 5, "paris": 5})}
+		items, _ := allocation.Allocate(allocation.Request{
+			Quantities: map[string]int32{laptop: 2}, Strategy: allocation.Nearest, ShippingCountry: "fr",
+		}, locations, stock)
+		require.Len(t, items, 1)
+		assert.Equal(t, "paris", items[0].LocationID)
+
+		items, _ = allocation.Allocate(allocation.Request{
+			Quantities: map[string]int32{laptop: 2}, Strategy: allocation.Nearest, ShippingCountry: "JP",
+		}, locations, stock)
+		require.Len(t, items, 1)
+		assert.Equal(t, "dallas", items[0].LocationID, "by priority without a location in the country")
+	})
+
+	t.Run("single-location shortages are against the best location", func(t *testing.T) {
+		_, shortages := allocation.Allocate(allocation.Request{
+			Quantities: map[string]int32{laptop: 6},
+			Strategy:   allocation.Priority,
+		}, locations, []model.StockLevel{levels(laptop, map[string]int32{"dallas": 2, "berlin": 4})})
+		assert.Equal(t, []model.Shortage{{ProductID: laptop, Requested: 6, Available: 4}}, shortages)
+	})
+
+	t.Run("split fills across locations by priority", func(t *testing.T) {
+		stock := model.StockLevel{ProductID: laptop}
+		stock.At("dallas").OnHand = 2
+		stock.At("dallas").Held = 1
+		stock.At("berlin").OnHand = 4
+		stock.At("paris").OnHand = 9
+		items, shortages := allocation.Allocate(allocation.Request{
+			Quantities: map[string]int32{laptop: 6},
+			Strategy:   allocation.Split,
+		}, locations, []model.StockLevel{stock})
+		require.Empty(t, shortages)
+		assert.Equal(t, []model.ReservationItem{
+			{ProductID: laptop, LocationID: "dallas", Quantity: 1},
+			{ProductID: laptop, LocationID: "berlin", Quantity: 4},
+			{ProductID: laptop, LocationID: "paris", Quantity: 1},
+		}, items)
+
+		_, shortages = allocation.Allocate(allocation.Request{
+			Quantities: map[string]int32{laptop: 20},
+			Strategy:   allocation.Split,
+		}, locations, []model.StockLevel{stock})
+		assert.Equal(t, []model.Shortage{{ProductID: laptop, Requested: 20, Available: 14}}, shortages)
+	})
+
+	t.Run("strategies are parsed", func(t *testing.T) {
+		strategy, err := allocation.ParseStrategy("")
+		require.NoError(t, err)
+		assert.Equal(t, allocation.Priority, strategy)
+		strategy, err = allocation.ParseStrategy(" Split ")
+		require.NoError(t, err)
+		assert.Equal(t, allocation.Split, strategy)
+		_, err = allocation.ParseStrategy("cheapest")
+		assert.ErrorIs(t, err, allocation.ErrUnknownStrategy)
+	})
+}
@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBackorders(t *testing.T) {
+	ctx := context.Background()
+	order := func(orderID string, quantity int32) *inventorypb.ReserveStockRequest {
+		return &inventorypb.ReserveStockRequest{OrderId: orderID, Items: []*inventorypb.StockItem{{ProductId: laptop, Quantity: quantity}}}
+	}
+
+	t.Run("shortfalls are backordered up to the product's limit", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 3, model.Product{ID: laptop, Stock: 2})
+		stock, err := svc.SetBackorderPolicy(ctx, &inventorypb.SetBackorderPolicyRequest{ProductId: laptop, Policy: "limited", Limit: 3})
+		require.NoError(t, err)
+		assert.Equal(t, int32(5), stock.Orderable)
+
+		resp, err := svc.ReserveStock(ctx, order("order-1", 4))
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		require.Len(t, resp.Items, 1)
+		assert.Equal(t, int32(2), resp.Items[0].Quantity)
+		require.Len(t, resp.Backorders, 1)
+		assert.Equal(t, int32(2), resp.Backorders[0].Quantity)
+		assert.Equal(t, "open", resp.Backorders[0].State)
+
+		stock, err = svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), stock.Backordered)
+		assert.Equal(t, int32(1), stock.Orderable)
+
+		resp, err = svc.ReserveStock(ctx, order("order-2", 2))
+		require.NoError(t, err)
+		assert.False(t, resp.Success, "past the limit")
+	})
+
+	t.Run("added stock goes to backorders first in, first out", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 8, model.Product{ID: laptop})
+		stock, err := svc.SetBackorderPolicy(ctx, &inventorypb.SetBackorderPolicyRequest{
+			ProductId: laptop, Policy: "preorder", ExpectedAt: "2026-12-01T00:00:00Z",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "2026-12-01T00:00:00Z", stock.BackorderExpectedAt)
+
+		first, err := svc.ReserveStock(ctx, order("order-1", 3))
+		require.NoError(t, err)
+		require.Len(t, first.Backorders, 1)
+		assert.Empty(t, first.Items)
+		assert.Equal(t, "2026-12-01T00:00:00Z", first.Backorders[0].ExpectedAt)
+		_, err = svc.ReserveStock(ctx, order("order-2", 2))
+		require.NoError(t, err)
+		_, err = svc.CommitReservation(ctx, &inventorypb.CommitReservationRequest{OrderId: "order-1"})
+		require.NoError(t, err)
+
+		updated, err := svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: 4, Reason: "restock"})
+		require.NoError(t, err)
+		require.Len(t, updated.AllocatedBackorders, 1, "order-2 does not fit in what is left")
+		assert.Equal(t, "order-1", updated.AllocatedBackorders[0].OrderId)
+		assert.Equal(t, int32(1), updated.NewStock, "order-1 was paid, so it is sold at once")
+
+		updated, err = svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: 1, Reason: "restock"})
+		require.NoError(t, err)
+		require.Len(t, updated.AllocatedBackorders, 1)
+		assert.Equal(t, "order-2", updated.AllocatedBackorders[0].OrderId)
+
+		stock, err = svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), stock.OnHand)
+		assert.Equal(t, int32(2), stock.Held, "order-2 is held until it is paid")
+		assert.Zero(t, stock.Backordered)
+
+		var allocated []interface{}
+		for _, event := range recorder.events {
+			if event["event"] == "stock.backorder_allocated" {
+				allocated = append(allocated, event["order_id"])
+			}
+		}
+		assert.Equal(t, []interface{}{"order-1", "order-2"}, allocated)
+	})
+
+	t.Run("releasing an order cancels its backorders", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 5, model.Product{ID: laptop, Stock: 1})
+		_, err := svc.SetBackorderPolicy(ctx, &inventorypb.SetBackorderPolicyRequest{ProductId: laptop, Policy: "preorder"})
+		require.NoError(t, err)
+		_, err = svc.ReserveStock(ctx, order("order-1", 3))
+		require.NoError(t, err)
+
+		released, err := svc.ReleaseReservation(ctx, &inventorypb.ReleaseReservationRequest{OrderId: "order-1"})
+		require.NoError(t, err)
+		require.Len(t, released.Backorders, 1)
+		assert.Equal(t, "cancelled", released.Backorders[0].State)
+
+		updated, err := svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: 5, Reason: "restock"})
+		require.NoError(t, err)
+		assert.Empty(t, updated.AllocatedBackorders)
+	})
+
+	t.Run("policies are validated", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 0, model.Product{ID: laptop, Stock: 1})
+		_, err := svc.SetBackorderPolicy(ctx, &inventorypb.SetBackorderPolicyRequest{ProductId: laptop, Policy: "sometimes"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = svc.SetBackorderPolicy(ctx, &inventorypb.SetBackorderPolicyRequest{ProductId: laptop, Policy: "limited"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// countStream is a client stream of stock count rows that keeps the response
+type countStream struct {
+	grpc.ServerStream
+	rows []*inventorypb.StockCountRow
+	resp *inventorypb.ImportStockCountsResponse
+}
+
+func (s *countStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *countStream) Recv() (*inventorypb.StockCountRow, error) {
+	if len(s.rows) == 0 {
+		return nil, io.EOF
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return row, nil
+}
+
+func (s *countStream) SendAndClose(resp *inventorypb.ImportStockCountsResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func TestImportStockCounts(t *testing.T) {
+	ctx := context.Background()
+	const mouse = "22222222-2222-2222-2222-222222222222"
+
+	t.Run("a dry run reports differences and rejected rows only", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 0, model.Product{ID: laptop, Stock: 10}, model.Product{ID: mouse, Stock: 5})
+		stream := &countStream{rows: []*inventorypb.StockCountRow{
+			{Line: 2, ProductId: laptop, Counted: 8, DryRun: true},
+			{Line: 3, ProductId: mouse, Counted: 5},
+			{Line: 4, ProductId: "44444444-4444-4444-4444-444444444444", Counted: 1},
+			{Line: 5, ProductId: laptop, LocationId: model.DefaultLocationID, Counted: 9},
+			{Line: 6, Counted: 1},
+		}}
+		require.NoError(t, svc.ImportStockCounts(stream))
+
+		resp := stream.resp
+		assert.True(t, resp.DryRun)
+		assert.Equal(t, int32(5), resp.Rows)
+		assert.Equal(t, int32(1), resp.Unchanged)
+		require.Len(t, resp.Differences, 1)
+		assert.Equal(t, &inventorypb.StockCountDifference{
+			Line: 2, ProductId: laptop, LocationId: model.DefaultLocationID, System: 10, Counted: 8, Delta: -2,
+		}, resp.Differences[0])
+		var lines []int32
+		for _, r := range resp.Rejected {
+			lines = append(lines, r.Line)
+		}
+		assert.Equal(t, []int32{4, 5, 6}, lines)
+		assert.Contains(t, resp.Rejected[1].Reason, "already counted on line 2")
+
+		stock, err := svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.Equal(t, int32(10), stock.OnHand, "nothing changed")
+	})
+
+	t.Run("counts are applied as cycle count movements", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 4, model.Product{ID: laptop, Stock: 10}, model.Product{ID: mouse, Stock: 5})
+		reserve(t, svc, "order-1", 3)
+		stream := &countStream{rows: []*inventorypb.StockCountRow{
+			{ProductId: laptop, Counted: 12, Reference: "count-7", Actor: "alice"},
+			{ProductId: mouse, Counted: 0},
+			{ProductId: laptop, LocationId: "berlin", Counted: 1},
+		}}
+		require.NoError(t, svc.ImportStockCounts(stream))
+
+		resp := stream.resp
+		assert.False(t, resp.DryRun)
+		require.Len(t, resp.Differences, 2)
+		assert.Equal(t, int32(2), resp.Differences[0].Delta)
+		assert.Equal(t, int32(2), resp.Differences[1].Line, "rows without a line are numbered")
+		require.Len(t, resp.Rejected, 1)
+		assert.Equal(t, "berlin", resp.Rejected[0].LocationId)
+
+		movements, err := svc.ListStockMovements(ctx, &inventorypb.ListStockMovementsRequest{Reason: "cycle_count"})
+		require.NoError(t, err)
+		require.Len(t, movements.Movements, 2)
+		mouseCount, laptopCount := movements.Movements[0], movements.Movements[1]
+		assert.Equal(t, int32(-5), mouseCount.Quantity)
+		assert.Equal(t, "count-7", mouseCount.Reference, "the first row names the import")
+		assert.Equal(t, int32(12), laptopCount.BalanceAfter)
+		assert.Equal(t, "alice", laptopCount.Actor)
+		assert.Equal(t, "stock.out", recorder.events[len(recorder.events)-1]["event"])
+	})
+
+	t.Run("a count cannot go below what reservations hold", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 1, model.Product{ID: laptop, Stock: 10})
+		reserve(t, svc, "order-1", 3)
+		stream := &countStream{rows: []*inventorypb.StockCountRow{{ProductId: laptop, Counted: 2}}}
+		require.NoError(t, svc.ImportStockCounts(stream))
+		require.Len(t, stream.resp.Rejected, 1)
+		assert.Contains(t, stream.resp.Rejected[0].Reason, "insufficient stock")
+	})
+}
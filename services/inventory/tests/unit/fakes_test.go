@@ -0,0 +1,638 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/allocation"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/service"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInventoryRepository is an in-memory InventoryRepository shared by the
+// inventory tests. Products start with their stock in the default location.
+type fakeInventoryRepository struct {
+	mu           sync.Mutex
+	products     map[string]*model.Product
+	stocks       map[stockKey]int32
+	locations    map[string]model.Location
+	reservations map[string]*model.Reservation
+	transfers    []model.StockTransfer
+	movements    []model.StockMovement
+	backorders   uint // the ID of the last backorder
+}
+
+type stockKey struct{ productID, locationID string }
+
+func newFakeInventoryRepository(products ...model.Product) *fakeInventoryRepository {
+	r := &fakeInventoryRepository{
+		products:     make(map[string]*model.Product),
+		stocks:       make(map[stockKey]int32),
+		locations:    map[string]model.Location{model.DefaultLocationID: {ID: model.DefaultLocationID, Name: "Default", Priority: 100}},
+		reservations: make(map[string]*model.Reservation),
+	}
+	for i := range products {
+		stock := products[i].Stock
+		products[i].Stock = 0
+		r.products[products[i].ID] = &products[i]
+		r.adjust(&model.StockMovement{
+			ProductID: products[i].ID, LocationID: model.DefaultLocationID, Quantity: stock,
+			Reason: model.MovementAdjustment, Reference: "opening balance", Actor: model.ActorSystem,
+		})
+	}
+	return r
+}
+
+func (r *fakeInventoryRepository) CheckStock(_ context.Context, productID string) (*model.StockLevel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.level(productID, time.Now())
+}
+
+func (r *fakeInventoryRepository) ReserveStock(_ context.Context, orderID string, items []model.ReservationItem, strategy allocation.Strategy, shippingCountry string, expiresAt time.Time) (*model.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.reservations[orderID]; ok {
+		if !existing.Active(time.Now()) {
+			return nil, repository.ErrReservationClosed
+		}
+		return r.copy(existing), nil
+	}
+	quantities := make(map[string]int32)
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, repository.ErrInvalidQuantity
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+	var levels []model.StockLevel
+	for productID := range quantities {
+		level, err := r.level(productID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if level.Archived {
+			return nil, repository.ErrProductArchived
+		}
+		levels = append(levels, *level)
+	}
+	var locations []model.Location
+	for _, l := range r.locations {
+		locations = append(locations, l)
+	}
+	request := allocation.Request{Quantities: quantities, Strategy: strategy, ShippingCountry: shippingCountry}
+	allocated, shortages := allocation.Allocate(request, locations, levels)
+	reservation := &model.Reservation{OrderID: orderID, State: model.ReservationHeld, ExpiresAt: expiresAt}
+	if len(shortages) > 0 {
+		var short []model.Shortage
+		for _, shortage := range shortages {
+			level, _ := r.level(shortage.ProductID, time.Now())
+			missing := shortage.Requested - max(shortage.Available, 0)
+			if level.Backorderable() < missing {
+				short = append(short, shortage)
+				continue
+			}
+			r.backorders++
+			reservation.Backorders = append(reservation.Backorders, model.Backorder{
+				ID: r.backorders, OrderID: orderID, ProductID: shortage.ProductID, Quantity: missing,
+				State: model.BackorderOpen, ExpectedAt: level.BackorderExpectedAt,
+			})
+			if quantities[shortage.ProductID] -= missing; quantities[shortage.ProductID] == 0 {
+				delete(quantities, shortage.ProductID)
+			}
+		}
+		if len(short) > 0 {
+			return nil, &repository.ShortageError{Shortages: short}
+		}
+		if allocated, shortages = allocation.Allocate(request, locations, levels); len(shortages) > 0 {
+			return nil, &repository.ShortageError{Shortages: shortages}
+		}
+	}
+	for i, item := range allocated {
+		item.ID, item.OrderID = uint(i+1), orderID
+		reservation.Items = append(reservation.Items, item)
+	}
+	r.reservations[orderID] = reservation
+	r.hold(reservation.Items, model.MovementReservation, 1)
+	return r.copy(reservation), nil
+}
+
+func (r *fakeInventoryRepository) CommitReservation(_ context.Context, orderID string, now time.Time) (*model.Reservation, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[orderID]
+	if !ok {
+		return nil, false, repository.ErrReservationNotFound
+	}
+	switch reservation.State {
+	case model.ReservationCommitted:
+		return r.copy(reservation), false, nil
+	case model.ReservationReleased:
+		return nil, false, repository.ErrReservationClosed
+	}
+	for _, item := range reservation.Items {
+		level, err := r.level(item.ProductID, now)
+		if err != nil {
+			return nil, false, err
+		}
+		if !reservation.Active(now) && level.At(item.LocationID).Available() < item.Quantity {
+			return nil, false, repository.ErrInsufficientStock
+		}
+	}
+	for _, item := range reservation.Items {
+		sale := &model.StockMovement{
+			ProductID: item.ProductID, LocationID: item.LocationID, Quantity: -item.Quantity,
+			Reason: model.MovementSale, Reference: orderID, Actor: model.ActorSystem,
+		}
+		if reservation.State == model.ReservationHeld {
+			sale.HeldQuantity = -item.Quantity
+		}
+		r.adjust(sale)
+	}
+	reservation.State = model.ReservationCommitted
+	return r.copy(reservation), true, nil
+}
+
+func (r *fakeInventoryRepository) ReleaseReservation(_ context.Context, orderID, reason string, _ time.Time) (*model.Reservation, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[orderID]
+	if !ok {
+		return nil, false, repository.ErrReservationNotFound
+	}
+	switch reservation.State {
+	case model.ReservationCommitted:
+		return nil, false, repository.ErrReservationClosed
+	case model.ReservationReleased, model.ReservationExpired:
+		return r.copy(reservation), false, nil
+	}
+	reservation.State, reservation.Reason = model.ReservationReleased, reason
+	r.cancelBackorders(reservation)
+	r.hold(reservation.Items, model.MovementRelease, -1)
+	return r.copy(reservation), true, nil
+}
+
+func (r *fakeInventoryRepository) ExtendReservation(_ context.Context, orderID string, expiresAt, now time.Time) (*model.Reservation, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reservation, ok := r.reservations[orderID]
+	if !ok {
+		return nil, false, repository.ErrReservationNotFound
+	}
+	switch reservation.State {
+	case model.ReservationCommitted:
+		return r.copy(reservation), false, nil
+	case model.ReservationReleased:
+		return nil, false, repository.ErrReservationClosed
+	}
+	if reservation.Active(now) {
+		if expiresAt.After(reservation.ExpiresAt) {
+			reservation.ExpiresAt = expiresAt
+		}
+		return r.copy(reservation), false, nil
+	}
+	for _, item := range reservation.Items {
+		level, err := r.level(item.ProductID, now)
+		if err != nil {
+			return nil, false, err
+		}
+		if level.At(item.LocationID).Available() < item.Quantity {
+			return nil, false, repository.ErrInsufficientStock
+		}
+	}
+	rehold := reservation.State == model.ReservationExpired
+	if rehold {
+		r.hold(reservation.Items, model.MovementReservation, 1)
+	}
+	reservation.State, reservation.Reason, reservation.ExpiresAt = model.ReservationHeld, "", expiresAt
+	return r.copy(reservation), rehold, nil
+}
+
+func (r *fakeInventoryRepository) ExpireReservations(_ context.Context, now time.Time, limit int) ([]model.Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var expired []model.Reservation
+	for _, reservation := range r.reservations {
+		if len(expired) == limit {
+			break
+		}
+		if reservation.State == model.ReservationHeld && !reservation.ExpiresAt.After(now) {
+			reservation.State, reservation.Reason = model.ReservationExpired, "expired"
+			r.cancelBackorders(reservation)
+			r.hold(reservation.Items, model.MovementRelease, -1)
+			expired = append(expired, *r.copy(reservation))
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ExpiresAt.Before(expired[j].ExpiresAt) })
+	return expired, nil
+}
+
+func (r *fakeInventoryRepository) UpdateStock(_ context.Context, movement *model.StockMovement) (*model.StockLevel, []model.Backorder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	productID, locationID, delta := movement.ProductID, movement.LocationID, movement.Quantity
+	if _, ok := r.locations[locationID]; !ok {
+		return nil, nil, repository.ErrLocationNotFound
+	}
+	level, err := r.level(productID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	at := level.At(locationID)
+	if at.OnHand+delta < 0 {
+		return nil, nil, errors.New("stock cannot be negative")
+	}
+	if at.OnHand+delta < at.Held {
+		return nil, nil, repository.ErrInsufficientStock
+	}
+	r.adjust(movement)
+	var allocated []model.Backorder
+	if delta > 0 {
+		allocated = r.allocateBackorders(productID, locationID)
+	}
+	level, err = r.level(productID, time.Now())
+	return level, allocated, err
+}
+
+func (r *fakeInventoryRepository) TransferStock(_ context.Context, productID, fromLocationID, toLocationID string, quantity int32, actor string) (*model.StockTransfer, *model.StockLevel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if quantity <= 0 || fromLocationID == toLocationID {
+		return nil, nil, repository.ErrInvalidQuantity
+	}
+	for _, id := range []string{fromLocationID, toLocationID} {
+		if _, ok := r.locations[id]; !ok {
+			return nil, nil, repository.ErrLocationNotFound
+		}
+	}
+	level, err := r.level(productID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if level.At(fromLocationID).Available() < quantity {
+		return nil, nil, repository.ErrInsufficientStock
+	}
+	transfer := model.StockTransfer{ID: "transfer-" + productID, ProductID: productID, FromLocationID: fromLocationID, ToLocationID: toLocationID, Quantity: quantity}
+	r.adjust(&model.StockMovement{ProductID: productID, LocationID: fromLocationID, Quantity: -quantity, Reason: model.MovementTransfer, Reference: transfer.ID, Actor: actor})
+	r.adjust(&model.StockMovement{ProductID: productID, LocationID: toLocationID, Quantity: quantity, Reason: model.MovementTransfer, Reference: transfer.ID, Actor: actor})
+	r.transfers = append(r.transfers, transfer)
+	level, err = r.level(productID, time.Now())
+	return &transfer, level, err
+}
+
+func (r *fakeInventoryRepository) CountStock(_ context.Context, count *model.StockCount, dryRun bool) (*model.StockLevel, []model.Backorder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if count.Counted < 0 {
+		return nil, nil, repository.ErrInvalidQuantity
+	}
+	if _, ok := r.locations[count.LocationID]; !ok {
+		return nil, nil, repository.ErrLocationNotFound
+	}
+	level, err := r.level(count.ProductID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	at := level.At(count.LocationID)
+	count.System = at.OnHand
+	if count.Counted < at.Held {
+		return nil, nil, repository.ErrInsufficientStock
+	}
+	if dryRun || count.Delta() == 0 {
+		return level, nil, nil
+	}
+	r.adjust(&model.StockMovement{
+		ProductID: count.ProductID, LocationID: count.LocationID, Quantity: count.Delta(),
+		Reason: model.MovementCycleCount, Reference: count.Reference, Actor: count.Actor,
+	})
+	var allocated []model.Backorder
+	if count.Delta() > 0 {
+		allocated = r.allocateBackorders(count.ProductID, count.LocationID)
+	}
+	level, err = r.level(count.ProductID, time.Now())
+	return level, allocated, err
+}
+
+func (r *fakeInventoryRepository) SaveLocation(_ context.Context, location *model.Location) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.locations[location.ID] = *location
+	return nil
+}
+
+func (r *fakeInventoryRepository) ListLocations(_ context.Context) ([]model.Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var locations []model.Location
+	for _, l := range r.locations {
+		locations = append(locations, l)
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].Priority < locations[j].Priority })
+	return locations, nil
+}
+
+func (r *fakeInventoryRepository) EnsureDefaultLocation(_ context.Context) error {
+	return nil
+}
+
+func (r *fakeInventoryRepository) SyncProduct(_ context.Context, productID, name string, initialStock int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if product, ok := r.products[productID]; ok {
+		product.Name, product.ArchivedAt = name, nil
+		return nil
+	}
+	r.products[productID] = &model.Product{ID: productID, Name: name}
+	r.adjust(&model.StockMovement{
+		ProductID: productID, LocationID: model.DefaultLocationID, Quantity: initialStock,
+		Reason: model.MovementAdjustment, Reference: "catalog", Actor: model.ActorSystem,
+	})
+	return nil
+}
+
+func (r *fakeInventoryRepository) ArchiveProduct(_ context.Context, productID string, now time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[productID]
+	if !ok {
+		return false, repository.ErrProductNotFound
+	}
+	if product.ArchivedAt != nil {
+		return false, nil
+	}
+	product.ArchivedAt = &now
+	return true, nil
+}
+
+func (r *fakeInventoryRepository) SetBackorderPolicy(_ context.Context, productID string, policy model.BackorderPolicy, limit int32, expectedAt *time.Time) (*model.StockLevel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, repository.ErrProductNotFound
+	}
+	product.BackorderPolicy, product.BackorderLimit, product.BackorderExpectedAt = policy, limit, expectedAt
+	return r.level(productID, time.Now())
+}
+
+func (r *fakeInventoryRepository) ListStockMovements(_ context.Context, filter repository.MovementFilter, after *repository.PageCursor, limit int) ([]model.StockMovement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var movements []model.StockMovement
+	for i := len(r.movements) - 1; i >= 0 && len(movements) < limit; i-- {
+		m := r.movements[i]
+		if (filter.ProductID != "" && m.ProductID != filter.ProductID) ||
+			(filter.LocationID != "" && m.LocationID != filter.LocationID) ||
+			(filter.Reason != "" && m.Reason != filter.Reason) ||
+			(filter.Reference != "" && m.Reference != filter.Reference) {
+			continue
+		}
+		if after != nil && !(m.CreatedAt.Before(after.CreatedAt) || m.CreatedAt.Equal(after.CreatedAt) && m.ID < after.ID) {
+			continue
+		}
+		movements = append(movements, m)
+	}
+	return movements, nil
+}
+
+func (r *fakeInventoryRepository) CheckConsistency(_ context.Context, productID string) ([]model.StockDiscrepancy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sums := make(map[stockKey]int32)
+	for _, m := range r.movements {
+		sums[stockKey{m.ProductID, m.LocationID}] += m.Quantity
+	}
+	totals := make(map[string]int32)
+	var discrepancies []model.StockDiscrepancy
+	for key, onHand := range r.stocks {
+		totals[key.productID] += onHand
+		if sums[key] != onHand && (productID == "" || key.productID == productID) {
+			discrepancies = append(discrepancies, model.StockDiscrepancy{
+				ProductID: key.productID, LocationID: key.locationID, Balance: onHand, Movements: sums[key],
+			})
+		}
+	}
+	for id, product := range r.products {
+		if product.Stock != totals[id] && (productID == "" || id == productID) {
+			discrepancies = append(discrepancies, model.StockDiscrepancy{ProductID: id, Balance: product.Stock, Movements: totals[id]})
+		}
+	}
+	sort.Slice(discrepancies, func(i, j int) bool { return discrepancies[i].LocationID < discrepancies[j].LocationID })
+	return discrepancies, nil
+}
+
+func (r *fakeInventoryRepository) SetStockThresholds(_ context.Context, productID string, lowStockThreshold, reorderPoint int32) (*model.StockLevel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lowStockThreshold < 0 || reorderPoint < 0 {
+		return nil, repository.ErrInvalidQuantity
+	}
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, repository.ErrProductNotFound
+	}
+	product.LowStockThreshold, product.ReorderPoint = lowStockThreshold, reorderPoint
+	return r.level(productID, time.Now())
+}
+
+func (r *fakeInventoryRepository) SwapAlertLevel(_ context.Context, productID string, from, to model.StockAlertLevel) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[productID]
+	if !ok {
+		return false, repository.ErrProductNotFound
+	}
+	current := product.AlertLevel
+	if current == "" {
+		current = model.StockOK
+	}
+	if current != from {
+		return false, nil
+	}
+	product.AlertLevel = to
+	return true, nil
+}
+
+// adjust applies a movement to the stock of its product at its location and
+// to the product's total, and records it; callers hold mu
+func (r *fakeInventoryRepository) adjust(movement *model.StockMovement) {
+	key := stockKey{movement.ProductID, movement.LocationID}
+	r.products[movement.ProductID].Stock += movement.Quantity
+	r.products[movement.ProductID].Version++
+	r.stocks[key] += movement.Quantity
+	movement.BalanceAfter = r.stocks[key]
+	r.record(*movement)
+}
+
+// allocateBackorders gives the available stock of a product at a location to
+// its open backorders, oldest first, until one no longer fits; callers hold mu
+func (r *fakeInventoryRepository) allocateBackorders(productID, locationID string) []model.Backorder {
+	var open []*model.Backorder
+	for _, reservation := range r.reservations {
+		for i := range reservation.Backorders {
+			if b := &reservation.Backorders[i]; b.ProductID == productID && b.State == model.BackorderOpen {
+				open = append(open, b)
+			}
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].ID < open[j].ID })
+	var allocated []model.Backorder
+	for _, b := range open {
+		level, _ := r.level(productID, time.Now())
+		if level.At(locationID).Available() < b.Quantity {
+			break
+		}
+		reservation := r.reservations[b.OrderID]
+		item := model.ReservationItem{ID: uint(len(reservation.Items) + 1), OrderID: b.OrderID, ProductID: productID, LocationID: locationID, Quantity: b.Quantity}
+		reservation.Items = append(reservation.Items, item)
+		if reservation.State == model.ReservationCommitted {
+			r.adjust(&model.StockMovement{
+				ProductID: productID, LocationID: locationID, Quantity: -b.Quantity,
+				Reason: model.MovementSale, Reference: b.OrderID, Actor: model.ActorSystem,
+			})
+		} else {
+			r.hold([]model.ReservationItem{item}, model.MovementReservation, 1)
+		}
+		now := time.Now()
+		b.State, b.LocationID, b.AllocatedAt = model.BackorderAllocated, locationID, &now
+		allocated = append(allocated, *b)
+	}
+	return allocated
+}
+
+// cancelBackorders cancels the open backorders of a reservation; callers hold mu
+func (r *fakeInventoryRepository) cancelBackorders(reservation *model.Reservation) {
+	for i := range reservation.Backorders {
+		if reservation.Backorders[i].State == model.BackorderOpen {
+			reservation.Backorders[i].State = model.BackorderCancelled
+		}
+	}
+}
+
+// hold records the stock items start (sign 1) or stop (sign -1) holding;
+// callers hold mu
+func (r *fakeInventoryRepository) hold(items []model.ReservationItem, reason model.MovementReason, sign int32) {
+	for _, item := range items {
+		r.record(model.StockMovement{
+			ProductID: item.ProductID, LocationID: item.LocationID, HeldQuantity: sign * item.Quantity,
+			BalanceAfter: r.stocks[stockKey{item.ProductID, item.LocationID}],
+			Reason:       reason, Reference: item.OrderID, Actor: model.ActorSystem,
+		})
+	}
+}
+
+// record appends a movement to the ledger; callers hold mu
+func (r *fakeInventoryRepository) record(movement model.StockMovement) {
+	movement.ID = fmt.Sprintf("movement-%04d", len(r.movements)+1)
+	movement.CreatedAt = time.Now()
+	r.movements = append(r.movements, movement)
+}
+
+// level is the stock level of a product at now; callers hold mu
+func (r *fakeInventoryRepository) level(productID string, now time.Time) (*model.StockLevel, error) {
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, repository.ErrProductNotFound
+	}
+	level := &model.StockLevel{
+		ProductID:         productID,
+		OnHand:            product.Stock,
+		Version:           product.Version,
+		LowStockThreshold: product.LowStockThreshold,
+		ReorderPoint:      product.ReorderPoint,
+		AlertLevel:        product.AlertLevel,
+		Archived:          product.ArchivedAt != nil,
+
+		BackorderPolicy:     product.BackorderPolicy,
+		BackorderLimit:      product.BackorderLimit,
+		BackorderExpectedAt: product.BackorderExpectedAt,
+	}
+	for key, onHand := range r.stocks {
+		if key.productID == productID {
+			level.At(key.locationID).OnHand = onHand
+		}
+	}
+	for _, reservation := range r.reservations {
+		for _, b := range reservation.Backorders {
+			if b.ProductID == productID && b.State == model.BackorderOpen {
+				level.Backordered += b.Quantity
+			}
+		}
+		if !reservation.Active(now) {
+			continue
+		}
+		for _, item := range reservation.Items {
+			if item.ProductID == productID {
+				level.At(item.LocationID).Held += item.Quantity
+				level.Held += item.Quantity
+			}
+		}
+	}
+	sort.Slice(level.Locations, func(i, j int) bool { return level.Locations[i].LocationID < level.Locations[j].LocationID })
+	return level, nil
+}
+
+func (r *fakeInventoryRepository) copy(reservation *model.Reservation) *model.Reservation {
+	copied := *reservation
+	copied.Items = append([]model.ReservationItem(nil), reservation.Items...)
+	copied.Backorders = append([]model.Backorder(nil), reservation.Backorders...)
+	return &copied
+}
+
+// fakeProductClient knows every product
+type fakeProductClient struct{}
+
+func (fakeProductClient) GetProduct(_ context.Context, productID string) (*productpb.ProductResponse, error) {
+	return &productpb.ProductResponse{ProductId: productID}, nil
+}
+
+// topicRecorder collects the topics a service published to and the decoded
+// events, in order
+type topicRecorder struct {
+	mu     sync.Mutex
+	topics []string
+	events []map[string]interface{}
+}
+
+func (r *topicRecorder) check(msg *sarama.ProducerMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.topics = append(r.topics, msg.Topic)
+	data, err := msg.Value.Encode()
+	if err != nil {
+		return err
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+// newInventoryService returns a service over products whose producer expects
+// exactly publishes messages
+func newInventoryService(t *testing.T, publishes int, products ...model.Product) (*service.InventoryService, *fakeInventoryRepository, *topicRecorder) {
+	recorder := &topicRecorder{}
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < publishes; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recorder.check)
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	repo := newFakeInventoryRepository(products...)
+	return service.NewInventoryService(repo, kafka.NewProducerWithClient(producer), fakeProductClient{}), repo, recorder
+}
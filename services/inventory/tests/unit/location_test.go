@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLocations(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stock is tracked per location", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 3, model.Product{ID: laptop, Stock: 10})
+		_, err := svc.SaveLocation(ctx, &inventorypb.SaveLocationRequest{LocationId: "berlin", Name: "Berlin", Country: "de", Priority: 1})
+		require.NoError(t, err)
+
+		updated, err := svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: 4, LocationId: "berlin"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(14), updated.NewStock)
+		assert.Equal(t, int32(4), updated.Location.OnHand)
+		assert.Equal(t, "Berlin", updated.Location.Name)
+
+		transfer, err := svc.TransferStock(ctx, &inventorypb.TransferStockRequest{
+			ProductId: laptop, FromLocationId: model.DefaultLocationID, ToLocationId: "berlin", Quantity: 3,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(7), transfer.From.OnHand)
+		assert.Equal(t, int32(7), transfer.To.OnHand)
+		assert.Equal(t, "stock.transferred", recorder.events[1]["event"])
+
+		reserved, err := svc.ReserveStock(ctx, &inventorypb.ReserveStockRequest{
+			OrderId: "order-1", Items: []*inventorypb.StockItem{{ProductId: laptop, Quantity: 2}},
+		})
+		require.NoError(t, err)
+		require.Len(t, reserved.Items, 1)
+		assert.Equal(t, "berlin", reserved.Items[0].LocationId, "the highest priority location serves it")
+
+		stock, err := svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.Equal(t, int32(14), stock.OnHand)
+		assert.Equal(t, int32(12), stock.Available)
+		require.Len(t, stock.Locations, 2)
+		assert.Equal(t, "berlin", stock.Locations[0].LocationId)
+		assert.Equal(t, int32(5), stock.Locations[0].Available)
+		assert.Equal(t, int32(7), stock.Locations[1].Available)
+	})
+
+	t.Run("split reservations span locations", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 2, model.Product{ID: laptop, Stock: 3})
+		_, err := svc.SaveLocation(ctx, &inventorypb.SaveLocationRequest{LocationId: "berlin", Name: "Berlin", Priority: 1})
+		require.NoError(t, err)
+		_, err = svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: 2, LocationId: "berlin"})
+		require.NoError(t, err)
+
+		resp, err := svc.ReserveStock(ctx, &inventorypb.ReserveStockRequest{
+			OrderId: "order-1", Items: []*inventorypb.StockItem{{ProductId: laptop, Quantity: 4}}, Strategy: "split",
+		})
+		require.NoError(t, err)
+		require.True(t, resp.Success, resp.Message)
+		require.Len(t, resp.Items, 2)
+		assert.Equal(t, int32(2), resp.Items[0].Quantity)
+		assert.Equal(t, int32(2), resp.Items[1].Quantity)
+
+		_, err = svc.ReserveStock(ctx, &inventorypb.ReserveStockRequest{
+			OrderId: "order-2", Items: []*inventorypb.StockItem{{ProductId: laptop, Quantity: 1}}, Strategy: "cheapest",
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("transfers need available stock and known locations", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 1, model.Product{ID: laptop, Stock: 3})
+		_, err := svc.SaveLocation(ctx, &inventorypb.SaveLocationRequest{LocationId: "berlin", Name: "Berlin"})
+		require.NoError(t, err)
+		reserve(t, svc, "order-1", 2)
+
+		_, err = svc.TransferStock(ctx, &inventorypb.TransferStockRequest{
+			ProductId: laptop, FromLocationId: model.DefaultLocationID, ToLocationId: "berlin", Quantity: 2,
+		})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err), "held stock stays put")
+		_, err = svc.TransferStock(ctx, &inventorypb.TransferStockRequest{
+			ProductId: laptop, FromLocationId: model.DefaultLocationID, ToLocationId: "madrid", Quantity: 1,
+		})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = svc.SaveLocation(ctx, &inventorypb.SaveLocationRequest{LocationId: "madrid", Name: "Madrid", Country: "ESP"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,98 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStockMovements(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("every change is written to the ledger", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 6, model.Product{ID: laptop, Stock: 10})
+		_, err := svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{
+			ProductId: laptop, StockDelta: 5, Reason: "restock", Reference: "po-7", Actor: "alice",
+		})
+		require.NoError(t, err)
+		reserve(t, svc, "order-1", 3)
+		_, err = svc.CommitReservation(ctx, &inventorypb.CommitReservationRequest{OrderId: "order-1"})
+		require.NoError(t, err)
+		reserve(t, svc, "order-2", 1)
+		_, err = svc.ReleaseReservation(ctx, &inventorypb.ReleaseReservationRequest{OrderId: "order-2"})
+		require.NoError(t, err)
+
+		resp, err := svc.ListStockMovements(ctx, &inventorypb.ListStockMovementsRequest{ProductId: laptop})
+		require.NoError(t, err)
+		var reasons []string
+		for _, m := range resp.Movements {
+			reasons = append(reasons, m.Reason)
+		}
+		assert.Equal(t, []string{"release", "reservation", "sale", "reservation", "restock", "adjustment"}, reasons, "newest first")
+
+		restock := resp.Movements[4]
+		assert.Equal(t, int32(5), restock.Quantity)
+		assert.Equal(t, int32(15), restock.BalanceAfter)
+		assert.Equal(t, "po-7", restock.Reference)
+		assert.Equal(t, "alice", restock.Actor)
+		sale := resp.Movements[2]
+		assert.Equal(t, int32(-3), sale.Quantity)
+		assert.Equal(t, int32(-3), sale.HeldQuantity)
+		assert.Equal(t, int32(12), sale.BalanceAfter)
+		assert.Equal(t, "order-1", sale.Reference)
+
+		consistency, err := svc.CheckStockConsistency(ctx, &inventorypb.CheckStockConsistencyRequest{})
+		require.NoError(t, err)
+		assert.True(t, consistency.Consistent)
+	})
+
+	t.Run("movements are filtered and paged", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 3, model.Product{ID: laptop, Stock: 10})
+		for i := 0; i < 3; i++ {
+			_, err := svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: 1, Reason: "return", Reference: "rma-1"})
+			require.NoError(t, err)
+		}
+
+		first, err := svc.ListStockMovements(ctx, &inventorypb.ListStockMovementsRequest{Reason: "return", PageSize: 2})
+		require.NoError(t, err)
+		require.Len(t, first.Movements, 2)
+		require.NotEmpty(t, first.NextPageToken)
+		assert.Equal(t, int32(13), first.Movements[0].BalanceAfter)
+
+		second, err := svc.ListStockMovements(ctx, &inventorypb.ListStockMovementsRequest{Reason: "return", PageSize: 2, PageToken: first.NextPageToken})
+		require.NoError(t, err)
+		require.Len(t, second.Movements, 1)
+		assert.Empty(t, second.NextPageToken)
+		assert.Equal(t, int32(11), second.Movements[0].BalanceAfter)
+
+		_, err = svc.ListStockMovements(ctx, &inventorypb.ListStockMovementsRequest{PageToken: "not-a-token"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("manual changes need a manual reason", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 0, model.Product{ID: laptop, Stock: 10})
+		_, err := svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: -1, Reason: "sale"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "sales come from reservations")
+		_, err = svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: -1, Reason: "restock"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("a balance that drifted from its movements is reported", func(t *testing.T) {
+		svc, repo, _ := newInventoryService(t, 0, model.Product{ID: laptop, Stock: 10})
+		repo.stocks[stockKey{laptop, model.DefaultLocationID}] = 8
+
+		resp, err := svc.CheckStockConsistency(ctx, &inventorypb.CheckStockConsistencyRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.False(t, resp.Consistent)
+		require.Len(t, resp.Discrepancies, 2)
+		assert.Equal(t, &inventorypb.StockDiscrepancy{ProductId: laptop, Balance: 10, Movements: 8}, resp.Discrepancies[0], "the total no longer matches its locations")
+		assert.Equal(t, &inventorypb.StockDiscrepancy{ProductId: laptop, LocationId: model.DefaultLocationID, Balance: 8, Movements: 10}, resp.Discrepancies[1])
+	})
+}
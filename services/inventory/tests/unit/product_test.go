@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductDeletion(t *testing.T) {
+	ctx := context.Background()
+	deleted := []byte(`{"product_id":"` + laptop + `","status":"deleted"}`)
+
+	t.Run("deleted products are archived and cannot be reserved", func(t *testing.T) {
+		svc, repo, recorder := newInventoryService(t, 1, model.Product{ID: laptop, Stock: 10})
+		svc.HandleProductEvent(ctx, deleted)
+		svc.HandleProductEvent(ctx, deleted)
+		require.NotNil(t, repo.products[laptop].ArchivedAt)
+
+		resp, err := svc.ReserveStock(ctx, &inventorypb.ReserveStockRequest{
+			OrderId: "order-1", Items: []*inventorypb.StockItem{{ProductId: laptop, Quantity: 1}},
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Message, "archived")
+		assert.Equal(t, "failed", recorder.events[0]["status"])
+
+		stock, err := svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.True(t, stock.Archived)
+		assert.Equal(t, int32(10), stock.OnHand, "the stock is kept")
+		movements, err := svc.ListStockMovements(ctx, &inventorypb.ListStockMovementsRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.Len(t, movements.Movements, 1, "and so is its history")
+	})
+
+	t.Run("recreating a product with the same ID restores it", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 1, model.Product{ID: laptop, Stock: 10})
+		svc.HandleProductEvent(ctx, deleted)
+		svc.HandleProductEvent(ctx, []byte(`{"product_id":"`+laptop+`","name":"Laptop","stock":12,"status":"created"}`))
+
+		stock, err := svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.False(t, stock.Archived)
+		assert.Equal(t, int32(10), stock.OnHand, "the inventory kept its stock")
+		reserve(t, svc, "order-1", 2)
+	})
+}
+
+func TestProductSync(t *testing.T) {
+	ctx := context.Background()
+	const mouse = "22222222-2222-2222-2222-222222222222"
+
+	t.Run("the catalog only seeds the stock of new products", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 0, model.Product{ID: laptop, Stock: 10})
+		svc.HandleProductEvent(ctx, []byte(`{"product_id":"`+mouse+`","name":"Mouse","stock":5,"status":"created"}`))
+		svc.HandleProductEvent(ctx, []byte(`{"product_id":"`+laptop+`","name":"Laptop Pro","stock":3,"status":"updated"}`))
+
+		stock, err := svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: mouse})
+		require.NoError(t, err)
+		assert.Equal(t, int32(5), stock.OnHand)
+		stock, err = svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.Equal(t, int32(10), stock.OnHand, "stock is owned by the inventory")
+	})
+
+	t.Run("stock updates carry a version to order them by", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 2, model.Product{ID: laptop, Stock: 10})
+		for _, delta := range []int32{2, -1} {
+			_, err := svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: delta})
+			require.NoError(t, err)
+		}
+		require.Len(t, recorder.events, 2)
+		assert.Equal(t, "stock.updated", recorder.events[1]["event"])
+		assert.Equal(t, float64(11), recorder.events[1]["new_stock"])
+		assert.Greater(t, recorder.events[1]["version"], recorder.events[0]["version"])
+	})
+}
@@ -0,0 +1,283 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/model"
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const laptop = "11111111-1111-1111-1111-111111111111"
+
+// reserve reserves quantity laptops for orderID and requires it to succeed
+func reserve(t *testing.T, svc *service.InventoryService, orderID string, quantity int32) {
+	resp, err := svc.ReserveStock(context.Background(), &inventorypb.ReserveStockRequest{
+		OrderId: orderID,
+		Items:   []*inventorypb.StockItem{{ProductId: laptop, Quantity: quantity}},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Success, resp.Message)
+}
+
+func TestReservations(t *testing.T) {
+	ctx := context.Background()
+	check := &inventorypb.CheckStockRequest{ProductId: laptop}
+
+	t.Run("reserved stock is held, not taken off on-hand", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 1, model.Product{ID: laptop, Stock: 10})
+		reserve(t, svc, "order-1", 3)
+
+		stock, err := svc.CheckStock(ctx, check)
+		require.NoError(t, err)
+		assert.Equal(t, int32(10), stock.OnHand)
+		assert.Equal(t, int32(3), stock.Held)
+		assert.Equal(t, int32(7), stock.Available)
+
+		assert.Equal(t, "stock-events", recorder.topics[0])
+		assert.Equal(t, "reserved", recorder.events[0]["status"])
+		assert.NotEmpty(t, recorder.events[0]["expires_at"])
+	})
+
+	t.Run("a reservation cannot hold more than is available", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 2, model.Product{ID: laptop, Stock: 5})
+		reserve(t, svc, "order-1", 3)
+
+		resp, err := svc.ReserveStock(ctx, &inventorypb.ReserveStockRequest{
+			OrderId: "order-2",
+			Items:   []*inventorypb.StockItem{{ProductId: laptop, Quantity: 1}, {ProductId: laptop, Quantity: 2}},
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Equal(t, "failed", recorder.events[1]["status"])
+	})
+
+	t.Run("reserving again for the same order returns the hold", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 2, model.Product{ID: laptop, Stock: 5})
+		reserve(t, svc, "order-1", 3)
+		reserve(t, svc, "order-1", 3)
+
+		stock, err := svc.CheckStock(ctx, check)
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), stock.Held)
+	})
+
+	t.Run("commit takes the stock off on-hand once", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 3, model.Product{ID: laptop, Stock: 10})
+		reserve(t, svc, "order-1", 3)
+
+		resp, err := svc.CommitReservation(ctx, &inventorypb.CommitReservationRequest{OrderId: "order-1"})
+		require.NoError(t, err)
+		assert.Equal(t, string(model.ReservationCommitted), resp.State)
+		_, err = svc.CommitReservation(ctx, &inventorypb.CommitReservationRequest{OrderId: "order-1"})
+		require.NoError(t, err)
+
+		stock, err := svc.CheckStock(ctx, check)
+		require.NoError(t, err)
+		assert.Equal(t, int32(7), stock.OnHand)
+		assert.Zero(t, stock.Held)
+		assert.Equal(t, "stock.committed", recorder.events[1]["event"])
+		sale := recorder.events[2]
+		assert.Equal(t, "stock.updated", sale["event"])
+		assert.Equal(t, "sale", sale["reason"])
+		assert.Equal(t, float64(7), sale["new_stock"])
+
+		_, err = svc.ReleaseReservation(ctx, &inventorypb.ReleaseReservationRequest{OrderId: "order-1"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("release gives the stock back", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 2, model.Product{ID: laptop, Stock: 10})
+		reserve(t, svc, "order-1", 3)
+
+		resp, err := svc.ReleaseReservation(ctx, &inventorypb.ReleaseReservationRequest{OrderId: "order-1", Reason: "order cancelled"})
+		require.NoError(t, err)
+		assert.Equal(t, string(model.ReservationReleased), resp.State)
+		_, err = svc.ReleaseReservation(ctx, &inventorypb.ReleaseReservationRequest{OrderId: "order-1"})
+		require.NoError(t, err, "releasing twice is a no-op")
+
+		stock, err := svc.CheckStock(ctx, check)
+		require.NoError(t, err)
+		assert.Equal(t, int32(10), stock.Available)
+		assert.Equal(t, "stock.released", recorder.events[1]["event"])
+		assert.Equal(t, "order cancelled", recorder.events[1]["reason"])
+
+		_, err = svc.CommitReservation(ctx, &inventorypb.CommitReservationRequest{OrderId: "order-1"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		_, err = svc.CommitReservation(ctx, &inventorypb.CommitReservationRequest{OrderId: "order-2"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("on-hand cannot drop below what is held", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 2, model.Product{ID: laptop, Stock: 10})
+		reserve(t, svc, "order-1", 8)
+
+		_, err := svc.UpdateStock(ctx, &inventorypb.UpdateStockRequest{ProductId: laptop, StockDelta: -5})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+func TestReserveAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	const (
+		mouse    = "22222222-2222-2222-2222-222222222222"
+		keyboard = "33333333-3333-3333-3333-333333333333"
+	)
+	svc, _, recorder := newInventoryService(t, 1,
+		model.Product{ID: laptop, Stock: 10}, model.Product{ID: mouse, Stock: 1}, model.Product{ID: keyboard, Stock: 0})
+
+	resp, err := svc.ReserveStock(ctx, &inventorypb.ReserveStockRequest{
+		OrderId: "order-1",
+		Items: []*inventorypb.StockItem{
+			{ProductId: laptop, Quantity: 2},
+			{ProductId: keyboard, Quantity: 1},
+			{ProductId: mouse, Quantity: 1},
+			{ProductId: mouse, Quantity: 2},
+		},
+	})
+	require.NoError(t, err)
+	assert.False(t, resp.Success)
+	require.Len(t, resp.Shortages, 2, "every short product is reported, in product order")
+	assert.Equal(t, mouse, resp.Shortages[0].ProductId)
+	assert.Equal(t, int32(3), resp.Shortages[0].Requested)
+	assert.Equal(t, int32(1), resp.Shortages[0].Available)
+	assert.Equal(t, keyboard, resp.Shortages[1].ProductId)
+	assert.Len(t, recorder.events[0]["shortages"], 2)
+
+	stock, err := svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+	require.NoError(t, err)
+	assert.Zero(t, stock.Held, "nothing is held when any item falls short")
+}
+
+func TestReservationExpiry(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("the sweeper releases lapsed holds", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 4, model.Product{ID: laptop, Stock: 10})
+		svc.ReservationTTL = time.Minute
+		reserve(t, svc, "order-1", 4)
+
+		n, err := svc.ExpireReservations(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, n, "the hold has not lapsed yet")
+
+		n, err = svc.ExpireReservations(ctx, time.Now().Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, "stock.released", recorder.events[1]["event"])
+		assert.Equal(t, "expired", recorder.events[1]["reason"])
+		assert.Equal(t, "order-1", recorder.events[1]["order_id"])
+
+		n, err = svc.ExpireReservations(ctx, time.Now().Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		// a late payment still commits while the stock is there
+		_, err = svc.CommitReservation(ctx, &inventorypb.CommitReservationRequest{OrderId: "order-1"})
+		require.NoError(t, err)
+	})
+
+	t.Run("a lapsed hold no longer counts against availability", func(t *testing.T) {
+		// order-2 holding everything also publishes stock.out
+		svc, _, _ := newInventoryService(t, 3, model.Product{ID: laptop, Stock: 5})
+		svc.ReservationTTL = -time.Second
+		reserve(t, svc, "order-1", 5)
+		svc.ReservationTTL = time.Minute
+		reserve(t, svc, "order-2", 5)
+
+		_, err := svc.CommitReservation(ctx, &inventorypb.CommitReservationRequest{OrderId: "order-1"})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+}
+
+func TestExtendReservation(t *testing.T) {
+	ctx := context.Background()
+	extend := func(svc *service.InventoryService, orderID string, until time.Time) (*inventorypb.ReservationResponse, error) {
+		return svc.ExtendReservation(ctx, &inventorypb.ExtendReservationRequest{OrderId: orderID, ExpiresAt: until.Format(time.RFC3339)})
+	}
+
+	t.Run("an extended hold outlives its ttl", func(t *testing.T) {
+		svc, _, _ := newInventoryService(t, 1, model.Product{ID: laptop, Stock: 10})
+		svc.ReservationTTL = time.Minute
+		reserve(t, svc, "order-1", 4)
+
+		until := time.Now().Add(time.Hour).Truncate(time.Second)
+		resp, err := extend(svc, "order-1", until)
+		require.NoError(t, err)
+		assert.Equal(t, until.Format(time.RFC3339), resp.ExpiresAt)
+		resp, err = extend(svc, "order-1", time.Now().Add(30*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, until.Format(time.RFC3339), resp.ExpiresAt, "a later expiry is kept")
+
+		n, err := svc.ExpireReservations(ctx, time.Now().Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("an expired reservation is held again while the stock is there", func(t *testing.T) {
+		svc, _, recorder := newInventoryService(t, 3, model.Product{ID: laptop, Stock: 10})
+		svc.ReservationTTL = time.Minute
+		reserve(t, svc, "order-1", 4)
+		_, err := svc.ExpireReservations(ctx, time.Now().Add(2*time.Minute))
+		require.NoError(t, err)
+
+		resp, err := extend(svc, "order-1", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, string(model.ReservationHeld), resp.State)
+		assert.Equal(t, "stock.reserved", recorder.events[2]["event"])
+
+		stock, err := svc.CheckStock(ctx, &inventorypb.CheckStockRequest{ProductId: laptop})
+		require.NoError(t, err)
+		assert.Equal(t, int32(4), stock.Held)
+	})
+
+	t.Run("a lapsed reservation whose stock was resold is not extended", func(t *testing.T) {
+		// order-2 holding everything also publishes stock.out
+		svc, _, _ := newInventoryService(t, 3, model.Product{ID: laptop, Stock: 5})
+		svc.ReservationTTL = -time.Second
+		reserve(t, svc, "order-1", 5)
+		svc.ReservationTTL = time.Minute
+		reserve(t, svc, "order-2", 5)
+
+		_, err := extend(svc, "order-1", time.Now().Add(time.Hour))
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		_, err = extend(svc, "order-2", time.Now().Add(-time.Hour))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = extend(svc, "order-3", time.Now().Add(time.Hour))
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestPaymentStatusSettlesReservations(t *testing.T) {
+	ctx := context.Background()
+
+	svc, repo, _ := newInventoryService(t, 5, model.Product{ID: laptop, Stock: 10})
+	reserve(t, svc, "order-1", 2)
+	reserve(t, svc, "order-2", 3)
+
+	svc.HandlePaymentStatus(ctx, []byte(`{"order_id":"order-1","status":"PAID"}`))
+	svc.HandlePaymentStatus(ctx, []byte(`{"order_id":"order-2","status":"FAILED"}`))
+	svc.HandlePaymentStatus(ctx, []byte(`{"order_id":"order-2","status":"PENDING"}`))
+
+	assert.Equal(t, model.ReservationCommitted, repo.reservations["order-1"].State)
+	assert.Equal(t, model.ReservationReleased, repo.reservations["order-2"].State)
+	assert.Equal(t, "payment FAILED", repo.reservations["order-2"].Reason)
+	assert.Equal(t, int32(8), repo.products[laptop].Stock)
+}
+
+func TestPaymentStatusReportsFailedCommits(t *testing.T) {
+	svc, _, recorder := newInventoryService(t, 1)
+	svc.HandlePaymentStatus(context.Background(), []byte(`{"order_id":"order-1","payment_id":"pay-1","status":"PAID"}`))
+
+	require.Len(t, recorder.events, 1)
+	assert.Equal(t, "stock.commit_failed", recorder.events[0]["event"])
+	assert.Equal(t, "order-1", recorder.events[0]["order_id"])
+	assert.Equal(t, "pay-1", recorder.events[0]["payment_id"])
+}
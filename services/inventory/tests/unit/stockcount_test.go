@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/SabinGhost19/go-micro-payment/services/inventory/stockcount"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll reads every row of a stock count file, keeping the rows that could
+// not be read apart
+func readAll(t *testing.T, input string, format stockcount.Format) ([]stockcount.Row, []*stockcount.RowError) {
+	r, err := stockcount.NewReader(strings.NewReader(input), format)
+	require.NoError(t, err)
+	var (
+		rows   []stockcount.Row
+		failed []*stockcount.RowError
+	)
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, failed
+		}
+		var rowErr *stockcount.RowError
+		if errors.As(err, &rowErr) {
+			failed = append(failed, rowErr)
+			continue
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestStockCountReader(t *testing.T) {
+	t.Run("CSV columns are found by their header", func(t *testing.T) {
+		input := "\xef\xbb\xbfLocation,counted_quantity,Product_ID\n" +
+			"berlin,12,p1\n" +
+			",0,p2\n" +
+			"berlin,twelve,p3\n" +
+			"berlin,-1,p4\n" +
+			"berlin,3,\n"
+		rows, failed := readAll(t, input, stockcount.CSV)
+		assert.Equal(t, []stockcount.Row{
+			{Line: 2, ProductID: "p1", LocationID: "berlin", Counted: 12},
+			{Line: 3, ProductID: "p2", Counted: 0},
+		}, rows)
+		require.Len(t, failed, 3)
+		assert.Equal(t, []int32{4, 5, 6}, []int32{failed[0].Line, failed[1].Line, failed[2].Line})
+		assert.Contains(t, failed[0].Error(), "not a whole number")
+
+		_, err := stockcount.NewReader(strings.NewReader("product_id,location\np1,berlin\n"), stockcount.CSV)
+		assert.ErrorContains(t, err, "no counted column")
+	})
+
+	t.Run("JSON may be an array or one object per line", func(t *testing.T) {
+		want := []stockcount.Row{
+			{Line: 1, ProductID: "p1", LocationID: "berlin", Counted: 12},
+			{Line: 3, ProductID: "p3", Counted: 4},
+		}
+		array := `[{"product_id": "p1", "location": "berlin", "counted": 12},
+			{"product_id": "p2", "counted": "a few"},
+			{"product_id": "p3", "counted": "4"}]`
+		rows, failed := readAll(t, array, stockcount.JSON)
+		assert.Equal(t, want, rows)
+		require.Len(t, failed, 1)
+		assert.Equal(t, int32(2), failed[0].Line)
+
+		lines := `{"product_id": "p1", "location_id": "berlin", "counted": 12}
+			{"product_id": "p2", "counted": true}
+			{"product_id": "p3", "counted": 4}`
+		rows, failed = readAll(t, lines, stockcount.JSON)
+		assert.Equal(t, want, rows)
+		assert.Len(t, failed, 1)
+	})
+
+	t.Run("formats are named or taken from the extension", func(t *testing.T) {
+		format, err := stockcount.ParseFormat(".NDJSON")
+		require.NoError(t, err)
+		assert.Equal(t, stockcount.JSON, format)
+		_, err = stockcount.ParseFormat("xlsx")
+		assert.ErrorIs(t, err, stockcount.ErrUnknownFormat)
+	})
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/SabinGhost19/go-micro-payment/proto/ledger"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+type LedgerHandler struct {
+	ledgerpb.UnimplementedLedgerServiceServer
+	svc *service.LedgerService
+}
+
+func NewLedgerHandler(svc *service.LedgerService) *LedgerHandler {
+	return &LedgerHandler{svc: svc}
+}
+
+func (h *LedgerHandler) GetAccountBalances(ctx context.Context, req *ledgerpb.GetAccountBalancesRequest) (*ledgerpb.GetAccountBalancesResponse, error) {
+	from, err := parseTime(req.From)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "from must be RFC3339")
+	}
+	to, err := parseTime(req.To)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "to must be RFC3339")
+	}
+	balances, err := h.svc.Balances(ctx, from, to, req.Account, req.Currency)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	resp := &ledgerpb.GetAccountBalancesResponse{}
+	for _, b := range balances {
+		resp.Balances = append(resp.Balances, &ledgerpb.AccountBalance{
+			Account:  b.Account.Code,
+			Type:     string(b.Account.Type),
+			Currency: b.Currency,
+			Debits:   fromMinor(b.Debits),
+			Credits:  fromMinor(b.Credits),
+			Balance:  fromMinor(b.Balance),
+		})
+	}
+	return resp, nil
+}
+
+func (h *LedgerHandler) CheckInvariants(ctx context.Context, _ *ledgerpb.CheckInvariantsRequest) (*ledgerpb.CheckInvariantsResponse, error) {
+	report, err := h.svc.CheckInvariants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &ledgerpb.CheckInvariantsResponse{
+		Balanced:       report.Balanced(),
+		EntriesChecked: report.EntriesChecked,
+	}
+	for _, imb := range report.Imbalances {
+		resp.Imbalances = append(resp.Imbalances, &ledgerpb.EntryImbalance{
+			EntryId:  imb.EntryID,
+			Currency: imb.Currency,
+			Sum:      fromMinor(imb.Sum),
+		})
+	}
+	return resp, nil
+}
+
+// toStatusError maps service errors to gRPC status codes
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidEntry), errors.Is(err, service.ErrUnbalanced):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return err
+}
+
+// parseTime parses an optional RFC3339 timestamp
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// fromMinor converts cents to a decimal amount
+func fromMinor(amount int64) float64 {
+	return float64(amount) / 100
+}
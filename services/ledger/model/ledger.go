@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// AccountType decides on which side an account's balance normally sits
+type AccountType string
+
+const (
+	AccountAsset     AccountType = "ASSET"
+	AccountLiability AccountType = "LIABILITY"
+	AccountRevenue   AccountType = "REVENUE"
+	AccountExpense   AccountType = "EXPENSE"
+)
+
+// account codes
+const (
+	CustomerReceivable = "customer_receivable" // billed to customers, not yet collected
+	CustomerCredit     = "customer_credit"     // store credit owed to customers, spendable on payments
+	ProviderClearing   = "provider_clearing"   // collected by a payment provider, not yet paid out
+	Cash               = "cash"                // settled to our bank account
+	Revenue            = "revenue"
+	Refunds            = "refunds"     // money returned to customers
+	Fees               = "fees"        // provider processing fees
+	Chargebacks        = "chargebacks" // money taken back by the customer's bank after a lost dispute
+	Goodwill           = "goodwill"    // store credit granted as a courtesy
+)
+
+// Account is a ledger account identified by its code
+type Account struct {
+	Code      string      `gorm:"primaryKey;type:varchar(50)"`
+	Name      string      `gorm:"type:varchar(100)"`
+	Type      AccountType `gorm:"type:varchar(20);not null"`
+	CreatedAt time.Time   `gorm:"autoCreateTime"`
+}
+
+// DefaultAccounts is the chart of accounts created at startup
+var DefaultAccounts = []Account{
+	{Code: CustomerReceivable, Name: "Customer receivable", Type: AccountAsset},
+	{Code: ProviderClearing, Name: "Provider clearing", Type: AccountAsset},
+	{Code: Cash, Name: "Cash", Type: AccountAsset},
+	{Code: CustomerCredit, Name: "Customer credit", Type: AccountLiability},
+	{Code: Revenue, Name: "Revenue", Type: AccountRevenue},
+	{Code: Refunds, Name: "Refunds", Type: AccountExpense},
+	{Code: Fees, Name: "Fees", Type: AccountExpense},
+	{Code: Chargebacks, Name: "Chargebacks", Type: AccountExpense},
+	{Code: Goodwill, Name: "Goodwill", Type: AccountExpense},
+}
+
+// DebitNormal reports whether the account's balance is debits minus credits
+func (t AccountType) DebitNormal() bool {
+	return t == AccountAsset || t == AccountExpense
+}
+
+// JournalEntry is an immutable, balanced set of lines. Mistakes are corrected
+// with a reversing entry, never by editing an existing one.
+type JournalEntry struct {
+	ID          string        `gorm:"primaryKey"`
+	SourceKey   string        `gorm:"type:varchar(255);uniqueIndex;not null"` // the event that caused it, e.g. "refund:<id>"
+	Description string        `gorm:"type:text"`
+	PaymentID   string        `gorm:"index"`
+	OrderID     string        `gorm:"index"`
+	PostedAt    time.Time     `gorm:"index;not null"`
+	Lines       []JournalLine `gorm:"foreignKey:EntryID"`
+}
+
+// JournalLine moves an amount in or out of one account. Amounts are in minor
+// units (cents); debits are positive and credits negative, so the lines of an
+// entry sum to zero per currency.
+type JournalLine struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	EntryID     string `gorm:"index;not null"`
+	AccountCode string `gorm:"type:varchar(50);index;not null"`
+	Amount      int64  `gorm:"not null"`
+	Currency    string `gorm:"type:varchar(3);not null"`
+}
+
+// AccountBalance is the total movement of an account in one currency
+type AccountBalance struct {
+	AccountCode string
+	Currency    string
+	Debits      int64
+	Credits     int64 // as a positive number
+}
+
+// EntryImbalance is a journal entry whose lines do not sum to zero in a currency
+type EntryImbalance struct {
+	EntryID  string
+	Currency string
+	Sum      int64
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type LedgerRepository interface {
+	EnsureAccounts(ctx context.Context, accounts []model.Account) error
+	ListAccounts(ctx context.Context) ([]model.Account, error)
+	CreateEntry(ctx context.Context, entry *model.JournalEntry) (bool, error)
+	Balances(ctx context.Context, from, to time.Time) ([]model.AccountBalance, error)
+	UnbalancedEntries(ctx context.Context) ([]model.EntryImbalance, error)
+	CountEntries(ctx context.Context) (int64, error)
+}
+
+type pgRepo struct {
+	db *gorm.DB
+}
+
+func NewPostgresLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &pgRepo{db: db}
+}
+
+// InstallImmutabilityGuards makes PostgreSQL reject updates and deletes of
+// journal entries and lines
+func InstallImmutabilityGuards(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION ledger_reject_change() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'ledger rows are immutable; post a reversing entry instead';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS journal_entries_immutable ON journal_entries`,
+		`CREATE TRIGGER journal_entries_immutable BEFORE UPDATE OR DELETE ON journal_entries
+			FOR EACH ROW EXECUTE FUNCTION ledger_reject_change()`,
+		`DROP TRIGGER IF EXISTS journal_lines_immutable ON journal_lines`,
+		`CREATE TRIGGER journal_lines_immutable BEFORE UPDATE OR DELETE ON journal_lines
+			FOR EACH ROW EXECUTE FUNCTION ledger_reject_change()`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureAccounts creates missing accounts and leaves existing ones untouched
+func (r *pgRepo) EnsureAccounts(ctx context.Context, accounts []model.Account) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&accounts).Error
+}
+
+func (r *pgRepo) ListAccounts(ctx context.Context) ([]model.Account, error) {
+	var accounts []model.Account
+	err := r.db.WithContext(ctx).Order("code").Find(&accounts).Error
+	return accounts, err
+}
+
+// CreateEntry stores an entry and its lines in one transaction. It returns
+// false without error when an entry with the same source key already exists.
+func (r *pgRepo) CreateEntry(ctx context.Context, entry *model.JournalEntry) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		lines := entry.Lines
+		entry.Lines = nil
+		defer func() { entry.Lines = lines }()
+
+		res := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "source_key"}}, DoNothing: true}).Create(entry)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		for i := range lines {
+			lines[i].EntryID = entry.ID
+		}
+		if err := tx.Create(&lines).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// Balances sums the lines of entries posted in [from, to); zero bounds are open
+func (r *pgRepo) Balances(ctx context.Context, from, to time.Time) ([]model.AccountBalance, error) {
+	query := r.db.WithContext(ctx).Table("journal_lines").
+		Select("journal_lines.account_code, journal_lines.currency, " +
+			"COALESCE(SUM(CASE WHEN journal_lines.amount > 0 THEN journal_lines.amount ELSE 0 END), 0) AS debits, " +
+			"COALESCE(SUM(CASE WHEN journal_lines.amount < 0 THEN -journal_lines.amount ELSE 0 END), 0) AS credits").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.entry_id").
+		Group("journal_lines.account_code, journal_lines.currency").
+		Order("journal_lines.account_code, journal_lines.currency")
+	if !from.IsZero() {
+		query = query.Where("journal_entries.posted_at >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("journal_entries.posted_at < ?", to)
+	}
+	var balances []model.AccountBalance
+	err := query.Scan(&balances).Error
+	return balances, err
+}
+
+// UnbalancedEntries lists entries whose lines do not sum to zero per currency,
+// including entries that have no lines at all
+func (r *pgRepo) UnbalancedEntries(ctx context.Context) ([]model.EntryImbalance, error) {
+	var imbalances []model.EntryImbalance
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT entry_id, currency, SUM(amount) AS sum
+		FROM journal_lines
+		GROUP BY entry_id, currency
+		HAVING SUM(amount) <> 0
+		UNION ALL
+		SELECT e.id AS entry_id, '' AS currency, 0 AS sum
+		FROM journal_entries e
+		WHERE NOT EXISTS (SELECT 1 FROM journal_lines l WHERE l.entry_id = e.id)
+		ORDER BY entry_id`).Scan(&imbalances).Error
+	return imbalances, err
+}
+
+func (r *pgRepo) CountEntries(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.JournalEntry{}).Count(&count).Error
+	return count, err
+}
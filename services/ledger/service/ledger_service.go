@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/IBM/sarama"
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/model"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/repository"
+	"log"
+	"time"
+)
+
+const (
+	// DeadLetterTopic receives the events the ledger could not post, with the error
+	DeadLetterTopic = "ledger-dead-letters"
+	// DefaultPostAttempts is how often an event is tried before it is dead-lettered
+	DefaultPostAttempts = 5
+	// DefaultRetryDelay is the wait before the second try; it doubles every try
+	DefaultRetryDelay = time.Second
+)
+
+var (
+	// ErrUnbalanced is returned for entries whose lines do not sum to zero per currency
+	ErrUnbalanced = errors.New("journal entry is unbalanced")
+	// ErrInvalidEntry is returned for entries that are malformed in any other way
+	ErrInvalidEntry = errors.New("invalid journal entry")
+	// ErrMalformedEvent is returned for events that cannot be decoded
+	ErrMalformedEvent = errors.New("malformed event")
+)
+
+type LedgerService struct {
+	Repo  repository.LedgerRepository
+	kafka *kafka.Producer
+
+	// PostAttempts is how often a consumed event is tried before it is dead-lettered
+	PostAttempts int
+	// RetryDelay is the wait before the second try; it doubles every try
+	RetryDelay time.Duration
+}
+
+func New(repo repository.LedgerRepository, kafka *kafka.Producer) *LedgerService {
+	return &LedgerService{
+		Repo:         repo,
+		kafka:        kafka,
+		PostAttempts: DefaultPostAttempts,
+		RetryDelay:   DefaultRetryDelay,
+	}
+}
+
+// Balance is the movement and normal-side balance of an account in one currency
+type Balance struct {
+	Account  model.Account
+	Currency string
+	Debits   int64
+	Credits  int64
+	Balance  int64
+}
+
+// InvariantReport is the result of checking every journal entry
+type InvariantReport struct {
+	EntriesChecked int64
+	Imbalances     []model.EntryImbalance
+}
+
+// Balanced reports whether no entry was found out of balance
+func (r *InvariantReport) Balanced() bool {
+	return len(r.Imbalances) == 0
+}
+
+// Setup creates the default chart of accounts
+func (s *LedgerService) Setup(ctx context.Context) error {
+	return s.Repo.EnsureAccounts(ctx, model.DefaultAccounts)
+}
+
+// PostEntry validates and stores a journal entry. Posting an entry whose
+// source key was already posted is a no-op and returns false.
+func (s *LedgerService) PostEntry(ctx context.Context, entry *model.JournalEntry) (bool, error) {
+	if entry.SourceKey == "" {
+		return false, fmt.Errorf("%w: missing source key", ErrInvalidEntry)
+	}
+	if len(entry.Lines) < 2 {
+		return false, fmt.Errorf("%w: an entry needs at least two lines", ErrInvalidEntry)
+	}
+	accounts, err := s.accounts(ctx)
+	if err != nil {
+		return false, err
+	}
+	sums := make(map[string]int64)
+	for _, l := range entry.Lines {
+		if _, ok := accounts[l.AccountCode]; !ok {
+			return false, fmt.Errorf("%w: unknown account %q", ErrInvalidEntry, l.AccountCode)
+		}
+		if l.Amount == 0 || l.Currency == "" {
+			return false, fmt.Errorf("%w: line on %s needs an amount and a currency", ErrInvalidEntry, l.AccountCode)
+		}
+		sums[l.Currency] += l.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return false, fmt.Errorf("%w: %s lines sum to %d", ErrUnbalanced, currency, sum)
+		}
+	}
+	if entry.PostedAt.IsZero() {
+		entry.PostedAt = time.Now()
+	}
+	return s.Repo.CreateEntry(ctx, entry)
+}
+
+// Balances returns the balance per account and currency of entries posted in
+// [from, to); empty filters match everything
+func (s *LedgerService) Balances(ctx context.Context, from, to time.Time, account, currency string) ([]Balance, error) {
+	accounts, err := s.accounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if account != "" {
+		if _, ok := accounts[account]; !ok {
+			return nil, fmt.Errorf("%w: unknown account %q", ErrInvalidEntry, account)
+		}
+	}
+	rows, err := s.Repo.Balances(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var balances []Balance
+	for _, row := range rows {
+		if (account != "" && row.AccountCode != account) || (currency != "" && row.Currency != currency) {
+			continue
+		}
+		acc := accounts[row.AccountCode]
+		balance := row.Credits - row.Debits
+		if acc.Type.DebitNormal() {
+			balance = row.Debits - row.Credits
+		}
+		balances = append(balances, Balance{
+			Account:  acc,
+			Currency: row.Currency,
+			Debits:   row.Debits,
+			Credits:  row.Credits,
+			Balance:  balance,
+		})
+	}
+	return balances, nil
+}
+
+// CheckInvariants verifies that every journal entry sums to zero per currency
+func (s *LedgerService) CheckInvariants(ctx context.Context) (*InvariantReport, error) {
+	count, err := s.Repo.CountEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	imbalances, err := s.Repo.UnbalancedEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &InvariantReport{EntriesChecked: count, Imbalances: imbalances}, nil
+}
+
+// HandleEvent posts the entry implied by a payment, refund, dispute or wallet event, if any
+func (s *LedgerService) HandleEvent(ctx context.Context, topic string, value []byte) error {
+	var entry *model.JournalEntry
+	switch topic {
+	case "payment-events", "payment-status-updates":
+		var evt PaymentEvent
+		if err := json.Unmarshal(value, &evt); err != nil {
+			return fmt.Errorf("%w: payment event: %v", ErrMalformedEvent, err)
+		}
+		if topic == "payment-events" {
+			entry = EntryForPaymentCreated(evt)
+		} else {
+			entry = EntryForPaymentStatus(evt)
+		}
+	case "refund-events":
+		var evt RefundEvent
+		if err := json.Unmarshal(value, &evt); err != nil {
+			return fmt.Errorf("%w: refund event: %v", ErrMalformedEvent, err)
+		}
+		entry = EntryForRefund(evt)
+	case "dispute-events":
+		var evt DisputeEvent
+		if err := json.Unmarshal(value, &evt); err != nil {
+			return fmt.Errorf("%w: dispute event: %v", ErrMalformedEvent, err)
+		}
+		entry = EntryForDispute(evt)
+	case "wallet-events":
+		var evt WalletEvent
+		if err := json.Unmarshal(value, &evt); err != nil {
+			return fmt.Errorf("%w: wallet event: %v", ErrMalformedEvent, err)
+		}
+		entry = EntryForWalletCredit(evt)
+	}
+	if entry == nil {
+		return nil
+	}
+	_, err := s.PostEntry(ctx, entry)
+	return err
+}
+
+// ProcessMessage posts the entry for a consumed message. Failures that may be
+// transient, such as a database outage, are retried with a growing delay; a
+// message that still cannot be posted is published on DeadLetterTopic. It
+// returns an error only when that fails too, and the message must then be
+// consumed again.
+func (s *LedgerService) ProcessMessage(ctx context.Context, topic string, key, value []byte) error {
+	err := s.HandleEvent(ctx, topic, value)
+	delay := s.RetryDelay
+	for attempt := 2; err != nil && attempt <= s.PostAttempts && retryable(err); attempt++ {
+		log.Printf("failed to post ledger entry for %s message, trying again in %s: %v", topic, delay, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		err = s.HandleEvent(ctx, topic, value)
+	}
+	if err == nil {
+		return nil
+	}
+
+	event := map[string]interface{}{
+		"topic":     topic,
+		"key":       string(key),
+		"value":     string(value),
+		"error":     err.Error(),
+		"failed_at": time.Now().Format(time.RFC3339),
+	}
+	if sendErr := s.kafka.SendMessage(ctx, DeadLetterTopic, string(key), event); sendErr != nil {
+		return fmt.Errorf("dead-letter %s message (%v): %w", topic, err, sendErr)
+	}
+	log.Printf("sent %s message to %s: %v", topic, DeadLetterTopic, err)
+	return nil
+}
+
+// retryable reports whether posting an event may succeed when tried again
+func retryable(err error) bool {
+	return !errors.Is(err, ErrMalformedEvent) && !errors.Is(err, ErrInvalidEntry) && !errors.Is(err, ErrUnbalanced)
+}
+
+func (s *LedgerService) accounts(ctx context.Context) (map[string]model.Account, error) {
+	list, err := s.Repo.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make(map[string]model.Account, len(list))
+	for _, a := range list {
+		accounts[a.Code] = a
+	}
+	return accounts, nil
+}
+
+// ConsumeEvents listens for payment, refund, dispute and wallet events from Kafka and posts them
+func (s *LedgerService) ConsumeEvents(ctx context.Context) error {
+	consumer, err := kafka.NewConsumer([]string{"kafka:9092"}, "ledger-service-group")
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	handler := &eventHandler{service: s}
+	topics := []string{"payment-events", "payment-status-updates", "refund-events", "dispute-events", "wallet-events"}
+	// a session ends on rebalances and when a message could not be handled;
+	// the next one resumes from the last message marked as consumed
+	for {
+		if err := consumer.Consume(ctx, topics, handler); err != nil {
+			log.Printf("ledger consumer session ended: %v", err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// eventHandler implements Sarama ConsumerGroupHandler for payment, refund, dispute and wallet events
+type eventHandler struct {
+	service *LedgerService
+}
+
+// Setup is called when the consumer group session starts
+func (h *eventHandler) Setup(_ sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup is called when the consumer group session ends
+func (h *eventHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim processes messages from the consumer group. A message that was
+// neither posted nor dead-lettered is left unmarked and ends the session.
+func (h *eventHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		if err := h.service.ProcessMessage(session.Context(), msg.Topic, msg.Key, msg.Value); err != nil {
+			return err
+		}
+		session.MarkMessage(msg, "")
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"github.com/SabinGhost19/go-micro-payment/configs/utils"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/model"
+	"math"
+	"strings"
+	"time"
+)
+
+// walletProvider is the provider of payments made entirely with store credit
+const walletProvider = "wallet"
+
+// PaymentEvent is the part of a payment event the ledger needs
+type PaymentEvent struct {
+	PaymentID      string  `json:"payment_id"`
+	OrderID        string  `json:"order_id"`
+	Status         string  `json:"status"`
+	Provider       string  `json:"provider"`
+	Amount         float64 `json:"amount"`
+	CapturedAmount float64 `json:"captured_amount"`
+	FeeAmount      float64 `json:"fee_amount"`
+	WalletAmount   float64 `json:"wallet_amount"` // store credit applied next to a card
+	Currency       string  `json:"currency"`
+}
+
+// RefundEvent is the part of a refund event the ledger needs
+type RefundEvent struct {
+	RefundID      string  `json:"refund_id"`
+	PaymentID     string  `json:"payment_id"`
+	OrderID       string  `json:"order_id"`
+	Status        string  `json:"status"`
+	Provider      string  `json:"provider"`
+	Amount        float64 `json:"amount"`
+	FeeAmount     float64 `json:"fee_amount"`
+	PaymentStatus string  `json:"payment_status"`
+	WalletAmount  float64 `json:"wallet_amount"`
+	Currency      string  `json:"currency"`
+}
+
+// WalletEvent is the part of a wallet event the ledger needs
+type WalletEvent struct {
+	Event         string  `json:"event"`
+	TransactionID string  `json:"transaction_id"`
+	UserID        string  `json:"user_id"`
+	Amount        float64 `json:"amount"`
+	Reason        string  `json:"reason"`
+	Currency      string  `json:"currency"`
+}
+
+// DisputeEvent is the part of a dispute event the ledger needs
+type DisputeEvent struct {
+	Event     string  `json:"event"`
+	DisputeID string  `json:"dispute_id"`
+	PaymentID string  `json:"payment_id"`
+	OrderID   string  `json:"order_id"`
+	Status    string  `json:"status"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+}
+
+// EntryForPaymentCreated bills the customer for a new payment: the amount
+// is recognized as revenue and, less the store credit applied to it, becomes
+// receivable. Payments created waiting on customer authentication, like 3D
+// Secure, are billed too, since they are collected or reversed later like
+// any other; payments that failed before reaching the provider are not.
+func EntryForPaymentCreated(evt PaymentEvent) *model.JournalEntry {
+	if (evt.Status != "PENDING" && evt.Status != "REQUIRES_ACTION") || evt.Amount <= 0 {
+		return nil
+	}
+	amount, wallet := toMinor(evt.Amount), toMinor(evt.WalletAmount)
+	return newEntry("payment:"+evt.PaymentID+":created", "payment created", evt.PaymentID, evt.OrderID,
+		line(model.CustomerReceivable, amount-wallet, evt.Currency),
+		line(model.CustomerCredit, wallet, evt.Currency),
+		line(model.Revenue, -amount, evt.Currency),
+	)
+}
+
+// EntryForPaymentStatus posts the money movement implied by a payment status
+// change; statuses that move no money return nil
+func EntryForPaymentStatus(evt PaymentEvent) *model.JournalEntry {
+	amount, wallet := toMinor(evt.Amount), toMinor(evt.WalletAmount)
+	billed := amount - wallet
+	key := "payment:" + evt.PaymentID + ":" + evt.Status
+	switch evt.Status {
+	case "PAID", "CAPTURED":
+		// the provider collected the receivable less its fee, or the customer
+		// paid it with store credit; a partial capture writes off the rest
+		captured := toMinor(evt.CapturedAmount)
+		if captured <= 0 {
+			captured = billed
+		}
+		fee := toMinor(evt.FeeAmount)
+		collected := model.ProviderClearing
+		if evt.Provider == walletProvider {
+			collected = model.CustomerCredit
+		}
+		return newEntry(key, "payment collected", evt.PaymentID, evt.OrderID,
+			line(collected, captured-fee, evt.Currency),
+			line(model.Fees, fee, evt.Currency),
+			line(model.CustomerReceivable, -billed, evt.Currency),
+			line(model.Revenue, billed-captured, evt.Currency),
+		)
+	case "FAILED", "VOIDED", "EXPIRED":
+		// nothing will be collected, so reverse the billing and give the
+		// store credit back
+		return newEntry(key, "payment "+strings.ToLower(evt.Status), evt.PaymentID, evt.OrderID,
+			line(model.Revenue, amount, evt.Currency),
+			line(model.CustomerReceivable, -billed, evt.Currency),
+			line(model.CustomerCredit, -wallet, evt.Currency),
+		)
+	}
+	return nil
+}
+
+// EntryForRefund posts money returned to the customer through the provider,
+// and the provider's fee for the refund. Payments made with store credit are
+// refunded to it, and the refund that completes a payment also gives back
+// the store credit applied next to its card.
+func EntryForRefund(evt RefundEvent) *model.JournalEntry {
+	if evt.Status != "SUCCEEDED" || evt.Amount <= 0 {
+		return nil
+	}
+	amount := toMinor(evt.Amount)
+	fee := toMinor(evt.FeeAmount)
+	paidFrom := model.ProviderClearing
+	if evt.Provider == walletProvider {
+		paidFrom = model.CustomerCredit
+	}
+	released := int64(0)
+	if evt.PaymentStatus == "REFUNDED" && evt.Provider != walletProvider {
+		released = toMinor(evt.WalletAmount)
+	}
+	return newEntry("refund:"+evt.RefundID, "refund", evt.PaymentID, evt.OrderID,
+		line(model.Refunds, amount+released, evt.Currency),
+		line(model.Fees, fee, evt.Currency),
+		line(paidFrom, -amount-fee, evt.Currency),
+		line(model.CustomerCredit, -released, evt.Currency),
+	)
+}
+
+// EntryForWalletCredit posts store credit granted to a customer: credit for
+// returned goods is a refund, any other a courtesy
+func EntryForWalletCredit(evt WalletEvent) *model.JournalEntry {
+	if evt.Event != "wallet.credited" || evt.Amount <= 0 {
+		return nil
+	}
+	amount := toMinor(evt.Amount)
+	expense := model.Goodwill
+	if evt.Reason == "return" {
+		expense = model.Refunds
+	}
+	return newEntry("wallet:"+evt.TransactionID, "store credit "+evt.Reason, "", "",
+		line(expense, amount, evt.Currency),
+		line(model.CustomerCredit, -amount, evt.Currency),
+	)
+}
+
+// EntryForDispute posts the money the customer's bank took back for a lost
+// dispute. Open and won disputes move no money.
+func EntryForDispute(evt DisputeEvent) *model.JournalEntry {
+	if evt.Status != "lost" || evt.Amount <= 0 {
+		return nil
+	}
+	amount := toMinor(evt.Amount)
+	return newEntry("dispute:"+evt.DisputeID+":lost", "dispute lost", evt.PaymentID, evt.OrderID,
+		line(model.Chargebacks, amount, evt.Currency),
+		line(model.ProviderClearing, -amount, evt.Currency),
+	)
+}
+
+// newEntry builds an entry, dropping zero lines
+func newEntry(sourceKey, description, paymentID, orderID string, lines ...model.JournalLine) *model.JournalEntry {
+	entry := &model.JournalEntry{
+		ID:          utils.GenerateUUID(),
+		SourceKey:   sourceKey,
+		Description: description,
+		PaymentID:   paymentID,
+		OrderID:     orderID,
+		PostedAt:    time.Now(),
+	}
+	for _, l := range lines {
+		if l.Amount != 0 {
+			entry.Lines = append(entry.Lines, l)
+		}
+	}
+	return entry
+}
+
+func line(account string, amount int64, currency string) model.JournalLine {
+	return model.JournalLine{AccountCode: account, Amount: amount, Currency: currency}
+}
+
+// toMinor converts a decimal amount to cents
+func toMinor(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
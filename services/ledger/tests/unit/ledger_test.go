@@ -0,0 +1,479 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/model"
+	"github.com/SabinGhost19/go-micro-payment/services/ledger/service"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLedgerRepository is an in-memory LedgerRepository
+type fakeLedgerRepository struct {
+	mu       sync.Mutex
+	accounts map[string]model.Account
+	entries  []*model.JournalEntry
+	outage   int // how many more CreateEntry calls fail
+}
+
+func newFakeLedgerRepository() *fakeLedgerRepository {
+	return &fakeLedgerRepository{accounts: make(map[string]model.Account)}
+}
+
+func (r *fakeLedgerRepository) EnsureAccounts(_ context.Context, accounts []model.Account) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range accounts {
+		if _, ok := r.accounts[a.Code]; !ok {
+			r.accounts[a.Code] = a
+		}
+	}
+	return nil
+}
+
+func (r *fakeLedgerRepository) ListAccounts(_ context.Context) ([]model.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var accounts []model.Account
+	for _, a := range r.accounts {
+		accounts = append(accounts, a)
+	}
+	return accounts, nil
+}
+
+func (r *fakeLedgerRepository) CreateEntry(_ context.Context, entry *model.JournalEntry) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.outage > 0 {
+		r.outage--
+		return false, errors.New("database unavailable")
+	}
+	for _, e := range r.entries {
+		if e.SourceKey == entry.SourceKey {
+			return false, nil
+		}
+	}
+	copied := *entry
+	copied.Lines = append([]model.JournalLine(nil), entry.Lines...)
+	r.entries = append(r.entries, &copied)
+	return true, nil
+}
+
+func (r *fakeLedgerRepository) Balances(_ context.Context, from, to time.Time) ([]model.AccountBalance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	totals := make(map[[2]string]*model.AccountBalance)
+	for _, e := range r.entries {
+		if (!from.IsZero() && e.PostedAt.Before(from)) || (!to.IsZero() && !e.PostedAt.Before(to)) {
+			continue
+		}
+		for _, l := range e.Lines {
+			key := [2]string{l.AccountCode, l.Currency}
+			b, ok := totals[key]
+			if !ok {
+				b = &model.AccountBalance{AccountCode: l.AccountCode, Currency: l.Currency}
+				totals[key] = b
+			}
+			if l.Amount > 0 {
+				b.Debits += l.Amount
+			} else {
+				b.Credits -= l.Amount
+			}
+		}
+	}
+	var balances []model.AccountBalance
+	for _, b := range totals {
+		balances = append(balances, *b)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].AccountCode < balances[j].AccountCode })
+	return balances, nil
+}
+
+func (r *fakeLedgerRepository) UnbalancedEntries(_ context.Context) ([]model.EntryImbalance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var imbalances []model.EntryImbalance
+	for _, e := range r.entries {
+		sums := make(map[string]int64)
+		for _, l := range e.Lines {
+			sums[l.Currency] += l.Amount
+		}
+		for currency, sum := range sums {
+			if sum != 0 {
+				imbalances = append(imbalances, model.EntryImbalance{EntryID: e.ID, Currency: currency, Sum: sum})
+			}
+		}
+	}
+	return imbalances, nil
+}
+
+func (r *fakeLedgerRepository) CountEntries(_ context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.entries)), nil
+}
+
+func newLedgerService(t *testing.T) (*service.LedgerService, *fakeLedgerRepository) {
+	svc, repo, _ := newDeadLetteringService(t, 0)
+	return svc, repo
+}
+
+// newDeadLetteringService is newLedgerService whose producer expects exactly
+// deadLetters messages, which it returns decoded
+func newDeadLetteringService(t *testing.T, deadLetters int) (*service.LedgerService, *fakeLedgerRepository, *[]map[string]string) {
+	var sent []map[string]string
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < deadLetters; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			if msg.Topic != service.DeadLetterTopic {
+				return errors.New("unexpected topic " + msg.Topic)
+			}
+			data, err := msg.Value.Encode()
+			if err != nil {
+				return err
+			}
+			var event map[string]string
+			if err := json.Unmarshal(data, &event); err != nil {
+				return err
+			}
+			sent = append(sent, event)
+			return nil
+		})
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	repo := newFakeLedgerRepository()
+	svc := service.New(repo, kafka.NewProducerWithClient(producer))
+	svc.RetryDelay = time.Millisecond
+	require.NoError(t, svc.Setup(context.Background()))
+	return svc, repo, &sent
+}
+
+// balanceOf returns the normal-side balance of an account in USD
+func balanceOf(t *testing.T, svc *service.LedgerService, account string) int64 {
+	balances, err := svc.Balances(context.Background(), time.Time{}, time.Time{}, account, "USD")
+	require.NoError(t, err)
+	if len(balances) == 0 {
+		return 0
+	}
+	require.Len(t, balances, 1)
+	return balances[0].Balance
+}
+
+func TestPostEntry(t *testing.T) {
+	ctx := context.Background()
+	entry := func(key string, lines ...model.JournalLine) *model.JournalEntry {
+		return &model.JournalEntry{ID: key, SourceKey: key, Lines: lines}
+	}
+	debit := model.JournalLine{AccountCode: model.CustomerReceivable, Amount: 1000, Currency: "USD"}
+	credit := model.JournalLine{AccountCode: model.Revenue, Amount: -1000, Currency: "USD"}
+
+	t.Run("balanced entry is stored once per source key", func(t *testing.T) {
+		svc, repo := newLedgerService(t)
+		created, err := svc.PostEntry(ctx, entry("k1", debit, credit))
+		require.NoError(t, err)
+		assert.True(t, created)
+
+		created, err = svc.PostEntry(ctx, entry("k1", debit, credit))
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Len(t, repo.entries, 1)
+	})
+
+	t.Run("unbalanced entry is rejected", func(t *testing.T) {
+		svc, repo := newLedgerService(t)
+		short := credit
+		short.Amount = -999
+		_, err := svc.PostEntry(ctx, entry("k1", debit, short))
+		assert.ErrorIs(t, err, service.ErrUnbalanced)
+		assert.Empty(t, repo.entries)
+	})
+
+	t.Run("lines balance per currency", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		eur := credit
+		eur.Currency = "EUR"
+		_, err := svc.PostEntry(ctx, entry("k1", debit, eur))
+		assert.ErrorIs(t, err, service.ErrUnbalanced)
+	})
+
+	t.Run("malformed entries are rejected", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		unknown := debit
+		unknown.AccountCode = "suspense"
+		zero := debit
+		zero.Amount = 0
+
+		_, err := svc.PostEntry(ctx, entry("k1", debit))
+		assert.ErrorIs(t, err, service.ErrInvalidEntry)
+		_, err = svc.PostEntry(ctx, entry("k2", unknown, credit))
+		assert.ErrorIs(t, err, service.ErrInvalidEntry)
+		_, err = svc.PostEntry(ctx, entry("k3", zero, debit, credit))
+		assert.ErrorIs(t, err, service.ErrInvalidEntry)
+		_, err = svc.PostEntry(ctx, entry("", debit, credit))
+		assert.ErrorIs(t, err, service.ErrInvalidEntry)
+	})
+}
+
+func TestPostingRules(t *testing.T) {
+	ctx := context.Background()
+	post := func(t *testing.T, svc *service.LedgerService, topic, payload string) {
+		require.NoError(t, svc.HandleEvent(ctx, topic, []byte(payload)))
+	}
+	created := `{"payment_id":"pay-1","order_id":"order-1","status":"PENDING","amount":100,"currency":"USD"}`
+
+	t.Run("paid payment moves the receivable to provider clearing", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "payment-events", created)
+		assert.Equal(t, int64(10000), balanceOf(t, svc, model.CustomerReceivable))
+		assert.Equal(t, int64(10000), balanceOf(t, svc, model.Revenue))
+
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"PAID","amount":100,"captured_amount":100,"currency":"USD"}`)
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.CustomerReceivable))
+		assert.Equal(t, int64(10000), balanceOf(t, svc, model.ProviderClearing))
+	})
+
+	t.Run("partial capture writes off the uncaptured rest", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "payment-events", created)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"AUTHORIZED","amount":100,"currency":"USD"}`)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"CAPTURED","amount":100,"captured_amount":60,"currency":"USD"}`)
+
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.CustomerReceivable))
+		assert.Equal(t, int64(6000), balanceOf(t, svc, model.ProviderClearing))
+		assert.Equal(t, int64(6000), balanceOf(t, svc, model.Revenue))
+	})
+
+	t.Run("failed payment reverses the billing", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "payment-events", created)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"FAILED","amount":100,"currency":"USD"}`)
+
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.CustomerReceivable))
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.Revenue))
+	})
+
+	t.Run("refund is paid out of provider clearing", func(t *testing.T) {
+		svc, repo := newLedgerService(t)
+		post(t, svc, "payment-events", created)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"PAID","amount":100,"captured_amount":100,"currency":"USD"}`)
+		refund := `{"refund_id":"ref-1","payment_id":"pay-1","status":"SUCCEEDED","amount":25.5,"currency":"USD"}`
+		post(t, svc, "refund-events", refund)
+		// redelivery posts nothing new
+		post(t, svc, "refund-events", refund)
+
+		assert.Len(t, repo.entries, 3)
+		assert.Equal(t, int64(7450), balanceOf(t, svc, model.ProviderClearing))
+		assert.Equal(t, int64(2550), balanceOf(t, svc, model.Refunds))
+	})
+
+	t.Run("provider fees are kept out of provider clearing", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "payment-events", created)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"PAID","amount":100,"captured_amount":100,"fee_amount":3.2,"currency":"USD"}`)
+		post(t, svc, "refund-events", `{"refund_id":"ref-1","payment_id":"pay-1","status":"SUCCEEDED","amount":20,"fee_amount":0.15,"currency":"USD"}`)
+
+		assert.Equal(t, int64(10000), balanceOf(t, svc, model.Revenue))
+		assert.Equal(t, int64(335), balanceOf(t, svc, model.Fees))
+		assert.Equal(t, int64(2000), balanceOf(t, svc, model.Refunds))
+		assert.Equal(t, int64(10000-320-2000-15), balanceOf(t, svc, model.ProviderClearing))
+	})
+
+	t.Run("lost dispute is charged back out of provider clearing", func(t *testing.T) {
+		svc, repo := newLedgerService(t)
+		post(t, svc, "payment-events", created)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"PAID","amount":100,"captured_amount":100,"currency":"USD"}`)
+		post(t, svc, "dispute-events", `{"event":"dispute.created","dispute_id":"dp-1","payment_id":"pay-1","status":"needs_response","amount":40,"currency":"USD"}`)
+		lost := `{"event":"dispute.closed","dispute_id":"dp-1","payment_id":"pay-1","status":"lost","amount":40,"currency":"USD"}`
+		post(t, svc, "dispute-events", lost)
+		post(t, svc, "dispute-events", lost)
+
+		assert.Len(t, repo.entries, 3)
+		assert.Equal(t, int64(6000), balanceOf(t, svc, model.ProviderClearing))
+		assert.Equal(t, int64(4000), balanceOf(t, svc, model.Chargebacks))
+	})
+
+	t.Run("granted store credit is owed to the customer", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "wallet-events", `{"event":"wallet.credited","transaction_id":"txn-1","user_id":"user-1","amount":20,"reason":"return","currency":"USD"}`)
+		post(t, svc, "wallet-events", `{"event":"wallet.credited","transaction_id":"txn-2","user_id":"user-1","amount":5,"reason":"goodwill","currency":"USD"}`)
+
+		assert.Equal(t, int64(2500), balanceOf(t, svc, model.CustomerCredit))
+		assert.Equal(t, int64(2000), balanceOf(t, svc, model.Refunds))
+		assert.Equal(t, int64(500), balanceOf(t, svc, model.Goodwill))
+	})
+
+	t.Run("wallet payment is paid and refunded with store credit", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "wallet-events", `{"event":"wallet.credited","transaction_id":"txn-1","amount":100,"reason":"goodwill","currency":"USD"}`)
+		post(t, svc, "payment-events", `{"payment_id":"pay-1","status":"PENDING","provider":"wallet","amount":100,"currency":"USD"}`)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"PAID","provider":"wallet","amount":100,"captured_amount":100,"currency":"USD"}`)
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.CustomerCredit))
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.CustomerReceivable))
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.ProviderClearing))
+
+		post(t, svc, "refund-events", `{"refund_id":"ref-1","payment_id":"pay-1","status":"SUCCEEDED","provider":"wallet","amount":30,"payment_status":"PARTIALLY_REFUNDED","currency":"USD"}`)
+		assert.Equal(t, int64(3000), balanceOf(t, svc, model.CustomerCredit))
+		assert.Equal(t, int64(3000), balanceOf(t, svc, model.Refunds))
+	})
+
+	t.Run("credit applied next to a card is spent and given back on a full refund", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "wallet-events", `{"event":"wallet.credited","transaction_id":"txn-1","amount":20,"reason":"goodwill","currency":"USD"}`)
+		post(t, svc, "payment-events", `{"payment_id":"pay-1","status":"PENDING","provider":"stripe","amount":100,"wallet_amount":20,"currency":"USD"}`)
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.CustomerCredit))
+		assert.Equal(t, int64(8000), balanceOf(t, svc, model.CustomerReceivable))
+
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"PAID","provider":"stripe","amount":100,"captured_amount":80,"wallet_amount":20,"currency":"USD"}`)
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.CustomerReceivable))
+		assert.Equal(t, int64(8000), balanceOf(t, svc, model.ProviderClearing))
+		assert.Equal(t, int64(10000), balanceOf(t, svc, model.Revenue))
+
+		post(t, svc, "refund-events", `{"refund_id":"ref-1","payment_id":"pay-1","status":"SUCCEEDED","provider":"stripe","amount":80,"payment_status":"REFUNDED","wallet_amount":20,"currency":"USD"}`)
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.ProviderClearing))
+		assert.Equal(t, int64(2000), balanceOf(t, svc, model.CustomerCredit))
+		assert.Equal(t, int64(10000), balanceOf(t, svc, model.Refunds))
+	})
+
+	t.Run("failed payment gives the credit applied to it back", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "wallet-events", `{"event":"wallet.credited","transaction_id":"txn-1","amount":20,"reason":"goodwill","currency":"USD"}`)
+		post(t, svc, "payment-events", `{"payment_id":"pay-1","status":"PENDING","provider":"stripe","amount":100,"wallet_amount":20,"currency":"USD"}`)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"FAILED","provider":"stripe","amount":100,"wallet_amount":20,"currency":"USD"}`)
+
+		assert.Equal(t, int64(2000), balanceOf(t, svc, model.CustomerCredit))
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.CustomerReceivable))
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.Revenue))
+	})
+
+	// balancesOf returns the USD balance of every account in the chart
+	balancesOf := func(t *testing.T, svc *service.LedgerService) map[string]int64 {
+		balances := map[string]int64{}
+		for _, account := range model.DefaultAccounts {
+			balances[account.Code] = balanceOf(t, svc, account.Code)
+		}
+		return balances
+	}
+	zero := func() map[string]int64 {
+		balances := map[string]int64{}
+		for _, account := range model.DefaultAccounts {
+			balances[account.Code] = 0
+		}
+		return balances
+	}
+	// 3D Secure required at creation, with store credit next to the card
+	challenged := `{"payment_id":"pay-1","status":"REQUIRES_ACTION","provider":"stripe","amount":100,"wallet_amount":20,"currency":"USD"}`
+
+	t.Run("payment challenged at creation is billed and collected", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "wallet-events", `{"event":"wallet.credited","transaction_id":"txn-1","amount":20,"reason":"goodwill","currency":"USD"}`)
+		post(t, svc, "payment-events", challenged)
+		assert.Equal(t, int64(8000), balanceOf(t, svc, model.CustomerReceivable))
+		assert.Equal(t, int64(0), balanceOf(t, svc, model.CustomerCredit))
+
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"AUTHORIZED","provider":"stripe","amount":100,"wallet_amount":20,"currency":"USD"}`)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"CAPTURED","provider":"stripe","amount":100,"captured_amount":80,"wallet_amount":20,"currency":"USD"}`)
+
+		want := zero()
+		want[model.ProviderClearing] = 8000
+		want[model.Revenue] = 10000
+		want[model.Goodwill] = 2000
+		assert.Equal(t, want, balancesOf(t, svc))
+	})
+
+	t.Run("payment challenged at creation and abandoned leaves nothing behind", func(t *testing.T) {
+		svc, _ := newLedgerService(t)
+		post(t, svc, "payment-events", challenged)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"EXPIRED","provider":"stripe","amount":100,"wallet_amount":20,"currency":"USD"}`)
+
+		assert.Equal(t, zero(), balancesOf(t, svc))
+	})
+
+	t.Run("events that move no money are ignored", func(t *testing.T) {
+		svc, repo := newLedgerService(t)
+		post(t, svc, "payment-events", `{"payment_id":"pay-1","status":"FAILED","amount":100,"currency":"USD"}`)
+		post(t, svc, "payment-status-updates", `{"payment_id":"pay-1","status":"REQUIRES_ACTION","amount":100,"currency":"USD"}`)
+		post(t, svc, "refund-events", `{"refund_id":"ref-1","payment_id":"pay-1","status":"FAILED","amount":10,"currency":"USD"}`)
+		post(t, svc, "dispute-events", `{"event":"dispute.closed","dispute_id":"dp-1","payment_id":"pay-1","status":"won","amount":10,"currency":"USD"}`)
+		assert.Empty(t, repo.entries)
+	})
+}
+
+func TestCheckInvariants(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newLedgerService(t)
+	require.NoError(t, svc.HandleEvent(ctx, "payment-events",
+		[]byte(`{"payment_id":"pay-1","status":"PENDING","amount":100,"currency":"USD"}`)))
+
+	report, err := svc.CheckInvariants(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.Balanced())
+	assert.Equal(t, int64(1), report.EntriesChecked)
+
+	// bypass validation to simulate a corrupted row
+	_, err = repo.CreateEntry(ctx, &model.JournalEntry{ID: "bad", SourceKey: "bad", Lines: []model.JournalLine{
+		{AccountCode: model.Cash, Amount: 500, Currency: "USD"},
+	}})
+	require.NoError(t, err)
+
+	report, err = svc.CheckInvariants(ctx)
+	require.NoError(t, err)
+	assert.False(t, report.Balanced())
+	require.Len(t, report.Imbalances, 1)
+	assert.Equal(t, model.EntryImbalance{EntryID: "bad", Currency: "USD", Sum: 500}, report.Imbalances[0])
+}
+
+func TestProcessMessage(t *testing.T) {
+	ctx := context.Background()
+	created := []byte(`{"payment_id":"pay-1","status":"PENDING","amount":100,"currency":"USD"}`)
+
+	t.Run("a transient failure is retried", func(t *testing.T) {
+		svc, repo, _ := newDeadLetteringService(t, 0)
+		repo.outage = 2
+		require.NoError(t, svc.ProcessMessage(ctx, "payment-events", []byte("pay-1"), created))
+		assert.Len(t, repo.entries, 1)
+	})
+
+	t.Run("a message that keeps failing is dead-lettered", func(t *testing.T) {
+		svc, repo, sent := newDeadLetteringService(t, 1)
+		svc.PostAttempts = 3
+		repo.outage = 3
+		require.NoError(t, svc.ProcessMessage(ctx, "payment-events", []byte("pay-1"), created))
+		assert.Empty(t, repo.entries)
+		require.Len(t, *sent, 1)
+		assert.Equal(t, "payment-events", (*sent)[0]["topic"])
+		assert.Equal(t, string(created), (*sent)[0]["value"])
+		assert.Contains(t, (*sent)[0]["error"], "database unavailable")
+	})
+
+	t.Run("a malformed message is dead-lettered at once", func(t *testing.T) {
+		svc, repo, sent := newDeadLetteringService(t, 1)
+		repo.outage = 1
+		require.NoError(t, svc.ProcessMessage(ctx, "refund-events", nil, []byte(`{"amount":"ten"`)))
+		require.Len(t, *sent, 1)
+		assert.Contains(t, (*sent)[0]["error"], service.ErrMalformedEvent.Error())
+		assert.Equal(t, 1, repo.outage, "nothing was posted")
+	})
+
+	t.Run("a message that cannot be dead-lettered is not consumed", func(t *testing.T) {
+		producer := mocks.NewSyncProducer(t, nil)
+		producer.ExpectSendMessageAndFail(errors.New("broker down"))
+		t.Cleanup(func() { require.NoError(t, producer.Close()) })
+		repo := newFakeLedgerRepository()
+		svc := service.New(repo, kafka.NewProducerWithClient(producer))
+		require.NoError(t, svc.Setup(ctx))
+
+		assert.Error(t, svc.ProcessMessage(ctx, "refund-events", nil, []byte(`not json`)))
+	})
+}
@@ -22,6 +22,10 @@ type NotificationService struct {
 	repo        repository.NotificationRepository
 	emailSender EmailSender
 	kafka       *kafka.Producer
+
+	// OpsRecipients are the email addresses stock alerts are sent to; without
+	// any, stock alerts are only logged
+	OpsRecipients []string
 }
 
 // New creates a new NotificationService
@@ -67,7 +71,7 @@ func (s *NotificationService) SendEmail(ctx context.Context, userID, to, subject
 	return n, err
 }
 
-// ConsumeEvents listens for order and payment events to trigger notifications
+// ConsumeEvents listens for order, payment, refund, dunning and stock alert events to trigger notifications
 func (s *NotificationService) ConsumeEvents(ctx context.Context) error {
 	consumer, err := kafka.NewConsumer([]string{"kafka:9092"}, "notification-service-group")
 	if err != nil {
@@ -76,7 +80,7 @@ func (s *NotificationService) ConsumeEvents(ctx context.Context) error {
 	defer consumer.Close()
 
 	handler := &eventHandler{service: s}
-	return consumer.Consume(ctx, []string{"order-events", "payment-status-updates"}, handler)
+	return consumer.Consume(ctx, []string{"order-events", "payment-status-updates", "refund-events", "notification-events", "stock-events"}, handler)
 }
 
 // eventHandler implements Sarama ConsumerGroupHandler for notification events
@@ -128,6 +132,10 @@ func (h *eventHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim s
 				log.Printf("failed to unmarshal payment event: %v", err)
 				continue
 			}
+			// declines waiting for a retry are covered by the dunning notices
+			if event.Status == "PENDING" || event.Status == "RETRY_SCHEDULED" {
+				break
+			}
 			// send payment status email
 			subject := fmt.Sprintf("Payment %s", event.Status)
 			body := fmt.Sprintf("Your payment for order %s is %s.", event.OrderID, event.Status)
@@ -135,6 +143,111 @@ func (h *eventHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim s
 			if err != nil {
 				log.Printf("failed to send payment status notification: %v", err)
 			}
+
+		case "refund-events":
+			var event struct {
+				RefundID string  `json:"refund_id"`
+				OrderID  string  `json:"order_id"`
+				UserID   string  `json:"user_id"`
+				Amount   float64 `json:"amount"`
+				Currency string  `json:"currency"`
+				Status   string  `json:"status"`
+			}
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("failed to unmarshal refund event: %v", err)
+				continue
+			}
+			// only tell the customer about money that was actually returned
+			if event.Status != "SUCCEEDED" {
+				break
+			}
+			subject := "Refund Processed"
+			body := fmt.Sprintf("A refund of %.2f %s for order %s has been issued.", event.Amount, event.Currency, event.OrderID)
+			_, err := h.service.SendEmail(context.Background(), event.UserID, "user@example.com", subject, body, event.OrderID)
+			if err != nil {
+				log.Printf("failed to send refund notification: %v", err)
+			}
+
+		case "notification-events":
+			var event struct {
+				Event       string  `json:"event"`
+				Stage       string  `json:"stage"`
+				OrderID     string  `json:"order_id"`
+				UserID      string  `json:"user_id"`
+				Amount      float64 `json:"amount"`
+				Currency    string  `json:"currency"`
+				Attempt     int     `json:"attempt"`
+				MaxAttempts int     `json:"max_attempts"`
+				NextRetryAt string  `json:"next_retry_at"`
+			}
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("failed to unmarshal notification event: %v", err)
+				continue
+			}
+			// the topic also carries our own notification.sent audit events
+			if event.Event != "payment.dunning" {
+				break
+			}
+			subject := "Payment Declined"
+			body := fmt.Sprintf("Your payment of %.2f %s for order %s was declined (attempt %d of %d). We will try again at %s.",
+				event.Amount, event.Currency, event.OrderID, event.Attempt, event.MaxAttempts, event.NextRetryAt)
+			if event.Stage == "retries_exhausted" {
+				subject = "Payment Failed"
+				body = fmt.Sprintf("We could not collect your payment of %.2f %s for order %s after %d attempts. The order has been cancelled.",
+					event.Amount, event.Currency, event.OrderID, event.Attempt)
+			}
+			_, err := h.service.SendEmail(context.Background(), event.UserID, "user@example.com", subject, body, event.OrderID)
+			if err != nil {
+				log.Printf("failed to send dunning notification: %v", err)
+			}
+
+		case "stock-events":
+			var event struct {
+				Event             string `json:"event"`
+				ProductID         string `json:"product_id"`
+				OrderID           string `json:"order_id"`
+				Message           string `json:"message"`
+				Available         int32  `json:"available"`
+				LowStockThreshold int32  `json:"low_stock_threshold"`
+				ReorderPoint      int32  `json:"reorder_point"`
+				Reorder           bool   `json:"reorder"`
+			}
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("failed to unmarshal stock event: %v", err)
+				continue
+			}
+			var subject, body string
+			reference := event.ProductID
+			switch event.Event {
+			case "stock.low":
+				subject = "Low Stock"
+				body = fmt.Sprintf("Product %s is down to %d available units (threshold %d).", event.ProductID, event.Available, event.LowStockThreshold)
+			case "stock.out":
+				subject = "Out of Stock"
+				body = fmt.Sprintf("Product %s is out of stock; new orders for it will fail.", event.ProductID)
+			case "stock.restored":
+				subject = "Stock Restored"
+				body = fmt.Sprintf("Product %s is back to %d available units.", event.ProductID, event.Available)
+			case "stock.commit_failed":
+				subject = "Paid Order Without Stock"
+				body = fmt.Sprintf("Order %s was paid but its reserved stock could not be taken off hand (%s); refund it or restock.", event.OrderID, event.Message)
+				reference = event.OrderID
+			}
+			// the topic also carries reservation and stock update events
+			if subject == "" {
+				break
+			}
+			if event.Reorder {
+				body += fmt.Sprintf(" It is at or below its reorder point of %d.", event.ReorderPoint)
+			}
+			if len(h.service.OpsRecipients) == 0 {
+				log.Printf("no OPS_ALERT_RECIPIENTS configured for %s: %s", event.Event, body)
+			}
+			for _, to := range h.service.OpsRecipients {
+				if _, err := h.service.SendEmail(context.Background(), "", to, subject, body, reference); err != nil {
+					log.Printf("failed to send stock alert to %s: %v", to, err)
+				}
+			}
 		}
 		session.MarkMessage(msg, "")
 	}
@@ -26,3 +26,11 @@ func (h *OrderHandler) GetOrder(ctx context.Context, req *orderpb.GetOrderReques
 func (h *OrderHandler) ListOrders(ctx context.Context, req *orderpb.ListOrdersRequest) (*orderpb.ListOrdersResponse, error) {
 	return h.svc.ListOrders(ctx, req)
 }
+
+func (h *OrderHandler) ReviewOrder(ctx context.Context, req *orderpb.ReviewOrderRequest) (*orderpb.OrderResponse, error) {
+	return h.svc.ReviewOrder(ctx, req)
+}
+
+func (h *OrderHandler) ShipOrder(ctx context.Context, req *orderpb.ShipOrderRequest) (*orderpb.OrderResponse, error) {
+	return h.svc.ShipOrder(ctx, req)
+}
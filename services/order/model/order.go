@@ -6,21 +6,35 @@ import "time"
 type OrderStatus string
 
 const (
-	OrderPending OrderStatus = "PENDING"
-	OrderPaid    OrderStatus = "PAID"
-	OrderFailed  OrderStatus = "FAILED"
+	OrderPending           OrderStatus = "PENDING"
+	OrderAuthorized        OrderStatus = "AUTHORIZED" // payment is held and will be captured on shipment
+	OrderPaid              OrderStatus = "PAID"
+	OrderFailed            OrderStatus = "FAILED"
+	OrderReview            OrderStatus = "REVIEW"   // held until an admin approves or rejects it
+	OrderRejected          OrderStatus = "REJECTED" // denied by risk evaluation or by a reviewer
+	OrderPartiallyRefunded OrderStatus = "PARTIALLY_REFUNDED"
+	OrderRefunded          OrderStatus = "REFUNDED"
+	OrderChargedBack       OrderStatus = "CHARGED_BACK" // the customer's bank took the money back after a lost dispute
 )
 
 // Order represents an order entity
 type Order struct {
-	ID        string      `gorm:"primaryKey;type:uuid"`
-	UserID    string      `gorm:"index;type:varchar(36)"`
-	Items     []OrderItem `gorm:"foreignKey:OrderID"`
-	Address   string      `gorm:"type:varchar(255)"`
-	Amount    float64     `gorm:"type:decimal(10,2)"`
-	Status    OrderStatus `gorm:"type:varchar(20);not null"`
-	CreatedAt time.Time   `gorm:"autoCreateTime"`
-	UpdatedAt time.Time   `gorm:"autoUpdateTime"`
+	ID              string      `gorm:"primaryKey;type:uuid"`
+	UserID          string      `gorm:"index;type:varchar(36)"`
+	Items           []OrderItem `gorm:"foreignKey:OrderID"`
+	Address         string      `gorm:"type:varchar(255)"`
+	Amount          float64     `gorm:"type:decimal(10,2)"`
+	Currency        string      `gorm:"type:varchar(3)"`
+	BillingCountry  string      `gorm:"type:varchar(2)"`
+	ShippingCountry string      `gorm:"type:varchar(2)"`
+	ClientIP        string      `gorm:"index;type:varchar(45)"`
+	Status          OrderStatus `gorm:"type:varchar(20);not null"`
+	RiskDecision    string      `gorm:"type:varchar(10)"`
+	RiskScore       int         `gorm:"type:integer"`
+	RiskReasons     string      `gorm:"type:text"` // semicolon separated
+	PaymentID       string      `gorm:"type:varchar(36)"`
+	CreatedAt       time.Time   `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time   `gorm:"autoUpdateTime"`
 }
 
 // OrderItem represents an item in an order
@@ -30,4 +44,8 @@ type OrderItem struct {
 	ProductID string    `gorm:"type:varchar(36)"`
 	Quantity  int32     `gorm:"type:integer;not null"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	// BackorderedQuantity is how much of Quantity waits for stock, expected at ExpectedAt
+	BackorderedQuantity int32 `gorm:"type:integer;not null;default:0"`
+	ExpectedAt          *time.Time
 }
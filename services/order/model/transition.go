@@ -0,0 +1,39 @@
+package model
+
+import "errors"
+
+// ErrInvalidTransition is returned when an order cannot move from its status
+// to another, e.g. for a payment event that arrived late
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+// transitions lists the statuses each status may move to. Statuses that are
+// missing, like FAILED, REJECTED or REFUNDED, are final.
+var transitions = map[OrderStatus][]OrderStatus{
+	OrderPending:           {OrderAuthorized, OrderPaid, OrderFailed},
+	OrderReview:            {OrderPending, OrderRejected},
+	OrderAuthorized:        {OrderPaid, OrderFailed},
+	OrderPaid:              {OrderPartiallyRefunded, OrderRefunded, OrderChargedBack},
+	OrderPartiallyRefunded: {OrderRefunded, OrderChargedBack},
+}
+
+// CanTransition reports whether an order may move from one status to another
+func CanTransition(from, to OrderStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusesBefore lists the statuses an order may move to status from,
+// including status itself, so setting a status again is harmless
+func StatusesBefore(status OrderStatus) []OrderStatus {
+	from := []OrderStatus{status}
+	for prev := range transitions {
+		if CanTransition(prev, status) {
+			from = append(from, prev)
+		}
+	}
+	return from
+}
@@ -3,8 +3,10 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/SabinGhost19/go-micro-payment/services/order/model"
 	"gorm.io/gorm"
+	"time"
 )
 
 // OrderRepository defines the interface for order data operations
@@ -13,6 +15,11 @@ type OrderRepository interface {
 	UpdateStatus(ctx context.Context, orderID string, status model.OrderStatus) error
 	FindByID(ctx context.Context, orderID string) (*model.Order, error)
 	ListByUserID(ctx context.Context, userID string, page, pageSize int32) ([]*model.Order, error)
+	CountByUserIDSince(ctx context.Context, userID string, since time.Time) (int64, error)
+	CountByClientIPSince(ctx context.Context, clientIP string, since time.Time) (int64, error)
+	SaveReview(ctx context.Context, orderID string, status model.OrderStatus, decision, reasons string) error
+	AllocateBackorder(ctx context.Context, orderID, productID string, quantity int32) error
+	SetPaymentID(ctx context.Context, orderID, paymentID string) error
 }
 
 // pgRepo implements OrderRepository using GORM
@@ -41,9 +48,21 @@ func (r *pgRepo) Save(ctx context.Context, order *model.Order) error {
 	})
 }
 
-// UpdateStatus updates the status of an order
+// UpdateStatus moves an order to status if model.CanTransition allows it
+// from the order's current status, and fails with model.ErrInvalidTransition
+// otherwise. The check is part of the update, so concurrent events cannot
+// move an order backwards.
 func (r *pgRepo) UpdateStatus(ctx context.Context, orderID string, status model.OrderStatus) error {
-	return r.db.WithContext(ctx).Model(&model.Order{}).Where("id = ?", orderID).Update("status", status).Error
+	res := r.db.WithContext(ctx).Model(&model.Order{}).
+		Where("id = ? AND status IN ?", orderID, model.StatusesBefore(status)).
+		Update("status", status)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: order %s to %s", model.ErrInvalidTransition, orderID, status)
+	}
+	return nil
 }
 
 // FindByID retrieves an order by its ID
@@ -66,3 +85,49 @@ func (r *pgRepo) ListByUserID(ctx context.Context, userID string, page, pageSize
 	err := r.db.WithContext(ctx).Preload("Items").Where("user_id = ?", userID).Limit(int(pageSize)).Offset(int(offset)).Find(&orders).Error
 	return orders, err
 }
+
+// CountByUserIDSince counts the orders a user placed since the given time
+func (r *pgRepo) CountByUserIDSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Order{}).Where("user_id = ? AND created_at >= ?", userID, since).Count(&count).Error
+	return count, err
+}
+
+// CountByClientIPSince counts the orders placed from an IP address since the given time
+func (r *pgRepo) CountByClientIPSince(ctx context.Context, clientIP string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Order{}).Where("client_ip = ? AND created_at >= ?", clientIP, since).Count(&count).Error
+	return count, err
+}
+
+// SaveReview moves an order out of REVIEW and records the reviewer's decision.
+// Only orders still in REVIEW are updated so two reviewers cannot both decide.
+func (r *pgRepo) SaveReview(ctx context.Context, orderID string, status model.OrderStatus, decision, reasons string) error {
+	res := r.db.WithContext(ctx).Model(&model.Order{}).
+		Where("id = ? AND status = ?", orderID, model.OrderReview).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"risk_decision": decision,
+			"risk_reasons":  reasons,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("order is not awaiting review")
+	}
+	return nil
+}
+
+// AllocateBackorder takes quantity off the backordered quantity of an order's
+// items of a product once the inventory found stock for it
+func (r *pgRepo) AllocateBackorder(ctx context.Context, orderID, productID string, quantity int32) error {
+	return r.db.WithContext(ctx).Model(&model.OrderItem{}).
+		Where("order_id = ? AND product_id = ? AND backordered_quantity > 0", orderID, productID).
+		Update("backordered_quantity", gorm.Expr("GREATEST(backordered_quantity - ?, 0)", quantity)).Error
+}
+
+// SetPaymentID records the payment initiated for an order
+func (r *pgRepo) SetPaymentID(ctx context.Context, orderID, paymentID string) error {
+	return r.db.WithContext(ctx).Model(&model.Order{}).Where("id = ?", orderID).Update("payment_id", paymentID).Error
+}
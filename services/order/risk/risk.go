@@ -0,0 +1,162 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Decision is the outcome of a risk evaluation
+type Decision string
+
+const (
+	Allow  Decision = "ALLOW"
+	Review Decision = "REVIEW"
+	Deny   Decision = "DENY"
+)
+
+// Input holds everything the evaluator knows about an order before payment
+type Input struct {
+	OrderID          string
+	UserID           string
+	ClientIP         string
+	Amount           float64
+	Currency         string
+	BillingCountry   string
+	ShippingCountry  string
+	AccountCreatedAt time.Time // zero when the account age is unknown
+}
+
+// Assessment is the result of a risk evaluation
+type Assessment struct {
+	Decision Decision
+	Score    int
+	Reasons  []string
+}
+
+// OrderHistory provides the order counts used by the velocity rules
+type OrderHistory interface {
+	CountByUserIDSince(ctx context.Context, userID string, since time.Time) (int64, error)
+	CountByClientIPSince(ctx context.Context, clientIP string, since time.Time) (int64, error)
+}
+
+// Config holds the thresholds of the built-in rule engine
+type Config struct {
+	VelocityWindow   time.Duration
+	MaxOrdersPerUser int64 // orders per user inside the window before the rule fires
+	MaxOrdersPerIP   int64 // orders per IP inside the window before the rule fires
+	ReviewAmount     float64
+	DenyAmount       float64
+	NewAccountAge    time.Duration
+	NewAccountAmount float64 // large order threshold for new accounts
+	ReviewScore      int
+	DenyScore        int
+	VelocityScore    int
+	AmountScore      int
+	CountryScore     int
+	NewAccountScore  int
+	// velocity above MaxOrders*DenyVelocityFactor denies outright regardless of the score
+	DenyVelocityFactor int64
+}
+
+// DefaultConfig returns the thresholds used when nothing is configured
+func DefaultConfig() Config {
+	return Config{
+		VelocityWindow:     time.Hour,
+		MaxOrdersPerUser:   5,
+		MaxOrdersPerIP:     10,
+		ReviewAmount:       1000,
+		DenyAmount:         10000,
+		NewAccountAge:      24 * time.Hour,
+		NewAccountAmount:   500,
+		ReviewScore:        40,
+		DenyScore:          80,
+		VelocityScore:      40,
+		AmountScore:        30,
+		CountryScore:       25,
+		NewAccountScore:    40,
+		DenyVelocityFactor: 3,
+	}
+}
+
+// RuleEngine is the built-in evaluator. Every rule that fires adds to the score
+// and records a reason; the final score is mapped to a decision.
+type RuleEngine struct {
+	cfg     Config
+	history OrderHistory
+}
+
+// NewRuleEngine creates a rule engine backed by the given order history
+func NewRuleEngine(cfg Config, history OrderHistory) *RuleEngine {
+	return &RuleEngine{cfg: cfg, history: history}
+}
+
+// Evaluate runs all rules against the input
+func (e *RuleEngine) Evaluate(ctx context.Context, in Input) (*Assessment, error) {
+	a := &Assessment{Decision: Allow}
+	hardDeny := false
+	now := time.Now()
+	since := now.Add(-e.cfg.VelocityWindow)
+
+	// velocity per user
+	if in.UserID != "" && e.cfg.MaxOrdersPerUser > 0 {
+		count, err := e.history.CountByUserIDSince(ctx, in.UserID, since)
+		if err != nil {
+			return nil, fmt.Errorf("count orders for user: %w", err)
+		}
+		if count >= e.cfg.MaxOrdersPerUser {
+			a.add(e.cfg.VelocityScore, fmt.Sprintf("user placed %d orders in the last %s", count, e.cfg.VelocityWindow))
+			if e.cfg.DenyVelocityFactor > 0 && count >= e.cfg.MaxOrdersPerUser*e.cfg.DenyVelocityFactor {
+				hardDeny = true
+			}
+		}
+	}
+
+	// velocity per IP
+	if in.ClientIP != "" && e.cfg.MaxOrdersPerIP > 0 {
+		count, err := e.history.CountByClientIPSince(ctx, in.ClientIP, since)
+		if err != nil {
+			return nil, fmt.Errorf("count orders for ip: %w", err)
+		}
+		if count >= e.cfg.MaxOrdersPerIP {
+			a.add(e.cfg.VelocityScore, fmt.Sprintf("ip %s placed %d orders in the last %s", in.ClientIP, count, e.cfg.VelocityWindow))
+			if e.cfg.DenyVelocityFactor > 0 && count >= e.cfg.MaxOrdersPerIP*e.cfg.DenyVelocityFactor {
+				hardDeny = true
+			}
+		}
+	}
+
+	// amount thresholds
+	switch {
+	case e.cfg.DenyAmount > 0 && in.Amount >= e.cfg.DenyAmount:
+		a.add(e.cfg.AmountScore*2, fmt.Sprintf("amount %.2f exceeds %.2f", in.Amount, e.cfg.DenyAmount))
+		hardDeny = true
+	case e.cfg.ReviewAmount > 0 && in.Amount >= e.cfg.ReviewAmount:
+		a.add(e.cfg.AmountScore, fmt.Sprintf("amount %.2f exceeds %.2f", in.Amount, e.cfg.ReviewAmount))
+	}
+
+	// billing and shipping country mismatch
+	if in.BillingCountry != "" && in.ShippingCountry != "" && !strings.EqualFold(in.BillingCountry, in.ShippingCountry) {
+		a.add(e.cfg.CountryScore, fmt.Sprintf("billing country %s differs from shipping country %s", strings.ToUpper(in.BillingCountry), strings.ToUpper(in.ShippingCountry)))
+	}
+
+	// new account placing a large order
+	if !in.AccountCreatedAt.IsZero() && now.Sub(in.AccountCreatedAt) < e.cfg.NewAccountAge && in.Amount >= e.cfg.NewAccountAmount {
+		a.add(e.cfg.NewAccountScore, fmt.Sprintf("account younger than %s placing an order of %.2f", e.cfg.NewAccountAge, in.Amount))
+	}
+
+	switch {
+	case hardDeny || a.Score >= e.cfg.DenyScore:
+		a.Decision = Deny
+	case a.Score >= e.cfg.ReviewScore:
+		a.Decision = Review
+	}
+	return a, nil
+}
+
+// add records a fired rule
+func (a *Assessment) add(score int, reason string) {
+	a.Score += score
+	a.Reasons = append(a.Reasons, reason)
+}
@@ -3,29 +3,38 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/IBM/sarama"
 	"github.com/SabinGhost19/go-micro-payment/configs/utils"
 	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
 	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
 	"github.com/SabinGhost19/go-micro-payment/proto/order"
 	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
+	userpb "github.com/SabinGhost19/go-micro-payment/proto/user"
 	"github.com/SabinGhost19/go-micro-payment/services/order/model"
 	"github.com/SabinGhost19/go-micro-payment/services/order/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/order/risk"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"log"
+	"strings"
 	"time"
 )
 
 // InventoryGrpcClient defines the gRPC client interface for Inventory Service
 type InventoryGrpcClient interface {
 	CheckStock(ctx context.Context, productID string) (int32, error)
-	ReserveStock(ctx context.Context, orderID string, items []inventorypb.StockItem) (bool, string, error)
+	ReserveStock(ctx context.Context, orderID string, items []inventorypb.StockItem) (*inventorypb.ReserveStockResponse, error)
+	ReleaseReservation(ctx context.Context, orderID, reason string) error
+	ExtendReservation(ctx context.Context, orderID string, expiresAt time.Time) error
 }
 
 // PaymentGrpcClient defines the gRPC client interface for Payment Service
 type PaymentGrpcClient interface {
-	InitiatePayment(ctx context.Context, orderID, userID string, amount float64, currency string) (paymentID, status string, err error)
+	InitiatePayment(ctx context.Context, orderID, userID string, amount float64, currency, captureMethod string) (paymentID, status string, err error)
+	CapturePayment(ctx context.Context, paymentID string) (status string, err error)
+	VoidPayment(ctx context.Context, paymentID, reason string) (status string, err error)
+	RefundPayment(ctx context.Context, paymentID, reason, idempotencyKey string) (status string, err error)
 }
 
 // ProductGrpcClient defines the gRPC client interface for Product Service
@@ -33,6 +42,28 @@ type ProductGrpcClient interface {
 	GetProduct(ctx context.Context, productID string) (*productpb.ProductResponse, error)
 }
 
+// UserGrpcClient defines the gRPC client interface for User Service
+type UserGrpcClient interface {
+	GetUser(ctx context.Context, userID string) (*userpb.UserResponse, error)
+}
+
+// RiskEvaluator scores an order between stock reservation and payment initiation
+type RiskEvaluator interface {
+	Evaluate(ctx context.Context, in risk.Input) (*risk.Assessment, error)
+}
+
+const (
+	// DefaultReviewHold is how long stock stays reserved for an order waiting
+	// for review by default
+	DefaultReviewHold = 72 * time.Hour
+	// DefaultPaymentHold is how long stock stays reserved for a payment
+	// attempt by default
+	DefaultPaymentHold = 24 * time.Hour
+	// DefaultCaptureMethod authorizes payments when the order is placed and
+	// captures them when it ships
+	DefaultCaptureMethod = "manual"
+)
+
 // OrderService handles order-related business logic
 type OrderService struct {
 	repo          repository.OrderRepository
@@ -40,17 +71,33 @@ type OrderService struct {
 	paymentGrpc   PaymentGrpcClient
 	inventoryGrpc InventoryGrpcClient
 	productGrpc   ProductGrpcClient
+	userGrpc      UserGrpcClient
+	risk          RiskEvaluator
 	orderpb.UnimplementedOrderServiceServer
+
+	// ReviewHold is how long the stock of an order in review stays reserved
+	ReviewHold time.Duration
+	// PaymentHold is how long the stock of an order stays reserved for a
+	// payment attempt; a scheduled retry is held for PaymentHold past its time
+	PaymentHold time.Duration
+	// CaptureMethod is requested for every payment: "manual" captures it in
+	// ShipOrder, "automatic" charges the customer as soon as they pay
+	CaptureMethod string
 }
 
-// New creates a new OrderService
-func New(repo repository.OrderRepository, kafka *kafka.Producer, paymentGrpc PaymentGrpcClient, inventoryGrpc InventoryGrpcClient, productGrpc ProductGrpcClient) *OrderService {
+// New creates a new OrderService. A nil risk evaluator lets every order through.
+func New(repo repository.OrderRepository, kafka *kafka.Producer, paymentGrpc PaymentGrpcClient, inventoryGrpc InventoryGrpcClient, productGrpc ProductGrpcClient, userGrpc UserGrpcClient, riskEvaluator RiskEvaluator) *OrderService {
 	return &OrderService{
 		repo:          repo,
 		kafka:         kafka,
 		paymentGrpc:   paymentGrpc,
 		inventoryGrpc: inventoryGrpc,
 		productGrpc:   productGrpc,
+		userGrpc:      userGrpc,
+		risk:          riskEvaluator,
+		ReviewHold:    DefaultReviewHold,
+		PaymentHold:   DefaultPaymentHold,
+		CaptureMethod: DefaultCaptureMethod,
 	}
 }
 
@@ -93,23 +140,43 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *orderpb.CreateOrder
 		}
 	}
 	order := &model.Order{
-		ID:        utils.GenerateUUID(),
-		UserID:    req.UserId,
-		Items:     items,
-		Address:   req.Address,
-		Amount:    totalAmount,
-		Status:    model.OrderPending,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:              utils.GenerateUUID(),
+		UserID:          req.UserId,
+		Items:           items,
+		Address:         req.Address,
+		Amount:          totalAmount,
+		Currency:        req.Currency,
+		BillingCountry:  strings.ToUpper(req.BillingCountry),
+		ShippingCountry: strings.ToUpper(req.ShippingCountry),
+		ClientIP:        req.ClientIp,
+		Status:          model.OrderPending,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	// reserve stock
-	success, message, err := s.inventoryGrpc.ReserveStock(ctx, order.ID, stockItems)
+	reserved, err := s.inventoryGrpc.ReserveStock(ctx, order.ID, stockItems)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to reserve stock: %s", message)
+		return nil, status.Errorf(codes.Internal, "failed to reserve stock: %v", err)
 	}
-	if !success {
-		return nil, status.Errorf(codes.FailedPrecondition, "stock reservation failed: %s", message)
+	if !reserved.Success {
+		return nil, status.Errorf(codes.FailedPrecondition, "stock reservation failed: %s", reserved.Message)
+	}
+	markBackorders(order, reserved.Backorders)
+
+	// evaluate risk before any money moves
+	assessment, err := s.evaluateRisk(ctx, order)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "risk evaluation failed: %v", err)
+	}
+	order.RiskDecision = string(assessment.Decision)
+	order.RiskScore = assessment.Score
+	order.RiskReasons = strings.Join(assessment.Reasons, "; ")
+	switch assessment.Decision {
+	case risk.Deny:
+		order.Status = model.OrderRejected
+	case risk.Review:
+		order.Status = model.OrderReview
 	}
 
 	// save order to database
@@ -117,21 +184,131 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *orderpb.CreateOrder
 		return nil, status.Errorf(codes.Internal, "failed to save order: %v", err)
 	}
 
+	switch order.Status {
+	case model.OrderRejected:
+		s.releaseStock(ctx, order.ID, "order rejected by risk evaluation")
+		return nil, status.Errorf(codes.PermissionDenied, "order %s rejected by risk evaluation: %s", order.ID, order.RiskReasons)
+	case model.OrderReview:
+		// payment is initiated once an admin approves the order; until then
+		// the stock stays held, and is held again on approval if it lapsed
+		if err := s.inventoryGrpc.ExtendReservation(ctx, order.ID, time.Now().Add(s.ReviewHold)); err != nil {
+			log.Printf("failed to hold stock of order %s for review: %v", order.ID, err)
+		}
+		return toOrderResponse(order), nil
+	}
+
+	if err := s.startPayment(ctx, order); err != nil {
+		return toOrderResponse(order), err
+	}
+	return toOrderResponse(order), nil
+}
+
+// ReviewOrder applies an admin decision to an order held in REVIEW. A
+// rejected order's stock is released; an approved order's stock is held for
+// its payment, and the order fails when that stock is gone.
+func (s *OrderService) ReviewOrder(ctx context.Context, req *orderpb.ReviewOrderRequest) (*orderpb.OrderResponse, error) {
+	if req.OrderId == "" || req.Reviewer == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "order_id and reviewer are required")
+	}
+	order, err := s.repo.FindByID(ctx, req.OrderId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "order not found: %v", err)
+	}
+	if order.Status != model.OrderReview {
+		return nil, status.Errorf(codes.FailedPrecondition, "order %s is %s, not %s", order.ID, order.Status, model.OrderReview)
+	}
+
+	verdict := "rejected"
+	newStatus, decision := model.OrderRejected, risk.Deny
+	if req.Approve {
+		verdict = "approved"
+		newStatus, decision = model.OrderPending, risk.Allow
+	}
+	reasons := order.RiskReasons
+	if reasons != "" {
+		reasons += "; "
+	}
+	reasons += verdict + " by " + req.Reviewer
+	if req.Note != "" {
+		reasons += ": " + req.Note
+	}
+	if err := s.repo.SaveReview(ctx, order.ID, newStatus, string(decision), reasons); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to review order: %v", err)
+	}
+	order.Status = newStatus
+	order.RiskDecision = string(decision)
+	order.RiskReasons = reasons
+
+	if !req.Approve {
+		s.releaseStock(ctx, order.ID, "order rejected by "+req.Reviewer)
+		return toOrderResponse(order), nil
+	}
+	if err := s.startPayment(ctx, order); err != nil {
+		return toOrderResponse(order), err
+	}
+	return toOrderResponse(order), nil
+}
+
+// releaseStock gives the stock reserved for an order that will not be paid
+// back; should the release fail, the reservation still expires
+func (s *OrderService) releaseStock(ctx context.Context, orderID, reason string) {
+	if err := s.inventoryGrpc.ReleaseReservation(ctx, orderID, reason); err != nil {
+		log.Printf("failed to release stock of order %s: %v", orderID, err)
+	}
+}
+
+// evaluateRisk runs the configured risk evaluator for a new order
+func (s *OrderService) evaluateRisk(ctx context.Context, order *model.Order) (*risk.Assessment, error) {
+	if s.risk == nil {
+		return &risk.Assessment{Decision: risk.Allow}, nil
+	}
+	in := risk.Input{
+		OrderID:         order.ID,
+		UserID:          order.UserID,
+		ClientIP:        order.ClientIP,
+		Amount:          order.Amount,
+		Currency:        order.Currency,
+		BillingCountry:  order.BillingCountry,
+		ShippingCountry: order.ShippingCountry,
+	}
+	if s.userGrpc != nil {
+		user, err := s.userGrpc.GetUser(ctx, order.UserID)
+		if err != nil {
+			log.Printf("failed to fetch user %s for risk evaluation: %v", order.UserID, err)
+		} else if createdAt, err := time.Parse(time.RFC3339, user.CreatedAt); err == nil {
+			in.AccountCreatedAt = createdAt
+		}
+	}
+	return s.risk.Evaluate(ctx, in)
+}
+
+// startPayment holds the stock of a saved order for as long as it can be paid,
+// initiates its payment and publishes order.created
+func (s *OrderService) startPayment(ctx context.Context, order *model.Order) error {
+	// the reservation outlives a hosted checkout page; it fails when the
+	// stock of an order that waited for review was taken meanwhile
+	if err := s.inventoryGrpc.ExtendReservation(ctx, order.ID, time.Now().Add(s.PaymentHold)); err != nil {
+		_ = s.repo.UpdateStatus(ctx, order.ID, model.OrderFailed)
+		order.Status = model.OrderFailed
+		return status.Errorf(codes.FailedPrecondition, "stock of order %s is no longer available: %v", order.ID, err)
+	}
 	// initiate payment via gRPC
-	paymentID, statusStr, err := s.paymentGrpc.InitiatePayment(ctx, order.ID, order.UserID, order.Amount, req.Currency)
+	paymentID, statusStr, err := s.paymentGrpc.InitiatePayment(ctx, order.ID, order.UserID, order.Amount, order.Currency, s.CaptureMethod)
 	if err != nil {
 		// update order status to FAILED if payment initiation fails
 		_ = s.repo.UpdateStatus(ctx, order.ID, model.OrderFailed)
-		return &orderpb.OrderResponse{
-			OrderId:   order.ID,
-			UserId:    order.UserID,
-			Items:     req.Items,
-			Address:   order.Address,
-			Amount:    order.Amount,
-			Status:    string(order.Status),
-			CreatedAt: order.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: order.UpdatedAt.Format(time.RFC3339),
-		}, status.Errorf(codes.Internal, "failed to initiate payment: %v", err)
+		order.Status = model.OrderFailed
+		return status.Errorf(codes.Internal, "failed to initiate payment: %v", err)
+	}
+	order.PaymentID = paymentID
+	if err := s.repo.SetPaymentID(ctx, order.ID, paymentID); err != nil {
+		log.Printf("failed to record payment %s of order %s: %v", paymentID, order.ID, err)
+	}
+	if statusStr == "FAILED" {
+		// the provider refused the payment outright
+		_ = s.repo.UpdateStatus(ctx, order.ID, model.OrderFailed)
+		order.Status = model.OrderFailed
+		return status.Errorf(codes.FailedPrecondition, "payment %s was declined", paymentID)
 	}
 
 	// publish order.created event to Kafka
@@ -147,17 +324,121 @@ func (s *OrderService) CreateOrder(ctx context.Context, req *orderpb.CreateOrder
 	if err := s.kafka.SendMessage(ctx, "order-events", order.ID, event); err != nil {
 		log.Printf("failed to publish order.created event: %v", err)
 	}
+	return nil
+}
 
-	return &orderpb.OrderResponse{
-		OrderId:   order.ID,
-		UserId:    order.UserID,
-		Items:     req.Items,
-		Address:   order.Address,
-		Amount:    order.Amount,
-		Status:    string(order.Status),
-		CreatedAt: order.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: order.UpdatedAt.Format(time.RFC3339),
-	}, nil
+// ShipOrder captures the authorized payment of an order that ships. Orders
+// paid with automatic capture have nothing to capture and are returned as
+// they are; an order with backordered items cannot ship yet.
+func (s *OrderService) ShipOrder(ctx context.Context, req *orderpb.ShipOrderRequest) (*orderpb.OrderResponse, error) {
+	if req.OrderId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "order_id is required")
+	}
+	order, err := s.repo.FindByID(ctx, req.OrderId)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "order not found: %v", err)
+	}
+	if order.Status == model.OrderPaid {
+		return toOrderResponse(order), nil
+	}
+	if order.Status != model.OrderAuthorized || order.PaymentID == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "order %s is %s, not %s", order.ID, order.Status, model.OrderAuthorized)
+	}
+	for _, item := range order.Items {
+		if item.BackorderedQuantity > 0 {
+			return nil, status.Errorf(codes.FailedPrecondition, "order %s still waits for %d of product %s", order.ID, item.BackorderedQuantity, item.ProductID)
+		}
+	}
+
+	if _, err := s.paymentGrpc.CapturePayment(ctx, order.PaymentID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to capture payment %s: %v", order.PaymentID, err)
+	}
+	// the payment's CAPTURED event marks the order PAID as well
+	if err := s.repo.UpdateStatus(ctx, order.ID, model.OrderPaid); err != nil {
+		log.Printf("failed to update order status: %v", err)
+	}
+	order.Status = model.OrderPaid
+	return toOrderResponse(order), nil
+}
+
+// HandlePaymentStatus moves an order along with its payment. While the
+// customer is still paying or a retry is scheduled, the order's stock is held
+// for PaymentHold more.
+func (s *OrderService) HandlePaymentStatus(ctx context.Context, value []byte) {
+	var event struct {
+		PaymentID   string `json:"payment_id"`
+		OrderID     string `json:"order_id"`
+		Status      string `json:"status"`
+		NextRetryAt string `json:"next_retry_at"`
+	}
+	if err := json.Unmarshal(value, &event); err != nil {
+		log.Printf("failed to unmarshal payment event: %v", err)
+		return
+	}
+	// map payment status to order status
+	var orderStatus model.OrderStatus
+	switch event.Status {
+	case "AUTHORIZED":
+		orderStatus = model.OrderAuthorized
+	case "PAID", "CAPTURED":
+		orderStatus = model.OrderPaid
+	case "FAILED", "VOIDED", "EXPIRED":
+		orderStatus = model.OrderFailed
+	case "PENDING", "REQUIRES_ACTION", "RETRY_SCHEDULED":
+		from := time.Now()
+		if retryAt, err := time.Parse(time.RFC3339, event.NextRetryAt); err == nil && retryAt.After(from) {
+			from = retryAt
+		}
+		if err := s.inventoryGrpc.ExtendReservation(ctx, event.OrderID, from.Add(s.PaymentHold)); err != nil {
+			log.Printf("failed to hold stock of order %s for payment %s: %v", event.OrderID, event.PaymentID, err)
+		}
+		return
+	default:
+		return
+	}
+	// a late or redelivered event may no longer apply to the order
+	if err := s.UpdateStatus(ctx, event.OrderID, orderStatus); errors.Is(err, model.ErrInvalidTransition) {
+		log.Printf("ignoring stale %s event for payment %s: %v", event.Status, event.PaymentID, err)
+	} else if err != nil {
+		log.Printf("failed to update order status: %v", err)
+	}
+}
+
+// HandleCommitFailed gives the customer their money back when the inventory
+// could not commit the stock of an order, e.g. because its reservation
+// expired and the stock was sold. An authorized payment is voided and the
+// order fails; a captured one is refunded in full, and its refund-events
+// message marks the order REFUNDED.
+func (s *OrderService) HandleCommitFailed(ctx context.Context, orderID, paymentID string) {
+	order, err := s.repo.FindByID(ctx, orderID)
+	if err != nil {
+		log.Printf("failed to find order %s without stock: %v", orderID, err)
+		return
+	}
+	if paymentID == "" {
+		paymentID = order.PaymentID
+	}
+	if paymentID == "" {
+		log.Printf("order %s without stock has no payment to give back", orderID)
+		return
+	}
+	// a redelivered event finds the order failed or refunded already
+	if !model.CanTransition(order.Status, model.OrderFailed) && !model.CanTransition(order.Status, model.OrderRefunded) {
+		return
+	}
+
+	const reason = "stock could not be committed"
+	_, voidErr := s.paymentGrpc.VoidPayment(ctx, paymentID, reason)
+	if voidErr == nil {
+		if err := s.repo.UpdateStatus(ctx, orderID, model.OrderFailed); err != nil {
+			log.Printf("failed to update order status: %v", err)
+		}
+		return
+	}
+	// a captured payment can no longer be voided; the key keeps redeliveries from refunding twice
+	if _, err := s.paymentGrpc.RefundPayment(ctx, paymentID, reason, "commit-failed-"+orderID); err != nil {
+		log.Printf("failed to void or refund payment %s of order %s without stock: %v; %v", paymentID, orderID, voidErr, err)
+	}
 }
 
 // UpdateStatus updates the order status
@@ -172,24 +453,7 @@ func (s *OrderService) GetOrder(ctx context.Context, req *orderpb.GetOrderReques
 		return nil, status.Errorf(codes.NotFound, "order not found: %v", err)
 	}
 
-	items := make([]*orderpb.OrderItem, len(order.Items))
-	for i, item := range order.Items {
-		items[i] = &orderpb.OrderItem{
-			ProductId: item.ProductID,
-			Quantity:  item.Quantity,
-		}
-	}
-
-	return &orderpb.OrderResponse{
-		OrderId:   order.ID,
-		UserId:    order.UserID,
-		Items:     items,
-		Address:   order.Address,
-		Amount:    order.Amount,
-		Status:    string(order.Status),
-		CreatedAt: order.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: order.UpdatedAt.Format(time.RFC3339),
-	}, nil
+	return toOrderResponse(order), nil
 }
 
 // ListOrders retrieves orders for a user with pagination
@@ -203,29 +467,65 @@ func (s *OrderService) ListOrders(ctx context.Context, req *orderpb.ListOrdersRe
 		Orders: make([]*orderpb.OrderResponse, len(orders)),
 	}
 	for i, order := range orders {
-		items := make([]*orderpb.OrderItem, len(order.Items))
-		for j, item := range order.Items {
-			items[j] = &orderpb.OrderItem{
-				ProductId: item.ProductID,
-				Quantity:  item.Quantity,
+		resp.Orders[i] = toOrderResponse(order)
+	}
+
+	return resp, nil
+}
+
+// markBackorders records on the order's items how much of them the inventory
+// backordered
+func markBackorders(order *model.Order, backorders []*inventorypb.Backorder) {
+	for _, b := range backorders {
+		remaining := b.Quantity
+		for i := range order.Items {
+			item := &order.Items[i]
+			if item.ProductID != b.ProductId || remaining == 0 {
+				continue
+			}
+			n := min(remaining, item.Quantity-item.BackorderedQuantity)
+			item.BackorderedQuantity += n
+			remaining -= n
+			if expectedAt, err := time.Parse(time.RFC3339, b.ExpectedAt); err == nil {
+				item.ExpectedAt = &expectedAt
 			}
-		}
-		resp.Orders[i] = &orderpb.OrderResponse{
-			OrderId:   order.ID,
-			UserId:    order.UserID,
-			Items:     items,
-			Address:   order.Address,
-			Amount:    order.Amount,
-			Status:    string(order.Status),
-			CreatedAt: order.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: order.UpdatedAt.Format(time.RFC3339),
 		}
 	}
+}
 
-	return resp, nil
+// toOrderResponse converts an order model to its protobuf representation
+func toOrderResponse(order *model.Order) *orderpb.OrderResponse {
+	items := make([]*orderpb.OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = &orderpb.OrderItem{
+			ProductId:           item.ProductID,
+			Quantity:            item.Quantity,
+			BackorderedQuantity: item.BackorderedQuantity,
+		}
+		if item.ExpectedAt != nil {
+			items[i].ExpectedAt = item.ExpectedAt.Format(time.RFC3339)
+		}
+	}
+	var reasons []string
+	if order.RiskReasons != "" {
+		reasons = strings.Split(order.RiskReasons, "; ")
+	}
+	return &orderpb.OrderResponse{
+		OrderId:      order.ID,
+		UserId:       order.UserID,
+		Items:        items,
+		Address:      order.Address,
+		Amount:       order.Amount,
+		Status:       string(order.Status),
+		CreatedAt:    order.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    order.UpdatedAt.Format(time.RFC3339),
+		RiskDecision: order.RiskDecision,
+		RiskReasons:  reasons,
+		PaymentId:    order.PaymentID,
+	}
 }
 
-// ConsumePaymentUpdates listens for payment and stock status updates from Kafka
+// ConsumePaymentUpdates listens for payment, stock, refund and dispute updates from Kafka
 func (s *OrderService) ConsumePaymentUpdates(ctx context.Context) error {
 	consumer, err := kafka.NewConsumer([]string{"kafka:9092"}, "order-service-group")
 	if err != nil {
@@ -234,10 +534,10 @@ func (s *OrderService) ConsumePaymentUpdates(ctx context.Context) error {
 	defer consumer.Close()
 
 	handler := &paymentUpdateHandler{service: s}
-	return consumer.Consume(ctx, []string{"payment-status-updates", "stock-events"}, handler)
+	return consumer.Consume(ctx, []string{"payment-status-updates", "stock-events", "refund-events", "dispute-events"}, handler)
 }
 
-// paymentUpdateHandler implements Sarama ConsumerGroupHandler for payment and stock events
+// paymentUpdateHandler implements Sarama ConsumerGroupHandler for payment, stock, refund and dispute events
 type paymentUpdateHandler struct {
 	service *OrderService
 }
@@ -257,40 +557,69 @@ func (h *paymentUpdateHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 	for msg := range claim.Messages() {
 		switch msg.Topic {
 		case "payment-status-updates":
+			h.service.HandlePaymentStatus(context.Background(), msg.Value)
+		case "stock-events":
 			var event struct {
-				PaymentID string `json:"payment_id"`
+				Event     string `json:"event"`
 				OrderID   string `json:"order_id"`
+				PaymentID string `json:"payment_id"`
+				ProductID string `json:"product_id"`
+				Quantity  int32  `json:"quantity"`
 				Status    string `json:"status"`
+				Message   string `json:"message"`
 			}
 			if err := json.Unmarshal(msg.Value, &event); err != nil {
-				log.Printf("failed to unmarshal payment event: %v", err)
+				log.Printf("failed to unmarshal stock event: %v", err)
 				continue
 			}
-			// map payment status to order status
-			var orderStatus model.OrderStatus
-			switch event.Status {
-			case "PAID":
-				orderStatus = model.OrderPaid
-			case "FAILED":
-				orderStatus = model.OrderFailed
-			default:
+			if event.Status == "failed" {
+				if err := h.service.UpdateStatus(context.Background(), event.OrderID, model.OrderFailed); err != nil {
+					log.Printf("failed to update order status: %v", err)
+				}
+			}
+			if event.Event == "stock.commit_failed" {
+				h.service.HandleCommitFailed(context.Background(), event.OrderID, event.PaymentID)
+			}
+			if event.Event == "stock.backorder_allocated" {
+				if err := h.service.repo.AllocateBackorder(context.Background(), event.OrderID, event.ProductID, event.Quantity); err != nil {
+					log.Printf("failed to mark backorder of order %s fulfillable: %v", event.OrderID, err)
+				}
+			}
+		case "refund-events":
+			var event struct {
+				RefundID      string `json:"refund_id"`
+				OrderID       string `json:"order_id"`
+				Status        string `json:"status"`
+				PaymentStatus string `json:"payment_status"`
+			}
+			if err := json.Unmarshal(msg.Value, &event); err != nil {
+				log.Printf("failed to unmarshal refund event: %v", err)
+				continue
+			}
+			if event.Status != "SUCCEEDED" {
 				continue
 			}
+			// the payment status tells whether the order is now fully refunded
+			orderStatus := model.OrderPartiallyRefunded
+			if event.PaymentStatus == "REFUNDED" {
+				orderStatus = model.OrderRefunded
+			}
 			if err := h.service.UpdateStatus(context.Background(), event.OrderID, orderStatus); err != nil {
 				log.Printf("failed to update order status: %v", err)
 			}
-		case "stock-events":
+		case "dispute-events":
 			var event struct {
-				OrderID string `json:"order_id"`
-				Status  string `json:"status"`
-				Message string `json:"message"`
+				DisputeID string `json:"dispute_id"`
+				OrderID   string `json:"order_id"`
+				Status    string `json:"status"`
 			}
 			if err := json.Unmarshal(msg.Value, &event); err != nil {
-				log.Printf("failed to unmarshal stock event: %v", err)
+				log.Printf("failed to unmarshal dispute event: %v", err)
 				continue
 			}
-			if event.Status == "failed" {
-				if err := h.service.UpdateStatus(context.Background(), event.OrderID, model.OrderFailed); err != nil {
+			// only a lost dispute takes the money back; open and won disputes leave the order as it is
+			if event.Status == "lost" {
+				if err := h.service.UpdateStatus(context.Background(), event.OrderID, model.OrderChargedBack); err != nil {
 					log.Printf("failed to update order status: %v", err)
 				}
 			}
@@ -0,0 +1,494 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	orderpb "github.com/SabinGhost19/go-micro-payment/proto/order"
+	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
+	"github.com/SabinGhost19/go-micro-payment/services/order/model"
+	"github.com/SabinGhost19/go-micro-payment/services/order/risk"
+	"github.com/SabinGhost19/go-micro-payment/services/order/service"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeOrderRepository is an in-memory OrderRepository
+type fakeOrderRepository struct {
+	mu     sync.Mutex
+	orders map[string]*model.Order
+}
+
+func (r *fakeOrderRepository) Save(_ context.Context, order *model.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := *order
+	r.orders[order.ID] = &saved
+	return nil
+}
+
+func (r *fakeOrderRepository) UpdateStatus(_ context.Context, orderID string, status model.OrderStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[orderID]
+	if !ok {
+		return errors.New("order not found")
+	}
+	if order.Status != status && !model.CanTransition(order.Status, status) {
+		return model.ErrInvalidTransition
+	}
+	order.Status = status
+	return nil
+}
+
+func (r *fakeOrderRepository) FindByID(_ context.Context, orderID string) (*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil, errors.New("order not found")
+	}
+	found := *order
+	return &found, nil
+}
+
+func (r *fakeOrderRepository) ListByUserID(context.Context, string, int32, int32) ([]*model.Order, error) {
+	return nil, nil
+}
+
+func (r *fakeOrderRepository) CountByUserIDSince(context.Context, string, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeOrderRepository) CountByClientIPSince(context.Context, string, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeOrderRepository) SaveReview(_ context.Context, orderID string, status model.OrderStatus, decision, reasons string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[orderID]
+	if !ok || order.Status != model.OrderReview {
+		return errors.New("order is not awaiting review")
+	}
+	order.Status, order.RiskDecision, order.RiskReasons = status, decision, reasons
+	return nil
+}
+
+func (r *fakeOrderRepository) AllocateBackorder(context.Context, string, string, int32) error {
+	return nil
+}
+
+func (r *fakeOrderRepository) SetPaymentID(_ context.Context, orderID, paymentID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[orderID]
+	if !ok {
+		return errors.New("order not found")
+	}
+	order.PaymentID = paymentID
+	return nil
+}
+
+// fakeInventory reserves whatever is asked and records releases and extensions
+type fakeInventory struct {
+	released  map[string]string    // reason by order
+	extended  map[string]time.Time // expiry by order
+	extendErr error
+}
+
+func (f *fakeInventory) CheckStock(context.Context, string) (int32, error) {
+	return 100, nil
+}
+
+func (f *fakeInventory) ReserveStock(_ context.Context, orderID string, _ []inventorypb.StockItem) (*inventorypb.ReserveStockResponse, error) {
+	return &inventorypb.ReserveStockResponse{OrderId: orderID, Success: true}, nil
+}
+
+func (f *fakeInventory) ReleaseReservation(_ context.Context, orderID, reason string) error {
+	f.released[orderID] = reason
+	return nil
+}
+
+func (f *fakeInventory) ExtendReservation(_ context.Context, orderID string, expiresAt time.Time) error {
+	if f.extendErr != nil {
+		return f.extendErr
+	}
+	f.extended[orderID] = expiresAt
+	return nil
+}
+
+// fakePayments records the orders a payment was initiated for and the
+// payments captured
+type fakePayments struct {
+	initiated     []string
+	captureMethod string // of the last payment initiated
+	captured      []string
+	captureErr    error
+	voided        []string
+	refunded      map[string]string // payment ID to idempotency key
+}
+
+func (f *fakePayments) InitiatePayment(_ context.Context, orderID, _ string, _ float64, _, captureMethod string) (string, string, error) {
+	f.initiated = append(f.initiated, orderID)
+	f.captureMethod = captureMethod
+	return "payment-" + orderID, "PENDING", nil
+}
+
+func (f *fakePayments) CapturePayment(_ context.Context, paymentID string) (string, error) {
+	if f.captureErr != nil {
+		return "", f.captureErr
+	}
+	f.captured = append(f.captured, paymentID)
+	return "CAPTURED", nil
+}
+
+// VoidPayment fails for payments that were captured, like the Payment Service
+func (f *fakePayments) VoidPayment(_ context.Context, paymentID, _ string) (string, error) {
+	for _, captured := range f.captured {
+		if captured == paymentID {
+			return "", errors.New("payment is CAPTURED")
+		}
+	}
+	f.voided = append(f.voided, paymentID)
+	return "VOIDED", nil
+}
+
+func (f *fakePayments) RefundPayment(_ context.Context, paymentID, _, idempotencyKey string) (string, error) {
+	if f.refunded == nil {
+		f.refunded = map[string]string{}
+	}
+	f.refunded[paymentID] = idempotencyKey
+	return "PENDING", nil
+}
+
+type fakeProducts struct{}
+
+func (fakeProducts) GetProduct(_ context.Context, productID string) (*productpb.ProductResponse, error) {
+	return &productpb.ProductResponse{ProductId: productID, Price: 25}, nil
+}
+
+// fixedRisk decides every order the same way
+type fixedRisk risk.Decision
+
+func (d fixedRisk) Evaluate(context.Context, risk.Input) (*risk.Assessment, error) {
+	return &risk.Assessment{Decision: risk.Decision(d), Reasons: []string{"test rule"}}, nil
+}
+
+type orderFixture struct {
+	svc       *service.OrderService
+	repo      *fakeOrderRepository
+	inventory *fakeInventory
+	payments  *fakePayments
+}
+
+// newOrderService returns an order service deciding every order as decision;
+// its producer expects exactly publishes messages
+func newOrderService(t *testing.T, decision risk.Decision, publishes int) *orderFixture {
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < publishes; i++ {
+		producer.ExpectSendMessageAndSucceed()
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	f := &orderFixture{
+		repo:      &fakeOrderRepository{orders: make(map[string]*model.Order)},
+		inventory: &fakeInventory{released: make(map[string]string), extended: make(map[string]time.Time)},
+		payments:  &fakePayments{},
+	}
+	f.svc = service.New(f.repo, kafka.NewProducerWithClient(producer), f.payments, f.inventory, fakeProducts{}, nil, fixedRisk(decision))
+	return f
+}
+
+// createOrder creates an order of two items for user-1
+func (f *orderFixture) createOrder() (*orderpb.OrderResponse, error) {
+	return f.svc.CreateOrder(context.Background(), &orderpb.CreateOrderRequest{
+		UserId:   "user-1",
+		Items:    []*orderpb.OrderItem{{ProductId: "product-1", Quantity: 2}},
+		Address:  "1 Main St",
+		Currency: "usd",
+	})
+}
+
+// onlyOrder returns the one order the repository holds
+func (f *orderFixture) onlyOrder(t *testing.T) *model.Order {
+	require.Len(t, f.repo.orders, 1)
+	for _, order := range f.repo.orders {
+		return order
+	}
+	return nil
+}
+
+func TestCreateOrderRisk(t *testing.T) {
+	t.Run("an allowed order starts its payment", func(t *testing.T) {
+		f := newOrderService(t, risk.Allow, 1)
+		f.svc.PaymentHold = 12 * time.Hour
+		resp, err := f.createOrder()
+		require.NoError(t, err)
+		assert.Equal(t, string(model.OrderPending), resp.Status)
+		assert.Equal(t, []string{resp.OrderId}, f.payments.initiated)
+		assert.Equal(t, service.DefaultCaptureMethod, f.payments.captureMethod)
+		assert.Equal(t, "payment-"+resp.OrderId, f.onlyOrder(t).PaymentID)
+		assert.WithinDuration(t, time.Now().Add(12*time.Hour), f.inventory.extended[resp.OrderId], time.Minute)
+		assert.Empty(t, f.inventory.released)
+	})
+
+	t.Run("a denied order is rejected and its stock released", func(t *testing.T) {
+		f := newOrderService(t, risk.Deny, 0)
+		_, err := f.createOrder()
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		order := f.onlyOrder(t)
+		assert.Equal(t, model.OrderRejected, order.Status)
+		assert.Contains(t, f.inventory.released, order.ID)
+		assert.Empty(t, f.payments.initiated)
+	})
+
+	t.Run("an order in review holds its stock until it is reviewed", func(t *testing.T) {
+		f := newOrderService(t, risk.Review, 0)
+		f.svc.ReviewHold = 48 * time.Hour
+		resp, err := f.createOrder()
+		require.NoError(t, err)
+		assert.Equal(t, string(model.OrderReview), resp.Status)
+		assert.Equal(t, []string{"test rule"}, resp.RiskReasons)
+
+		assert.WithinDuration(t, time.Now().Add(48*time.Hour), f.inventory.extended[resp.OrderId], time.Minute)
+		assert.Empty(t, f.inventory.released)
+		assert.Empty(t, f.payments.initiated)
+	})
+}
+
+func TestReviewOrder(t *testing.T) {
+	ctx := context.Background()
+	// inReview creates an order held in review
+	inReview := func(t *testing.T, publishes int) (*orderFixture, string) {
+		f := newOrderService(t, risk.Review, publishes)
+		resp, err := f.createOrder()
+		require.NoError(t, err)
+		delete(f.inventory.extended, resp.OrderId)
+		return f, resp.OrderId
+	}
+
+	t.Run("approval holds the stock for payment and starts it", func(t *testing.T) {
+		f, orderID := inReview(t, 1)
+		f.svc.PaymentHold = 6 * time.Hour
+		resp, err := f.svc.ReviewOrder(ctx, &orderpb.ReviewOrderRequest{OrderId: orderID, Reviewer: "alice", Approve: true, Note: "known customer"})
+		require.NoError(t, err)
+		assert.Equal(t, string(model.OrderPending), resp.Status)
+		assert.Equal(t, string(risk.Allow), resp.RiskDecision)
+		assert.Equal(t, "approved by alice: known customer", resp.RiskReasons[len(resp.RiskReasons)-1])
+
+		assert.WithinDuration(t, time.Now().Add(6*time.Hour), f.inventory.extended[orderID], time.Minute)
+		assert.Equal(t, []string{orderID}, f.payments.initiated)
+	})
+
+	t.Run("approval fails the order when its stock is gone", func(t *testing.T) {
+		f, orderID := inReview(t, 0)
+		f.inventory.extendErr = status.Error(codes.FailedPrecondition, "insufficient stock")
+		resp, err := f.svc.ReviewOrder(ctx, &orderpb.ReviewOrderRequest{OrderId: orderID, Reviewer: "alice", Approve: true})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, string(model.OrderFailed), resp.Status)
+		assert.Equal(t, model.OrderFailed, f.onlyOrder(t).Status)
+		assert.Empty(t, f.payments.initiated)
+	})
+
+	t.Run("rejection releases the stock", func(t *testing.T) {
+		f, orderID := inReview(t, 0)
+		resp, err := f.svc.ReviewOrder(ctx, &orderpb.ReviewOrderRequest{OrderId: orderID, Reviewer: "bob"})
+		require.NoError(t, err)
+		assert.Equal(t, string(model.OrderRejected), resp.Status)
+		assert.Equal(t, string(risk.Deny), f.onlyOrder(t).RiskDecision)
+		assert.Equal(t, "order rejected by bob", f.inventory.released[orderID])
+		assert.Empty(t, f.payments.initiated)
+
+		_, err = f.svc.ReviewOrder(ctx, &orderpb.ReviewOrderRequest{OrderId: orderID, Reviewer: "bob", Approve: true})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err), "a reviewed order cannot be reviewed again")
+	})
+
+	t.Run("a reviewer is required", func(t *testing.T) {
+		f, orderID := inReview(t, 0)
+		_, err := f.svc.ReviewOrder(ctx, &orderpb.ReviewOrderRequest{OrderId: orderID, Approve: true})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = f.svc.ReviewOrder(ctx, &orderpb.ReviewOrderRequest{OrderId: "missing", Reviewer: "alice"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestShipOrder(t *testing.T) {
+	ctx := context.Background()
+	// placed creates an allowed order whose payment reached status
+	placed := func(t *testing.T, orderStatus model.OrderStatus) (*orderFixture, string) {
+		f := newOrderService(t, risk.Allow, 1)
+		resp, err := f.createOrder()
+		require.NoError(t, err)
+		f.repo.orders[resp.OrderId].Status = orderStatus
+		return f, resp.OrderId
+	}
+
+	t.Run("shipping captures the authorization", func(t *testing.T) {
+		f, orderID := placed(t, model.OrderAuthorized)
+		resp, err := f.svc.ShipOrder(ctx, &orderpb.ShipOrderRequest{OrderId: orderID})
+		require.NoError(t, err)
+		assert.Equal(t, string(model.OrderPaid), resp.Status)
+		assert.Equal(t, "payment-"+orderID, resp.PaymentId)
+		assert.Equal(t, []string{"payment-" + orderID}, f.payments.captured)
+		assert.Equal(t, model.OrderPaid, f.onlyOrder(t).Status)
+	})
+
+	t.Run("a paid order has nothing to capture", func(t *testing.T) {
+		f, orderID := placed(t, model.OrderPaid)
+		resp, err := f.svc.ShipOrder(ctx, &orderpb.ShipOrderRequest{OrderId: orderID})
+		require.NoError(t, err)
+		assert.Equal(t, string(model.OrderPaid), resp.Status)
+		assert.Empty(t, f.payments.captured)
+	})
+
+	t.Run("an unpaid order cannot ship", func(t *testing.T) {
+		for _, orderStatus := range []model.OrderStatus{model.OrderPending, model.OrderFailed, model.OrderRefunded} {
+			f, orderID := placed(t, orderStatus)
+			_, err := f.svc.ShipOrder(ctx, &orderpb.ShipOrderRequest{OrderId: orderID})
+			assert.Equal(t, codes.FailedPrecondition, status.Code(err), orderStatus)
+			assert.Empty(t, f.payments.captured)
+		}
+	})
+
+	t.Run("an order waiting for backordered stock cannot ship", func(t *testing.T) {
+		f, orderID := placed(t, model.OrderAuthorized)
+		f.repo.orders[orderID].Items = []model.OrderItem{{ProductID: "product-1", Quantity: 2, BackorderedQuantity: 1}}
+		_, err := f.svc.ShipOrder(ctx, &orderpb.ShipOrderRequest{OrderId: orderID})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Empty(t, f.payments.captured)
+	})
+
+	t.Run("a failed capture leaves the order authorized", func(t *testing.T) {
+		f, orderID := placed(t, model.OrderAuthorized)
+		f.payments.captureErr = errors.New("authorization expired")
+		_, err := f.svc.ShipOrder(ctx, &orderpb.ShipOrderRequest{OrderId: orderID})
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, model.OrderAuthorized, f.onlyOrder(t).Status)
+	})
+
+	t.Run("automatic capture is passed on", func(t *testing.T) {
+		f := newOrderService(t, risk.Allow, 1)
+		f.svc.CaptureMethod = "automatic"
+		_, err := f.createOrder()
+		require.NoError(t, err)
+		assert.Equal(t, "automatic", f.payments.captureMethod)
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		f := newOrderService(t, risk.Allow, 0)
+		_, err := f.svc.ShipOrder(ctx, &orderpb.ShipOrderRequest{OrderId: "missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = f.svc.ShipOrder(ctx, &orderpb.ShipOrderRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestPaymentStatusHoldsStock(t *testing.T) {
+	ctx := context.Background()
+	f := newOrderService(t, risk.Allow, 1)
+	f.svc.PaymentHold = time.Hour
+	resp, err := f.createOrder()
+	require.NoError(t, err)
+
+	t.Run("a scheduled retry holds the stock past its time", func(t *testing.T) {
+		retryAt := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+		f.svc.HandlePaymentStatus(ctx, []byte(`{"order_id":"`+resp.OrderId+`","status":"RETRY_SCHEDULED","next_retry_at":"`+retryAt.Format(time.RFC3339)+`"}`))
+		assert.True(t, retryAt.Add(time.Hour).Equal(f.inventory.extended[resp.OrderId]))
+		assert.Equal(t, model.OrderPending, f.onlyOrder(t).Status)
+	})
+
+	t.Run("a payment awaiting the customer holds the stock from now", func(t *testing.T) {
+		f.svc.HandlePaymentStatus(ctx, []byte(`{"order_id":"`+resp.OrderId+`","status":"REQUIRES_ACTION"}`))
+		assert.WithinDuration(t, time.Now().Add(time.Hour), f.inventory.extended[resp.OrderId], time.Minute)
+	})
+}
+
+func TestPaymentStatusIgnoresStaleEvents(t *testing.T) {
+	ctx := context.Background()
+	event := func(orderID, paymentStatus string) []byte {
+		return []byte(`{"payment_id":"payment-` + orderID + `","order_id":"` + orderID + `","status":"` + paymentStatus + `"}`)
+	}
+
+	stale := []struct {
+		orderStatus   model.OrderStatus
+		paymentStatus string
+	}{
+		{model.OrderPaid, "AUTHORIZED"},
+		{model.OrderPaid, "FAILED"},
+		{model.OrderPartiallyRefunded, "CAPTURED"},
+		{model.OrderRefunded, "VOIDED"},
+		{model.OrderChargedBack, "PAID"},
+		{model.OrderFailed, "AUTHORIZED"},
+	}
+	for _, tc := range stale {
+		t.Run(tc.paymentStatus+" after "+string(tc.orderStatus), func(t *testing.T) {
+			f := newOrderService(t, risk.Allow, 1)
+			resp, err := f.createOrder()
+			require.NoError(t, err)
+			f.repo.orders[resp.OrderId].Status = tc.orderStatus
+			f.svc.HandlePaymentStatus(ctx, event(resp.OrderId, tc.paymentStatus))
+			assert.Equal(t, tc.orderStatus, f.onlyOrder(t).Status)
+		})
+	}
+
+	t.Run("payment events move a pending order forward", func(t *testing.T) {
+		f := newOrderService(t, risk.Allow, 1)
+		resp, err := f.createOrder()
+		require.NoError(t, err)
+		f.svc.HandlePaymentStatus(ctx, event(resp.OrderId, "AUTHORIZED"))
+		assert.Equal(t, model.OrderAuthorized, f.onlyOrder(t).Status)
+		f.svc.HandlePaymentStatus(ctx, event(resp.OrderId, "CAPTURED"))
+		assert.Equal(t, model.OrderPaid, f.onlyOrder(t).Status)
+		// a redelivered capture is harmless
+		f.svc.HandlePaymentStatus(ctx, event(resp.OrderId, "CAPTURED"))
+		assert.Equal(t, model.OrderPaid, f.onlyOrder(t).Status)
+	})
+}
+
+func TestCommitFailedGivesPaymentBack(t *testing.T) {
+	ctx := context.Background()
+	// placed creates an allowed order whose payment reached orderStatus
+	placed := func(t *testing.T, orderStatus model.OrderStatus) (*orderFixture, string) {
+		f := newOrderService(t, risk.Allow, 1)
+		resp, err := f.createOrder()
+		require.NoError(t, err)
+		f.repo.orders[resp.OrderId].Status = orderStatus
+		return f, resp.OrderId
+	}
+
+	t.Run("an authorized payment is voided and the order fails", func(t *testing.T) {
+		f, orderID := placed(t, model.OrderAuthorized)
+		f.svc.HandleCommitFailed(ctx, orderID, "payment-"+orderID)
+		assert.Equal(t, []string{"payment-" + orderID}, f.payments.voided)
+		assert.Empty(t, f.payments.refunded)
+		assert.Equal(t, model.OrderFailed, f.onlyOrder(t).Status)
+	})
+
+	t.Run("a captured payment is refunded", func(t *testing.T) {
+		f, orderID := placed(t, model.OrderPaid)
+		f.payments.captured = []string{"payment-" + orderID}
+		f.svc.HandleCommitFailed(ctx, orderID, "")
+		assert.Empty(t, f.payments.voided)
+		assert.Equal(t, map[string]string{"payment-" + orderID: "commit-failed-" + orderID}, f.payments.refunded)
+		// the refund's event marks the order refunded
+		assert.Equal(t, model.OrderPaid, f.onlyOrder(t).Status)
+	})
+
+	t.Run("an order that failed already is left alone", func(t *testing.T) {
+		f, orderID := placed(t, model.OrderFailed)
+		f.svc.HandleCommitFailed(ctx, orderID, "payment-"+orderID)
+		assert.Empty(t, f.payments.voided)
+		assert.Empty(t, f.payments.refunded)
+	})
+}
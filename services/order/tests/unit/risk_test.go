@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/services/order/risk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOrderHistory struct {
+	byUser map[string]int64
+	byIP   map[string]int64
+	err    error
+}
+
+func (h *fakeOrderHistory) CountByUserIDSince(ctx context.Context, userID string, since time.Time) (int64, error) {
+	return h.byUser[userID], h.err
+}
+
+func (h *fakeOrderHistory) CountByClientIPSince(ctx context.Context, clientIP string, since time.Time) (int64, error) {
+	return h.byIP[clientIP], h.err
+}
+
+func TestRuleEngineEvaluate(t *testing.T) {
+	cfg := risk.DefaultConfig()
+	oldAccount := time.Now().Add(-365 * 24 * time.Hour)
+
+	t.Run("ordinary order is allowed", func(t *testing.T) {
+		engine := risk.NewRuleEngine(cfg, &fakeOrderHistory{})
+		a, err := engine.Evaluate(context.Background(), risk.Input{
+			UserID:           "user-1",
+			ClientIP:         "10.0.0.1",
+			Amount:           49.99,
+			BillingCountry:   "RO",
+			ShippingCountry:  "RO",
+			AccountCreatedAt: oldAccount,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, risk.Allow, a.Decision)
+		assert.Empty(t, a.Reasons)
+	})
+
+	t.Run("country mismatch alone is allowed but scored", func(t *testing.T) {
+		engine := risk.NewRuleEngine(cfg, &fakeOrderHistory{})
+		a, err := engine.Evaluate(context.Background(), risk.Input{
+			UserID:           "user-1",
+			Amount:           20,
+			BillingCountry:   "ro",
+			ShippingCountry:  "DE",
+			AccountCreatedAt: oldAccount,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, risk.Allow, a.Decision)
+		assert.Equal(t, cfg.CountryScore, a.Score)
+		assert.Len(t, a.Reasons, 1)
+	})
+
+	t.Run("new account placing a large order goes to review", func(t *testing.T) {
+		engine := risk.NewRuleEngine(cfg, &fakeOrderHistory{})
+		a, err := engine.Evaluate(context.Background(), risk.Input{
+			UserID:           "user-2",
+			Amount:           cfg.NewAccountAmount + 1,
+			AccountCreatedAt: time.Now().Add(-time.Hour),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, risk.Review, a.Decision)
+	})
+
+	t.Run("user velocity goes to review", func(t *testing.T) {
+		engine := risk.NewRuleEngine(cfg, &fakeOrderHistory{byUser: map[string]int64{"user-3": cfg.MaxOrdersPerUser}})
+		a, err := engine.Evaluate(context.Background(), risk.Input{UserID: "user-3", Amount: 10})
+		require.NoError(t, err)
+		assert.Equal(t, risk.Review, a.Decision)
+	})
+
+	t.Run("extreme ip velocity is denied", func(t *testing.T) {
+		engine := risk.NewRuleEngine(cfg, &fakeOrderHistory{byIP: map[string]int64{"10.0.0.9": cfg.MaxOrdersPerIP * cfg.DenyVelocityFactor}})
+		a, err := engine.Evaluate(context.Background(), risk.Input{UserID: "user-4", ClientIP: "10.0.0.9", Amount: 10})
+		require.NoError(t, err)
+		assert.Equal(t, risk.Deny, a.Decision)
+	})
+
+	t.Run("amount above the deny threshold is denied", func(t *testing.T) {
+		engine := risk.NewRuleEngine(cfg, &fakeOrderHistory{})
+		a, err := engine.Evaluate(context.Background(), risk.Input{UserID: "user-5", Amount: cfg.DenyAmount, AccountCreatedAt: oldAccount})
+		require.NoError(t, err)
+		assert.Equal(t, risk.Deny, a.Decision)
+	})
+
+	t.Run("combined signals are denied", func(t *testing.T) {
+		engine := risk.NewRuleEngine(cfg, &fakeOrderHistory{})
+		a, err := engine.Evaluate(context.Background(), risk.Input{
+			UserID:           "user-6",
+			Amount:           cfg.ReviewAmount,
+			BillingCountry:   "US",
+			ShippingCountry:  "NG",
+			AccountCreatedAt: time.Now().Add(-time.Minute),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, risk.Deny, a.Decision)
+		assert.Len(t, a.Reasons, 3)
+	})
+
+	t.Run("history error is returned", func(t *testing.T) {
+		engine := risk.NewRuleEngine(cfg, &fakeOrderHistory{err: errors.New("db down")})
+		a, err := engine.Evaluate(context.Background(), risk.Input{UserID: "user-7", Amount: 10})
+		assert.Error(t, err)
+		assert.Nil(t, a)
+	})
+}
@@ -2,8 +2,13 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"github.com/SabinGhost19/go-micro-payment/proto/payment"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
 	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"time"
 )
 
@@ -17,27 +22,20 @@ func NewPaymentHandler(svc *service.PaymentService) *PaymentHandler {
 }
 
 func (h *PaymentHandler) InitiatePayment(ctx context.Context, req *paymentpb.InitiatePaymentRequest) (*paymentpb.PaymentResponse, error) {
-	p, err := h.svc.InitiatePayment(ctx, req.OrderId, req.UserId, req.Amount, req.Currency)
-	if err != nil {
-		return &paymentpb.PaymentResponse{
-			PaymentId: p.ID,
-			OrderId:   p.OrderID,
-			Status:    string(p.Status),
-			Provider:  p.Provider,
-			CreatedAt: p.CreatedAt.Format(time.RFC3339),
-			UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
-			Message:   p.Message,
-		}, err
-	}
-	return &paymentpb.PaymentResponse{
-		PaymentId: p.ID,
-		OrderId:   p.OrderID,
-		Status:    string(p.Status),
-		Provider:  p.Provider,
-		CreatedAt: p.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
-		Message:   p.Message,
-	}, nil
+	p, err := h.svc.InitiatePayment(ctx, service.InitiateRequest{
+		OrderID:         req.OrderId,
+		UserID:          req.UserId,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		Provider:        req.Provider,
+		CaptureMethod:   model.CaptureMethod(req.CaptureMethod),
+		PaymentMethodID: req.PaymentMethodId,
+		WalletAmount:    req.WalletAmount,
+	})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toPaymentResponse(p), nil
 }
 
 func (h *PaymentHandler) CheckPaymentStatus(ctx context.Context, req *paymentpb.CheckPaymentStatusRequest) (*paymentpb.PaymentResponse, error) {
@@ -45,13 +43,422 @@ func (h *PaymentHandler) CheckPaymentStatus(ctx context.Context, req *paymentpb.
 	if err != nil {
 		return nil, err
 	}
-	return &paymentpb.PaymentResponse{
-		PaymentId: p.ID,
-		OrderId:   p.OrderID,
-		Status:    string(p.Status),
-		Provider:  p.Provider,
-		CreatedAt: p.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
-		Message:   p.Message,
-	}, nil
+	return toPaymentResponse(p), nil
+}
+
+func (h *PaymentHandler) RefundPayment(ctx context.Context, req *paymentpb.RefundPaymentRequest) (*paymentpb.RefundResponse, error) {
+	r, p, err := h.svc.RefundPayment(ctx, req.PaymentId, req.Amount, req.Reason, req.IdempotencyKey)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toRefundResponse(r, p), nil
+}
+
+func (h *PaymentHandler) CapturePayment(ctx context.Context, req *paymentpb.CapturePaymentRequest) (*paymentpb.PaymentResponse, error) {
+	p, err := h.svc.CapturePayment(ctx, req.PaymentId, req.Amount)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toPaymentResponse(p), nil
+}
+
+func (h *PaymentHandler) VoidPayment(ctx context.Context, req *paymentpb.VoidPaymentRequest) (*paymentpb.PaymentResponse, error) {
+	p, err := h.svc.VoidPayment(ctx, req.PaymentId, req.Reason)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toPaymentResponse(p), nil
+}
+
+func (h *PaymentHandler) ConfirmPayment(ctx context.Context, req *paymentpb.ConfirmPaymentRequest) (*paymentpb.PaymentResponse, error) {
+	p, err := h.svc.ConfirmPayment(ctx, req.PaymentId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toPaymentResponse(p), nil
+}
+
+func (h *PaymentHandler) GetReconciliationReport(ctx context.Context, req *paymentpb.GetReconciliationReportRequest) (*paymentpb.ReconciliationReportResponse, error) {
+	report, err := h.svc.GetReconciliationReport(req.ReportId, req.Provider)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, "reconciliation report not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp := &paymentpb.ReconciliationReportResponse{
+		ReportId:          report.ID,
+		Provider:          report.Provider,
+		WindowStart:       report.WindowStart.Format(time.RFC3339),
+		WindowEnd:         report.WindowEnd.Format(time.RFC3339),
+		StartedAt:         report.StartedAt.Format(time.RFC3339),
+		FinishedAt:        report.FinishedAt.Format(time.RFC3339),
+		Matched:           int32(report.Matched),
+		MissingLocally:    int32(report.MissingLocally),
+		MissingAtProvider: int32(report.MissingAtProvider),
+		AmountMismatches:  int32(report.AmountMismatches),
+		StatusMismatches:  int32(report.StatusMismatches),
+		Fixed:             int32(report.Fixed),
+	}
+	for _, item := range report.Items {
+		resp.Items = append(resp.Items, &paymentpb.ReconciliationItem{
+			Kind:           string(item.Kind),
+			PaymentId:      item.PaymentID,
+			SessionId:      item.SessionID,
+			LocalStatus:    item.LocalStatus,
+			ProviderStatus: item.ProviderStatus,
+			LocalAmount:    item.LocalAmount,
+			ProviderAmount: item.ProviderAmount,
+			Fixed:          item.Fixed,
+			Note:           item.Note,
+		})
+	}
+	return resp, nil
+}
+
+func (h *PaymentHandler) GetSettlementReport(ctx context.Context, req *paymentpb.GetSettlementReportRequest) (*paymentpb.SettlementReportResponse, error) {
+	from, err := time.Parse(settlementDate, req.From)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "from must be a date such as 2024-05-01")
+	}
+	to, err := time.Parse(settlementDate, req.To)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "to must be a date such as 2024-05-31")
+	}
+	report, err := h.svc.GetSettlementReport(from, to, req.Provider, req.Currency)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	resp := &paymentpb.SettlementReportResponse{
+		From: report.From.Format(settlementDate),
+		To:   report.To.Format(settlementDate),
+	}
+	for _, l := range report.Days {
+		resp.Days = append(resp.Days, toSettlementLine(l))
+	}
+	for _, l := range report.Totals {
+		resp.Totals = append(resp.Totals, toSettlementLine(l))
+	}
+	return resp, nil
+}
+
+func (h *PaymentHandler) ListPayments(ctx context.Context, req *paymentpb.ListPaymentsRequest) (*paymentpb.ListPaymentsResponse, error) {
+	filter := repository.PaymentFilter{
+		OrderID:  req.OrderId,
+		UserID:   req.UserId,
+		Status:   model.PaymentStatus(req.Status),
+		Provider: req.Provider,
+	}
+	var err error
+	if filter.CreatedFrom, err = parseTime(req.CreatedFrom); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "created_from must be RFC3339")
+	}
+	if filter.CreatedTo, err = parseTime(req.CreatedTo); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "created_to must be RFC3339")
+	}
+	payments, next, err := h.svc.ListPayments(filter, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	resp := &paymentpb.ListPaymentsResponse{NextPageToken: next}
+	for i := range payments {
+		resp.Payments = append(resp.Payments, toPaymentResponse(&payments[i]))
+	}
+	return resp, nil
+}
+
+func (h *PaymentHandler) GetPaymentsForOrder(ctx context.Context, req *paymentpb.GetPaymentsForOrderRequest) (*paymentpb.OrderPaymentsResponse, error) {
+	if req.OrderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_id is required")
+	}
+	payments, err := h.svc.GetPaymentsForOrder(req.OrderId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	resp := &paymentpb.OrderPaymentsResponse{OrderId: req.OrderId}
+	for i := range payments {
+		p := &payments[i].Payment
+		op := &paymentpb.OrderPayment{Payment: toPaymentResponse(p)}
+		for _, a := range payments[i].Attempts {
+			attempt := &paymentpb.PaymentAttemptResponse{
+				Number:      int32(a.Number),
+				SessionId:   a.SessionID,
+				Status:      string(a.Status),
+				DeclineCode: a.DeclineCode,
+				Message:     a.Message,
+				CreatedAt:   a.CreatedAt.Format(time.RFC3339),
+			}
+			if a.FinishedAt != nil {
+				attempt.FinishedAt = a.FinishedAt.Format(time.RFC3339)
+			}
+			op.Attempts = append(op.Attempts, attempt)
+		}
+		for j := range p.Refunds {
+			op.Refunds = append(op.Refunds, toRefundResponse(&p.Refunds[j], p))
+		}
+		resp.Payments = append(resp.Payments, op)
+	}
+	return resp, nil
+}
+
+func (h *PaymentHandler) AttachPaymentMethod(ctx context.Context, req *paymentpb.AttachPaymentMethodRequest) (*paymentpb.PaymentMethodResponse, error) {
+	m, err := h.svc.AttachPaymentMethod(ctx, req.UserId, req.Provider, req.Token, req.MakeDefault)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toPaymentMethodResponse(m), nil
+}
+
+func (h *PaymentHandler) ListPaymentMethods(ctx context.Context, req *paymentpb.ListPaymentMethodsRequest) (*paymentpb.ListPaymentMethodsResponse, error) {
+	methods, err := h.svc.ListPaymentMethods(req.UserId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	resp := &paymentpb.ListPaymentMethodsResponse{}
+	for i := range methods {
+		resp.PaymentMethods = append(resp.PaymentMethods, toPaymentMethodResponse(&methods[i]))
+	}
+	return resp, nil
+}
+
+func (h *PaymentHandler) DetachPaymentMethod(ctx context.Context, req *paymentpb.DetachPaymentMethodRequest) (*paymentpb.DetachPaymentMethodResponse, error) {
+	if err := h.svc.DetachPaymentMethod(ctx, req.UserId, req.PaymentMethodId); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &paymentpb.DetachPaymentMethodResponse{PaymentMethodId: req.PaymentMethodId}, nil
+}
+
+func (h *PaymentHandler) SubmitDisputeEvidence(ctx context.Context, req *paymentpb.SubmitDisputeEvidenceRequest) (*paymentpb.DisputeResponse, error) {
+	files := make([]service.EvidenceFile, 0, len(req.Files))
+	for _, f := range req.Files {
+		files = append(files, service.EvidenceFile{Name: f.FileName, ContentType: f.ContentType, Content: f.Content})
+	}
+	d, err := h.svc.SubmitDisputeEvidence(ctx, req.DisputeId, req.Text, files)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toDisputeResponse(d), nil
+}
+
+func (h *PaymentHandler) GetDispute(ctx context.Context, req *paymentpb.GetDisputeRequest) (*paymentpb.DisputeResponse, error) {
+	d, err := h.svc.GetDispute(req.DisputeId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toDisputeResponse(d), nil
+}
+
+func (h *PaymentHandler) ListDisputes(ctx context.Context, req *paymentpb.ListDisputesRequest) (*paymentpb.ListDisputesResponse, error) {
+	disputes, err := h.svc.ListDisputes(req.PaymentId, model.DisputeStatus(req.Status))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	resp := &paymentpb.ListDisputesResponse{}
+	for i := range disputes {
+		resp.Disputes = append(resp.Disputes, toDisputeResponse(&disputes[i]))
+	}
+	return resp, nil
+}
+
+func (h *PaymentHandler) GetWalletBalance(ctx context.Context, req *paymentpb.GetWalletBalanceRequest) (*paymentpb.WalletBalanceResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	wallets, err := h.svc.GetWalletBalances(req.UserId, req.Currency)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	resp := &paymentpb.WalletBalanceResponse{UserId: req.UserId}
+	for _, w := range wallets {
+		balance := &paymentpb.WalletBalance{Currency: w.Currency, Balance: w.Balance}
+		if !w.UpdatedAt.IsZero() {
+			balance.UpdatedAt = w.UpdatedAt.Format(time.RFC3339)
+		}
+		resp.Balances = append(resp.Balances, balance)
+	}
+	return resp, nil
+}
+
+func (h *PaymentHandler) ListWalletTransactions(ctx context.Context, req *paymentpb.ListWalletTransactionsRequest) (*paymentpb.ListWalletTransactionsResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	txns, next, err := h.svc.ListWalletTransactions(req.UserId, req.Currency, int(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	resp := &paymentpb.ListWalletTransactionsResponse{NextPageToken: next}
+	for i := range txns {
+		resp.Transactions = append(resp.Transactions, toWalletTransactionResponse(&txns[i]))
+	}
+	return resp, nil
+}
+
+func (h *PaymentHandler) GrantWalletCredit(ctx context.Context, req *paymentpb.GrantWalletCreditRequest) (*paymentpb.WalletTransactionResponse, error) {
+	txn, err := h.svc.GrantCredit(ctx, req.UserId, req.Currency, req.Amount, model.WalletReason(req.Reason), req.Note, req.GrantedBy, req.IdempotencyKey)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toWalletTransactionResponse(txn), nil
+}
+
+// settlementDate is the layout of settlement report days
+const settlementDate = "2006-01-02"
+
+// parseTime parses an optional RFC3339 timestamp
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// toStatusError maps service and repository errors to gRPC status codes
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return status.Error(codes.NotFound, "payment not found")
+	case errors.Is(err, service.ErrPaymentMethodNotFound), errors.Is(err, service.ErrDisputeNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrIdempotencyKeyRequired), errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrInvalidPageToken),
+		errors.Is(err, service.ErrInvalidPaymentMethod), errors.Is(err, service.ErrRawCardData), errors.Is(err, service.ErrInvalidEvidence),
+		errors.Is(err, service.ErrInvalidDateRange), errors.Is(err, service.ErrInvalidCreditReason):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, repository.ErrIdempotencyKeyReused):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, repository.ErrNotRefundable), errors.Is(err, repository.ErrRefundExceedsPayment),
+		errors.Is(err, repository.ErrInvalidTransition), errors.Is(err, service.ErrNotCapturable), errors.Is(err, service.ErrNotVoidable),
+		errors.Is(err, service.ErrNotConfirmable), errors.Is(err, service.ErrDisputeClosed), errors.Is(err, repository.ErrInsufficientCredit):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return err
+}
+
+// toPaymentResponse converts a payment model to its protobuf representation
+func toPaymentResponse(p *model.Payment) *paymentpb.PaymentResponse {
+	resp := &paymentpb.PaymentResponse{
+		PaymentId:       p.ID,
+		OrderId:         p.OrderID,
+		Status:          string(p.Status),
+		Provider:        p.Provider,
+		CreatedAt:       p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       p.UpdatedAt.Format(time.RFC3339),
+		Message:         p.Message,
+		CheckoutUrl:     p.CheckoutURL,
+		RefundedAmount:  p.RefundedAmount,
+		Amount:          p.Amount,
+		CapturedAmount:  p.CapturedAmount,
+		CaptureMethod:   string(p.CaptureMethod),
+		Attempts:        int32(p.Attempts),
+		DeclineCode:     p.DeclineCode,
+		UserId:          p.UserID,
+		Currency:        p.Currency,
+		PaymentMethodId: p.PaymentMethodID,
+		FeeAmount:       p.FeeAmount,
+		NetAmount:       p.NetAmount,
+		WalletAmount:    p.WalletAmount,
+	}
+	if p.NextRetryAt != nil {
+		resp.NextRetryAt = p.NextRetryAt.Format(time.RFC3339)
+	}
+	if p.Status == model.PaymentRequiresAction && p.NextActionType != "" {
+		resp.NextAction = &paymentpb.NextAction{Type: p.NextActionType, RedirectUrl: p.NextActionURL, ClientSecret: p.ClientSecret}
+	}
+	return resp
+}
+
+// toRefundResponse converts a refund and its payment to the protobuf representation
+func toRefundResponse(r *model.Refund, p *model.Payment) *paymentpb.RefundResponse {
+	return &paymentpb.RefundResponse{
+		RefundId:       r.ID,
+		PaymentId:      r.PaymentID,
+		Amount:         r.Amount,
+		Currency:       r.Currency,
+		Status:         string(r.Status),
+		Reason:         r.Reason,
+		Message:        r.Message,
+		CreatedAt:      r.CreatedAt.Format(time.RFC3339),
+		PaymentStatus:  string(p.Status),
+		RefundedAmount: p.RefundedAmount,
+		FeeAmount:      r.FeeAmount,
+	}
+}
+
+// toPaymentMethodResponse converts a saved payment method to its protobuf representation
+func toPaymentMethodResponse(m *model.PaymentMethod) *paymentpb.PaymentMethodResponse {
+	return &paymentpb.PaymentMethodResponse{
+		PaymentMethodId: m.ID,
+		UserId:          m.UserID,
+		Provider:        m.Provider,
+		Brand:           m.Brand,
+		Last4:           m.Last4,
+		ExpMonth:        int32(m.ExpMonth),
+		ExpYear:         int32(m.ExpYear),
+		IsDefault:       m.IsDefault,
+		CreatedAt:       m.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// toDisputeResponse converts a dispute to its protobuf representation
+func toDisputeResponse(d *model.Dispute) *paymentpb.DisputeResponse {
+	resp := &paymentpb.DisputeResponse{
+		DisputeId:         d.ID,
+		PaymentId:         d.PaymentID,
+		Provider:          d.Provider,
+		ProviderDisputeId: d.ProviderDisputeID,
+		Reason:            d.Reason,
+		Amount:            d.Amount,
+		Currency:          d.Currency,
+		Status:            string(d.Status),
+		EvidenceText:      d.EvidenceText,
+		CreatedAt:         d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.EvidenceDueBy != nil {
+		resp.EvidenceDueBy = d.EvidenceDueBy.Format(time.RFC3339)
+	}
+	if d.EvidenceSubmittedAt != nil {
+		resp.EvidenceSubmittedAt = d.EvidenceSubmittedAt.Format(time.RFC3339)
+	}
+	if d.ClosedAt != nil {
+		resp.ClosedAt = d.ClosedAt.Format(time.RFC3339)
+	}
+	for _, e := range d.Evidence {
+		resp.EvidenceFiles = append(resp.EvidenceFiles, &paymentpb.EvidenceFileInfo{
+			FileName:    e.FileName,
+			ContentType: e.ContentType,
+			Size:        e.Size,
+			CreatedAt:   e.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return resp
+}
+
+// toSettlementLine converts a settlement line to its protobuf representation
+func toSettlementLine(l service.SettlementLine) *paymentpb.SettlementLine {
+	return &paymentpb.SettlementLine{
+		Date:        l.Date,
+		Currency:    l.Currency,
+		Gross:       l.Gross,
+		Fees:        l.Fees,
+		Refunds:     l.Refunds,
+		Net:         l.Net,
+		Captures:    int32(l.Captures),
+		RefundCount: int32(l.RefundCount),
+	}
+}
+
+// toWalletTransactionResponse converts a wallet transaction to its protobuf representation
+func toWalletTransactionResponse(t *model.WalletTransaction) *paymentpb.WalletTransactionResponse {
+	return &paymentpb.WalletTransactionResponse{
+		TransactionId: t.ID,
+		UserId:        t.UserID,
+		Currency:      t.Currency,
+		Amount:        t.Amount,
+		BalanceAfter:  t.BalanceAfter,
+		Reason:        string(t.Reason),
+		Note:          t.Note,
+		PaymentId:     t.PaymentID,
+		CreatedBy:     t.CreatedBy,
+		CreatedAt:     t.CreatedAt.Format(time.RFC3339),
+	}
 }
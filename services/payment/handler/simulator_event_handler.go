@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+	"io"
+	"log"
+	"net/http"
+)
+
+// SimulatorEventHandler lets developers trigger provider-side events of the
+// simulator, such as a customer disputing a payment, which real providers
+// would report by webhook. It must not be exposed in production.
+type SimulatorEventHandler struct {
+	svc *service.PaymentService
+	sim *provider.Simulator
+}
+
+func NewSimulatorEventHandler(svc *service.PaymentService, sim *provider.Simulator) *SimulatorEventHandler {
+	return &SimulatorEventHandler{svc: svc, sim: sim}
+}
+
+// simulatorEvent is the request body:
+//
+//	{"type": "dispute.created", "payment_id": "...", "reason": "fraudulent", "amount": 10}
+//	{"type": "dispute.closed", "provider_dispute_id": "sim_dp_...", "outcome": "lost"}
+type simulatorEvent struct {
+	Type      string  `json:"type"`
+	PaymentID string  `json:"payment_id"`
+	Reason    string  `json:"reason"`
+	Amount    float64 `json:"amount"` // 0 disputes the whole payment
+	DisputeID string  `json:"provider_dispute_id"`
+	Outcome   string  `json:"outcome"` // "won" or "lost"
+}
+
+// ServeHTTP makes the simulator raise the event and applies it like a webhook
+func (h *SimulatorEventHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusRequestEntityTooLarge)
+		return
+	}
+	var req simulatorEvent
+	if err := json.Unmarshal(payload, &req); err != nil {
+		http.Error(w, "invalid event payload", http.StatusBadRequest)
+		return
+	}
+
+	var update *provider.DisputeUpdate
+	switch req.Type {
+	case "dispute.created":
+		payment, err := h.svc.Repo.FindByID(req.PaymentID)
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "payment not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to load payment", http.StatusInternalServerError)
+			return
+		}
+		update, err = h.sim.OpenDispute(r.Context(), payment.StripeSessionID, req.Reason, req.Amount)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	case "dispute.closed":
+		if req.Outcome != provider.DisputeWon && req.Outcome != provider.DisputeLost {
+			http.Error(w, `outcome must be "won" or "lost"`, http.StatusBadRequest)
+			return
+		}
+		update, err = h.sim.CloseDispute(r.Context(), req.DisputeID, req.Outcome == provider.DisputeWon)
+		if errors.Is(err, provider.ErrNotFound) {
+			http.Error(w, "dispute not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	default:
+		http.Error(w, "unknown event type", http.StatusBadRequest)
+		return
+	}
+
+	evt := service.ProviderEvent{
+		// one event per dispute stage, so replaying a request is deduplicated
+		ID:              "sim_evt_" + update.ID + "_" + update.Status,
+		Provider:        provider.SimulatorName,
+		Type:            req.Type,
+		SessionID:       update.SessionID,
+		PaymentIntentID: update.PaymentIntentID,
+		PaymentID:       update.PaymentID,
+		Message:         "dispute " + update.Status,
+		Dispute:         update,
+	}
+	if err := h.svc.ApplyProviderEvent(r.Context(), evt); err != nil {
+		log.Printf("failed to apply simulator event %s: %v", evt.ID, err)
+		http.Error(w, "failed to process event", http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]string{"provider_dispute_id": update.ID, "status": update.Status}
+	if dispute, err := h.svc.Repo.FindDisputeByProviderID(update.ID); err == nil {
+		resp["dispute_id"] = dispute.ID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("failed to write simulator event response: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+	"github.com/stripe/stripe-go/v74"
+	"github.com/stripe/stripe-go/v74/webhook"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// maxWebhookBodyBytes bounds the size of a webhook payload
+const maxWebhookBodyBytes = 65536
+
+// StripeWebhookHandler receives Stripe webhooks and drives payment status
+type StripeWebhookHandler struct {
+	svc    *service.PaymentService
+	secret string
+}
+
+func NewStripeWebhookHandler(svc *service.PaymentService, secret string) *StripeWebhookHandler {
+	return &StripeWebhookHandler{svc: svc, secret: secret}
+}
+
+// ServeHTTP verifies the Stripe-Signature header and applies the event
+func (h *StripeWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	event, err := webhook.ConstructEventWithOptions(payload, r.Header.Get("Stripe-Signature"), h.secret, webhook.ConstructEventOptions{
+		IgnoreAPIVersionMismatch: true,
+	})
+	if err != nil {
+		log.Printf("rejected stripe webhook: %v", err)
+		http.Error(w, "invalid signature", http.StatusBadRequest)
+		return
+	}
+
+	evt, ok, err := toProviderEvent(event)
+	if err != nil {
+		log.Printf("failed to parse stripe %s event %s: %v", event.Type, event.ID, err)
+		http.Error(w, "invalid event payload", http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		// acknowledge event types we do not handle so Stripe stops sending them
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := h.svc.ApplyProviderEvent(r.Context(), evt); err != nil {
+		if errors.Is(err, service.ErrPaymentNotFound) {
+			// the payment may not be committed yet; let Stripe retry
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Printf("failed to apply stripe event %s: %v", event.ID, err)
+		http.Error(w, "failed to process event", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// toProviderEvent maps the Stripe event types we handle; ok is false for all others
+func toProviderEvent(event stripe.Event) (service.ProviderEvent, bool, error) {
+	evt := service.ProviderEvent{ID: event.ID, Provider: provider.StripeName, Type: string(event.Type)}
+
+	switch event.Type {
+	case "checkout.session.completed", "checkout.session.expired":
+		var cs stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &cs); err != nil {
+			return evt, false, err
+		}
+		evt.SessionID = cs.ID
+		evt.PaymentID = cs.ClientReferenceID
+		evt.Attempt, _ = strconv.Atoi(cs.Metadata["attempt"])
+		if cs.PaymentIntent != nil {
+			evt.PaymentIntentID = cs.PaymentIntent.ID
+		}
+		switch {
+		case event.Type == "checkout.session.expired":
+			evt.Status = model.PaymentExpired
+			evt.Message = "checkout session expired"
+		case cs.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid:
+			// asynchronous and manually captured payments complete the session before the money arrives
+			evt.Status = model.PaymentPaid
+			evt.Message = "checkout session completed"
+		}
+
+	case "payment_intent.succeeded", "payment_intent.payment_failed", "payment_intent.amount_capturable_updated",
+		"payment_intent.requires_action", "payment_intent.canceled":
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return evt, false, err
+		}
+		evt.PaymentIntentID = pi.ID
+		evt.PaymentID = pi.Metadata["payment_id"]
+		evt.Attempt, _ = strconv.Atoi(pi.Metadata["attempt"])
+		switch event.Type {
+		case "payment_intent.succeeded":
+			evt.Status = model.PaymentPaid
+			evt.Amount = float64(pi.AmountReceived) / 100
+			evt.Message = "payment intent succeeded"
+		case "payment_intent.payment_failed":
+			evt.Status = model.PaymentFailed
+			evt.Message = "payment failed"
+			if pi.LastPaymentError != nil {
+				evt.DeclineCode = provider.DeclineCodeOf(pi.LastPaymentError)
+				if pi.LastPaymentError.Msg != "" {
+					evt.Message = pi.LastPaymentError.Msg
+				}
+			}
+		case "payment_intent.amount_capturable_updated":
+			evt.Status = model.PaymentAuthorized
+			evt.Message = "payment authorized"
+		case "payment_intent.requires_action":
+			evt.Status = model.PaymentRequiresAction
+			evt.Message = "customer action required"
+			evt.NextAction = provider.NextActionOf(&pi)
+		case "payment_intent.canceled":
+			evt.Status = model.PaymentVoided
+			evt.Message = "payment intent canceled"
+		}
+
+	case "charge.refunded":
+		var ch stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &ch); err != nil {
+			return evt, false, err
+		}
+		if ch.PaymentIntent != nil {
+			evt.PaymentIntentID = ch.PaymentIntent.ID
+		}
+		evt.PaymentID = ch.Metadata["payment_id"]
+		evt.Message = "charge refunded"
+		// the refunds are not part of the charge unless expanded; the
+		// service then asks Stripe for them
+		evt.RefundsChanged = true
+		if ch.Refunds != nil {
+			for _, r := range ch.Refunds.Data {
+				evt.Refunds = append(evt.Refunds, *provider.FromStripeRefund(r))
+			}
+		}
+
+	case "refund.created", "refund.updated", "refund.failed", "charge.refund.updated":
+		var r stripe.Refund
+		if err := json.Unmarshal(event.Data.Raw, &r); err != nil {
+			return evt, false, err
+		}
+		if r.PaymentIntent != nil {
+			evt.PaymentIntentID = r.PaymentIntent.ID
+		}
+		evt.Refunds = []provider.Refund{*provider.FromStripeRefund(&r)}
+		evt.Message = "refund " + string(r.Status)
+
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed",
+		"charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated":
+		var d stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &d); err != nil {
+			return evt, false, err
+		}
+		evt.Dispute = provider.FromStripeDispute(&d)
+		evt.PaymentIntentID = evt.Dispute.PaymentIntentID
+		evt.PaymentID = evt.Dispute.PaymentID
+		evt.Message = "dispute " + evt.Dispute.Status
+
+	default:
+		return evt, false, nil
+	}
+	return evt, true, nil
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+type AttemptStatus string
+
+const (
+	AttemptPending   AttemptStatus = "PENDING"
+	AttemptSucceeded AttemptStatus = "SUCCEEDED"
+	AttemptFailed    AttemptStatus = "FAILED"
+)
+
+// PaymentAttempt is one try at charging a payment. The first attempt is made
+// when the payment is initiated; retries after soft declines add more, each
+// with its own provider session.
+type PaymentAttempt struct {
+	ID              uint          `gorm:"primaryKey;autoIncrement"`
+	PaymentID       string        `gorm:"uniqueIndex:idx_payment_attempt;not null"`
+	Number          int           `gorm:"uniqueIndex:idx_payment_attempt;not null"` // 1 for the initial attempt
+	SessionID       string        `gorm:"type:varchar(255);index"`
+	PaymentIntentID string        `gorm:"type:varchar(255)"`
+	Status          AttemptStatus `gorm:"type:varchar(20)"`
+	DeclineCode     string        `gorm:"type:varchar(50)"`
+	Message         string        `gorm:"type:text"`
+	CreatedAt       time.Time     `gorm:"autoCreateTime"`
+	FinishedAt      *time.Time    `gorm:"default:null"`
+}
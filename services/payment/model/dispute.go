@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+type DisputeStatus string
+
+const (
+	DisputeNeedsResponse DisputeStatus = "needs_response" // evidence is due by EvidenceDueBy
+	DisputeUnderReview   DisputeStatus = "under_review"   // evidence was submitted
+	DisputeWon           DisputeStatus = "won"
+	DisputeLost          DisputeStatus = "lost" // the disputed amount was charged back
+)
+
+// disputeStages orders dispute statuses; a dispute never moves back a stage
+var disputeStages = map[DisputeStatus]int{
+	DisputeNeedsResponse: 0,
+	DisputeUnderReview:   1,
+	DisputeWon:           2,
+	DisputeLost:          2,
+}
+
+// CanAdvanceDispute reports whether a dispute may move from one status to another
+func CanAdvanceDispute(from, to DisputeStatus) bool {
+	fromStage, ok := disputeStages[from]
+	toStage, ok2 := disputeStages[to]
+	return ok && ok2 && toStage > fromStage
+}
+
+// Closed reports whether the dispute was decided
+func (s DisputeStatus) Closed() bool {
+	return s == DisputeWon || s == DisputeLost
+}
+
+// Dispute is a chargeback or inquiry a customer raised with their bank
+// against a payment
+type Dispute struct {
+	ID                  string            `gorm:"primaryKey"`
+	PaymentID           string            `gorm:"index;not null"`
+	Provider            string            `gorm:"type:varchar(50)"`
+	ProviderDisputeID   string            `gorm:"type:varchar(255);uniqueIndex;not null"`
+	Reason              string            `gorm:"type:varchar(100)"`
+	Amount              float64           `gorm:"type:decimal(10,2)"`
+	Currency            string            `gorm:"type:varchar(3)"`
+	Status              DisputeStatus     `gorm:"type:varchar(20);index"`
+	EvidenceDueBy       *time.Time        `gorm:"default:null"`
+	EvidenceText        string            `gorm:"type:text"`
+	EvidenceSubmittedAt *time.Time        `gorm:"default:null"`
+	ClosedAt            *time.Time        `gorm:"default:null"`
+	CreatedAt           time.Time         `gorm:"autoCreateTime"`
+	UpdatedAt           time.Time         `gorm:"autoUpdateTime"`
+	Evidence            []DisputeEvidence `gorm:"foreignKey:DisputeID"`
+}
+
+// DisputeEvidence is a file submitted as evidence for a dispute. The content
+// is kept on local disk under the evidence directory, not in the database.
+type DisputeEvidence struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	DisputeID   string    `gorm:"index;not null"`
+	FileName    string    `gorm:"type:varchar(255)"`
+	ContentType string    `gorm:"type:varchar(100)"`
+	Size        int64     `gorm:"default:0"`
+	Path        string    `gorm:"type:text"` // relative to the evidence directory
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
@@ -1,13 +1,32 @@
 package model
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type PaymentStatus string
 
 const (
-	PaymentPending PaymentStatus = "PENDING"
-	PaymentPaid    PaymentStatus = "PAID"
-	PaymentFailed  PaymentStatus = "FAILED"
+	PaymentPending           PaymentStatus = "PENDING"
+	PaymentRequiresAction    PaymentStatus = "REQUIRES_ACTION" // the customer must complete an extra step
+	PaymentAuthorized        PaymentStatus = "AUTHORIZED"      // funds are held, waiting for capture
+	PaymentCaptured          PaymentStatus = "CAPTURED"        // an authorization was captured
+	PaymentPaid              PaymentStatus = "PAID"            // charged immediately with automatic capture
+	PaymentFailed            PaymentStatus = "FAILED"
+	PaymentRetryScheduled    PaymentStatus = "RETRY_SCHEDULED" // declined, another attempt is due at NextRetryAt
+	PaymentVoided            PaymentStatus = "VOIDED"          // cancelled before any money moved
+	PaymentExpired           PaymentStatus = "EXPIRED"         // abandoned checkout or lapsed authorization
+	PaymentPartiallyRefunded PaymentStatus = "PARTIALLY_REFUNDED"
+	PaymentRefunded          PaymentStatus = "REFUNDED"
+)
+
+// CaptureMethod controls whether a payment is charged at once or only authorized
+type CaptureMethod string
+
+const (
+	CaptureAutomatic CaptureMethod = "automatic"
+	CaptureManual    CaptureMethod = "manual"
 )
 
 type Payment struct {
@@ -15,11 +34,45 @@ type Payment struct {
 	OrderID         string        `gorm:"index"`
 	UserID          string        `gorm:"index"`
 	Amount          float64       `gorm:"type:decimal(10,2)"`
+	CapturedAmount  float64       `gorm:"type:decimal(10,2);default:0"`
+	RefundedAmount  float64       `gorm:"type:decimal(10,2);default:0"`
+	FeeAmount       float64       `gorm:"type:decimal(10,2);default:0"` // provider fee of the capture; refunds carry their own
+	NetAmount       float64       `gorm:"type:decimal(10,2);default:0"` // captured less refunds and all fees
+	WalletAmount    float64       `gorm:"type:decimal(10,2);default:0"` // store credit applied; the provider charges the rest
 	Currency        string        `gorm:"type:varchar(3)"`
-	StripeSessionID string        `gorm:"type:varchar(255)"`
+	CaptureMethod   CaptureMethod `gorm:"type:varchar(20);default:automatic"`
+	StripeSessionID string        `gorm:"type:varchar(255);index"` // provider session ID, whichever provider is used
+	PaymentIntentID string        `gorm:"type:varchar(255);index"`
+	CheckoutURL     string        `gorm:"type:text"`
 	Status          PaymentStatus `gorm:"type:varchar(20)"`
 	Provider        string        `gorm:"type:varchar(50)"`
-	CreatedAt       time.Time     `gorm:"autoCreateTime"`
+	CreatedAt       time.Time     `gorm:"autoCreateTime;index"`
 	UpdatedAt       time.Time     `gorm:"autoUpdateTime"`
+	CapturedAt      *time.Time    `gorm:"default:null"`
 	Message         string        `gorm:"type:text"`
+	Attempts        int           `gorm:"default:0"`          // tries made so far, including the current one
+	NextRetryAt     *time.Time    `gorm:"index;default:null"` // when the next attempt is due
+	DeclineCode     string        `gorm:"type:varchar(50)"`   // decline code of the last failed attempt
+	PaymentMethodID string        `gorm:"type:varchar(255)"`  // saved method charged off-session, if any
+	NextActionType  string        `gorm:"type:varchar(30)"`   // how the customer completes REQUIRES_ACTION
+	NextActionURL   string        `gorm:"type:text"`          // challenge page to redirect the customer to
+	ClientSecret    string        `gorm:"type:varchar(255)"`  // for completing the challenge with the provider SDK
+	SweepLeaseUntil *time.Time    `gorm:"default:null"`       // hides a claimed PENDING payment from other sweepers
+	Refunds         []Refund      `gorm:"foreignKey:PaymentID"`
+}
+
+// ProviderAmount is the part of the amount the provider charges, after the
+// store credit applied to the payment
+func (p *Payment) ProviderAmount() float64 {
+	return float64(math.Round(p.Amount*100)-math.Round(p.WalletAmount*100)) / 100
+}
+
+// RefundableBase is the amount refunds are limited to: what was captured, or
+// the provider's part for payments charged before captures were tracked.
+// Store credit applied to the payment is returned to the wallet instead.
+func (p *Payment) RefundableBase() float64 {
+	if p.CapturedAmount > 0 {
+		return p.CapturedAmount
+	}
+	return p.ProviderAmount()
 }
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// PaymentMethod is a tokenized payment method saved by a user for later
+// charges. Only the provider's token and display data are kept; card numbers
+// and security codes never reach the service.
+type PaymentMethod struct {
+	ID            string    `gorm:"primaryKey"`
+	UserID        string    `gorm:"index;not null"`
+	Provider      string    `gorm:"type:varchar(50);not null"`
+	ProviderToken string    `gorm:"type:varchar(255);uniqueIndex;not null"` // e.g. a Stripe PaymentMethod ID
+	CustomerID    string    `gorm:"type:varchar(255)"`                      // provider customer the token is attached to
+	Brand         string    `gorm:"type:varchar(30)"`
+	Last4         string    `gorm:"type:varchar(4)"`
+	ExpMonth      int       `gorm:"default:0"`
+	ExpYear       int       `gorm:"default:0"`
+	IsDefault     bool      `gorm:"default:false"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
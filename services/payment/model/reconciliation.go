@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// DiscrepancyKind classifies how a local payment and a provider transaction compare
+type DiscrepancyKind string
+
+const (
+	DiscrepancyMissingLocally    DiscrepancyKind = "MISSING_LOCALLY"     // the provider has a transaction we have no payment for
+	DiscrepancyMissingAtProvider DiscrepancyKind = "MISSING_AT_PROVIDER" // we have a payment the provider does not know
+	DiscrepancyAmountMismatch    DiscrepancyKind = "AMOUNT_MISMATCH"
+	DiscrepancyStatusMismatch    DiscrepancyKind = "STATUS_MISMATCH"
+)
+
+// ReconciliationReport summarizes one reconciliation run for a provider and time window
+type ReconciliationReport struct {
+	ID                string               `gorm:"primaryKey"`
+	Provider          string               `gorm:"type:varchar(50);index"`
+	WindowStart       time.Time            `gorm:"not null"`
+	WindowEnd         time.Time            `gorm:"not null"`
+	Matched           int                  `gorm:"not null;default:0"`
+	MissingLocally    int                  `gorm:"not null;default:0"`
+	MissingAtProvider int                  `gorm:"not null;default:0"`
+	AmountMismatches  int                  `gorm:"not null;default:0"`
+	StatusMismatches  int                  `gorm:"not null;default:0"`
+	Fixed             int                  `gorm:"not null;default:0"`
+	StartedAt         time.Time            `gorm:"not null"`
+	FinishedAt        time.Time            `gorm:"index"`
+	Items             []ReconciliationItem `gorm:"foreignKey:ReportID"`
+}
+
+// ReconciliationItem is one discrepancy found by a run; matched payments are only counted
+type ReconciliationItem struct {
+	ID             uint            `gorm:"primaryKey;autoIncrement"`
+	ReportID       string          `gorm:"index;not null"`
+	Kind           DiscrepancyKind `gorm:"type:varchar(30)"`
+	PaymentID      string          `gorm:"index"`
+	SessionID      string          `gorm:"type:varchar(255)"`
+	LocalStatus    string          `gorm:"type:varchar(20)"`
+	ProviderStatus string          `gorm:"type:varchar(20)"`
+	LocalAmount    float64         `gorm:"type:decimal(10,2)"`
+	ProviderAmount float64         `gorm:"type:decimal(10,2)"`
+	Fixed          bool            `gorm:"not null;default:false"` // corrected automatically
+	Note           string          `gorm:"type:text"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+type RefundStatus string
+
+const (
+	RefundPending   RefundStatus = "PENDING"
+	RefundSucceeded RefundStatus = "SUCCEEDED"
+	RefundFailed    RefundStatus = "FAILED"
+)
+
+// Refund is a full or partial refund of a payment. A payment can have several
+// refunds; pending and succeeded ones count against the refundable amount.
+type Refund struct {
+	ID               string       `gorm:"primaryKey"`
+	PaymentID        string       `gorm:"index;not null"`
+	IdempotencyKey   string       `gorm:"type:varchar(255);uniqueIndex;not null"`
+	Amount           float64      `gorm:"type:decimal(10,2)"`
+	FeeAmount        float64      `gorm:"type:decimal(10,2);default:0"` // provider fee of the refund, once it succeeded
+	Currency         string       `gorm:"type:varchar(3)"`
+	Reason           string       `gorm:"type:text"`
+	Status           RefundStatus `gorm:"type:varchar(20)"`
+	ProviderRefundID string       `gorm:"type:varchar(255)"`
+	Message          string       `gorm:"type:text"`
+	CreatedAt        time.Time    `gorm:"autoCreateTime"`
+	UpdatedAt        time.Time    `gorm:"autoUpdateTime"` // when the refund completed; refunds do not change afterwards
+}
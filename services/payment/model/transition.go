@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// PaymentTransition records one status change of a payment
+type PaymentTransition struct {
+	ID         uint          `gorm:"primaryKey;autoIncrement"`
+	PaymentID  string        `gorm:"index;not null"`
+	FromStatus PaymentStatus `gorm:"type:varchar(20)"` // empty for the initial status
+	ToStatus   PaymentStatus `gorm:"type:varchar(20);not null"`
+	Message    string        `gorm:"type:text"`
+	CreatedAt  time.Time     `gorm:"autoCreateTime"`
+}
+
+// transitions lists the statuses each status may move to. Statuses that are
+// missing, like FAILED or REFUNDED, are final.
+var transitions = map[PaymentStatus][]PaymentStatus{
+	PaymentPending:           {PaymentRequiresAction, PaymentAuthorized, PaymentPaid, PaymentFailed, PaymentRetryScheduled, PaymentVoided, PaymentExpired},
+	PaymentRequiresAction:    {PaymentAuthorized, PaymentPaid, PaymentFailed, PaymentRetryScheduled, PaymentVoided, PaymentExpired},
+	PaymentRetryScheduled:    {PaymentPending, PaymentFailed, PaymentVoided},
+	PaymentAuthorized:        {PaymentCaptured, PaymentVoided, PaymentExpired},
+	PaymentCaptured:          {PaymentPartiallyRefunded, PaymentRefunded},
+	PaymentPaid:              {PaymentPartiallyRefunded, PaymentRefunded},
+	PaymentPartiallyRefunded: {PaymentRefunded},
+}
+
+// CanTransition reports whether a payment may move from one status to another
+func CanTransition(from, to PaymentStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
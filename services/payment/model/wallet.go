@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// WalletReason says why store credit moved
+type WalletReason string
+
+const (
+	WalletReturn   WalletReason = "return"   // credit issued for returned goods instead of a cash refund
+	WalletGoodwill WalletReason = "goodwill" // credit granted as a courtesy
+	WalletPayment  WalletReason = "payment"  // credit spent on a payment
+	WalletRefund   WalletReason = "refund"   // a payment made with credit was refunded
+	WalletRelease  WalletReason = "release"  // credit applied to a payment that failed or was refunded is returned
+)
+
+// Granted reports whether admins may grant credit for the reason
+func (r WalletReason) Granted() bool {
+	return r == WalletReturn || r == WalletGoodwill
+}
+
+// Wallet is the store credit balance of a user in one currency. The balance
+// only changes together with a WalletTransaction and never goes negative.
+type Wallet struct {
+	ID        string    `gorm:"primaryKey"`
+	UserID    string    `gorm:"type:varchar(255);uniqueIndex:idx_wallet_owner;not null"`
+	Currency  string    `gorm:"type:varchar(3);uniqueIndex:idx_wallet_owner;not null"`
+	Balance   float64   `gorm:"type:decimal(10,2);default:0;check:balance >= 0"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// WalletTransaction is one movement of store credit; credits are positive and
+// debits negative. IdempotencyKey makes retried movements apply once.
+type WalletTransaction struct {
+	ID             string       `gorm:"primaryKey"`
+	WalletID       string       `gorm:"index;not null"`
+	UserID         string       `gorm:"type:varchar(255);index;not null"`
+	Currency       string       `gorm:"type:varchar(3);not null"`
+	Amount         float64      `gorm:"type:decimal(10,2);not null"`
+	BalanceAfter   float64      `gorm:"type:decimal(10,2)"`
+	Reason         WalletReason `gorm:"type:varchar(20);not null"`
+	Note           string       `gorm:"type:text"`
+	PaymentID      string       `gorm:"type:varchar(255);index"` // payment the credit was spent on or returned from
+	CreatedBy      string       `gorm:"type:varchar(255)"`       // admin who granted the credit
+	IdempotencyKey string       `gorm:"type:varchar(255);uniqueIndex;not null"`
+	CreatedAt      time.Time    `gorm:"autoCreateTime;index"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// WebhookEvent records a processed provider webhook so redeliveries are ignored
+type WebhookEvent struct {
+	ID          string    `gorm:"primaryKey;type:varchar(255)"` // provider event ID, e.g. evt_...
+	Provider    string    `gorm:"type:varchar(50)"`
+	Type        string    `gorm:"type:varchar(100)"`
+	PaymentID   string    `gorm:"index"`
+	ProcessedAt time.Time `gorm:"autoCreateTime"`
+}
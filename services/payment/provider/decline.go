@@ -0,0 +1,32 @@
+package provider
+
+import "github.com/stripe/stripe-go/v74"
+
+// decline codes used by the simulator; providers report Stripe's names
+const (
+	DeclineGeneric           = "generic_decline"
+	DeclineInsufficientFunds = "insufficient_funds"
+	DeclineLostCard          = "lost_card"
+	DeclineProcessingError   = "processing_error" // the attempt could not be started
+	// DeclineAuthenticationFailed is Stripe's code for a failed 3-D Secure challenge
+	DeclineAuthenticationFailed = "payment_intent_authentication_failure"
+)
+
+// retryableDeclines are soft declines that may succeed when the charge is tried
+// again later. Every other code, including an unknown or empty one, is terminal:
+// retrying a lost, stolen or fraudulent card only adds declines.
+var retryableDeclines = map[string]bool{
+	DeclineGeneric:                                 true,
+	DeclineInsufficientFunds:                       true,
+	string(stripe.DeclineCodeDoNotHonor):           true,
+	string(stripe.DeclineCodeTryAgainLater):        true,
+	string(stripe.DeclineCodeIssuerNotAvailable):   true,
+	DeclineProcessingError:                         true,
+	string(stripe.DeclineCodeReenterTransaction):   true,
+	string(stripe.DeclineCodeCardVelocityExceeded): true,
+}
+
+// IsRetryableDecline reports whether a payment declined with code may be retried
+func IsRetryableDecline(code string) bool {
+	return retryableDeclines[code]
+}
@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"context"
+	"time"
+)
+
+// dispute statuses, in the order a dispute moves through them
+const (
+	DisputeNeedsResponse = "needs_response" // evidence is due by EvidenceDueBy
+	DisputeUnderReview   = "under_review"   // evidence was submitted to the card network
+	DisputeWon           = "won"
+	DisputeLost          = "lost"
+)
+
+// DisputeUpdate is a provider-neutral snapshot of a dispute (chargeback) of a payment
+type DisputeUpdate struct {
+	ID              string // provider dispute ID
+	SessionID       string
+	PaymentIntentID string
+	PaymentID       string // from provider metadata, if present
+	Reason          string // e.g. "fraudulent" or "product_not_received"
+	Amount          float64
+	Currency        string
+	Status          string
+	EvidenceDueBy   time.Time
+}
+
+// DisputeResponder is implemented by providers that accept dispute evidence
+type DisputeResponder interface {
+	// SubmitDisputeEvidence sends the evidence text to the card network
+	SubmitDisputeEvidence(ctx context.Context, disputeID, text string) (*DisputeUpdate, error)
+}
@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the provider-side state of a payment session
+type Status string
+
+const (
+	StatusPending         Status = "pending"
+	StatusRequiresAction  Status = "requires_action"  // the customer must authenticate
+	StatusRequiresCapture Status = "requires_capture" // authorized, waiting for Capture
+	StatusSucceeded       Status = "succeeded"
+	StatusFailed          Status = "failed"
+	StatusCanceled        Status = "canceled"
+	StatusRefunded        Status = "refunded"
+)
+
+// next action types; the client either redirects the customer or completes the
+// challenge with the provider's SDK
+const (
+	NextActionRedirect        = "redirect_to_url"
+	NextActionUseClientSecret = "use_client_secret"
+)
+
+// NextAction tells the client how the customer completes authentication, such
+// as a 3-D Secure challenge
+type NextAction struct {
+	Type         string // NextActionRedirect or NextActionUseClientSecret
+	RedirectURL  string
+	ClientSecret string
+}
+
+// ErrNotFound is returned when the provider has no record of a session
+var ErrNotFound = errors.New("provider session not found")
+
+// SessionRequest describes a payment to collect
+type SessionRequest struct {
+	PaymentID string
+	OrderID   string
+	UserID    string
+	Amount    float64
+	Currency  string
+	// ManualCapture only authorizes the payment; it is charged by a later Capture
+	ManualCapture bool
+	// Attempt numbers the tries of one payment, starting at 1; each try gets its own session
+	Attempt int
+	// PaymentMethod is a saved method token (see MethodVault) to charge
+	// off-session instead of opening a hosted payment page
+	PaymentMethod string
+	// CustomerID is the provider customer PaymentMethod is attached to
+	CustomerID string
+}
+
+// Session is a snapshot of a provider payment session
+type Session struct {
+	ID              string // checkout session ID
+	PaymentIntentID string // set once the provider created the underlying intent
+	URL             string // hosted payment page, if any
+	Status          Status
+	Amount          float64
+	Message         string
+	DeclineCode     string      // why the payment failed, e.g. "insufficient_funds"
+	NextAction      *NextAction // set while Status is StatusRequiresAction
+}
+
+// Refund is the provider result of a refund request. Refunds can stay
+// StatusPending for days before they succeed or fail.
+type Refund struct {
+	ID       string
+	RefundID string // the idempotency key it was requested with; empty for refunds made at the provider
+	Amount   float64
+	Status   Status
+	Message  string // why it failed
+}
+
+// Transaction is a provider-side payment as listed for reconciliation
+type Transaction struct {
+	SessionID       string
+	PaymentIntentID string
+	PaymentID       string // from provider metadata, if present
+	Status          Status
+	DeclineCode     string
+	Amount          float64 // captured amount once money moved, otherwise the requested amount
+	Currency        string
+	CreatedAt       time.Time
+}
+
+// TransactionPage is one page of ListTransactions; NextCursor is empty on the last page
+type TransactionPage struct {
+	Transactions []Transaction
+	NextCursor   string
+}
+
+// PaymentProvider is implemented by every payment processor the service can use.
+// Every method except CreateSession takes the session ID returned by CreateSession.
+// Refund is retried safely: calls with the same idempotency key refund only once.
+type PaymentProvider interface {
+	Name() string
+	CreateSession(ctx context.Context, req SessionRequest) (*Session, error)
+	Capture(ctx context.Context, sessionID string, amount float64) (*Session, error)
+	Cancel(ctx context.Context, sessionID string) (*Session, error)
+	Refund(ctx context.Context, sessionID string, amount float64, reason, idempotencyKey string) (*Refund, error)
+	// FetchRefund returns the refund of a session requested with
+	// idempotencyKey, or ErrNotFound when the provider never received it
+	FetchRefund(ctx context.Context, sessionID, idempotencyKey string) (*Refund, error)
+	FetchStatus(ctx context.Context, sessionID string) (*Session, error)
+	// Confirm resumes a session once the customer completed its next action
+	Confirm(ctx context.Context, sessionID string) (*Session, error)
+	// ListTransactions pages through sessions created in [from, to); pass the
+	// previous NextCursor to get the following page
+	ListTransactions(ctx context.Context, from, to time.Time, cursor string, limit int) (*TransactionPage, error)
+}
+
+// RefundLister is implemented by providers that can list every refund of a
+// session, including refunds made outside this service
+type RefundLister interface {
+	ListRefunds(ctx context.Context, sessionID string) ([]Refund, error)
+}
+
+// Registry holds the configured providers and the default one
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]PaymentProvider
+	fallback  string
+}
+
+// NewRegistry creates a registry whose default provider is defaultName
+func NewRegistry(defaultName string) *Registry {
+	return &Registry{providers: make(map[string]PaymentProvider), fallback: defaultName}
+}
+
+// Register adds a provider under its own name
+func (r *Registry) Register(p PaymentProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[p.Name()] = p
+}
+
+// Get returns the named provider, or the default one when name is empty
+func (r *Registry) Get(name string) (PaymentProvider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name == "" {
+		name = r.fallback
+	}
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("payment provider %q is not configured (available: %v)", name, r.namesLocked())
+	}
+	return p, nil
+}
+
+// Names lists the registered providers
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.namesLocked()
+}
+
+func (r *Registry) namesLocked() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// toMinor converts a decimal amount to the smallest currency unit
+func toMinor(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// fromMinor converts an amount in the smallest currency unit to a decimal amount
+func fromMinor(amount int64) float64 {
+	return float64(amount) / 100
+}
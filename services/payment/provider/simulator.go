@@ -0,0 +1,446 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimulatorName is the registry name of the in-process simulator
+const SimulatorName = "simulator"
+
+// simulated outcomes keyed by the cents part of the amount, in the spirit of
+// Stripe's test cards. Every other amount succeeds.
+const (
+	SimulatorDeclineCents           = 2  // e.g. 10.02 is declined with generic_decline on every attempt
+	SimulatorLostCardCents          = 41 // declined with lost_card, which is never retried
+	SimulatorInsufficientFundsCents = 51 // declined with insufficient_funds on the first attempt only
+	SimulatorChallengeCents         = 20 // requires a 3-D Secure challenge that succeeds on Confirm
+	SimulatorChallengeFailCents     = 22 // requires a 3-D Secure challenge that fails on Confirm
+	// refunds are keyed by the cents part of the refunded amount
+	SimulatorRefundPendingCents = 7 // e.g. a refund of 5.07 stays pending until it is fetched
+)
+
+// simSession is the simulator's record of a session
+type simSession struct {
+	session   Session
+	paymentID string
+	currency  string
+	manual    bool // authorize only; see SessionRequest.ManualCapture
+	createdAt time.Time
+	refunded  float64
+	refunds   map[string]*Refund // by idempotency key
+}
+
+// Simulator is a deterministic in-process provider for development and tests.
+// The same payment ID and attempt always yield the same session ID, and the
+// outcome of a payment depends only on its amount and attempt.
+type Simulator struct {
+	mu       sync.Mutex
+	sessions map[string]*simSession
+	detached map[string]bool // payment method tokens that can no longer be charged
+	disputes map[string]*DisputeUpdate
+}
+
+// NewSimulator creates an empty simulator
+func NewSimulator() *Simulator {
+	return &Simulator{
+		sessions: make(map[string]*simSession),
+		detached: make(map[string]bool),
+		disputes: make(map[string]*DisputeUpdate),
+	}
+}
+
+// Name returns the registry name
+func (s *Simulator) Name() string {
+	return SimulatorName
+}
+
+// CreateSession records a new session; the simulated customer completes it
+// immediately, so the final outcome is visible through FetchStatus. Sessions
+// that need a challenge are returned as requiring action until Confirm.
+func (s *Simulator) CreateSession(ctx context.Context, req SessionRequest) (*Session, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("simulator: invalid amount %.2f", req.Amount)
+	}
+	id := simulatorID(req.PaymentID)
+	if req.Attempt > 1 {
+		id = simulatorID(fmt.Sprintf("%s#%d", req.PaymentID, req.Attempt))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.PaymentMethod != "" && s.detached[req.PaymentMethod] {
+		return nil, fmt.Errorf("simulator: payment method %s is detached", req.PaymentMethod)
+	}
+	if existing, ok := s.sessions[id]; ok {
+		out := existing.session
+		return &out, nil
+	}
+	sess := Session{
+		ID:              "sim_cs_" + id,
+		PaymentIntentID: "sim_pi_" + id,
+		URL:             "https://simulator.local/checkout/sim_cs_" + id,
+		Status:          StatusPending,
+		Amount:          req.Amount,
+		Message:         "simulated session created",
+	}
+	if req.PaymentMethod != "" {
+		// off-session charges have no hosted page
+		sess.URL = ""
+	}
+	rec := &simSession{
+		session:   sess,
+		paymentID: req.PaymentID,
+		currency:  req.Currency,
+		manual:    req.ManualCapture,
+		createdAt: time.Now(),
+		refunds:   make(map[string]*Refund),
+	}
+	rec.session.Status, rec.session.Message, rec.session.DeclineCode = simulatedOutcome(req.Amount, req.Attempt)
+	if req.ManualCapture && rec.session.Status == StatusSucceeded {
+		rec.session.Status, rec.session.Message = StatusRequiresCapture, "simulated authorization succeeded"
+	}
+	s.sessions[id] = rec
+	if rec.session.Status == StatusRequiresAction {
+		rec.session.NextAction = &NextAction{
+			Type:         NextActionRedirect,
+			RedirectURL:  "https://simulator.local/3ds/" + sess.ID,
+			ClientSecret: sess.PaymentIntentID + "_secret",
+		}
+		out := rec.session
+		return &out, nil
+	}
+	return &sess, nil
+}
+
+// Confirm completes the challenge of a session requiring action; other
+// sessions are returned as they are
+func (s *Simulator) Confirm(ctx context.Context, sessionID string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.lookup(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if rec.session.Status == StatusRequiresAction {
+		rec.session.NextAction = nil
+		switch {
+		case toMinor(rec.session.Amount)%100 == SimulatorChallengeFailCents:
+			rec.session.Status, rec.session.Message = StatusFailed, "simulated 3-D Secure challenge failed"
+			rec.session.DeclineCode = DeclineAuthenticationFailed
+		case rec.manual:
+			rec.session.Status, rec.session.Message = StatusRequiresCapture, "simulated authorization succeeded"
+		default:
+			rec.session.Status, rec.session.Message = StatusSucceeded, "simulated payment succeeded"
+		}
+	}
+	out := rec.session
+	return &out, nil
+}
+
+// Capture captures an authorized session, or less for a partial capture
+func (s *Simulator) Capture(ctx context.Context, sessionID string, amount float64) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.lookup(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if rec.session.Status != StatusRequiresCapture {
+		return nil, fmt.Errorf("simulator: cannot capture a %s session", rec.session.Status)
+	}
+	if amount > 0 && amount > rec.session.Amount {
+		return nil, fmt.Errorf("simulator: capture amount %.2f exceeds %.2f", amount, rec.session.Amount)
+	}
+	if amount > 0 {
+		rec.session.Amount = amount
+	}
+	rec.session.Status = StatusSucceeded
+	rec.session.Message = "simulated capture succeeded"
+	out := rec.session
+	return &out, nil
+}
+
+// Cancel cancels a session that has not been paid
+func (s *Simulator) Cancel(ctx context.Context, sessionID string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.lookup(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if rec.session.Status == StatusSucceeded || rec.session.Status == StatusRefunded {
+		return nil, fmt.Errorf("simulator: cannot cancel a %s session", rec.session.Status)
+	}
+	rec.session.Status = StatusCanceled
+	out := rec.session
+	return &out, nil
+}
+
+// Refund refunds part or all of a paid session
+func (s *Simulator) Refund(ctx context.Context, sessionID string, amount float64, reason, idempotencyKey string) (*Refund, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.lookup(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if prev, ok := rec.refunds[idempotencyKey]; ok && idempotencyKey != "" {
+		out := *prev
+		return &out, nil
+	}
+	if rec.session.Status != StatusSucceeded {
+		return nil, fmt.Errorf("simulator: cannot refund a %s session", rec.session.Status)
+	}
+	if toMinor(rec.refunded+amount) > toMinor(rec.session.Amount) {
+		return nil, fmt.Errorf("simulator: refund %.2f exceeds remaining %.2f", amount, rec.session.Amount-rec.refunded)
+	}
+	rec.refunded += amount
+	if toMinor(rec.refunded) == toMinor(rec.session.Amount) {
+		rec.session.Status = StatusRefunded
+	}
+	r := &Refund{
+		ID:       fmt.Sprintf("sim_re_%s_%d", rec.session.ID[len("sim_cs_"):], toMinor(rec.refunded)),
+		RefundID: idempotencyKey,
+		Amount:   amount,
+		Status:   StatusSucceeded,
+	}
+	if toMinor(amount)%100 == SimulatorRefundPendingCents {
+		r.Status = StatusPending
+	}
+	if idempotencyKey != "" {
+		rec.refunds[idempotencyKey] = r
+	}
+	out := *r
+	return &out, nil
+}
+
+// FetchRefund returns the refund requested with idempotencyKey; a pending
+// refund has succeeded by the time it is fetched
+func (s *Simulator) FetchRefund(ctx context.Context, sessionID, idempotencyKey string) (*Refund, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.lookup(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := rec.refunds[idempotencyKey]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	r.Status = StatusSucceeded
+	out := *r
+	return &out, nil
+}
+
+// FetchStatus returns the current state of a session
+func (s *Simulator) FetchStatus(ctx context.Context, sessionID string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.lookup(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	out := rec.session
+	return &out, nil
+}
+
+// ListTransactions lists sessions in creation order; the cursor is the last session ID returned
+func (s *Simulator) ListTransactions(ctx context.Context, from, to time.Time, cursor string, limit int) (*TransactionPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var recs []*simSession
+	for _, rec := range s.sessions {
+		if !rec.createdAt.Before(from) && rec.createdAt.Before(to) {
+			recs = append(recs, rec)
+		}
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].createdAt.Equal(recs[j].createdAt) {
+			return recs[i].session.ID < recs[j].session.ID
+		}
+		return recs[i].createdAt.Before(recs[j].createdAt)
+	})
+
+	start := 0
+	if cursor != "" {
+		for i, rec := range recs {
+			if rec.session.ID == cursor {
+				start = i + 1
+				break
+			}
+		}
+	}
+	page := &TransactionPage{}
+	for _, rec := range recs[start:] {
+		if len(page.Transactions) == limit {
+			page.NextCursor = page.Transactions[limit-1].SessionID
+			break
+		}
+		page.Transactions = append(page.Transactions, Transaction{
+			SessionID:       rec.session.ID,
+			PaymentIntentID: rec.session.PaymentIntentID,
+			PaymentID:       rec.paymentID,
+			Status:          rec.session.Status,
+			DeclineCode:     rec.session.DeclineCode,
+			Amount:          rec.session.Amount,
+			Currency:        rec.currency,
+			CreatedAt:       rec.createdAt,
+		})
+	}
+	return page, nil
+}
+
+// AttachMethod accepts tokens of the form sim_pm_<brand>_<last4>, e.g.
+// sim_pm_visa_4242; the simulated card expires at the end of the year after next
+func (s *Simulator) AttachMethod(ctx context.Context, customerID, userID, token string) (*MethodDetails, error) {
+	rest, ok := strings.CutPrefix(token, "sim_pm_")
+	brand, last4, ok2 := strings.Cut(rest, "_")
+	if !ok || !ok2 || brand == "" || len(last4) != 4 || strings.Trim(last4, "0123456789") != "" {
+		return nil, fmt.Errorf("%w: %q", ErrMethodNotSupported, token)
+	}
+	if customerID == "" {
+		customerID = "sim_cus_" + simulatorID(userID)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.detached, token)
+	return &MethodDetails{
+		Token:      token,
+		CustomerID: customerID,
+		Brand:      brand,
+		Last4:      last4,
+		ExpMonth:   12,
+		ExpYear:    time.Now().Year() + 2,
+	}, nil
+}
+
+// DetachMethod rejects later charges of the token
+func (s *Simulator) DetachMethod(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detached[token] = true
+	return nil
+}
+
+// simulatorEvidenceWindow is how long the merchant has to answer a simulated dispute
+const simulatorEvidenceWindow = 7 * 24 * time.Hour
+
+// OpenDispute simulates the customer disputing a paid session; amount 0
+// disputes the whole payment. A session is disputed at most once, so opening
+// it again returns the existing dispute.
+func (s *Simulator) OpenDispute(ctx context.Context, sessionID, reason string, amount float64) (*DisputeUpdate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, err := s.lookup(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	id := "sim_dp_" + simulatorID(sessionID+"#dispute")
+	if d, ok := s.disputes[id]; ok {
+		out := *d
+		return &out, nil
+	}
+	if rec.session.Status != StatusSucceeded && rec.session.Status != StatusRefunded {
+		return nil, fmt.Errorf("simulator: cannot dispute a %s session", rec.session.Status)
+	}
+	if amount <= 0 {
+		amount = rec.session.Amount
+	}
+	if toMinor(amount) > toMinor(rec.session.Amount) {
+		return nil, fmt.Errorf("simulator: dispute amount %.2f exceeds %.2f", amount, rec.session.Amount)
+	}
+	if reason == "" {
+		reason = "general"
+	}
+	d := &DisputeUpdate{
+		ID:              id,
+		SessionID:       rec.session.ID,
+		PaymentIntentID: rec.session.PaymentIntentID,
+		PaymentID:       rec.paymentID,
+		Reason:          reason,
+		Amount:          amount,
+		Currency:        rec.currency,
+		Status:          DisputeNeedsResponse,
+		EvidenceDueBy:   time.Now().Add(simulatorEvidenceWindow).Truncate(time.Second),
+	}
+	s.disputes[id] = d
+	out := *d
+	return &out, nil
+}
+
+// CloseDispute simulates the card network deciding a dispute
+func (s *Simulator) CloseDispute(ctx context.Context, disputeID string, won bool) (*DisputeUpdate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.disputes[disputeID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if d.Status != DisputeWon && d.Status != DisputeLost {
+		d.Status = DisputeLost
+		if won {
+			d.Status = DisputeWon
+		}
+	}
+	out := *d
+	return &out, nil
+}
+
+// SubmitDisputeEvidence puts a dispute that needs a response under review
+func (s *Simulator) SubmitDisputeEvidence(ctx context.Context, disputeID, text string) (*DisputeUpdate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.disputes[disputeID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if d.Status != DisputeNeedsResponse {
+		return nil, fmt.Errorf("simulator: cannot submit evidence for a %s dispute", d.Status)
+	}
+	d.Status = DisputeUnderReview
+	out := *d
+	return &out, nil
+}
+
+// lookup finds a session by its ID; the caller holds the lock
+func (s *Simulator) lookup(sessionID string) (*simSession, error) {
+	if len(sessionID) <= len("sim_cs_") {
+		return nil, ErrNotFound
+	}
+	rec, ok := s.sessions[sessionID[len("sim_cs_"):]]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return rec, nil
+}
+
+// simulatorID derives a stable identifier from the payment ID
+func simulatorID(paymentID string) string {
+	sum := sha256.Sum256([]byte(paymentID))
+	return hex.EncodeToString(sum[:12])
+}
+
+// simulatedOutcome decides the result of a payment attempt from its amount,
+// returning the status, a message and the decline code of failures
+func simulatedOutcome(amount float64, attempt int) (Status, string, string) {
+	switch toMinor(amount) % 100 {
+	case SimulatorDeclineCents:
+		return StatusFailed, "simulated card decline", DeclineGeneric
+	case SimulatorLostCardCents:
+		return StatusFailed, "simulated lost card", DeclineLostCard
+	case SimulatorInsufficientFundsCents:
+		if attempt <= 1 {
+			return StatusFailed, "simulated insufficient funds", DeclineInsufficientFunds
+		}
+	case SimulatorChallengeCents, SimulatorChallengeFailCents:
+		return StatusRequiresAction, "simulated 3-D Secure challenge required", ""
+	}
+	return StatusSucceeded, "simulated payment succeeded", ""
+}
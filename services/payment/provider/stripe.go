@@ -0,0 +1,564 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stripe/stripe-go/v74"
+	checkoutsession "github.com/stripe/stripe-go/v74/checkout/session"
+	"github.com/stripe/stripe-go/v74/customer"
+	"github.com/stripe/stripe-go/v74/dispute"
+	"github.com/stripe/stripe-go/v74/paymentintent"
+	"github.com/stripe/stripe-go/v74/paymentmethod"
+	"github.com/stripe/stripe-go/v74/refund"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StripeName is the registry name of the Stripe provider
+const StripeName = "stripe"
+
+// stripeIntentsCursor starts the listing of off-session payment intents,
+// which ListTransactions returns after the Checkout sessions
+const stripeIntentsCursor = "payment_intents"
+
+// Stripe collects payments through Stripe Checkout, or charges saved payment
+// methods off-session through payment intents. Off-session payments use the
+// payment intent ID as their session ID.
+type Stripe struct {
+	sessions   *checkoutsession.Client
+	intents    *paymentintent.Client
+	refunds    *refund.Client
+	customers  *customer.Client
+	methods    *paymentmethod.Client
+	disputes   *dispute.Client
+	successURL string
+	cancelURL  string
+}
+
+// NewStripe creates a Stripe provider using its own API key, so the key is only
+// required when Stripe is actually configured
+func NewStripe(apiKey, successURL, cancelURL string) (*Stripe, error) {
+	if apiKey == "" {
+		return nil, errors.New("stripe: STRIPE_API_KEY is required")
+	}
+	backend := stripe.GetBackend(stripe.APIBackend)
+	return &Stripe{
+		sessions:   &checkoutsession.Client{B: backend, Key: apiKey},
+		intents:    &paymentintent.Client{B: backend, Key: apiKey},
+		refunds:    &refund.Client{B: backend, Key: apiKey},
+		customers:  &customer.Client{B: backend, Key: apiKey},
+		methods:    &paymentmethod.Client{B: backend, Key: apiKey},
+		disputes:   &dispute.Client{B: backend, Key: apiKey},
+		successURL: successURL,
+		cancelURL:  cancelURL,
+	}, nil
+}
+
+// Name returns the registry name
+func (s *Stripe) Name() string {
+	return StripeName
+}
+
+// CreateSession opens a Checkout session for the payment, or charges the saved
+// payment method off-session when one is given
+func (s *Stripe) CreateSession(ctx context.Context, req SessionRequest) (*Session, error) {
+	if req.PaymentMethod != "" {
+		return s.chargeOffSession(ctx, req)
+	}
+	params := &stripe.CheckoutSessionParams{
+		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency:    stripe.String(strings.ToLower(req.Currency)),
+					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{Name: stripe.String("Order " + req.OrderID)},
+					UnitAmount:  stripe.Int64(toMinor(req.Amount)),
+				},
+				Quantity: stripe.Int64(1),
+			},
+		},
+		Mode:              stripe.String(string(stripe.CheckoutSessionModePayment)),
+		ClientReferenceID: stripe.String(req.PaymentID),
+		SuccessURL:        stripe.String(s.successURL),
+		CancelURL:         stripe.String(s.cancelURL),
+		PaymentIntentData: &stripe.CheckoutSessionPaymentIntentDataParams{
+			Metadata: map[string]string{"payment_id": req.PaymentID, "order_id": req.OrderID, "attempt": strconv.Itoa(req.Attempt)},
+		},
+	}
+	if req.ManualCapture {
+		params.PaymentIntentData.CaptureMethod = stripe.String(string(stripe.PaymentIntentCaptureMethodManual))
+	}
+	params.Context = ctx
+	params.AddMetadata("payment_id", req.PaymentID)
+	params.AddMetadata("order_id", req.OrderID)
+	params.AddMetadata("attempt", strconv.Itoa(req.Attempt))
+
+	cs, err := s.sessions.New(params)
+	if err != nil {
+		return nil, err
+	}
+	return fromCheckoutSession(cs), nil
+}
+
+// chargeOffSession confirms a payment intent for a saved payment method while
+// the customer is not present. Declines come back as a failed session rather
+// than an error, like for Checkout.
+func (s *Stripe) chargeOffSession(ctx context.Context, req SessionRequest) (*Session, error) {
+	params := &stripe.PaymentIntentParams{
+		Amount:        stripe.Int64(toMinor(req.Amount)),
+		Currency:      stripe.String(strings.ToLower(req.Currency)),
+		Customer:      stripe.String(req.CustomerID),
+		PaymentMethod: stripe.String(req.PaymentMethod),
+		Confirm:       stripe.Bool(true),
+		OffSession:    stripe.Bool(true),
+	}
+	if req.ManualCapture {
+		params.CaptureMethod = stripe.String(string(stripe.PaymentIntentCaptureMethodManual))
+	}
+	params.Context = ctx
+	params.SetIdempotencyKey(req.PaymentID + "#" + strconv.Itoa(req.Attempt))
+	params.AddMetadata("payment_id", req.PaymentID)
+	params.AddMetadata("order_id", req.OrderID)
+	params.AddMetadata("attempt", strconv.Itoa(req.Attempt))
+	// tells these intents from the ones Checkout creates when listing them
+	params.AddMetadata("off_session", "true")
+
+	pi, err := s.intents.New(params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if !errors.As(err, &stripeErr) || stripeErr.PaymentIntent == nil {
+			return nil, err
+		}
+		// card errors still create the intent; report it as declined
+		pi = stripeErr.PaymentIntent
+		if pi.LastPaymentError == nil {
+			pi.LastPaymentError = stripeErr
+		}
+	}
+	return fromCheckoutSession(intentSession(pi)), nil
+}
+
+// AttachMethod attaches a PaymentMethod created by Stripe.js to the user's
+// Stripe customer, creating the customer on first use
+func (s *Stripe) AttachMethod(ctx context.Context, customerID, userID, token string) (*MethodDetails, error) {
+	if !strings.HasPrefix(token, "pm_") {
+		return nil, fmt.Errorf("%w: expected a PaymentMethod ID", ErrMethodNotSupported)
+	}
+	if customerID == "" {
+		params := &stripe.CustomerParams{}
+		params.Context = ctx
+		params.AddMetadata("user_id", userID)
+		c, err := s.customers.New(params)
+		if err != nil {
+			return nil, err
+		}
+		customerID = c.ID
+	}
+	params := &stripe.PaymentMethodAttachParams{Customer: stripe.String(customerID)}
+	params.Context = ctx
+	pm, err := s.methods.Attach(token, params)
+	if err != nil {
+		return nil, err
+	}
+	if pm.Card == nil {
+		return nil, fmt.Errorf("%w: only cards can be saved", ErrMethodNotSupported)
+	}
+	return &MethodDetails{
+		Token:      pm.ID,
+		CustomerID: customerID,
+		Brand:      string(pm.Card.Brand),
+		Last4:      pm.Card.Last4,
+		ExpMonth:   int(pm.Card.ExpMonth),
+		ExpYear:    int(pm.Card.ExpYear),
+	}, nil
+}
+
+// DetachMethod detaches the PaymentMethod from its customer
+func (s *Stripe) DetachMethod(ctx context.Context, token string) error {
+	params := &stripe.PaymentMethodDetachParams{}
+	params.Context = ctx
+	_, err := s.methods.Detach(token, params)
+	return err
+}
+
+// Capture captures an authorized payment intent
+func (s *Stripe) Capture(ctx context.Context, sessionID string, amount float64) (*Session, error) {
+	cs, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if cs.PaymentIntent == nil {
+		return nil, errors.New("stripe: session has no payment intent to capture")
+	}
+	params := &stripe.PaymentIntentCaptureParams{}
+	params.Context = ctx
+	if amount > 0 {
+		params.AmountToCapture = stripe.Int64(toMinor(amount))
+	}
+	pi, err := s.intents.Capture(cs.PaymentIntent.ID, params)
+	if err != nil {
+		return nil, err
+	}
+	cs.PaymentIntent = pi
+	return fromCheckoutSession(cs), nil
+}
+
+// Cancel expires an open session or cancels its payment intent
+func (s *Stripe) Cancel(ctx context.Context, sessionID string) (*Session, error) {
+	cs, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if cs.Status == stripe.CheckoutSessionStatusOpen {
+		params := &stripe.CheckoutSessionExpireParams{}
+		params.Context = ctx
+		if cs, err = s.sessions.Expire(sessionID, params); err != nil {
+			return nil, err
+		}
+		return fromCheckoutSession(cs), nil
+	}
+	if cs.PaymentIntent == nil {
+		return fromCheckoutSession(cs), nil
+	}
+	params := &stripe.PaymentIntentCancelParams{}
+	params.Context = ctx
+	pi, err := s.intents.Cancel(cs.PaymentIntent.ID, params)
+	if err != nil {
+		return nil, err
+	}
+	cs.PaymentIntent = pi
+	return fromCheckoutSession(cs), nil
+}
+
+// Refund refunds part or all of a captured payment
+func (s *Stripe) Refund(ctx context.Context, sessionID string, amount float64, reason, idempotencyKey string) (*Refund, error) {
+	cs, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if cs.PaymentIntent == nil {
+		return nil, errors.New("stripe: session has no payment to refund")
+	}
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(cs.PaymentIntent.ID),
+		Amount:        stripe.Int64(toMinor(amount)),
+	}
+	params.Context = ctx
+	params.SetIdempotencyKey(idempotencyKey)
+	// the key is kept on the refund, so FetchRefund and webhooks can match it
+	// after Stripe forgot the idempotency key
+	params.AddMetadata("refund_id", idempotencyKey)
+	if reason != "" {
+		params.AddMetadata("reason", reason)
+	}
+	r, err := s.refunds.New(params)
+	if err != nil {
+		return nil, err
+	}
+	return FromStripeRefund(r), nil
+}
+
+// FetchRefund finds the refund requested with idempotencyKey among the
+// refunds of the session's payment intent
+func (s *Stripe) FetchRefund(ctx context.Context, sessionID, idempotencyKey string) (*Refund, error) {
+	refunds, err := s.ListRefunds(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range refunds {
+		if refunds[i].RefundID == idempotencyKey {
+			return &refunds[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListRefunds returns every refund of the session's payment intent, newest first
+func (s *Stripe) ListRefunds(ctx context.Context, sessionID string) ([]Refund, error) {
+	cs, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if cs.PaymentIntent == nil {
+		return nil, nil
+	}
+	params := &stripe.RefundListParams{PaymentIntent: stripe.String(cs.PaymentIntent.ID)}
+	params.Context = ctx
+	var refunds []Refund
+	it := s.refunds.List(params)
+	for it.Next() {
+		refunds = append(refunds, *FromStripeRefund(it.Refund()))
+	}
+	return refunds, it.Err()
+}
+
+// FromStripeRefund maps a Stripe refund to the provider-neutral result
+func FromStripeRefund(r *stripe.Refund) *Refund {
+	out := &Refund{ID: r.ID, RefundID: r.Metadata["refund_id"], Amount: fromMinor(r.Amount), Status: StatusPending}
+	switch r.Status {
+	case stripe.RefundStatusSucceeded:
+		out.Status = StatusSucceeded
+	case stripe.RefundStatusFailed, stripe.RefundStatusCanceled:
+		out.Status = StatusFailed
+		out.Message = "refund " + string(r.Status)
+		if r.FailureReason != "" {
+			out.Message += ": " + string(r.FailureReason)
+		}
+	}
+	return out
+}
+
+// Confirm confirms the payment intent again after the customer completed
+// authentication; intents that moved on by themselves are returned as they are
+func (s *Stripe) Confirm(ctx context.Context, sessionID string) (*Session, error) {
+	cs, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if cs.PaymentIntent == nil || cs.PaymentIntent.Status != stripe.PaymentIntentStatusRequiresConfirmation {
+		return fromCheckoutSession(cs), nil
+	}
+	params := &stripe.PaymentIntentConfirmParams{}
+	params.Context = ctx
+	pi, err := s.intents.Confirm(cs.PaymentIntent.ID, params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if !errors.As(err, &stripeErr) || stripeErr.PaymentIntent == nil {
+			return nil, err
+		}
+		pi = stripeErr.PaymentIntent
+	}
+	cs.PaymentIntent = pi
+	return fromCheckoutSession(cs), nil
+}
+
+// FetchStatus retrieves the current state of a session
+func (s *Stripe) FetchStatus(ctx context.Context, sessionID string) (*Session, error) {
+	cs, err := s.getSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return fromCheckoutSession(cs), nil
+}
+
+// ListTransactions lists Checkout sessions, then the payment intents of
+// off-session charges, each newest first as Stripe returns them
+func (s *Stripe) ListTransactions(ctx context.Context, from, to time.Time, cursor string, limit int) (*TransactionPage, error) {
+	if cursor == stripeIntentsCursor || strings.HasPrefix(cursor, "pi_") {
+		return s.listIntents(ctx, from, to, strings.TrimPrefix(cursor, stripeIntentsCursor), limit)
+	}
+	params := &stripe.CheckoutSessionListParams{}
+	params.Context = ctx
+	params.Single = true
+	params.Limit = stripe.Int64(int64(limit))
+	params.Filters.AddFilter("created", "gte", strconv.FormatInt(from.Unix(), 10))
+	params.Filters.AddFilter("created", "lt", strconv.FormatInt(to.Unix(), 10))
+	params.AddExpand("data.payment_intent")
+	if cursor != "" {
+		params.StartingAfter = stripe.String(cursor)
+	}
+
+	page := &TransactionPage{}
+	it := s.sessions.List(params)
+	for it.Next() {
+		cs := it.CheckoutSession()
+		sess := fromCheckoutSession(cs)
+		page.Transactions = append(page.Transactions, Transaction{
+			SessionID:       sess.ID,
+			PaymentIntentID: sess.PaymentIntentID,
+			PaymentID:       cs.ClientReferenceID,
+			Status:          sess.Status,
+			DeclineCode:     sess.DeclineCode,
+			Amount:          sess.Amount,
+			Currency:        strings.ToUpper(string(cs.Currency)),
+			CreatedAt:       time.Unix(cs.Created, 0),
+		})
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	page.NextCursor = stripeIntentsCursor
+	if it.Meta().HasMore && len(page.Transactions) > 0 {
+		page.NextCursor = page.Transactions[len(page.Transactions)-1].SessionID
+	}
+	return page, nil
+}
+
+// listIntents lists the payment intents of off-session charges after cursor;
+// intents created by Checkout are skipped, their sessions were listed already
+func (s *Stripe) listIntents(ctx context.Context, from, to time.Time, cursor string, limit int) (*TransactionPage, error) {
+	params := &stripe.PaymentIntentListParams{}
+	params.Context = ctx
+	params.Single = true
+	params.Limit = stripe.Int64(int64(limit))
+	params.Filters.AddFilter("created", "gte", strconv.FormatInt(from.Unix(), 10))
+	params.Filters.AddFilter("created", "lt", strconv.FormatInt(to.Unix(), 10))
+	if cursor != "" {
+		params.StartingAfter = stripe.String(cursor)
+	}
+
+	page := &TransactionPage{}
+	last := ""
+	it := s.intents.List(params)
+	for it.Next() {
+		pi := it.PaymentIntent()
+		last = pi.ID
+		if pi.Metadata["off_session"] != "true" {
+			continue
+		}
+		sess := fromCheckoutSession(intentSession(pi))
+		page.Transactions = append(page.Transactions, Transaction{
+			SessionID:       sess.ID,
+			PaymentIntentID: sess.PaymentIntentID,
+			PaymentID:       pi.Metadata["payment_id"],
+			Status:          sess.Status,
+			DeclineCode:     sess.DeclineCode,
+			Amount:          sess.Amount,
+			Currency:        strings.ToUpper(string(pi.Currency)),
+			CreatedAt:       time.Unix(pi.Created, 0),
+		})
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	if it.Meta().HasMore && last != "" {
+		page.NextCursor = last
+	}
+	return page, nil
+}
+
+// SubmitDisputeEvidence submits the evidence text of a dispute to the bank
+func (s *Stripe) SubmitDisputeEvidence(ctx context.Context, disputeID, text string) (*DisputeUpdate, error) {
+	params := &stripe.DisputeParams{
+		Evidence: &stripe.DisputeEvidenceParams{UncategorizedText: stripe.String(text)},
+		Submit:   stripe.Bool(true),
+	}
+	params.Context = ctx
+	d, err := s.disputes.Update(disputeID, params)
+	if err != nil {
+		return nil, err
+	}
+	return FromStripeDispute(d), nil
+}
+
+// FromStripeDispute maps a Stripe dispute to the provider-neutral snapshot.
+// Inquiries (warning_* statuses) are treated like disputes, and a dispute
+// closed by refunding the charge counts as won: the refund already returned
+// the money.
+func FromStripeDispute(d *stripe.Dispute) *DisputeUpdate {
+	out := &DisputeUpdate{
+		ID:       d.ID,
+		Reason:   string(d.Reason),
+		Amount:   fromMinor(d.Amount),
+		Currency: strings.ToUpper(string(d.Currency)),
+		Status:   DisputeNeedsResponse,
+	}
+	switch d.Status {
+	case stripe.DisputeStatusUnderReview, stripe.DisputeStatusWarningUnderReview:
+		out.Status = DisputeUnderReview
+	case stripe.DisputeStatusWon, stripe.DisputeStatusWarningClosed, stripe.DisputeStatusChargeRefunded:
+		out.Status = DisputeWon
+	case stripe.DisputeStatusLost:
+		out.Status = DisputeLost
+	}
+	if d.PaymentIntent != nil {
+		out.PaymentIntentID = d.PaymentIntent.ID
+		out.PaymentID = d.PaymentIntent.Metadata["payment_id"]
+	}
+	if d.EvidenceDetails != nil && d.EvidenceDetails.DueBy > 0 {
+		out.EvidenceDueBy = time.Unix(d.EvidenceDetails.DueBy, 0)
+	}
+	return out
+}
+
+// getSession loads a session together with its payment intent; off-session
+// payment intents are wrapped in a session of their own
+func (s *Stripe) getSession(ctx context.Context, sessionID string) (*stripe.CheckoutSession, error) {
+	var cs *stripe.CheckoutSession
+	var err error
+	if strings.HasPrefix(sessionID, "pi_") {
+		params := &stripe.PaymentIntentParams{}
+		params.Context = ctx
+		var pi *stripe.PaymentIntent
+		if pi, err = s.intents.Get(sessionID, params); err == nil {
+			cs = intentSession(pi)
+		}
+	} else {
+		params := &stripe.CheckoutSessionParams{}
+		params.Context = ctx
+		params.AddExpand("payment_intent")
+		cs, err = s.sessions.Get(sessionID, params)
+	}
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.HTTPStatusCode == 404 {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return cs, nil
+}
+
+// intentSession presents an off-session payment intent as a session; its
+// status is taken from the intent alone
+func intentSession(pi *stripe.PaymentIntent) *stripe.CheckoutSession {
+	return &stripe.CheckoutSession{ID: pi.ID, AmountTotal: pi.Amount, PaymentIntent: pi}
+}
+
+// fromCheckoutSession maps a Stripe session to the provider-neutral snapshot
+func fromCheckoutSession(cs *stripe.CheckoutSession) *Session {
+	out := &Session{ID: cs.ID, URL: cs.URL, Amount: fromMinor(cs.AmountTotal), Status: StatusPending}
+	switch cs.Status {
+	case stripe.CheckoutSessionStatusExpired:
+		out.Status = StatusCanceled
+	case stripe.CheckoutSessionStatusComplete:
+		if cs.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid {
+			out.Status = StatusSucceeded
+		}
+	}
+	if pi := cs.PaymentIntent; pi != nil {
+		out.PaymentIntentID = pi.ID
+		if pi.AmountReceived > 0 {
+			out.Amount = fromMinor(pi.AmountReceived)
+		}
+		switch pi.Status {
+		case stripe.PaymentIntentStatusSucceeded:
+			out.Status = StatusSucceeded
+		case stripe.PaymentIntentStatusRequiresCapture:
+			out.Status = StatusRequiresCapture
+		case stripe.PaymentIntentStatusRequiresAction:
+			out.Status = StatusRequiresAction
+			out.NextAction = NextActionOf(pi)
+		case stripe.PaymentIntentStatusCanceled:
+			out.Status = StatusCanceled
+		}
+		if pi.LastPaymentError != nil {
+			out.Message = pi.LastPaymentError.Msg
+			out.DeclineCode = DeclineCodeOf(pi.LastPaymentError)
+			if pi.Status == stripe.PaymentIntentStatusRequiresPaymentMethod {
+				out.Status = StatusFailed
+			}
+		}
+	}
+	return out
+}
+
+// NextActionOf tells the client how to authenticate a payment intent: by
+// redirecting the customer, or with Stripe.js and the client secret
+func NextActionOf(pi *stripe.PaymentIntent) *NextAction {
+	if pi.NextAction != nil && pi.NextAction.RedirectToURL != nil {
+		return &NextAction{Type: NextActionRedirect, RedirectURL: pi.NextAction.RedirectToURL.URL, ClientSecret: pi.ClientSecret}
+	}
+	return &NextAction{Type: NextActionUseClientSecret, ClientSecret: pi.ClientSecret}
+}
+
+// DeclineCodeOf returns the issuer's decline code of a payment error, or the
+// Stripe error code when the issuer gave none
+func DeclineCodeOf(err *stripe.Error) string {
+	if err.DeclineCode != "" {
+		return string(err.DeclineCode)
+	}
+	return string(err.Code)
+}
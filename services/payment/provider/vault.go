@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMethodNotSupported is returned for payment method tokens a provider cannot use
+var ErrMethodNotSupported = errors.New("payment method is not supported by the provider")
+
+// MethodDetails describes a tokenized payment method kept by a provider. Only
+// display data is exposed; card numbers never leave the provider.
+type MethodDetails struct {
+	Token      string // provider reference used to charge the method
+	CustomerID string // provider customer the method is attached to, if any
+	Brand      string // e.g. "visa"
+	Last4      string
+	ExpMonth   int
+	ExpYear    int
+}
+
+// MethodVault is implemented by providers that can keep payment methods for
+// later off-session charges
+type MethodVault interface {
+	// AttachMethod attaches a token created client-side to the customer, or to a
+	// new customer when customerID is empty, and returns its details
+	AttachMethod(ctx context.Context, customerID, userID, token string) (*MethodDetails, error)
+	// DetachMethod makes the token unusable for further charges
+	DetachMethod(ctx context.Context, token string) error
+}
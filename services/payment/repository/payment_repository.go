@@ -2,15 +2,110 @@ package repository
 
 import (
 	"errors"
+	"fmt"
 	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
 	"time"
 )
 
+var (
+	// ErrNotFound is returned when a payment does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrNotRefundable is returned when the payment status does not allow refunds
+	ErrNotRefundable = errors.New("payment cannot be refunded in its current status")
+	// ErrRefundExceedsPayment is returned when a refund is larger than the refundable remainder
+	ErrRefundExceedsPayment = errors.New("refund exceeds the refundable amount")
+	// ErrIdempotencyKeyReused is returned when a refund key was already used for another payment
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different payment")
+	// ErrInvalidTransition is returned when a status change is not allowed by model.CanTransition
+	ErrInvalidTransition = errors.New("invalid payment status transition")
+	// ErrEvidenceNotAccepted is returned when a dispute no longer accepts evidence
+	ErrEvidenceNotAccepted = errors.New("dispute does not accept evidence in its current status")
+	// ErrInsufficientCredit is returned when a debit would make a wallet balance negative
+	ErrInsufficientCredit = errors.New("insufficient store credit")
+)
+
+// PaymentFilter selects payments; empty fields match every payment
+type PaymentFilter struct {
+	OrderID     string
+	UserID      string
+	Status      model.PaymentStatus
+	Provider    string
+	CreatedFrom time.Time // inclusive
+	CreatedTo   time.Time // exclusive
+}
+
+// PageCursor is the position after the last payment of a page, in the
+// newest-first order used by List
+type PageCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// SettlementRow sums the captures or the succeeded refunds of one UTC day in
+// one currency
+type SettlementRow struct {
+	Day      string // e.g. "2024-05-31"
+	Currency string
+	Amount   float64
+	Fees     float64
+	Count    int
+}
+
+// WalletFilter selects wallet transactions; empty fields match every transaction
+type WalletFilter struct {
+	UserID      string
+	Currency    string
+	Reason      model.WalletReason
+	CreatedFrom time.Time // inclusive
+	CreatedTo   time.Time // exclusive
+}
+
 type PaymentRepository interface {
 	Save(payment *model.Payment) error
-	UpdateStatus(paymentID string, status model.PaymentStatus, message string) error
+	Transition(paymentID string, to model.PaymentStatus, message string, fields map[string]interface{}) (*model.Payment, error)
 	FindByID(paymentID string) (*model.Payment, error)
+	FindByStripeSessionID(sessionID string) (*model.Payment, error)
+	FindByPaymentIntentID(intentID string) (*model.Payment, error)
+	List(filter PaymentFilter, after *PageCursor, limit int) ([]model.Payment, error)
+	ListByOrderWithRefunds(orderID string) ([]model.Payment, error)
+	SetPaymentIntentID(paymentID, intentID string) error
+	IsWebhookEventProcessed(eventID string) (bool, error)
+	SaveWebhookEvent(event *model.WebhookEvent) error
+	CreateRefund(refund *model.Refund) (*model.Refund, error)
+	CompleteRefund(refundID string, status model.RefundStatus, providerRefundID, message string, fee float64) (*model.Refund, *model.Payment, error)
+	UpdatePendingRefund(refundID, providerRefundID, message string) error
+	FindRefund(refundID string) (*model.Refund, error)
+	ListPendingRefunds(createdBefore time.Time, limit int) ([]model.Refund, error)
+	ListTransitions(paymentID string) ([]model.PaymentTransition, error)
+	ListByProviderCreatedBetween(provider string, from, to time.Time) ([]model.Payment, error)
+	SettlementCaptures(from, to time.Time, provider string) ([]SettlementRow, error)
+	SettlementRefunds(from, to time.Time, provider string) ([]SettlementRow, error)
+	SaveReconciliationReport(report *model.ReconciliationReport) error
+	FindReconciliationReport(reportID string) (*model.ReconciliationReport, error)
+	LatestReconciliationReport(provider string) (*model.ReconciliationReport, error)
+	SaveAttempt(attempt *model.PaymentAttempt) error
+	FinishAttempt(paymentID string, number int, status model.AttemptStatus, declineCode, message string) error
+	ListAttempts(paymentID string) ([]model.PaymentAttempt, error)
+	FindAttemptBySessionID(sessionID string) (*model.PaymentAttempt, error)
+	ClaimDueRetries(now time.Time, lease time.Duration, limit int) ([]model.Payment, error)
+	ClaimStalePending(cutoff, now time.Time, lease time.Duration, limit int) ([]model.Payment, error)
+	SavePaymentMethod(method *model.PaymentMethod) error
+	FindPaymentMethod(methodID string) (*model.PaymentMethod, error)
+	ListPaymentMethods(userID string) ([]model.PaymentMethod, error)
+	DeletePaymentMethod(methodID string) error
+	SaveDispute(dispute *model.Dispute) error
+	AdvanceDispute(disputeID string, to model.DisputeStatus) (*model.Dispute, bool, error)
+	RecordDisputeEvidence(disputeID, text string, files []model.DisputeEvidence) (*model.Dispute, error)
+	FindDispute(disputeID string) (*model.Dispute, error)
+	FindDisputeByProviderID(providerDisputeID string) (*model.Dispute, error)
+	ListDisputes(paymentID string, status model.DisputeStatus) ([]model.Dispute, error)
+	PostWalletTransaction(txn *model.WalletTransaction) (*model.WalletTransaction, error)
+	FindWalletTransaction(idempotencyKey string) (*model.WalletTransaction, error)
+	ListWalletTransactions(filter WalletFilter, after *PageCursor, limit int) ([]model.WalletTransaction, error)
+	ListWallets(userID string) ([]model.Wallet, error)
 }
 
 type pgRepo struct {
@@ -21,23 +116,735 @@ func NewPostgresPaymentRepository(db *gorm.DB) PaymentRepository {
 	return &pgRepo{db: db}
 }
 
+// Save stores a new payment together with its initial transition
 func (r *pgRepo) Save(payment *model.Payment) error {
-	return r.db.Create(payment).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+		return recordTransition(tx, payment.ID, "", payment.Status, payment.Message)
+	})
+}
+
+// Transition moves a payment to a new status, together with any extra columns
+// in fields, and records the change. The payment row is locked so concurrent
+// transitions are validated one after the other.
+func (r *pgRepo) Transition(paymentID string, to model.PaymentStatus, message string, fields map[string]interface{}) (*model.Payment, error) {
+	var payment model.Payment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", paymentID).First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if !model.CanTransition(payment.Status, to) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, payment.Status, to)
+		}
+		from := payment.Status
+
+		updates := map[string]interface{}{
+			"status":     to,
+			"message":    message,
+			"updated_at": time.Now(),
+		}
+		for column, value := range fields {
+			updates[column] = value
+		}
+		if err := tx.Model(&model.Payment{}).Where("id = ?", paymentID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := recordTransition(tx, paymentID, from, to, message); err != nil {
+			return err
+		}
+		return tx.Where("id = ?", paymentID).First(&payment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *pgRepo) FindByID(paymentID string) (*model.Payment, error) {
+	return r.findOne("id = ?", paymentID)
+}
+
+func (r *pgRepo) FindByStripeSessionID(sessionID string) (*model.Payment, error) {
+	return r.findOne("stripe_session_id = ?", sessionID)
+}
+
+func (r *pgRepo) FindByPaymentIntentID(intentID string) (*model.Payment, error) {
+	return r.findOne("payment_intent_id = ?", intentID)
 }
 
-func (r *pgRepo) UpdateStatus(paymentID string, status model.PaymentStatus, message string) error {
+// List returns up to limit payments matching filter, newest first, starting
+// after the cursor when one is given
+func (r *pgRepo) List(filter PaymentFilter, after *PageCursor, limit int) ([]model.Payment, error) {
+	query := r.db.Order("created_at DESC, id DESC").Limit(limit)
+	if filter.OrderID != "" {
+		query = query.Where("order_id = ?", filter.OrderID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedTo)
+	}
+	if after != nil {
+		query = query.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+	var payments []model.Payment
+	err := query.Find(&payments).Error
+	return payments, err
+}
+
+// ListByOrderWithRefunds returns every payment of an order, oldest first, with its refunds
+func (r *pgRepo) ListByOrderWithRefunds(orderID string) ([]model.Payment, error) {
+	var payments []model.Payment
+	err := r.db.Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Where("order_id = ?", orderID).Order("created_at, id").Find(&payments).Error
+	return payments, err
+}
+
+func (r *pgRepo) SetPaymentIntentID(paymentID, intentID string) error {
 	return r.db.Model(&model.Payment{}).Where("id = ?", paymentID).Updates(map[string]interface{}{
-		"status":     status,
-		"updated_at": time.Now(),
-		"message":    message,
+		"payment_intent_id": intentID,
+		"updated_at":        time.Now(),
 	}).Error
 }
 
-func (r *pgRepo) FindByID(paymentID string) (*model.Payment, error) {
+func (r *pgRepo) IsWebhookEventProcessed(eventID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.WebhookEvent{}).Where("id = ?", eventID).Count(&count).Error
+	return count > 0, err
+}
+
+// SaveWebhookEvent stores a processed event; saving the same event twice is a no-op
+func (r *pgRepo) SaveWebhookEvent(event *model.WebhookEvent) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(event).Error
+}
+
+// CreateRefund stores a pending refund while holding a lock on its payment, so
+// concurrent refunds cannot exceed the payment amount. An amount of 0 refunds
+// the remainder. If the idempotency key was used before, the existing refund is
+// returned instead.
+func (r *pgRepo) CreateRefund(refund *model.Refund) (*model.Refund, error) {
+	stored := refund
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var payment model.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", refund.PaymentID).First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		var existing model.Refund
+		err := tx.Where("idempotency_key = ?", refund.IdempotencyKey).First(&existing).Error
+		if err == nil {
+			if existing.PaymentID != refund.PaymentID {
+				return ErrIdempotencyKeyReused
+			}
+			stored = &existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if !model.CanTransition(payment.Status, model.PaymentRefunded) {
+			return ErrNotRefundable
+		}
+		var outstanding float64
+		if err := tx.Model(&model.Refund{}).
+			Where("payment_id = ? AND status IN ?", payment.ID, []model.RefundStatus{model.RefundPending, model.RefundSucceeded}).
+			Select("COALESCE(SUM(amount), 0)").Scan(&outstanding).Error; err != nil {
+			return err
+		}
+		remaining := cents(payment.RefundableBase()) - cents(outstanding)
+		if refund.Amount == 0 {
+			refund.Amount = float64(remaining) / 100
+		}
+		if cents(refund.Amount) <= 0 || cents(refund.Amount) > remaining {
+			return ErrRefundExceedsPayment
+		}
+		return tx.Create(refund).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// CompleteRefund finalizes a pending refund and, when it succeeded, records
+// its fee and adds it to the payment's refunded amount. Completing a refund
+// twice is a no-op.
+func (r *pgRepo) CompleteRefund(refundID string, status model.RefundStatus, providerRefundID, message string, fee float64) (*model.Refund, *model.Payment, error) {
+	var refund model.Refund
 	var payment model.Payment
-	err := r.db.Where("id = ?", paymentID).First(&payment).Error
-	if err == gorm.ErrRecordNotFound {
-		return nil, errors.New("not found")
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", refundID).First(&refund).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", refund.PaymentID).First(&payment).Error; err != nil {
+			return err
+		}
+
+		if status != model.RefundSucceeded {
+			fee = 0
+		}
+		res := tx.Model(&model.Refund{}).Where("id = ? AND status = ?", refundID, model.RefundPending).Updates(map[string]interface{}{
+			"status":             status,
+			"provider_refund_id": providerRefundID,
+			"message":            message,
+			"fee_amount":         fee,
+			"updated_at":         time.Now(),
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// already completed by an earlier call
+			return tx.Where("id = ?", refundID).First(&refund).Error
+		}
+		refund.Status = status
+		refund.ProviderRefundID = providerRefundID
+		refund.Message = message
+		refund.FeeAmount = fee
+		if status != model.RefundSucceeded {
+			return nil
+		}
+
+		from := payment.Status
+		payment.RefundedAmount = float64(cents(payment.RefundedAmount)+cents(refund.Amount)) / 100
+		payment.NetAmount = float64(cents(payment.NetAmount)-cents(refund.Amount)-cents(fee)) / 100
+		payment.Status = model.PaymentPartiallyRefunded
+		if cents(payment.RefundedAmount) >= cents(payment.RefundableBase()) {
+			payment.Status = model.PaymentRefunded
+		}
+		if err := tx.Model(&model.Payment{}).Where("id = ?", payment.ID).Updates(map[string]interface{}{
+			"refunded_amount": payment.RefundedAmount,
+			"net_amount":      payment.NetAmount,
+			"status":          payment.Status,
+			"updated_at":      time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		if from == payment.Status {
+			return nil
+		}
+		return recordTransition(tx, payment.ID, from, payment.Status, message)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &refund, &payment, nil
+}
+
+// UpdatePendingRefund records what is known of a refund that is still pending;
+// an empty provider refund ID keeps the stored one
+func (r *pgRepo) UpdatePendingRefund(refundID, providerRefundID, message string) error {
+	updates := map[string]interface{}{"message": message}
+	if providerRefundID != "" {
+		updates["provider_refund_id"] = providerRefundID
+	}
+	return r.db.Model(&model.Refund{}).Where("id = ? AND status = ?", refundID, model.RefundPending).Updates(updates).Error
+}
+
+// FindRefund returns a refund by ID
+func (r *pgRepo) FindRefund(refundID string) (*model.Refund, error) {
+	var refund model.Refund
+	err := r.db.Where("id = ?", refundID).First(&refund).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// ListPendingRefunds returns up to limit refunds created before createdBefore
+// that are still pending, oldest first
+func (r *pgRepo) ListPendingRefunds(createdBefore time.Time, limit int) ([]model.Refund, error) {
+	var refunds []model.Refund
+	err := r.db.Where("status = ? AND created_at < ?", model.RefundPending, createdBefore).
+		Order("created_at").Limit(limit).Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *pgRepo) ListTransitions(paymentID string) ([]model.PaymentTransition, error) {
+	var transitions []model.PaymentTransition
+	err := r.db.Where("payment_id = ?", paymentID).Order("id").Find(&transitions).Error
+	return transitions, err
+}
+
+func (r *pgRepo) ListByProviderCreatedBetween(provider string, from, to time.Time) ([]model.Payment, error) {
+	var payments []model.Payment
+	err := r.db.Where("provider = ? AND created_at >= ? AND created_at < ?", provider, from, to).Order("created_at").Find(&payments).Error
+	return payments, err
+}
+
+// SettlementCaptures sums what was captured in [from, to) per day and
+// currency; an empty provider matches every provider
+func (r *pgRepo) SettlementCaptures(from, to time.Time, provider string) ([]SettlementRow, error) {
+	query := r.db.Model(&model.Payment{}).
+		Select("to_char(captured_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, currency, SUM(captured_amount) AS amount, SUM(fee_amount) AS fees, COUNT(*) AS count").
+		Where("captured_at >= ? AND captured_at < ? AND captured_amount > 0", from, to)
+	if provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	var rows []SettlementRow
+	err := query.Group("day, currency").Scan(&rows).Error
+	return rows, err
+}
+
+// SettlementRefunds sums the refunds that succeeded in [from, to) per day and
+// currency; an empty provider matches every provider
+func (r *pgRepo) SettlementRefunds(from, to time.Time, provider string) ([]SettlementRow, error) {
+	query := r.db.Table("refunds").
+		Select("to_char(refunds.updated_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, refunds.currency, SUM(refunds.amount) AS amount, SUM(refunds.fee_amount) AS fees, COUNT(*) AS count").
+		Joins("JOIN payments ON payments.id = refunds.payment_id").
+		Where("refunds.status = ? AND refunds.updated_at >= ? AND refunds.updated_at < ?", model.RefundSucceeded, from, to)
+	if provider != "" {
+		query = query.Where("payments.provider = ?", provider)
 	}
-	return &payment, err
+	var rows []SettlementRow
+	err := query.Group("day, refunds.currency").Scan(&rows).Error
+	return rows, err
+}
+
+// SaveReconciliationReport stores a report together with its items
+func (r *pgRepo) SaveReconciliationReport(report *model.ReconciliationReport) error {
+	return r.db.Create(report).Error
+}
+
+func (r *pgRepo) FindReconciliationReport(reportID string) (*model.ReconciliationReport, error) {
+	var report model.ReconciliationReport
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Where("id = ?", reportID).First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// LatestReconciliationReport returns the newest report, for one provider or for any when provider is empty
+func (r *pgRepo) LatestReconciliationReport(provider string) (*model.ReconciliationReport, error) {
+	query := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Order("finished_at DESC")
+	if provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	var report model.ReconciliationReport
+	err := query.First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *pgRepo) SaveAttempt(attempt *model.PaymentAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// FinishAttempt records the outcome of a pending attempt; finished attempts are left as they are
+func (r *pgRepo) FinishAttempt(paymentID string, number int, status model.AttemptStatus, declineCode, message string) error {
+	return r.db.Model(&model.PaymentAttempt{}).
+		Where("payment_id = ? AND number = ? AND status = ?", paymentID, number, model.AttemptPending).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"decline_code": declineCode,
+			"message":      message,
+			"finished_at":  time.Now(),
+		}).Error
+}
+
+func (r *pgRepo) ListAttempts(paymentID string) ([]model.PaymentAttempt, error) {
+	var attempts []model.PaymentAttempt
+	err := r.db.Where("payment_id = ?", paymentID).Order("number").Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *pgRepo) FindAttemptBySessionID(sessionID string) (*model.PaymentAttempt, error) {
+	var attempt model.PaymentAttempt
+	err := r.db.Where("session_id = ?", sessionID).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// ClaimDueRetries returns up to limit payments whose retry is due and pushes
+// their NextRetryAt forward by lease, so other workers skip them. Rows locked by
+// another worker are skipped rather than waited for; a claim that is not acted
+// on becomes due again once the lease runs out.
+func (r *pgRepo) ClaimDueRetries(now time.Time, lease time.Duration, limit int) ([]model.Payment, error) {
+	var payments []model.Payment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_retry_at <= ?", model.PaymentRetryScheduled, now).
+			Order("next_retry_at").Limit(limit).Find(&payments).Error; err != nil {
+			return err
+		}
+		if len(payments) == 0 {
+			return nil
+		}
+		ids := make([]string, len(payments))
+		for i := range payments {
+			ids[i] = payments[i].ID
+		}
+		return tx.Model(&model.Payment{}).Where("id IN ?", ids).Update("next_retry_at", now.Add(lease)).Error
+	})
+	return payments, err
+}
+
+// ClaimStalePending returns up to limit payments that have been PENDING since
+// before cutoff and leases them until now+lease, so concurrent sweepers skip
+// them. Rows locked by another sweeper are skipped rather than waited for.
+func (r *pgRepo) ClaimStalePending(cutoff, now time.Time, lease time.Duration, limit int) ([]model.Payment, error) {
+	var payments []model.Payment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND updated_at < ?", model.PaymentPending, cutoff).
+			Where("sweep_lease_until IS NULL OR sweep_lease_until <= ?", now).
+			Order("updated_at").Limit(limit).Find(&payments).Error; err != nil {
+			return err
+		}
+		if len(payments) == 0 {
+			return nil
+		}
+		ids := make([]string, len(payments))
+		for i := range payments {
+			ids[i] = payments[i].ID
+		}
+		// UpdateColumn keeps updated_at, which measures how long the payment is pending
+		return tx.Model(&model.Payment{}).Where("id IN ?", ids).UpdateColumn("sweep_lease_until", now.Add(lease)).Error
+	})
+	return payments, err
+}
+
+// SavePaymentMethod stores a new payment method. The user's first method, or
+// one saved with IsDefault, becomes the only default one.
+func (r *pgRepo) SavePaymentMethod(method *model.PaymentMethod) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.PaymentMethod{}).Where("user_id = ?", method.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			method.IsDefault = true
+		}
+		if method.IsDefault {
+			if err := tx.Model(&model.PaymentMethod{}).Where("user_id = ?", method.UserID).Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(method).Error
+	})
+}
+
+func (r *pgRepo) FindPaymentMethod(methodID string) (*model.PaymentMethod, error) {
+	var method model.PaymentMethod
+	err := r.db.Where("id = ?", methodID).First(&method).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// ListPaymentMethods returns the user's methods, the default one first
+func (r *pgRepo) ListPaymentMethods(userID string) ([]model.PaymentMethod, error) {
+	var methods []model.PaymentMethod
+	err := r.db.Where("user_id = ?", userID).Order("is_default DESC, created_at DESC").Find(&methods).Error
+	return methods, err
+}
+
+// DeletePaymentMethod removes a method; when it was the default, the user's
+// newest remaining method takes its place
+func (r *pgRepo) DeletePaymentMethod(methodID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var method model.PaymentMethod
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", methodID).First(&method).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&method).Error; err != nil {
+			return err
+		}
+		if !method.IsDefault {
+			return nil
+		}
+		var next model.PaymentMethod
+		err := tx.Where("user_id = ?", method.UserID).Order("created_at DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
+
+func (r *pgRepo) SaveDispute(dispute *model.Dispute) error {
+	return r.db.Create(dispute).Error
+}
+
+// AdvanceDispute moves a dispute to a later status; changed is false when the
+// dispute already is at that stage or past it, e.g. for a late webhook
+func (r *pgRepo) AdvanceDispute(disputeID string, to model.DisputeStatus) (*model.Dispute, bool, error) {
+	var dispute model.Dispute
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockDispute(tx, disputeID, &dispute); err != nil {
+			return err
+		}
+		if !model.CanAdvanceDispute(dispute.Status, to) {
+			return nil
+		}
+		updates := map[string]interface{}{"status": to}
+		if to.Closed() {
+			updates["closed_at"] = time.Now()
+		}
+		if err := tx.Model(&dispute).Updates(updates).Error; err != nil {
+			return err
+		}
+		changed = true
+		return tx.Preload("Evidence").Where("id = ?", disputeID).First(&dispute).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &dispute, changed, nil
+}
+
+// RecordDisputeEvidence stores the evidence of a dispute that needs a response
+// and puts it under review
+func (r *pgRepo) RecordDisputeEvidence(disputeID, text string, files []model.DisputeEvidence) (*model.Dispute, error) {
+	var dispute model.Dispute
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockDispute(tx, disputeID, &dispute); err != nil {
+			return err
+		}
+		if dispute.Status != model.DisputeNeedsResponse {
+			return fmt.Errorf("%w: status is %s", ErrEvidenceNotAccepted, dispute.Status)
+		}
+		for i := range files {
+			files[i].DisputeID = disputeID
+		}
+		if len(files) > 0 {
+			if err := tx.Create(&files).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&dispute).Updates(map[string]interface{}{
+			"status":                model.DisputeUnderReview,
+			"evidence_text":         text,
+			"evidence_submitted_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Preload("Evidence").Where("id = ?", disputeID).First(&dispute).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+func (r *pgRepo) FindDispute(disputeID string) (*model.Dispute, error) {
+	return r.findDispute("id = ?", disputeID)
+}
+
+func (r *pgRepo) FindDisputeByProviderID(providerDisputeID string) (*model.Dispute, error) {
+	return r.findDispute("provider_dispute_id = ?", providerDisputeID)
+}
+
+// ListDisputes returns disputes, newest first; empty arguments match every dispute
+func (r *pgRepo) ListDisputes(paymentID string, status model.DisputeStatus) ([]model.Dispute, error) {
+	query := r.db.Preload("Evidence").Order("created_at DESC")
+	if paymentID != "" {
+		query = query.Where("payment_id = ?", paymentID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var disputes []model.Dispute
+	err := query.Find(&disputes).Error
+	return disputes, err
+}
+
+// PostWalletTransaction applies a credit or debit to the wallet of the
+// transaction's user and currency, creating the wallet on first use. The
+// wallet row is locked, so concurrent debits cannot overdraw it. If the
+// idempotency key was used before, the existing transaction is returned.
+func (r *pgRepo) PostWalletTransaction(txn *model.WalletTransaction) (*model.WalletTransaction, error) {
+	stored := txn
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// the wallet ID is derived from its owner, so opening it twice is harmless
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Wallet{
+			ID:       txn.UserID + ":" + txn.Currency,
+			UserID:   txn.UserID,
+			Currency: txn.Currency,
+		}).Error; err != nil {
+			return err
+		}
+		var wallet model.Wallet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND currency = ?", txn.UserID, txn.Currency).First(&wallet).Error; err != nil {
+			return err
+		}
+
+		// checked under the lock, so a concurrent retry sees the first one's row
+		var existing model.WalletTransaction
+		err := tx.Where("idempotency_key = ?", txn.IdempotencyKey).First(&existing).Error
+		if err == nil {
+			if existing.WalletID != wallet.ID || cents(existing.Amount) != cents(txn.Amount) {
+				return ErrIdempotencyKeyReused
+			}
+			stored = &existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		balance := cents(wallet.Balance) + cents(txn.Amount)
+		if balance < 0 {
+			return fmt.Errorf("%w: balance is %.2f %s", ErrInsufficientCredit, wallet.Balance, wallet.Currency)
+		}
+		txn.WalletID = wallet.ID
+		txn.BalanceAfter = float64(balance) / 100
+		if err := tx.Model(&wallet).Updates(map[string]interface{}{
+			"balance":    txn.BalanceAfter,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(txn).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func (r *pgRepo) FindWalletTransaction(idempotencyKey string) (*model.WalletTransaction, error) {
+	var txn model.WalletTransaction
+	err := r.db.Where("idempotency_key = ?", idempotencyKey).First(&txn).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &txn, nil
+}
+
+// ListWalletTransactions returns up to limit transactions matching filter,
+// newest first, starting after the cursor when one is given
+func (r *pgRepo) ListWalletTransactions(filter WalletFilter, after *PageCursor, limit int) ([]model.WalletTransaction, error) {
+	query := r.db.Order("created_at DESC, id DESC").Limit(limit)
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedTo)
+	}
+	if after != nil {
+		query = query.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+	var txns []model.WalletTransaction
+	err := query.Find(&txns).Error
+	return txns, err
+}
+
+// ListWallets returns the wallets of a user, one per currency
+func (r *pgRepo) ListWallets(userID string) ([]model.Wallet, error) {
+	var wallets []model.Wallet
+	err := r.db.Where("user_id = ?", userID).Order("currency").Find(&wallets).Error
+	return wallets, err
+}
+
+func (r *pgRepo) findDispute(query string, args ...interface{}) (*model.Dispute, error) {
+	var dispute model.Dispute
+	err := r.db.Preload("Evidence").Where(query, args...).First(&dispute).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// lockDispute loads a dispute with its row locked for the rest of the transaction
+func lockDispute(tx *gorm.DB, disputeID string, dispute *model.Dispute) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", disputeID).First(dispute).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *pgRepo) findOne(query string, args ...interface{}) (*model.Payment, error) {
+	var payment model.Payment
+	err := r.db.Where(query, args...).First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// recordTransition appends a row to payment_transitions
+func recordTransition(tx *gorm.DB, paymentID string, from, to model.PaymentStatus, message string) error {
+	return tx.Create(&model.PaymentTransition{
+		PaymentID:  paymentID,
+		FromStatus: from,
+		ToStatus:   to,
+		Message:    message,
+		CreatedAt:  time.Now(),
+	}).Error
+}
+
+// cents converts an amount to whole cents so comparisons are exact
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
 }
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/configs/utils"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDisputeNotFound is returned for disputes that do not exist
+	ErrDisputeNotFound = errors.New("dispute not found")
+	// ErrInvalidEvidence is returned for empty, oversized or badly named evidence
+	ErrInvalidEvidence = errors.New("invalid dispute evidence")
+	// ErrDisputeClosed is returned when submitting evidence for a dispute that no
+	// longer needs a response
+	ErrDisputeClosed = errors.New("dispute does not accept evidence")
+)
+
+// limits on submitted evidence, in line with what card networks accept
+const (
+	maxEvidenceTextLen   = 20000
+	maxEvidenceFiles     = 10
+	maxEvidenceFileBytes = 5 << 20
+)
+
+// EvidenceFile is a file submitted as evidence for a dispute
+type EvidenceFile struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// applyDispute records a dispute reported by the provider and publishes its
+// changes. Updates for a stage the dispute already passed are ignored.
+func (s *PaymentService) applyDispute(ctx context.Context, payment *model.Payment, update *provider.DisputeUpdate) error {
+	dispute, err := s.Repo.FindDisputeByProviderID(update.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		dispute = &model.Dispute{
+			ID:                utils.GenerateUUID(),
+			PaymentID:         payment.ID,
+			Provider:          payment.Provider,
+			ProviderDisputeID: update.ID,
+			Reason:            update.Reason,
+			Amount:            update.Amount,
+			Currency:          update.Currency,
+			Status:            model.DisputeStatus(update.Status),
+		}
+		if !update.EvidenceDueBy.IsZero() {
+			dueBy := update.EvidenceDueBy
+			dispute.EvidenceDueBy = &dueBy
+		}
+		if dispute.Status.Closed() {
+			now := time.Now()
+			dispute.ClosedAt = &now
+		}
+		if err := s.Repo.SaveDispute(dispute); err != nil {
+			return fmt.Errorf("save dispute %s: %w", update.ID, err)
+		}
+		s.publishDispute(ctx, payment, dispute, "dispute.created")
+		if dispute.Status.Closed() {
+			s.publishDispute(ctx, payment, dispute, "dispute.closed")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dispute, changed, err := s.Repo.AdvanceDispute(dispute.ID, model.DisputeStatus(update.Status))
+	if err != nil {
+		return fmt.Errorf("update dispute %s: %w", update.ID, err)
+	}
+	if !changed {
+		return nil
+	}
+	if dispute.Status.Closed() {
+		s.publishDispute(ctx, payment, dispute, "dispute.closed")
+	} else {
+		s.publishDispute(ctx, payment, dispute, "dispute.updated")
+	}
+	return nil
+}
+
+// SubmitDisputeEvidence answers a dispute that needs a response. The files are
+// stored under the evidence directory, the text is sent to the provider, and
+// the dispute is put under review.
+func (s *PaymentService) SubmitDisputeEvidence(ctx context.Context, disputeID, text string, files []EvidenceFile) (*model.Dispute, error) {
+	text = strings.TrimSpace(text)
+	if err := validateEvidence(text, files); err != nil {
+		return nil, err
+	}
+	dispute, err := s.findDispute(disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status != model.DisputeNeedsResponse {
+		return nil, fmt.Errorf("%w: status is %s", ErrDisputeClosed, dispute.Status)
+	}
+	payment, err := s.Repo.FindByID(dispute.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.storeEvidence(dispute.ID, files)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sendEvidence(ctx, dispute, text); err != nil {
+		s.removeEvidence(stored)
+		return nil, err
+	}
+	updated, err := s.Repo.RecordDisputeEvidence(dispute.ID, text, stored)
+	if errors.Is(err, repository.ErrEvidenceNotAccepted) {
+		// a webhook closed the dispute while the evidence was being sent
+		s.removeEvidence(stored)
+		return nil, fmt.Errorf("%w: %v", ErrDisputeClosed, err)
+	}
+	if err != nil {
+		s.removeEvidence(stored)
+		return nil, fmt.Errorf("record evidence: %w", err)
+	}
+	s.publishDispute(ctx, payment, updated, "dispute.updated")
+	return updated, nil
+}
+
+// GetDispute returns a dispute with its evidence files
+func (s *PaymentService) GetDispute(disputeID string) (*model.Dispute, error) {
+	return s.findDispute(disputeID)
+}
+
+// ListDisputes returns disputes, newest first; empty arguments match every dispute
+func (s *PaymentService) ListDisputes(paymentID string, status model.DisputeStatus) ([]model.Dispute, error) {
+	return s.Repo.ListDisputes(paymentID, status)
+}
+
+func (s *PaymentService) findDispute(disputeID string) (*model.Dispute, error) {
+	dispute, err := s.Repo.FindDispute(disputeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDisputeNotFound
+	}
+	return dispute, err
+}
+
+// sendEvidence forwards the evidence text to providers that accept it
+func (s *PaymentService) sendEvidence(ctx context.Context, dispute *model.Dispute, text string) error {
+	p, err := s.providers.Get(dispute.Provider)
+	if err != nil {
+		return err
+	}
+	responder, ok := p.(provider.DisputeResponder)
+	if !ok {
+		return nil
+	}
+	if _, err := responder.SubmitDisputeEvidence(ctx, dispute.ProviderDisputeID, text); err != nil {
+		return fmt.Errorf("submit evidence: %w", err)
+	}
+	return nil
+}
+
+// storeEvidence writes the files to <evidence dir>/<dispute ID>/ under unique
+// names and returns their records
+func (s *PaymentService) storeEvidence(disputeID string, files []EvidenceFile) ([]model.DisputeEvidence, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+	dir := filepath.Join(s.EvidenceDir, disputeID)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create evidence directory: %w", err)
+	}
+	stored := make([]model.DisputeEvidence, 0, len(files))
+	for _, f := range files {
+		name := filepath.Base(f.Name)
+		rel := filepath.Join(disputeID, utils.GenerateUUID()+"-"+name)
+		if err := os.WriteFile(filepath.Join(s.EvidenceDir, rel), f.Content, 0o640); err != nil {
+			s.removeEvidence(stored)
+			return nil, fmt.Errorf("store evidence %s: %w", name, err)
+		}
+		stored = append(stored, model.DisputeEvidence{
+			FileName:    name,
+			ContentType: f.ContentType,
+			Size:        int64(len(f.Content)),
+			Path:        rel,
+		})
+	}
+	return stored, nil
+}
+
+// removeEvidence deletes files stored for a submission that did not go through
+func (s *PaymentService) removeEvidence(stored []model.DisputeEvidence) {
+	for _, e := range stored {
+		if err := os.Remove(filepath.Join(s.EvidenceDir, e.Path)); err != nil {
+			log.Printf("failed to remove evidence file %s: %v", e.Path, err)
+		}
+	}
+}
+
+// validateEvidence checks the submission before anything is stored or sent
+func validateEvidence(text string, files []EvidenceFile) error {
+	if text == "" && len(files) == 0 {
+		return fmt.Errorf("%w: text or files are required", ErrInvalidEvidence)
+	}
+	if len(text) > maxEvidenceTextLen {
+		return fmt.Errorf("%w: text is longer than %d characters", ErrInvalidEvidence, maxEvidenceTextLen)
+	}
+	if len(files) > maxEvidenceFiles {
+		return fmt.Errorf("%w: at most %d files are accepted", ErrInvalidEvidence, maxEvidenceFiles)
+	}
+	for _, f := range files {
+		// names are used on disk, so anything that is not a plain file name is refused
+		if f.Name == "" || f.Name == "." || f.Name == ".." || strings.ContainsAny(f.Name, "/\\\x00") {
+			return fmt.Errorf("%w: bad file name %q", ErrInvalidEvidence, f.Name)
+		}
+		if len(f.Content) == 0 || len(f.Content) > maxEvidenceFileBytes {
+			return fmt.Errorf("%w: %s must hold 1 byte to %d bytes", ErrInvalidEvidence, f.Name, maxEvidenceFileBytes)
+		}
+	}
+	return nil
+}
+
+// publishDispute tells the ledger and order services about a dispute
+func (s *PaymentService) publishDispute(ctx context.Context, payment *model.Payment, dispute *model.Dispute, eventType string) {
+	event := map[string]interface{}{
+		"event":      eventType,
+		"dispute_id": dispute.ID,
+		"payment_id": payment.ID,
+		"order_id":   payment.OrderID,
+		"user_id":    payment.UserID,
+		"provider":   dispute.Provider,
+		"reason":     dispute.Reason,
+		"amount":     dispute.Amount,
+		"currency":   dispute.Currency,
+		"status":     dispute.Status,
+	}
+	if dispute.EvidenceDueBy != nil {
+		event["evidence_due_by"] = dispute.EvidenceDueBy.Format(time.RFC3339)
+	}
+	if err := s.kafka.SendMessage(ctx, "dispute-events", dispute.ID, event); err != nil {
+		log.Printf("failed to publish %s event: %v", eventType, err)
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// fee operations
+const (
+	FeeCapture = "capture"
+	FeeRefund  = "refund"
+)
+
+// feeWildcard matches any provider, operation, method or currency
+const feeWildcard = "*"
+
+// CheckoutMethod is the fee method of payments made on a hosted payment page;
+// off-session payments use the brand of their saved method, e.g. "visa"
+const CheckoutMethod = "checkout"
+
+// FeeRule charges Percent of the amount plus Fixed for one kind of money
+// movement. Empty or "*" fields match anything.
+type FeeRule struct {
+	Provider  string
+	Operation string // FeeCapture or FeeRefund
+	Method    string // CheckoutMethod or a card brand
+	Currency  string
+	Percent   float64
+	Fixed     float64 // in the currency of the payment
+}
+
+// FeeSchedule holds the processing fees providers charge. The zero value
+// charges nothing.
+type FeeSchedule struct {
+	Rules []FeeRule
+}
+
+// ParseFeeSchedule reads comma-separated rules of the form
+// provider:operation:method:currency=percent%+fixed, e.g.
+// "stripe:capture:*:USD=2.9%+0.30,stripe:capture:amex:*=3.5%+0.30". Either
+// part of the fee may be left out ("1.5%" or "0.25").
+func ParseFeeSchedule(s string) (FeeSchedule, error) {
+	var schedule FeeSchedule
+	if strings.TrimSpace(s) == "" {
+		return schedule, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		key, fee, ok := strings.Cut(strings.TrimSpace(part), "=")
+		fields := strings.Split(key, ":")
+		if !ok || len(fields) != 4 {
+			return FeeSchedule{}, fmt.Errorf("invalid fee rule %q", part)
+		}
+		rule := FeeRule{
+			Provider:  strings.TrimSpace(fields[0]),
+			Operation: strings.TrimSpace(fields[1]),
+			Method:    strings.ToLower(strings.TrimSpace(fields[2])),
+			Currency:  strings.ToUpper(strings.TrimSpace(fields[3])),
+		}
+		if rule.Operation != FeeCapture && rule.Operation != FeeRefund && rule.Operation != feeWildcard {
+			return FeeSchedule{}, fmt.Errorf("invalid fee operation %q in %q", rule.Operation, part)
+		}
+		var err error
+		if rule.Percent, rule.Fixed, err = parseFee(fee); err != nil {
+			return FeeSchedule{}, fmt.Errorf("invalid fee in %q: %w", part, err)
+		}
+		schedule.Rules = append(schedule.Rules, rule)
+	}
+	return schedule, nil
+}
+
+// parseFee reads "2.9%+0.30", "2.9%" or "0.30"
+func parseFee(s string) (percent, fixed float64, err error) {
+	for _, term := range strings.Split(s, "+") {
+		term = strings.TrimSpace(term)
+		isPercent := strings.HasSuffix(term, "%")
+		v, err := strconv.ParseFloat(strings.TrimSuffix(term, "%"), 64)
+		if err != nil || v < 0 {
+			return 0, 0, fmt.Errorf("bad term %q", term)
+		}
+		if isPercent {
+			percent += v
+		} else {
+			fixed += v
+		}
+	}
+	return percent, fixed, nil
+}
+
+// Fee returns the fee for moving amount, rounded to cents. The rule naming
+// the most of provider, method and currency wins; among equals the first
+// listed one does. Without a matching rule there is no fee.
+func (f FeeSchedule) Fee(providerName, operation, method, currency string, amount float64) float64 {
+	var best *FeeRule
+	bestScore := -1
+	for i := range f.Rules {
+		r := &f.Rules[i]
+		if !feeMatch(r.Provider, providerName) || !feeMatch(r.Operation, operation) ||
+			!feeMatch(r.Method, strings.ToLower(method)) || !feeMatch(r.Currency, strings.ToUpper(currency)) {
+			continue
+		}
+		score := feeSpecific(r.Provider) + feeSpecific(r.Method) + feeSpecific(r.Currency)
+		if score > bestScore {
+			best, bestScore = r, score
+		}
+	}
+	if best == nil || amount <= 0 {
+		return 0
+	}
+	return float64(cents(amount*best.Percent/100)+cents(best.Fixed)) / 100
+}
+
+func feeMatch(pattern, value string) bool {
+	return pattern == "" || pattern == feeWildcard || pattern == value
+}
+
+func feeSpecific(pattern string) int {
+	if pattern == "" || pattern == feeWildcard {
+		return 0
+	}
+	return 1
+}
+
+// feeMethod is the method a payment's fees are looked up by
+func (s *PaymentService) feeMethod(payment *model.Payment) string {
+	if payment.PaymentMethodID == "" {
+		return CheckoutMethod
+	}
+	method, err := s.Repo.FindPaymentMethod(payment.PaymentMethodID)
+	if err != nil || method.Brand == "" {
+		// the method may have been detached since; the schedule's wildcard rules still apply
+		return ""
+	}
+	return method.Brand
+}
+
+// cents converts a decimal amount to whole cents
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when ListPayments is called without a page size
+	DefaultPageSize = 50
+	// MaxPageSize bounds the page size of ListPayments
+	MaxPageSize = 200
+)
+
+// ErrInvalidPageToken is returned for page tokens ListPayments did not issue
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// OrderPayment is a payment of an order together with its attempts; refunds
+// are loaded into Payment.Refunds
+type OrderPayment struct {
+	Payment  model.Payment
+	Attempts []model.PaymentAttempt
+}
+
+// ListPayments returns one page of payments matching filter, newest first, and
+// the token of the next page, which is empty on the last page
+func (s *PaymentService) ListPayments(filter repository.PaymentFilter, pageSize int, pageToken string) ([]model.Payment, string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	var after *repository.PageCursor
+	if pageToken != "" {
+		cursor, err := decodePageToken(pageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		after = cursor
+	}
+
+	// one extra row tells whether another page follows
+	payments, err := s.Repo.List(filter, after, pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(payments) > pageSize {
+		payments = payments[:pageSize]
+		last := payments[pageSize-1]
+		next = encodePageToken(repository.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	return payments, next, nil
+}
+
+// GetPaymentsForOrder returns every payment of an order, oldest first, with
+// its attempts and refunds
+func (s *PaymentService) GetPaymentsForOrder(orderID string) ([]OrderPayment, error) {
+	payments, err := s.Repo.ListByOrderWithRefunds(orderID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]OrderPayment, 0, len(payments))
+	for _, p := range payments {
+		attempts, err := s.Repo.ListAttempts(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, OrderPayment{Payment: p, Attempts: attempts})
+	}
+	return out, nil
+}
+
+// encodePageToken turns a cursor into an opaque token
+func encodePageToken(c repository.PageCursor) string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePageToken is the inverse of encodePageToken
+func decodePageToken(token string) (*repository.PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	nanos, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidPageToken
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	return &repository.PageCursor{CreatedAt: time.Unix(0, n), ID: id}, nil
+}
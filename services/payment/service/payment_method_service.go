@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/configs/utils"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"time"
+)
+
+var (
+	// ErrPaymentMethodNotFound is returned for methods that do not exist or
+	// belong to another user, so other users' methods cannot be probed
+	ErrPaymentMethodNotFound = errors.New("payment method not found")
+	// ErrInvalidPaymentMethod is returned for incomplete or unusable payment method requests
+	ErrInvalidPaymentMethod = errors.New("invalid payment method")
+	// ErrRawCardData is returned when a token looks like a card number
+	ErrRawCardData = errors.New("raw card data is not accepted; tokenize the card with the provider first")
+)
+
+// minCardDigits is the length of the shortest card numbers in use
+const minCardDigits = 12
+
+// AttachPaymentMethod saves a provider token for the user. The provider
+// attaches it to the user's customer and reports its brand, last four digits
+// and expiry. The user's first method becomes the default one.
+func (s *PaymentService) AttachPaymentMethod(ctx context.Context, userID, providerName, token string, makeDefault bool) (*model.PaymentMethod, error) {
+	if userID == "" || token == "" {
+		return nil, fmt.Errorf("%w: user_id and token are required", ErrInvalidPaymentMethod)
+	}
+	if looksLikeCardNumber(token) {
+		return nil, ErrRawCardData
+	}
+	p, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+	vault, ok := p.(provider.MethodVault)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s cannot save payment methods", ErrInvalidPaymentMethod, p.Name())
+	}
+
+	// reuse the provider customer of the user's other methods
+	existing, err := s.Repo.ListPaymentMethods(userID)
+	if err != nil {
+		return nil, err
+	}
+	customerID := ""
+	for _, m := range existing {
+		if m.Provider == p.Name() && m.CustomerID != "" {
+			customerID = m.CustomerID
+			break
+		}
+	}
+	details, err := vault.AttachMethod(ctx, customerID, userID, token)
+	if errors.Is(err, provider.ErrMethodNotSupported) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPaymentMethod, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("attach payment method: %w", err)
+	}
+
+	method := &model.PaymentMethod{
+		ID:            utils.GenerateUUID(),
+		UserID:        userID,
+		Provider:      p.Name(),
+		ProviderToken: details.Token,
+		CustomerID:    details.CustomerID,
+		Brand:         details.Brand,
+		Last4:         details.Last4,
+		ExpMonth:      details.ExpMonth,
+		ExpYear:       details.ExpYear,
+		IsDefault:     makeDefault,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.Repo.SavePaymentMethod(method); err != nil {
+		return nil, fmt.Errorf("db failed: %w", err)
+	}
+	return method, nil
+}
+
+// ListPaymentMethods returns the user's saved methods, the default one first
+func (s *PaymentService) ListPaymentMethods(userID string) ([]model.PaymentMethod, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", ErrInvalidPaymentMethod)
+	}
+	return s.Repo.ListPaymentMethods(userID)
+}
+
+// DetachPaymentMethod removes one of the user's methods at the provider and
+// from the vault
+func (s *PaymentService) DetachPaymentMethod(ctx context.Context, userID, methodID string) error {
+	method, err := s.ownedPaymentMethod(userID, methodID)
+	if err != nil {
+		return err
+	}
+	p, err := s.providers.Get(method.Provider)
+	if err != nil {
+		return err
+	}
+	if vault, ok := p.(provider.MethodVault); ok {
+		if err := vault.DetachMethod(ctx, method.ProviderToken); err != nil {
+			return fmt.Errorf("detach payment method: %w", err)
+		}
+	}
+	return s.Repo.DeletePaymentMethod(method.ID)
+}
+
+// ownedPaymentMethod loads a method and checks that it belongs to the user
+func (s *PaymentService) ownedPaymentMethod(userID, methodID string) (*model.PaymentMethod, error) {
+	method, err := s.Repo.FindPaymentMethod(methodID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPaymentMethodNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" || method.UserID != userID {
+		return nil, ErrPaymentMethodNotFound
+	}
+	return method, nil
+}
+
+// looksLikeCardNumber reports whether s contains a run of digits as long as a
+// card number, allowing the spaces and dashes card numbers are written with
+func looksLikeCardNumber(s string) bool {
+	run := 0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			run++
+			if run >= minCardDigits {
+				return true
+			}
+		case (c == ' ' || c == '-') && run > 0:
+		default:
+			run = 0
+		}
+	}
+	return false
+}
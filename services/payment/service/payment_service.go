@@ -2,141 +2,425 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/SabinGhost19/go-micro-payment/configs/utils"
 	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
 	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
 	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/wallet"
 	"log"
 	"time"
 )
 
+var (
+	// ErrNotCapturable is returned when capturing a payment that is not authorized
+	ErrNotCapturable = errors.New("payment is not authorized")
+	// ErrNotVoidable is returned when voiding a payment that already moved money
+	ErrNotVoidable = errors.New("payment can no longer be voided")
+	// ErrInvalidAmount is returned for negative amounts or captures above the authorization
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrNotConfirmable is returned when confirming a payment that is not waiting on the customer
+	ErrNotConfirmable = errors.New("payment does not require action")
+)
+
 type PaymentService struct {
-	Repo  repository.PaymentRepository
-	kafka *kafka.Producer
+	Repo        repository.PaymentRepository
+	RetryPolicy RetryPolicy   // retries of declined payments; none by default
+	PendingTTL  time.Duration // PENDING payments older than this are swept; zero disables the sweeper
+	EvidenceDir string        // where dispute evidence files are stored
+	Fees        FeeSchedule   // provider fees recorded on captures and refunds; none by default
+	kafka       *kafka.Producer
+	providers   *provider.Registry
 }
 
-func New(repo repository.PaymentRepository, kafka *kafka.Producer) *PaymentService {
-	return &PaymentService{Repo: repo, kafka: kafka}
+func New(repo repository.PaymentRepository, kafka *kafka.Producer, providers *provider.Registry) *PaymentService {
+	return &PaymentService{Repo: repo, kafka: kafka, providers: providers}
 }
 
-// // initiatePayment initiates a payment via Stripe
-//
-//	func (s *PaymentService) InitiatePayment(ctx context.Context, orderID, userID string, amount float64, currency string) (*model.Payment, error) {
-//		params := &stripe.CheckoutSessionParams{
-//			PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
-//			LineItems: []*stripe.CheckoutSessionLineItemParams{
-//				{
-//					PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-//						Currency:    stripe.String(currency),
-//						ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{Name: stripe.String("Order " + orderID)},
-//						UnitAmount:  stripe.Int64(int64(amount * 100)),
-//					},
-//					Quantity: stripe.Int64(1),
-//				},
-//			},
-//			Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
-//			SuccessURL: stripe.String("https://your-app.com/payment/success"),
-//			CancelURL:  stripe.String("https://your-app.com/payment/cancel"),
-//		}
-//
-//		sessionObj, err := session.New(params)
-//		status := model.PaymentPending
-//		message := "Stripe session initiated"
-//		if err != nil {
-//			status = model.PaymentFailed
-//			message = err.Error()
-//		}
-//
-//		payment := &model.Payment{
-//			ID:              utils.GenerateUUID(),
-//			OrderID:         orderID,
-//			UserID:          userID,
-//			Amount:          amount,
-//			Currency:        currency,
-//			StripeSessionID: sessionObj.ID,
-//			Status:          status,
-//			Provider:        "stripe",
-//			CreatedAt:       time.Now(),
-//			UpdatedAt:       time.Now(),
-//			Message:         message,
-//		}
-//
-//		// save payment to database
-//		if err := s.Repo.Save(payment); err != nil {
-//			return nil, fmt.Errorf("db failed: %w", err)
-//		}
-//
-//		fmt.Println("PAYMENT INITIATED!!!!!")
-//
-//		// publish payment.created event
-//		event := map[string]interface{}{
-//			"payment_id": payment.ID,
-//			"order_id":   payment.OrderID,
-//			"status":     payment.Status,
-//		}
-//		if err := s.kafka.SendMessage(ctx, "payment-events", payment.ID, event); err != nil {
-//			log.Printf("failed to publish payment.created event: %v", err)
-//		}
-//		return payment, nil
-//	}
-//
-
-func (s *PaymentService) InitiatePayment(ctx context.Context, orderID, userID string, amount float64, currency string) (*model.Payment, error) {
-	// Simulăm răspunsul Stripe fără a apela API-ul real
-	mockStripeSessionID := "mock_stripe_session_12345"
-
-	status := model.PaymentPending
-	message := "Mock Stripe session initiated successfully"
-
-	// Construim obiectul Payment
+// InitiateRequest describes a payment to start
+type InitiateRequest struct {
+	OrderID       string
+	UserID        string
+	Amount        float64
+	Currency      string
+	Provider      string              // empty selects the configured default
+	CaptureMethod model.CaptureMethod // empty means automatic
+	// PaymentMethodID is one of the user's saved methods to charge off-session;
+	// empty opens a hosted payment page
+	PaymentMethodID string
+	// WalletAmount is store credit to apply; the provider charges the rest.
+	// To pay with store credit alone, use the wallet provider instead.
+	WalletAmount float64
+}
+
+// InitiatePayment opens a payment session with the requested provider, or the
+// configured default one when no provider is given. With a saved payment
+// method the payment is charged off-session and its outcome fetched at once.
+// Store credit in WalletAmount is debited up front and returned to the wallet
+// if the payment does not go through.
+func (s *PaymentService) InitiatePayment(ctx context.Context, req InitiateRequest) (*model.Payment, error) {
+	var method *model.PaymentMethod
+	if req.PaymentMethodID != "" {
+		m, err := s.ownedPaymentMethod(req.UserID, req.PaymentMethodID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Provider != "" && req.Provider != m.Provider {
+			return nil, fmt.Errorf("%w: method was saved with %s", ErrInvalidPaymentMethod, m.Provider)
+		}
+		method, req.Provider = m, m.Provider
+	}
+	p, err := s.providers.Get(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if req.CaptureMethod == "" {
+		req.CaptureMethod = model.CaptureAutomatic
+	}
+	if req.CaptureMethod != model.CaptureAutomatic && req.CaptureMethod != model.CaptureManual {
+		return nil, fmt.Errorf("unknown capture method %q", req.CaptureMethod)
+	}
+	if req.WalletAmount < 0 || (req.WalletAmount > 0 && (p.Name() == wallet.ProviderName || cents(req.WalletAmount) >= cents(req.Amount))) {
+		return nil, fmt.Errorf("%w: store credit must be less than the amount; pay it in full with the %s provider", ErrInvalidAmount, wallet.ProviderName)
+	}
+
 	payment := &model.Payment{
-		ID:              utils.GenerateUUID(),
-		OrderID:         orderID,
-		UserID:          userID,
-		Amount:          amount,
-		Currency:        currency,
-		StripeSessionID: mockStripeSessionID,
-		Status:          status,
-		Provider:        "stripe-mock",
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
-		Message:         message,
-	}
-
-	// Salvăm în DB ca de obicei
+		ID:            utils.GenerateUUID(),
+		OrderID:       req.OrderID,
+		UserID:        req.UserID,
+		Amount:        req.Amount,
+		WalletAmount:  float64(cents(req.WalletAmount)) / 100,
+		Currency:      req.Currency,
+		CaptureMethod: req.CaptureMethod,
+		Status:        model.PaymentPending,
+		Provider:      p.Name(),
+		Attempts:      1,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	sessReq := provider.SessionRequest{
+		PaymentID:     payment.ID,
+		OrderID:       req.OrderID,
+		UserID:        req.UserID,
+		Amount:        payment.ProviderAmount(),
+		Currency:      req.Currency,
+		ManualCapture: req.CaptureMethod == model.CaptureManual,
+		Attempt:       1,
+	}
+	if method != nil {
+		payment.PaymentMethodID = method.ID
+		sessReq.PaymentMethod, sessReq.CustomerID = method.ProviderToken, method.CustomerID
+	}
+
+	if payment.WalletAmount > 0 {
+		if err := s.applyWalletCredit(payment); err != nil {
+			return nil, err
+		}
+	}
+
+	sess, err := p.CreateSession(ctx, sessReq)
+	attempt := &model.PaymentAttempt{PaymentID: payment.ID, Number: 1, Status: model.AttemptPending, CreatedAt: time.Now()}
+	settled := false
+	// a decline is failed once the payment is stored, so it goes through the retry policy
+	declined, declineCode := err != nil, ""
+	if err != nil {
+		payment.Message = err.Error()
+	} else {
+		payment.StripeSessionID = sess.ID
+		payment.PaymentIntentID = sess.PaymentIntentID
+		payment.CheckoutURL = sess.URL
+		payment.Message = p.Name() + " session initiated"
+		// off-session declines are settled by the sync below, under the retry policy
+		if sess.Status == provider.StatusFailed && method == nil {
+			declined, declineCode = true, sess.DeclineCode
+			payment.Message = sess.Message
+		}
+		if sess.Status == provider.StatusRequiresAction && sess.NextAction != nil {
+			payment.Status = model.PaymentRequiresAction
+			payment.Message = sess.Message
+			payment.NextActionType = sess.NextAction.Type
+			payment.NextActionURL = sess.NextAction.RedirectURL
+			payment.ClientSecret = sess.NextAction.ClientSecret
+		}
+		attempt.SessionID, attempt.PaymentIntentID = sess.ID, sess.PaymentIntentID
+		settled = sess.Status == provider.StatusSucceeded
+	}
+
+	// save payment to database
 	if err := s.Repo.Save(payment); err != nil {
+		payment.Status = model.PaymentFailed
+		s.releaseWalletCredit(payment)
+		return nil, fmt.Errorf("db failed: %w", err)
+	}
+	if err := s.Repo.SaveAttempt(attempt); err != nil {
 		return nil, fmt.Errorf("db failed: %w", err)
 	}
 
-	fmt.Println("[MOCK STRIPE] Payment initiated in DB:", payment.ID)
-
-	// Publicăm event-ul real către Kafka
+	// publish payment.created event
 	event := map[string]interface{}{
 		"payment_id": payment.ID,
 		"order_id":   payment.OrderID,
+		"user_id":    payment.UserID,
 		"status":     payment.Status,
+		"provider":   payment.Provider,
+		"amount":     payment.Amount,
+		"currency":   payment.Currency,
+	}
+	if payment.WalletAmount > 0 {
+		event["wallet_amount"] = payment.WalletAmount
 	}
 	if err := s.kafka.SendMessage(ctx, "payment-events", payment.ID, event); err != nil {
 		log.Printf("failed to publish payment.created event: %v", err)
 	}
 
+	if declined {
+		return s.fail(ctx, payment, declineCode, payment.Message)
+	}
+	// off-session charges and store credit settle at once
+	if (method != nil || settled) && payment.Status == model.PaymentPending {
+		return s.syncWithProvider(ctx, p, payment)
+	}
 	return payment, nil
 }
 
-// updateStatus updates the payment status and publishes an event
+// UpdateStatus moves the payment to a new status and publishes an event.
+// Setting the status a payment already has is a no-op, so redelivered updates
+// are harmless; any other change must be allowed by model.CanTransition.
+// Failures go through the retry policy.
 func (s *PaymentService) UpdateStatus(ctx context.Context, paymentID string, status model.PaymentStatus, message string) error {
-	if err := s.Repo.UpdateStatus(paymentID, status, message); err != nil {
+	payment, err := s.Repo.FindByID(paymentID)
+	if err != nil {
+		return err
+	}
+	if payment.Status == status {
+		return nil
+	}
+	if status == model.PaymentFailed {
+		_, err = s.fail(ctx, payment, "", message)
 		return err
 	}
+	_, err = s.transition(ctx, payment, status, message, nil)
+	return err
+}
+
+// CapturePayment charges an authorized payment, fully when amount is 0 or
+// partially otherwise. The rest of the authorization is released.
+func (s *PaymentService) CapturePayment(ctx context.Context, paymentID string, amount float64) (*model.Payment, error) {
+	payment, err := s.Repo.FindByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	// the customer may have completed checkout without us hearing about it yet
+	if payment.Status == model.PaymentPending || payment.Status == model.PaymentRequiresAction {
+		if payment, err = s.syncWithProvider(ctx, p, payment); err != nil {
+			return nil, err
+		}
+	}
+	if payment.Status != model.PaymentAuthorized {
+		return nil, fmt.Errorf("%w: status is %s", ErrNotCapturable, payment.Status)
+	}
+	if amount == 0 {
+		amount = payment.ProviderAmount()
+	}
+	if amount < 0 || amount > payment.ProviderAmount() {
+		return nil, fmt.Errorf("%w: capture %.2f of %.2f", ErrInvalidAmount, amount, payment.ProviderAmount())
+	}
+
+	sess, err := p.Capture(ctx, payment.StripeSessionID, amount)
+	if err != nil {
+		return nil, fmt.Errorf("capture failed: %w", err)
+	}
+	return s.transition(ctx, payment, model.PaymentCaptured, fmt.Sprintf("captured %.2f", sess.Amount), map[string]interface{}{
+		"captured_amount": sess.Amount,
+		"captured_at":     time.Now(),
+	})
+}
+
+// VoidPayment releases an authorization or abandons a payment that has not
+// been charged yet
+func (s *PaymentService) VoidPayment(ctx context.Context, paymentID, reason string) (*model.Payment, error) {
+	payment, err := s.Repo.FindByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if !model.CanTransition(payment.Status, model.PaymentVoided) {
+		return nil, fmt.Errorf("%w: status is %s", ErrNotVoidable, payment.Status)
+	}
+	p, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if payment.StripeSessionID != "" {
+		if _, err := p.Cancel(ctx, payment.StripeSessionID); err != nil {
+			return nil, fmt.Errorf("void failed: %w", err)
+		}
+	}
+	if reason == "" {
+		reason = "voided"
+	}
+	return s.transition(ctx, payment, model.PaymentVoided, reason, nil)
+}
+
+// syncWithProvider applies the provider's view of a payment when it is a valid
+// next step for the stored status
+func (s *PaymentService) syncWithProvider(ctx context.Context, p provider.PaymentProvider, payment *model.Payment) (*model.Payment, error) {
+	sess, err := p.FetchStatus(ctx, payment.StripeSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch provider status: %w", err)
+	}
+	return s.applySession(ctx, payment, sess)
+}
+
+// ConfirmPayment resumes a payment after the customer completed its next
+// action, such as a 3-D Secure challenge, and applies the outcome
+func (s *PaymentService) ConfirmPayment(ctx context.Context, paymentID string) (*model.Payment, error) {
+	payment, err := s.Repo.FindByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != model.PaymentRequiresAction {
+		return nil, fmt.Errorf("%w: status is %s", ErrNotConfirmable, payment.Status)
+	}
+	p, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := p.Confirm(ctx, payment.StripeSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("confirm failed: %w", err)
+	}
+	return s.applySession(ctx, payment, sess)
+}
+
+// applySession moves a payment to the status of its provider session
+func (s *PaymentService) applySession(ctx context.Context, payment *model.Payment, sess *provider.Session) (*model.Payment, error) {
+	next := statusFromProvider(payment.Status, sess.Status)
+	if next == "" || next == payment.Status || !model.CanTransition(payment.Status, next) {
+		return payment, nil
+	}
+	if next == model.PaymentFailed {
+		return s.fail(ctx, payment, sess.DeclineCode, sess.Message)
+	}
+	var fields map[string]interface{}
+	if next == model.PaymentRequiresAction {
+		fields = nextActionFields(sess.NextAction)
+	}
+	return s.transition(ctx, payment, next, sess.Message, fields)
+}
+
+// transition applies a validated status change and publishes it
+func (s *PaymentService) transition(ctx context.Context, payment *model.Payment, to model.PaymentStatus, message string, fields map[string]interface{}) (*model.Payment, error) {
+	// money moved; unless the caller knows better, the whole amount was captured
+	if (to == model.PaymentPaid || to == model.PaymentCaptured) && fields == nil {
+		fields = map[string]interface{}{
+			"captured_amount": payment.ProviderAmount(),
+			"captured_at":     time.Now(),
+		}
+	}
+	// the provider keeps its fee out of what it collected
+	if captured, ok := fields["captured_amount"].(float64); ok && (to == model.PaymentPaid || to == model.PaymentCaptured) {
+		fee := s.Fees.Fee(payment.Provider, FeeCapture, s.feeMethod(payment), payment.Currency, captured)
+		fields["fee_amount"] = fee
+		fields["net_amount"] = float64(cents(captured)-cents(fee)) / 100
+	}
+	// the challenge is over, whatever its outcome
+	if payment.Status == model.PaymentRequiresAction && to != model.PaymentRequiresAction {
+		if fields == nil {
+			fields = map[string]interface{}{}
+		}
+		for column, value := range nextActionFields(nil) {
+			fields[column] = value
+		}
+	}
+	updated, err := s.Repo.Transition(payment.ID, to, message, fields)
+	if err != nil {
+		return nil, err
+	}
+	if outcome, done := attemptOutcome(to); done {
+		declineCode, _ := fields["decline_code"].(string)
+		if err := s.Repo.FinishAttempt(updated.ID, updated.Attempts, outcome, declineCode, message); err != nil {
+			log.Printf("failed to record outcome of attempt %d of payment %s: %v", updated.Attempts, updated.ID, err)
+		}
+	}
+	if releasesWalletCredit(to) {
+		s.releaseWalletCredit(updated)
+	}
 
 	// publish payment.status-updated event
 	event := map[string]interface{}{
-		"payment_id": paymentID,
-		"status":     status,
+		"payment_id":      updated.ID,
+		"order_id":        updated.OrderID,
+		"user_id":         updated.UserID,
+		"status":          updated.Status,
+		"amount":          updated.Amount,
+		"captured_amount": updated.CapturedAmount,
+		"fee_amount":      updated.FeeAmount,
+		"net_amount":      updated.NetAmount,
+		"wallet_amount":   updated.WalletAmount,
+		"currency":        updated.Currency,
+		"provider":        updated.Provider,
 	}
-	if err := s.kafka.SendMessage(ctx, "payment-status-updates", paymentID, event); err != nil {
+	if updated.Status == model.PaymentRetryScheduled && updated.NextRetryAt != nil {
+		// the inventory holds the order's stock until the retry
+		event["next_retry_at"] = updated.NextRetryAt.Format(time.RFC3339)
+	}
+	if err := s.kafka.SendMessage(ctx, "payment-status-updates", updated.ID, event); err != nil {
 		log.Printf("failed to publish payment.status-updated event: %v", err)
 	}
-	return nil
+	return updated, nil
+}
+
+// attemptOutcome tells how the current attempt ended when a payment moves to status
+func attemptOutcome(status model.PaymentStatus) (model.AttemptStatus, bool) {
+	switch status {
+	case model.PaymentAuthorized, model.PaymentPaid:
+		return model.AttemptSucceeded, true
+	case model.PaymentRetryScheduled, model.PaymentFailed, model.PaymentVoided, model.PaymentExpired:
+		return model.AttemptFailed, true
+	}
+	return "", false
+}
+
+// nextActionFields returns the payment columns describing a next action; nil clears them
+func nextActionFields(a *provider.NextAction) map[string]interface{} {
+	if a == nil {
+		a = &provider.NextAction{}
+	}
+	return map[string]interface{}{
+		"next_action_type": a.Type,
+		"next_action_url":  a.RedirectURL,
+		"client_secret":    a.ClientSecret,
+	}
+}
+
+// statusFromProvider maps a provider status onto the payment lifecycle; an
+// empty result means the provider reports nothing new
+func statusFromProvider(current model.PaymentStatus, st provider.Status) model.PaymentStatus {
+	switch st {
+	case provider.StatusRequiresAction:
+		return model.PaymentRequiresAction
+	case provider.StatusRequiresCapture:
+		return model.PaymentAuthorized
+	case provider.StatusSucceeded:
+		if current == model.PaymentAuthorized {
+			return model.PaymentCaptured
+		}
+		return model.PaymentPaid
+	case provider.StatusFailed:
+		return model.PaymentFailed
+	case provider.StatusCanceled:
+		if current == model.PaymentAuthorized {
+			return model.PaymentVoided
+		}
+		return model.PaymentExpired
+	case provider.StatusRefunded:
+		return model.PaymentRefunded
+	}
+	return ""
 }
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"log"
+	"time"
+)
+
+// ErrPaymentNotFound is returned when a provider event refers to an unknown payment
+var ErrPaymentNotFound = errors.New("payment not found for provider event")
+
+// ProviderEvent is a provider notification reduced to what the service needs
+type ProviderEvent struct {
+	ID              string // provider event ID, used for deduplication
+	Provider        string
+	Type            string
+	SessionID       string
+	PaymentIntentID string
+	PaymentID       string              // from provider metadata, if present
+	Status          model.PaymentStatus // empty when the event carries no status change
+	Amount          float64             // amount received, for events that move money
+	Message         string
+	DeclineCode     string // for failures, e.g. "insufficient_funds"
+	Attempt         int    // attempt number from provider metadata; 0 when unknown
+	// NextAction tells the customer how to authenticate, for REQUIRES_ACTION events
+	NextAction *provider.NextAction
+	// Dispute is the provider's view of a dispute of the payment, for dispute events
+	Dispute *provider.DisputeUpdate
+	// Refunds are the provider's view of refunds of the payment, for refund events
+	Refunds []provider.Refund
+	// RefundsChanged is set by events that refunded the payment without
+	// listing its refunds; they are then fetched from the provider
+	RefundsChanged bool
+}
+
+// ApplyProviderEvent applies a provider event to its payment exactly once.
+// Events that were already processed are ignored.
+func (s *PaymentService) ApplyProviderEvent(ctx context.Context, evt ProviderEvent) error {
+	processed, err := s.Repo.IsWebhookEventProcessed(evt.ID)
+	if err != nil {
+		return fmt.Errorf("check event %s: %w", evt.ID, err)
+	}
+	if processed {
+		log.Printf("ignoring redelivered %s event %s", evt.Type, evt.ID)
+		return nil
+	}
+
+	payment, err := s.findPaymentForEvent(evt)
+	if err != nil {
+		return err
+	}
+	// an earlier attempt was already given up on and replaced by a retry
+	if evt.Attempt > 0 && payment.Attempts > 0 && evt.Attempt != payment.Attempts {
+		log.Printf("ignoring %s event %s for superseded attempt %d of payment %s", evt.Type, evt.ID, evt.Attempt, payment.ID)
+		return s.saveWebhookEvent(evt, payment.ID)
+	}
+
+	// remember the intent so later intent and charge events can be matched
+	if evt.PaymentIntentID != "" && payment.PaymentIntentID == "" {
+		if err := s.Repo.SetPaymentIntentID(payment.ID, evt.PaymentIntentID); err != nil {
+			return fmt.Errorf("store payment intent for %s: %w", payment.ID, err)
+		}
+	}
+
+	next := evt.Status
+	if next == model.PaymentPaid && payment.Status == model.PaymentAuthorized {
+		// the authorization was captured outside this service, e.g. in the dashboard
+		next = model.PaymentCaptured
+	}
+	// events describing a state the payment already moved past are stale
+	if next != "" && next != payment.Status && model.CanTransition(payment.Status, next) {
+		var fields map[string]interface{}
+		if evt.Amount > 0 && (next == model.PaymentPaid || next == model.PaymentCaptured) {
+			fields = map[string]interface{}{"captured_amount": evt.Amount, "captured_at": time.Now()}
+		}
+		if next == model.PaymentRequiresAction && evt.NextAction != nil {
+			fields = nextActionFields(evt.NextAction)
+		}
+		if next == model.PaymentFailed {
+			_, err = s.fail(ctx, payment, evt.DeclineCode, evt.Message)
+		} else {
+			_, err = s.transition(ctx, payment, next, evt.Message, fields)
+		}
+		if err != nil {
+			return fmt.Errorf("update payment %s: %w", payment.ID, err)
+		}
+	}
+	if evt.Dispute != nil {
+		if err := s.applyDispute(ctx, payment, evt.Dispute); err != nil {
+			return err
+		}
+	}
+	if len(evt.Refunds) > 0 || evt.RefundsChanged {
+		if err := s.applyProviderRefunds(ctx, payment, evt.Refunds); err != nil {
+			return err
+		}
+	}
+
+	return s.saveWebhookEvent(evt, payment.ID)
+}
+
+// saveWebhookEvent marks an event as processed
+func (s *PaymentService) saveWebhookEvent(evt ProviderEvent, paymentID string) error {
+	return s.Repo.SaveWebhookEvent(&model.WebhookEvent{
+		ID:          evt.ID,
+		Provider:    evt.Provider,
+		Type:        evt.Type,
+		PaymentID:   paymentID,
+		ProcessedAt: time.Now(),
+	})
+}
+
+// findPaymentForEvent matches an event by session, then intent, then metadata
+func (s *PaymentService) findPaymentForEvent(evt ProviderEvent) (*model.Payment, error) {
+	lookups := []struct {
+		key  string
+		find func(string) (*model.Payment, error)
+	}{
+		{evt.SessionID, s.Repo.FindByStripeSessionID},
+		{evt.PaymentIntentID, s.Repo.FindByPaymentIntentID},
+		{evt.PaymentID, s.Repo.FindByID},
+	}
+	for _, l := range lookups {
+		if l.key == "" {
+			continue
+		}
+		payment, err := l.find(l.key)
+		if err == nil {
+			return payment, nil
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, ErrPaymentNotFound
+}
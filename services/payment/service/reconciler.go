@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/configs/utils"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"log"
+	"math"
+	"time"
+)
+
+// reconcilePageSize is the number of provider transactions fetched per page
+const reconcilePageSize = 100
+
+// RunReconciler reconciles every configured provider once per interval over
+// the trailing window, until ctx is cancelled
+func (s *PaymentService) RunReconciler(ctx context.Context, interval, window time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			to := time.Now()
+			for _, name := range s.providers.Names() {
+				report, err := s.Reconcile(ctx, name, to.Add(-window), to)
+				if err != nil {
+					log.Printf("reconciliation for %s failed: %v", name, err)
+					continue
+				}
+				log.Printf("reconciliation %s for %s: %d matched, %d missing locally, %d missing at provider, %d amount and %d status mismatches, %d fixed",
+					report.ID, name, report.Matched, report.MissingLocally, report.MissingAtProvider, report.AmountMismatches, report.StatusMismatches, report.Fixed)
+			}
+		}
+	}
+}
+
+// Reconcile compares the provider's transactions created in [from, to) with
+// the local payments, fixes status drift that is a valid transition (such as a
+// lost webhook) and stores the resulting report
+func (s *PaymentService) Reconcile(ctx context.Context, providerName string, from, to time.Time) (*model.ReconciliationReport, error) {
+	p, err := s.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+	report := &model.ReconciliationReport{
+		ID:          utils.GenerateUUID(),
+		Provider:    p.Name(),
+		WindowStart: from,
+		WindowEnd:   to,
+		StartedAt:   time.Now(),
+	}
+
+	local, err := s.Repo.ListByProviderCreatedBetween(p.Name(), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("list local payments: %w", err)
+	}
+	bySession := make(map[string]*model.Payment, len(local))
+	for i := range local {
+		if local[i].StripeSessionID != "" {
+			bySession[local[i].StripeSessionID] = &local[i]
+		}
+	}
+	seen := make(map[string]bool)
+
+	cursor := ""
+	for {
+		page, err := p.ListTransactions(ctx, from, to, cursor, reconcilePageSize)
+		if err != nil {
+			return nil, fmt.Errorf("list provider transactions: %w", err)
+		}
+		for _, tx := range page.Transactions {
+			payment, err := s.matchTransaction(bySession, tx)
+			if err != nil {
+				return nil, err
+			}
+			if payment == nil {
+				// sessions of attempts replaced by a retry belong to a known payment
+				superseded, err := s.isSupersededAttempt(tx)
+				if err != nil {
+					return nil, err
+				}
+				if superseded {
+					report.Matched++
+					continue
+				}
+				report.MissingLocally++
+				report.Items = append(report.Items, model.ReconciliationItem{
+					Kind:           model.DiscrepancyMissingLocally,
+					PaymentID:      tx.PaymentID,
+					SessionID:      tx.SessionID,
+					ProviderStatus: string(tx.Status),
+					ProviderAmount: tx.Amount,
+				})
+				continue
+			}
+			seen[payment.ID] = true
+			s.compareTransaction(ctx, report, payment, tx)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	// payments that failed before a session was created never reached the provider
+	for _, payment := range local {
+		if seen[payment.ID] || payment.StripeSessionID == "" {
+			continue
+		}
+		report.MissingAtProvider++
+		report.Items = append(report.Items, model.ReconciliationItem{
+			Kind:        model.DiscrepancyMissingAtProvider,
+			PaymentID:   payment.ID,
+			SessionID:   payment.StripeSessionID,
+			LocalStatus: string(payment.Status),
+			LocalAmount: payment.ProviderAmount(),
+		})
+	}
+
+	report.FinishedAt = time.Now()
+	for i := range report.Items {
+		report.Items[i].ReportID = report.ID
+	}
+	if err := s.Repo.SaveReconciliationReport(report); err != nil {
+		return nil, fmt.Errorf("save reconciliation report: %w", err)
+	}
+	return report, nil
+}
+
+// GetReconciliationReport returns a report by ID, or the latest one for the
+// provider when reportID is empty
+func (s *PaymentService) GetReconciliationReport(reportID, providerName string) (*model.ReconciliationReport, error) {
+	if reportID != "" {
+		return s.Repo.FindReconciliationReport(reportID)
+	}
+	return s.Repo.LatestReconciliationReport(providerName)
+}
+
+// matchTransaction finds the local payment for a provider transaction. Payments
+// created just outside the window are looked up directly.
+func (s *PaymentService) matchTransaction(bySession map[string]*model.Payment, tx provider.Transaction) (*model.Payment, error) {
+	if payment, ok := bySession[tx.SessionID]; ok {
+		return payment, nil
+	}
+	lookups := []struct {
+		key  string
+		find func(string) (*model.Payment, error)
+	}{
+		{tx.SessionID, s.Repo.FindByStripeSessionID},
+		{tx.PaymentIntentID, s.Repo.FindByPaymentIntentID},
+	}
+	for _, l := range lookups {
+		if l.key == "" {
+			continue
+		}
+		payment, err := l.find(l.key)
+		if err == nil {
+			return payment, nil
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// isSupersededAttempt reports whether a transaction is the session of an
+// earlier attempt of a payment that has since been retried
+func (s *PaymentService) isSupersededAttempt(tx provider.Transaction) (bool, error) {
+	if tx.SessionID == "" {
+		return false, nil
+	}
+	_, err := s.Repo.FindAttemptBySessionID(tx.SessionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// compareTransaction records amount and status differences for a matched pair
+func (s *PaymentService) compareTransaction(ctx context.Context, report *model.ReconciliationReport, payment *model.Payment, tx provider.Transaction) {
+	matched := true
+
+	expectedAmount := payment.ProviderAmount()
+	if payment.CapturedAmount > 0 {
+		expectedAmount = payment.CapturedAmount
+	}
+	if math.Round(expectedAmount*100) != math.Round(tx.Amount*100) {
+		// amounts are never changed automatically
+		matched = false
+		report.AmountMismatches++
+		report.Items = append(report.Items, model.ReconciliationItem{
+			Kind:           model.DiscrepancyAmountMismatch,
+			PaymentID:      payment.ID,
+			SessionID:      tx.SessionID,
+			LocalStatus:    string(payment.Status),
+			ProviderStatus: string(tx.Status),
+			LocalAmount:    expectedAmount,
+			ProviderAmount: tx.Amount,
+		})
+	}
+
+	if expected, consistent := reconciledStatus(payment.Status, tx.Status); !consistent {
+		matched = false
+		report.StatusMismatches++
+		item := model.ReconciliationItem{
+			Kind:           model.DiscrepancyStatusMismatch,
+			PaymentID:      payment.ID,
+			SessionID:      tx.SessionID,
+			LocalStatus:    string(payment.Status),
+			ProviderStatus: string(tx.Status),
+			LocalAmount:    expectedAmount,
+			ProviderAmount: tx.Amount,
+		}
+		// a valid forward transition is what the lost webhook would have done
+		if model.CanTransition(payment.Status, expected) {
+			message := "reconciliation: provider reports " + string(tx.Status)
+			var updated *model.Payment
+			var err error
+			if expected == model.PaymentFailed {
+				// declines still go through the retry policy
+				updated, err = s.fail(ctx, payment, tx.DeclineCode, message)
+			} else {
+				updated, err = s.transition(ctx, payment, expected, message, nil)
+			}
+			if err != nil {
+				item.Note = "automatic fix failed: " + err.Error()
+			} else {
+				item.Fixed = true
+				item.Note = fmt.Sprintf("moved from %s to %s", payment.Status, updated.Status)
+				report.Fixed++
+			}
+		} else {
+			item.Note = fmt.Sprintf("provider implies %s; needs manual review", expected)
+		}
+		report.Items = append(report.Items, item)
+	}
+
+	if matched {
+		report.Matched++
+	}
+}
+
+// reconciledStatus returns the local status the provider status implies and
+// whether the stored status already agrees with it
+func reconciledStatus(local model.PaymentStatus, st provider.Status) (model.PaymentStatus, bool) {
+	expected := statusFromProvider(local, st)
+	if expected == "" || expected == local {
+		return local, true
+	}
+	// the declined session of a payment waiting for its retry stays failed
+	if st == provider.StatusFailed && local == model.PaymentRetryScheduled {
+		return local, true
+	}
+	// the provider keeps reporting success for captured and partially refunded payments
+	if st == provider.StatusSucceeded {
+		switch local {
+		case model.PaymentPaid, model.PaymentCaptured, model.PaymentPartiallyRefunded, model.PaymentRefunded:
+			return local, true
+		}
+	}
+	return expected, false
+}
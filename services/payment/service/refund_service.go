@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/configs/utils"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"log"
+	"time"
+)
+
+// refundSyncBatchSize is the number of pending refunds settled per sync
+const refundSyncBatchSize = 100
+
+// ErrIdempotencyKeyRequired is returned when a refund request has no idempotency key
+var ErrIdempotencyKeyRequired = errors.New("idempotency key is required")
+
+// RefundPayment refunds amount of a paid payment, or the remainder when amount
+// is 0. Retrying with the same idempotency key returns the original refund, and
+// a refund left pending by an earlier attempt is sent to the provider again.
+// A refund the provider has not settled, or whose request failed on the way,
+// is returned PENDING: the provider may still refund it, so it is settled
+// later by SyncPendingRefunds or a provider event and never failed here.
+func (s *PaymentService) RefundPayment(ctx context.Context, paymentID string, amount float64, reason, idempotencyKey string) (*model.Refund, *model.Payment, error) {
+	if idempotencyKey == "" {
+		return nil, nil, ErrIdempotencyKeyRequired
+	}
+	if amount < 0 {
+		return nil, nil, fmt.Errorf("invalid refund amount %.2f", amount)
+	}
+	payment, err := s.Repo.FindByID(paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refund, err := s.Repo.CreateRefund(&model.Refund{
+		ID:             utils.GenerateUUID(),
+		PaymentID:      payment.ID,
+		IdempotencyKey: idempotencyKey,
+		Amount:         amount,
+		Currency:       payment.Currency,
+		Reason:         reason,
+		Status:         model.RefundPending,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if refund.Status != model.RefundPending {
+		// replay of a refund that already completed
+		payment, err := s.Repo.FindByID(paymentID)
+		return refund, payment, err
+	}
+
+	// the refund ID doubles as the provider idempotency key, so a retry never refunds twice
+	result, err := p.Refund(ctx, payment.StripeSessionID, refund.Amount, reason, refund.ID)
+	if err != nil {
+		refund.Message = "refund not confirmed by the provider: " + err.Error()
+		if err := s.Repo.UpdatePendingRefund(refund.ID, "", refund.Message); err != nil {
+			log.Printf("failed to record refund %s: %v", refund.ID, err)
+		}
+		return refund, payment, nil
+	}
+	return s.completeRefund(ctx, payment, refund, result)
+}
+
+// completeRefund applies the provider's result to a pending refund: a refund
+// still pending keeps waiting, any other is completed and published on
+// refund-events
+func (s *PaymentService) completeRefund(ctx context.Context, payment *model.Payment, refund *model.Refund, result *provider.Refund) (*model.Refund, *model.Payment, error) {
+	status, message, fee := model.RefundFailed, result.Message, 0.0
+	switch result.Status {
+	case provider.StatusPending:
+		refund.ProviderRefundID, refund.Message = result.ID, "refund pending at the provider"
+		if err := s.Repo.UpdatePendingRefund(refund.ID, result.ID, refund.Message); err != nil {
+			return nil, nil, fmt.Errorf("update refund: %w", err)
+		}
+		return refund, payment, nil
+	case provider.StatusSucceeded:
+		status, message = model.RefundSucceeded, "refund succeeded"
+		fee = s.Fees.Fee(payment.Provider, FeeRefund, s.feeMethod(payment), payment.Currency, refund.Amount)
+	default:
+		if message == "" {
+			message = "refund " + string(result.Status)
+		}
+	}
+
+	refund, payment, err := s.Repo.CompleteRefund(refund.ID, status, result.ID, message, fee)
+	if err != nil {
+		return nil, nil, fmt.Errorf("complete refund: %w", err)
+	}
+	if payment.Status == model.PaymentRefunded {
+		s.releaseWalletCredit(payment)
+	}
+
+	// publish refund event
+	event := map[string]interface{}{
+		"refund_id":       refund.ID,
+		"payment_id":      payment.ID,
+		"order_id":        payment.OrderID,
+		"user_id":         payment.UserID,
+		"amount":          refund.Amount,
+		"fee_amount":      refund.FeeAmount,
+		"currency":        refund.Currency,
+		"status":          refund.Status,
+		"reason":          refund.Reason,
+		"payment_status":  payment.Status,
+		"refunded_amount": payment.RefundedAmount,
+		"wallet_amount":   payment.WalletAmount,
+		"provider":        payment.Provider,
+	}
+	if err := s.kafka.SendMessage(ctx, "refund-events", refund.ID, event); err != nil {
+		log.Printf("failed to publish refund event: %v", err)
+	}
+	return refund, payment, nil
+}
+
+// applyProviderRefunds settles the refunds a provider event reports, or every
+// refund of the payment when the event did not list them. Refunds requested
+// here are completed through the refund path; refunds made at the provider,
+// e.g. in its dashboard, are recorded once they succeeded.
+func (s *PaymentService) applyProviderRefunds(ctx context.Context, payment *model.Payment, refunds []provider.Refund) error {
+	if len(refunds) == 0 {
+		p, err := s.providers.Get(payment.Provider)
+		if err != nil {
+			return err
+		}
+		lister, ok := p.(provider.RefundLister)
+		if !ok {
+			log.Printf("provider %s cannot list the refunds of payment %s", payment.Provider, payment.ID)
+			return nil
+		}
+		if refunds, err = lister.ListRefunds(ctx, payment.StripeSessionID); err != nil {
+			return fmt.Errorf("list refunds of payment %s: %w", payment.ID, err)
+		}
+	}
+	for i := range refunds {
+		if err := s.applyProviderRefund(ctx, payment, &refunds[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyProviderRefund completes the pending refund result belongs to
+func (s *PaymentService) applyProviderRefund(ctx context.Context, payment *model.Payment, result *provider.Refund) error {
+	var refund *model.Refund
+	var err error
+	if result.RefundID != "" {
+		refund, err = s.Repo.FindRefund(result.RefundID)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Printf("ignoring unknown refund %s of payment %s", result.RefundID, payment.ID)
+			return nil
+		}
+	} else {
+		if result.Status != provider.StatusSucceeded {
+			return nil
+		}
+		// keyed by the provider refund, so a redelivered event records it once
+		refund, err = s.Repo.CreateRefund(&model.Refund{
+			ID:             utils.GenerateUUID(),
+			PaymentID:      payment.ID,
+			IdempotencyKey: "provider_" + result.ID,
+			Amount:         result.Amount,
+			Currency:       payment.Currency,
+			Reason:         "refunded at the provider",
+			Status:         model.RefundPending,
+		})
+		if errors.Is(err, repository.ErrNotRefundable) || errors.Is(err, repository.ErrRefundExceedsPayment) {
+			log.Printf("cannot record provider refund %s of payment %s: %v", result.ID, payment.ID, err)
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("find refund %s: %w", result.ID, err)
+	}
+	if refund.Status != model.RefundPending || refund.PaymentID != payment.ID {
+		return nil
+	}
+	// an earlier refund of the same event may have changed the payment
+	current, err := s.Repo.FindByID(payment.ID)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.completeRefund(ctx, current, refund, result)
+	return err
+}
+
+// RunRefundSync settles pending refunds once per interval, until ctx is cancelled
+func (s *PaymentService) RunRefundSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.SyncPendingRefunds(ctx, time.Now().Add(-interval))
+			if err != nil {
+				log.Printf("refund sync failed: %v", err)
+			} else if n > 0 {
+				log.Printf("checked %d pending refunds", n)
+			}
+		}
+	}
+}
+
+// SyncPendingRefunds asks the provider about every refund created before
+// createdBefore that is still pending and completes those it settled. A
+// refund the provider never received is sent again under its own key. It
+// returns how many refunds were checked.
+func (s *PaymentService) SyncPendingRefunds(ctx context.Context, createdBefore time.Time) (int, error) {
+	refunds, err := s.Repo.ListPendingRefunds(createdBefore, refundSyncBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("list pending refunds: %w", err)
+	}
+	for i := range refunds {
+		if err := s.syncRefund(ctx, &refunds[i]); err != nil {
+			log.Printf("sync of refund %s failed: %v", refunds[i].ID, err)
+		}
+	}
+	return len(refunds), nil
+}
+
+// syncRefund settles one pending refund
+func (s *PaymentService) syncRefund(ctx context.Context, refund *model.Refund) error {
+	payment, err := s.Repo.FindByID(refund.PaymentID)
+	if err != nil {
+		return err
+	}
+	p, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return err
+	}
+	result, err := p.FetchRefund(ctx, payment.StripeSessionID, refund.ID)
+	if errors.Is(err, provider.ErrNotFound) {
+		result, err = p.Refund(ctx, payment.StripeSessionID, refund.Amount, refund.Reason, refund.ID)
+	}
+	if err != nil {
+		return err
+	}
+	_, _, err = s.completeRefund(ctx, payment, refund, result)
+	return err
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// retryLease hides a claimed retry from other workers while it is attempted
+	retryLease = 5 * time.Minute
+	// retryBatchSize is the number of due retries claimed per run
+	retryBatchSize = 50
+)
+
+// dunning stages published on notification-events
+const (
+	DunningRetryScheduled   = "retry_scheduled"
+	DunningRetriesExhausted = "retries_exhausted"
+)
+
+// RetryPolicy decides whether and when a declined payment is tried again. The
+// zero value makes no retries.
+type RetryPolicy struct {
+	Schedule    []time.Duration // wait before each retry; the last entry repeats
+	MaxAttempts int             // tries including the first one
+}
+
+// DefaultRetryPolicy retries after an hour, a day and three days
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{Schedule: []time.Duration{time.Hour, 24 * time.Hour, 72 * time.Hour}, MaxAttempts: 4}
+}
+
+// ParseRetryPolicy builds a policy from a comma-separated list of durations and
+// a maximum number of attempts. An empty schedule uses the default one, and an
+// empty maximum allows one retry per schedule entry.
+func ParseRetryPolicy(schedule, maxAttempts string) (RetryPolicy, error) {
+	policy := DefaultRetryPolicy()
+	if schedule != "" {
+		policy.Schedule = nil
+		for _, part := range strings.Split(schedule, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(part))
+			if err != nil || d <= 0 {
+				return RetryPolicy{}, fmt.Errorf("invalid retry delay %q", part)
+			}
+			policy.Schedule = append(policy.Schedule, d)
+		}
+	}
+	policy.MaxAttempts = len(policy.Schedule) + 1
+	if maxAttempts != "" {
+		n, err := strconv.Atoi(maxAttempts)
+		if err != nil || n < 1 {
+			return RetryPolicy{}, fmt.Errorf("invalid maximum attempts %q", maxAttempts)
+		}
+		policy.MaxAttempts = n
+	}
+	return policy, nil
+}
+
+// NextDelay returns how long to wait for the next try after attempts tries,
+// or false when the policy allows no more
+func (p RetryPolicy) NextDelay(attempts int) (time.Duration, bool) {
+	if attempts >= p.MaxAttempts || len(p.Schedule) == 0 {
+		return 0, false
+	}
+	i := attempts - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(p.Schedule) {
+		i = len(p.Schedule) - 1
+	}
+	return p.Schedule[i], true
+}
+
+// RunRetryWorker makes the due payment retries once per interval, until ctx is cancelled
+func (s *PaymentService) RunRetryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.RetryDuePayments(ctx, time.Now())
+			if err != nil {
+				log.Printf("payment retries failed: %v", err)
+			} else if n > 0 {
+				log.Printf("retried %d payments", n)
+			}
+		}
+	}
+}
+
+// RetryDuePayments makes the next attempt for every payment whose retry is due
+// at now and returns how many were attempted
+func (s *PaymentService) RetryDuePayments(ctx context.Context, now time.Time) (int, error) {
+	payments, err := s.Repo.ClaimDueRetries(now, retryLease, retryBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim due retries: %w", err)
+	}
+	for i := range payments {
+		if _, err := s.retryPayment(ctx, &payments[i]); err != nil {
+			log.Printf("retry of payment %s failed: %v", payments[i].ID, err)
+		}
+	}
+	return len(payments), nil
+}
+
+// retryPayment opens a new provider session for the next attempt and moves the
+// payment back to PENDING. Off-session attempts usually resolve at once, so
+// the outcome is fetched right away.
+func (s *PaymentService) retryPayment(ctx context.Context, payment *model.Payment) (*model.Payment, error) {
+	p, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	number := payment.Attempts + 1
+	req := provider.SessionRequest{
+		PaymentID:     payment.ID,
+		OrderID:       payment.OrderID,
+		UserID:        payment.UserID,
+		Amount:        payment.ProviderAmount(),
+		Currency:      payment.Currency,
+		ManualCapture: payment.CaptureMethod == model.CaptureManual,
+		Attempt:       number,
+	}
+	// charge the saved method again; if it was detached meanwhile, fall back to a hosted page
+	if payment.PaymentMethodID != "" {
+		if method, err := s.Repo.FindPaymentMethod(payment.PaymentMethodID); err == nil {
+			req.PaymentMethod, req.CustomerID = method.ProviderToken, method.CustomerID
+		}
+	}
+	sess, sessErr := p.CreateSession(ctx, req)
+
+	attempt := &model.PaymentAttempt{PaymentID: payment.ID, Number: number, Status: model.AttemptPending, CreatedAt: time.Now()}
+	fields := map[string]interface{}{"attempts": number, "next_retry_at": nil}
+	if sessErr == nil {
+		attempt.SessionID, attempt.PaymentIntentID = sess.ID, sess.PaymentIntentID
+		fields["stripe_session_id"] = sess.ID
+		fields["payment_intent_id"] = sess.PaymentIntentID
+		fields["checkout_url"] = sess.URL
+	}
+	if err := s.Repo.SaveAttempt(attempt); err != nil {
+		return nil, fmt.Errorf("save attempt: %w", err)
+	}
+	updated, err := s.transition(ctx, payment, model.PaymentPending, fmt.Sprintf("retry attempt %d", number), fields)
+	if err != nil {
+		return nil, err
+	}
+	if sessErr != nil {
+		// the provider could not start the attempt; count it as a soft decline
+		return s.fail(ctx, updated, provider.DeclineProcessingError, sessErr.Error())
+	}
+	return s.syncWithProvider(ctx, p, updated)
+}
+
+// fail handles a declined attempt: soft declines are retried while the policy
+// allows it, anything else fails the payment for good
+func (s *PaymentService) fail(ctx context.Context, payment *model.Payment, declineCode, message string) (*model.Payment, error) {
+	attempts := payment.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay, retry := s.RetryPolicy.NextDelay(attempts)
+	if retry && provider.IsRetryableDecline(declineCode) && model.CanTransition(payment.Status, model.PaymentRetryScheduled) {
+		updated, err := s.transition(ctx, payment, model.PaymentRetryScheduled, message, map[string]interface{}{
+			"decline_code":  declineCode,
+			"next_retry_at": time.Now().Add(delay),
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.publishDunning(ctx, updated, DunningRetryScheduled)
+		return updated, nil
+	}
+
+	updated, err := s.transition(ctx, payment, model.PaymentFailed, message, map[string]interface{}{
+		"decline_code":  declineCode,
+		"next_retry_at": nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if updated.Attempts > 1 {
+		s.publishDunning(ctx, updated, DunningRetriesExhausted)
+	}
+	return updated, nil
+}
+
+// publishDunning asks the notification service to tell the customer about a
+// declined payment
+func (s *PaymentService) publishDunning(ctx context.Context, payment *model.Payment, stage string) {
+	event := map[string]interface{}{
+		"event":        "payment.dunning",
+		"stage":        stage,
+		"payment_id":   payment.ID,
+		"order_id":     payment.OrderID,
+		"user_id":      payment.UserID,
+		"amount":       payment.Amount,
+		"currency":     payment.Currency,
+		"attempt":      payment.Attempts,
+		"max_attempts": s.RetryPolicy.MaxAttempts,
+		"decline_code": payment.DeclineCode,
+		"message":      payment.Message,
+	}
+	if payment.NextRetryAt != nil {
+		event["next_retry_at"] = payment.NextRetryAt.Format(time.RFC3339)
+	}
+	if err := s.kafka.SendMessage(ctx, "notification-events", payment.ID, event); err != nil {
+		log.Printf("failed to publish payment.dunning event: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSettlementDays bounds the period of one settlement report
+const maxSettlementDays = 366
+
+// ErrInvalidDateRange is returned for settlement periods that are empty,
+// reversed or too long
+var ErrInvalidDateRange = errors.New("invalid date range")
+
+// SettlementLine sums the money movements of one day, or of the whole period
+// when Date is empty, in one currency. Net is what the provider owes us:
+// Gross less Refunds and Fees.
+type SettlementLine struct {
+	Date        string
+	Currency    string
+	Gross       float64 // captured
+	Fees        float64 // of captures and refunds
+	Refunds     float64
+	Net         float64
+	Captures    int
+	RefundCount int
+}
+
+// SettlementReport summarizes captures, refunds and fees per UTC day and
+// currency, so payouts can be matched against it
+type SettlementReport struct {
+	From   time.Time // first day
+	To     time.Time // last day, inclusive
+	Days   []SettlementLine
+	Totals []SettlementLine // one per currency
+}
+
+// GetSettlementReport builds the report for the days from through to, both
+// inclusive. Empty provider and currency match every provider and currency.
+func (s *PaymentService) GetSettlementReport(from, to time.Time, providerName, currency string) (*SettlementReport, error) {
+	from = utcDay(from)
+	to = utcDay(to)
+	if to.Before(from) || to.Sub(from) >= maxSettlementDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: from must not be after to, and at most %d days are reported", ErrInvalidDateRange, maxSettlementDays)
+	}
+	end := to.AddDate(0, 0, 1)
+	captures, err := s.Repo.SettlementCaptures(from, end, providerName)
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.Repo.SettlementRefunds(from, end, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	currency = strings.ToUpper(currency)
+	days := map[[2]string]*SettlementLine{}
+	line := func(day, cur string) *SettlementLine {
+		key := [2]string{day, cur}
+		if days[key] == nil {
+			days[key] = &SettlementLine{Date: day, Currency: cur}
+		}
+		return days[key]
+	}
+	for _, row := range captures {
+		if currency != "" && row.Currency != currency {
+			continue
+		}
+		l := line(row.Day, row.Currency)
+		l.Gross = addCents(l.Gross, row.Amount)
+		l.Fees = addCents(l.Fees, row.Fees)
+		l.Captures += row.Count
+	}
+	for _, row := range refunds {
+		if currency != "" && row.Currency != currency {
+			continue
+		}
+		l := line(row.Day, row.Currency)
+		l.Refunds = addCents(l.Refunds, row.Amount)
+		l.Fees = addCents(l.Fees, row.Fees)
+		l.RefundCount += row.Count
+	}
+
+	report := &SettlementReport{From: from, To: to}
+	totals := map[string]*SettlementLine{}
+	for _, l := range days {
+		l.Net = float64(cents(l.Gross)-cents(l.Refunds)-cents(l.Fees)) / 100
+		report.Days = append(report.Days, *l)
+
+		t := totals[l.Currency]
+		if t == nil {
+			t = &SettlementLine{Currency: l.Currency}
+			totals[l.Currency] = t
+		}
+		t.Gross = addCents(t.Gross, l.Gross)
+		t.Fees = addCents(t.Fees, l.Fees)
+		t.Refunds = addCents(t.Refunds, l.Refunds)
+		t.Net = addCents(t.Net, l.Net)
+		t.Captures += l.Captures
+		t.RefundCount += l.RefundCount
+	}
+	for _, t := range totals {
+		report.Totals = append(report.Totals, *t)
+	}
+	sort.Slice(report.Days, func(i, j int) bool {
+		if report.Days[i].Date != report.Days[j].Date {
+			return report.Days[i].Date < report.Days[j].Date
+		}
+		return report.Days[i].Currency < report.Days[j].Currency
+	})
+	sort.Slice(report.Totals, func(i, j int) bool { return report.Totals[i].Currency < report.Totals[j].Currency })
+	return report, nil
+}
+
+// utcDay truncates t to the start of its UTC day
+func utcDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// addCents adds two amounts without accumulating float error
+func addCents(a, b float64) float64 {
+	return float64(cents(a)+cents(b)) / 100
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"log"
+	"time"
+)
+
+const (
+	// sweepLease hides a claimed payment from other sweepers while it is settled
+	sweepLease = 5 * time.Minute
+	// sweepBatchSize is the number of stale payments claimed per run
+	sweepBatchSize = 100
+)
+
+// RunPendingSweeper settles payments that stayed PENDING for longer than
+// PendingTTL once per interval, until ctx is cancelled
+func (s *PaymentService) RunPendingSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.SweepPendingPayments(ctx, time.Now())
+			if err != nil {
+				log.Printf("pending payment sweep failed: %v", err)
+			} else if n > 0 {
+				log.Printf("swept %d pending payments", n)
+			}
+		}
+	}
+}
+
+// SweepPendingPayments asks the provider for the final status of every payment
+// PENDING for longer than PendingTTL at now. Payments the provider settled
+// follow it; the rest are cancelled at the provider and marked EXPIRED. It
+// returns how many payments were claimed.
+func (s *PaymentService) SweepPendingPayments(ctx context.Context, now time.Time) (int, error) {
+	if s.PendingTTL <= 0 {
+		return 0, nil
+	}
+	payments, err := s.Repo.ClaimStalePending(now.Add(-s.PendingTTL), now, sweepLease, sweepBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim stale payments: %w", err)
+	}
+	for i := range payments {
+		if _, err := s.sweepPayment(ctx, &payments[i]); err != nil {
+			log.Printf("sweep of payment %s failed: %v", payments[i].ID, err)
+		}
+	}
+	return len(payments), nil
+}
+
+// sweepPayment settles one stale payment
+func (s *PaymentService) sweepPayment(ctx context.Context, payment *model.Payment) (*model.Payment, error) {
+	p, err := s.providers.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if payment.StripeSessionID != "" {
+		sess, err := p.FetchStatus(ctx, payment.StripeSessionID)
+		if err != nil && !errors.Is(err, provider.ErrNotFound) {
+			return nil, fmt.Errorf("fetch provider status: %w", err)
+		}
+		if err == nil && sess.Status != provider.StatusPending && sess.Status != provider.StatusRequiresAction {
+			return s.applySession(ctx, payment, sess)
+		}
+		if err == nil {
+			// close the session so the customer cannot pay an expired payment
+			if _, err := p.Cancel(ctx, payment.StripeSessionID); err != nil {
+				return nil, fmt.Errorf("cancel provider session: %w", err)
+			}
+		}
+	}
+	return s.transition(ctx, payment, model.PaymentExpired, fmt.Sprintf("pending for longer than %s", s.PendingTTL), nil)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/configs/utils"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/wallet"
+	"log"
+	"strings"
+)
+
+// ErrInvalidCreditReason is returned when credit is granted for a reason
+// admins may not use, such as "payment"
+var ErrInvalidCreditReason = errors.New("invalid credit reason")
+
+// GrantCredit adds store credit to a user's wallet, e.g. for a return or as
+// goodwill. Retrying with the same idempotency key grants it once.
+func (s *PaymentService) GrantCredit(ctx context.Context, userID, currency string, amount float64, reason model.WalletReason, note, grantedBy, idempotencyKey string) (*model.WalletTransaction, error) {
+	if idempotencyKey == "" {
+		return nil, ErrIdempotencyKeyRequired
+	}
+	if !reason.Granted() {
+		return nil, fmt.Errorf("%w %q: use %q or %q", ErrInvalidCreditReason, reason, model.WalletReturn, model.WalletGoodwill)
+	}
+	if userID == "" || len(currency) != 3 || cents(amount) <= 0 {
+		return nil, fmt.Errorf("%w: credit needs a user, a currency and a positive amount", ErrInvalidAmount)
+	}
+	id := utils.GenerateUUID()
+	txn, err := s.Repo.PostWalletTransaction(&model.WalletTransaction{
+		ID:             id,
+		UserID:         userID,
+		Currency:       strings.ToUpper(currency),
+		Amount:         float64(cents(amount)) / 100,
+		Reason:         reason,
+		Note:           note,
+		CreatedBy:      grantedBy,
+		IdempotencyKey: "grant_" + idempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if txn.ID != id {
+		// replay of a grant that was already published
+		return txn, nil
+	}
+
+	event := map[string]interface{}{
+		"event":          "wallet.credited",
+		"transaction_id": txn.ID,
+		"user_id":        txn.UserID,
+		"amount":         txn.Amount,
+		"currency":       txn.Currency,
+		"balance":        txn.BalanceAfter,
+		"reason":         txn.Reason,
+		"note":           txn.Note,
+	}
+	if err := s.kafka.SendMessage(ctx, "wallet-events", txn.UserID, event); err != nil {
+		log.Printf("failed to publish wallet.credited event: %v", err)
+	}
+	return txn, nil
+}
+
+// GetWalletBalances returns the user's wallets, one per currency, or only the
+// one in currency when it is given. A wallet never credited has a zero balance.
+func (s *PaymentService) GetWalletBalances(userID, currency string) ([]model.Wallet, error) {
+	wallets, err := s.Repo.ListWallets(userID)
+	if err != nil {
+		return nil, err
+	}
+	if currency == "" {
+		return wallets, nil
+	}
+	currency = strings.ToUpper(currency)
+	for _, w := range wallets {
+		if w.Currency == currency {
+			return []model.Wallet{w}, nil
+		}
+	}
+	return []model.Wallet{{UserID: userID, Currency: currency}}, nil
+}
+
+// ListWalletTransactions returns one page of a user's wallet history, newest
+// first, and the token of the next page, which is empty on the last page
+func (s *PaymentService) ListWalletTransactions(userID, currency string, pageSize int, pageToken string) ([]model.WalletTransaction, string, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	var after *repository.PageCursor
+	if pageToken != "" {
+		cursor, err := decodePageToken(pageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		after = cursor
+	}
+
+	filter := repository.WalletFilter{UserID: userID, Currency: strings.ToUpper(currency)}
+	txns, err := s.Repo.ListWalletTransactions(filter, after, pageSize+1)
+	if err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(txns) > pageSize {
+		txns = txns[:pageSize]
+		last := txns[pageSize-1]
+		next = encodePageToken(repository.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	return txns, next, nil
+}
+
+// applyWalletCredit debits the store credit a payment combines with a card
+func (s *PaymentService) applyWalletCredit(payment *model.Payment) error {
+	_, err := s.Repo.PostWalletTransaction(&model.WalletTransaction{
+		ID:             utils.GenerateUUID(),
+		UserID:         payment.UserID,
+		Currency:       strings.ToUpper(payment.Currency),
+		Amount:         -payment.WalletAmount,
+		Reason:         model.WalletPayment,
+		Note:           "order " + payment.OrderID,
+		PaymentID:      payment.ID,
+		IdempotencyKey: "apply_" + payment.ID,
+	})
+	return err
+}
+
+// releaseWalletCredit returns the store credit applied to a payment that
+// ended without charging the card or was refunded in full. It runs once per
+// payment, however often it is called.
+func (s *PaymentService) releaseWalletCredit(payment *model.Payment) {
+	if payment.WalletAmount <= 0 || payment.Provider == wallet.ProviderName {
+		return
+	}
+	_, err := s.Repo.PostWalletTransaction(&model.WalletTransaction{
+		ID:             utils.GenerateUUID(),
+		UserID:         payment.UserID,
+		Currency:       strings.ToUpper(payment.Currency),
+		Amount:         payment.WalletAmount,
+		Reason:         model.WalletRelease,
+		Note:           "payment " + strings.ToLower(string(payment.Status)),
+		PaymentID:      payment.ID,
+		IdempotencyKey: "release_" + payment.ID,
+	})
+	if err != nil {
+		log.Printf("failed to return store credit of payment %s: %v", payment.ID, err)
+	}
+}
+
+// releasesWalletCredit reports whether a payment in status is done with the
+// store credit applied to it
+func releasesWalletCredit(status model.PaymentStatus) bool {
+	switch status {
+	case model.PaymentFailed, model.PaymentVoided, model.PaymentExpired, model.PaymentRefunded:
+		return true
+	}
+	return false
+}
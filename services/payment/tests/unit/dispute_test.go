@@ -0,0 +1,195 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/handler"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// disputeFixture is a paid simulator payment with the simulator event endpoint
+type disputeFixture struct {
+	svc      *service.PaymentService
+	repo     *fakePaymentRepository
+	recorder *topicRecorder
+	events   http.Handler
+	payment  *model.Payment
+}
+
+// newDisputeFixture pays a simulator payment; the producer expects the
+// payment's two messages plus publishes more
+func newDisputeFixture(t *testing.T, publishes int) *disputeFixture {
+	recorder := &topicRecorder{}
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < 2+publishes; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recorder.check)
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	sim := provider.NewSimulator()
+	providers := provider.NewRegistry(provider.SimulatorName)
+	providers.Register(sim)
+	repo := newFakePaymentRepository()
+	svc := service.New(repo, kafka.NewProducerWithClient(producer), providers)
+	svc.EvidenceDir = t.TempDir()
+
+	ctx := context.Background()
+	method, err := svc.AttachPaymentMethod(ctx, "user-1", "", "sim_pm_visa_4242", false)
+	require.NoError(t, err)
+	payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+		OrderID: "order-1", UserID: "user-1", Amount: 30, Currency: "USD", PaymentMethodID: method.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, model.PaymentPaid, payment.Status)
+
+	return &disputeFixture{svc: svc, repo: repo, recorder: recorder, events: handler.NewSimulatorEventHandler(svc, sim), payment: payment}
+}
+
+// trigger posts a simulator event and returns the status code and response
+func (f *disputeFixture) trigger(t *testing.T, body map[string]interface{}) (int, map[string]string) {
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/simulator", bytes.NewReader(payload))
+	rec := httptest.NewRecorder()
+	f.events.ServeHTTP(rec, req)
+	var resp map[string]string
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+	return rec.Code, resp
+}
+
+// open disputes the fixture's payment
+func (f *disputeFixture) open(t *testing.T) map[string]string {
+	code, resp := f.trigger(t, map[string]interface{}{"type": "dispute.created", "payment_id": f.payment.ID, "reason": "product_not_received", "amount": 12.5})
+	require.Equal(t, http.StatusOK, code)
+	return resp
+}
+
+func TestDisputes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("simulator dispute is recorded and published", func(t *testing.T) {
+		f := newDisputeFixture(t, 1)
+		resp := f.open(t)
+
+		d, err := f.svc.GetDispute(resp["dispute_id"])
+		require.NoError(t, err)
+		assert.Equal(t, f.payment.ID, d.PaymentID)
+		assert.Equal(t, resp["provider_dispute_id"], d.ProviderDisputeID)
+		assert.Equal(t, "product_not_received", d.Reason)
+		assert.Equal(t, 12.5, d.Amount)
+		assert.Equal(t, model.DisputeNeedsResponse, d.Status)
+		assert.NotNil(t, d.EvidenceDueBy)
+
+		assert.Equal(t, "dispute-events", f.recorder.topics[2])
+		event := f.recorder.events[2]
+		assert.Equal(t, "dispute.created", event["event"])
+		assert.Equal(t, "order-1", event["order_id"])
+		assert.Equal(t, "needs_response", event["status"])
+		assert.NotEmpty(t, event["evidence_due_by"])
+
+		// opening it again is deduplicated
+		assert.Equal(t, resp, f.open(t))
+		disputes, err := f.svc.ListDisputes(f.payment.ID, "")
+		require.NoError(t, err)
+		assert.Len(t, disputes, 1)
+	})
+
+	t.Run("evidence is stored and puts the dispute under review", func(t *testing.T) {
+		f := newDisputeFixture(t, 2)
+		resp := f.open(t)
+
+		d, err := f.svc.SubmitDisputeEvidence(ctx, resp["dispute_id"], "  shipped with tracking 1Z999  ", []service.EvidenceFile{
+			{Name: "receipt.pdf", ContentType: "application/pdf", Content: []byte("%PDF-1.4")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.DisputeUnderReview, d.Status)
+		assert.Equal(t, "shipped with tracking 1Z999", d.EvidenceText)
+		assert.NotNil(t, d.EvidenceSubmittedAt)
+		require.Len(t, d.Evidence, 1)
+		assert.Equal(t, "receipt.pdf", d.Evidence[0].FileName)
+		assert.Equal(t, int64(8), d.Evidence[0].Size)
+
+		content, err := os.ReadFile(filepath.Join(f.svc.EvidenceDir, d.Evidence[0].Path))
+		require.NoError(t, err)
+		assert.Equal(t, "%PDF-1.4", string(content))
+		assert.Equal(t, "dispute.updated", f.recorder.events[3]["event"])
+
+		// a dispute under review takes no more evidence
+		_, err = f.svc.SubmitDisputeEvidence(ctx, resp["dispute_id"], "more", nil)
+		assert.ErrorIs(t, err, service.ErrDisputeClosed)
+	})
+
+	t.Run("bad evidence is rejected before anything is stored", func(t *testing.T) {
+		f := newDisputeFixture(t, 1)
+		resp := f.open(t)
+
+		for name, files := range map[string][]service.EvidenceFile{
+			"path traversal": {{Name: "../../etc/passwd", Content: []byte("x")}},
+			"directory":      {{Name: "a/b.txt", Content: []byte("x")}},
+			"empty file":     {{Name: "empty.txt"}},
+		} {
+			_, err := f.svc.SubmitDisputeEvidence(ctx, resp["dispute_id"], "text", files)
+			assert.ErrorIs(t, err, service.ErrInvalidEvidence, name)
+		}
+		_, err := f.svc.SubmitDisputeEvidence(ctx, resp["dispute_id"], " ", nil)
+		assert.ErrorIs(t, err, service.ErrInvalidEvidence)
+		_, err = f.svc.SubmitDisputeEvidence(ctx, "missing", "text", nil)
+		assert.ErrorIs(t, err, service.ErrDisputeNotFound)
+
+		entries, err := os.ReadDir(f.svc.EvidenceDir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("lost dispute is closed once", func(t *testing.T) {
+		f := newDisputeFixture(t, 2)
+		resp := f.open(t)
+
+		closeEvent := map[string]interface{}{"type": "dispute.closed", "provider_dispute_id": resp["provider_dispute_id"], "outcome": "lost"}
+		code, _ := f.trigger(t, closeEvent)
+		require.Equal(t, http.StatusOK, code)
+		code, _ = f.trigger(t, closeEvent)
+		require.Equal(t, http.StatusOK, code)
+
+		d, err := f.svc.GetDispute(resp["dispute_id"])
+		require.NoError(t, err)
+		assert.Equal(t, model.DisputeLost, d.Status)
+		assert.NotNil(t, d.ClosedAt)
+		assert.Equal(t, "dispute.closed", f.recorder.events[3]["event"])
+		assert.Equal(t, "lost", f.recorder.events[3]["status"])
+
+		lost, err := f.svc.ListDisputes("", model.DisputeLost)
+		require.NoError(t, err)
+		assert.Len(t, lost, 1)
+		_, err = f.svc.SubmitDisputeEvidence(ctx, d.ID, "too late", nil)
+		assert.ErrorIs(t, err, service.ErrDisputeClosed)
+	})
+
+	t.Run("simulator events are validated", func(t *testing.T) {
+		f := newDisputeFixture(t, 0)
+		code, _ := f.trigger(t, map[string]interface{}{"type": "dispute.created", "payment_id": "missing"})
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = f.trigger(t, map[string]interface{}{"type": "dispute.closed", "provider_dispute_id": "sim_dp_missing", "outcome": "lost"})
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = f.trigger(t, map[string]interface{}{"type": "dispute.closed", "provider_dispute_id": "sim_dp_missing", "outcome": "maybe"})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = f.trigger(t, map[string]interface{}{"type": "charge.refunded"})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
@@ -0,0 +1,762 @@
+package unit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+)
+
+// fakePaymentRepository is an in-memory PaymentRepository shared by the payment tests
+type fakePaymentRepository struct {
+	mu          sync.Mutex
+	payments    map[string]*model.Payment
+	refunds     map[string]*model.Refund
+	events      map[string]*model.WebhookEvent
+	transitions []model.PaymentTransition
+	reports     []*model.ReconciliationReport
+	attempts    []*model.PaymentAttempt
+	methods     []*model.PaymentMethod
+	disputes    []*model.Dispute
+	wallets     map[string]*model.Wallet
+	walletTxns  []*model.WalletTransaction
+}
+
+func newFakePaymentRepository(payments ...*model.Payment) *fakePaymentRepository {
+	r := &fakePaymentRepository{
+		payments: make(map[string]*model.Payment),
+		refunds:  make(map[string]*model.Refund),
+		events:   make(map[string]*model.WebhookEvent),
+		wallets:  make(map[string]*model.Wallet),
+	}
+	for _, p := range payments {
+		r.payments[p.ID] = p
+	}
+	return r
+}
+
+func (r *fakePaymentRepository) Save(payment *model.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *payment
+	r.payments[payment.ID] = &copied
+	r.record(payment.ID, "", payment.Status, payment.Message)
+	return nil
+}
+
+func (r *fakePaymentRepository) Transition(paymentID string, to model.PaymentStatus, message string, fields map[string]interface{}) (*model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.payments[paymentID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if !model.CanTransition(p.Status, to) {
+		return nil, fmt.Errorf("%w: %s to %s", repository.ErrInvalidTransition, p.Status, to)
+	}
+	r.record(paymentID, p.Status, to, message)
+	p.Status = to
+	p.Message = message
+	p.UpdatedAt = time.Now()
+	applyFields(p, fields)
+	copied := *p
+	return &copied, nil
+}
+
+func (r *fakePaymentRepository) FindByID(paymentID string) (*model.Payment, error) {
+	return r.find(func(p *model.Payment) bool { return p.ID == paymentID })
+}
+
+func (r *fakePaymentRepository) FindByStripeSessionID(sessionID string) (*model.Payment, error) {
+	return r.find(func(p *model.Payment) bool { return p.StripeSessionID == sessionID })
+}
+
+func (r *fakePaymentRepository) FindByPaymentIntentID(intentID string) (*model.Payment, error) {
+	return r.find(func(p *model.Payment) bool { return p.PaymentIntentID == intentID })
+}
+
+func (r *fakePaymentRepository) List(filter repository.PaymentFilter, after *repository.PageCursor, limit int) ([]model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.Payment
+	for _, p := range r.payments {
+		switch {
+		case filter.OrderID != "" && p.OrderID != filter.OrderID,
+			filter.UserID != "" && p.UserID != filter.UserID,
+			filter.Status != "" && p.Status != filter.Status,
+			filter.Provider != "" && p.Provider != filter.Provider,
+			!filter.CreatedFrom.IsZero() && p.CreatedAt.Before(filter.CreatedFrom),
+			!filter.CreatedTo.IsZero() && !p.CreatedAt.Before(filter.CreatedTo):
+			continue
+		}
+		if after != nil && !newerFirst(after.CreatedAt, after.ID, p.CreatedAt, p.ID) {
+			continue
+		}
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return newerFirst(out[i].CreatedAt, out[i].ID, out[j].CreatedAt, out[j].ID) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepository) ListByOrderWithRefunds(orderID string) ([]model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.Payment
+	for _, p := range r.payments {
+		if p.OrderID != orderID {
+			continue
+		}
+		copied := *p
+		copied.Refunds = nil
+		for _, refund := range r.refunds {
+			if refund.PaymentID == p.ID {
+				copied.Refunds = append(copied.Refunds, *refund)
+			}
+		}
+		out = append(out, copied)
+	}
+	sort.Slice(out, func(i, j int) bool { return newerFirst(out[j].CreatedAt, out[j].ID, out[i].CreatedAt, out[i].ID) })
+	return out, nil
+}
+
+// newerFirst reports whether payment a sorts before payment b in the
+// newest-first order of List
+func newerFirst(aCreated time.Time, aID string, bCreated time.Time, bID string) bool {
+	if !aCreated.Equal(bCreated) {
+		return aCreated.After(bCreated)
+	}
+	return aID > bID
+}
+
+func (r *fakePaymentRepository) SetPaymentIntentID(paymentID, intentID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.payments[paymentID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	p.PaymentIntentID = intentID
+	return nil
+}
+
+func (r *fakePaymentRepository) IsWebhookEventProcessed(eventID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.events[eventID]
+	return ok, nil
+}
+
+func (r *fakePaymentRepository) SaveWebhookEvent(event *model.WebhookEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[event.ID] = event
+	return nil
+}
+
+func (r *fakePaymentRepository) find(match func(p *model.Payment) bool) (*model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.payments {
+		if match(p) {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakePaymentRepository) CreateRefund(refund *model.Refund) (*model.Refund, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.payments[refund.PaymentID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	var outstanding int64
+	for _, existing := range r.refunds {
+		if existing.IdempotencyKey == refund.IdempotencyKey {
+			if existing.PaymentID != refund.PaymentID {
+				return nil, repository.ErrIdempotencyKeyReused
+			}
+			copied := *existing
+			return &copied, nil
+		}
+		if existing.PaymentID == refund.PaymentID && existing.Status != model.RefundFailed {
+			outstanding += toCents(existing.Amount)
+		}
+	}
+	if !model.CanTransition(p.Status, model.PaymentRefunded) {
+		return nil, repository.ErrNotRefundable
+	}
+	remaining := toCents(p.RefundableBase()) - outstanding
+	if refund.Amount == 0 {
+		refund.Amount = float64(remaining) / 100
+	}
+	if toCents(refund.Amount) <= 0 || toCents(refund.Amount) > remaining {
+		return nil, repository.ErrRefundExceedsPayment
+	}
+	refund.CreatedAt = time.Now()
+	copied := *refund
+	r.refunds[refund.ID] = &copied
+	return refund, nil
+}
+
+func (r *fakePaymentRepository) CompleteRefund(refundID string, status model.RefundStatus, providerRefundID, message string, fee float64) (*model.Refund, *model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refund, ok := r.refunds[refundID]
+	if !ok {
+		return nil, nil, repository.ErrNotFound
+	}
+	p := r.payments[refund.PaymentID]
+	if refund.Status == model.RefundPending {
+		refund.Status, refund.ProviderRefundID, refund.Message = status, providerRefundID, message
+		refund.UpdatedAt = time.Now()
+		if status == model.RefundSucceeded {
+			from := p.Status
+			refund.FeeAmount = fee
+			p.RefundedAmount = float64(toCents(p.RefundedAmount)+toCents(refund.Amount)) / 100
+			p.NetAmount = float64(toCents(p.NetAmount)-toCents(refund.Amount)-toCents(fee)) / 100
+			p.Status = model.PaymentPartiallyRefunded
+			if toCents(p.RefundedAmount) >= toCents(p.RefundableBase()) {
+				p.Status = model.PaymentRefunded
+			}
+			if from != p.Status {
+				r.record(p.ID, from, p.Status, message)
+			}
+		}
+	}
+	copiedRefund, copiedPayment := *refund, *p
+	return &copiedRefund, &copiedPayment, nil
+}
+
+func (r *fakePaymentRepository) UpdatePendingRefund(refundID, providerRefundID, message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refund, ok := r.refunds[refundID]
+	if !ok || refund.Status != model.RefundPending {
+		return nil
+	}
+	refund.Message = message
+	if providerRefundID != "" {
+		refund.ProviderRefundID = providerRefundID
+	}
+	return nil
+}
+
+func (r *fakePaymentRepository) FindRefund(refundID string) (*model.Refund, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refund, ok := r.refunds[refundID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *refund
+	return &copied, nil
+}
+
+func (r *fakePaymentRepository) ListPendingRefunds(createdBefore time.Time, limit int) ([]model.Refund, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var refunds []model.Refund
+	for _, refund := range r.refunds {
+		if refund.Status == model.RefundPending && refund.CreatedAt.Before(createdBefore) {
+			refunds = append(refunds, *refund)
+		}
+	}
+	sort.Slice(refunds, func(i, j int) bool { return refunds[i].CreatedAt.Before(refunds[j].CreatedAt) })
+	if len(refunds) > limit {
+		refunds = refunds[:limit]
+	}
+	return refunds, nil
+}
+
+func (r *fakePaymentRepository) ListTransitions(paymentID string) ([]model.PaymentTransition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.PaymentTransition
+	for _, t := range r.transitions {
+		if t.PaymentID == paymentID {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepository) ListByProviderCreatedBetween(provider string, from, to time.Time) ([]model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.Payment
+	for _, p := range r.payments {
+		if p.Provider == provider && !p.CreatedAt.Before(from) && p.CreatedAt.Before(to) {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepository) SettlementCaptures(from, to time.Time, provider string) ([]repository.SettlementRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rows []repository.SettlementRow
+	for _, p := range r.payments {
+		if p.CapturedAt == nil || p.CapturedAmount <= 0 || p.CapturedAt.Before(from) || !p.CapturedAt.Before(to) || (provider != "" && p.Provider != provider) {
+			continue
+		}
+		rows = addSettlementRow(rows, p.CapturedAt.UTC().Format("2006-01-02"), p.Currency, p.CapturedAmount, p.FeeAmount)
+	}
+	return rows, nil
+}
+
+func (r *fakePaymentRepository) SettlementRefunds(from, to time.Time, provider string) ([]repository.SettlementRow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rows []repository.SettlementRow
+	for _, refund := range r.refunds {
+		p := r.payments[refund.PaymentID]
+		if refund.Status != model.RefundSucceeded || refund.UpdatedAt.Before(from) || !refund.UpdatedAt.Before(to) || (provider != "" && p.Provider != provider) {
+			continue
+		}
+		rows = addSettlementRow(rows, refund.UpdatedAt.UTC().Format("2006-01-02"), refund.Currency, refund.Amount, refund.FeeAmount)
+	}
+	return rows, nil
+}
+
+// addSettlementRow adds an amount to the row of its day and currency
+func addSettlementRow(rows []repository.SettlementRow, day, currency string, amount, fee float64) []repository.SettlementRow {
+	for i := range rows {
+		if rows[i].Day == day && rows[i].Currency == currency {
+			rows[i].Amount = float64(toCents(rows[i].Amount)+toCents(amount)) / 100
+			rows[i].Fees = float64(toCents(rows[i].Fees)+toCents(fee)) / 100
+			rows[i].Count++
+			return rows
+		}
+	}
+	return append(rows, repository.SettlementRow{Day: day, Currency: currency, Amount: amount, Fees: fee, Count: 1})
+}
+
+func (r *fakePaymentRepository) SaveReconciliationReport(report *model.ReconciliationReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+func (r *fakePaymentRepository) FindReconciliationReport(reportID string) (*model.ReconciliationReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, report := range r.reports {
+		if report.ID == reportID {
+			return report, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakePaymentRepository) LatestReconciliationReport(provider string) (*model.ReconciliationReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.reports) - 1; i >= 0; i-- {
+		if provider == "" || r.reports[i].Provider == provider {
+			return r.reports[i], nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakePaymentRepository) SaveAttempt(attempt *model.PaymentAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *attempt
+	copied.ID = uint(len(r.attempts) + 1)
+	r.attempts = append(r.attempts, &copied)
+	return nil
+}
+
+func (r *fakePaymentRepository) FinishAttempt(paymentID string, number int, status model.AttemptStatus, declineCode, message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.attempts {
+		if a.PaymentID == paymentID && a.Number == number && a.Status == model.AttemptPending {
+			now := time.Now()
+			a.Status, a.DeclineCode, a.Message, a.FinishedAt = status, declineCode, message, &now
+		}
+	}
+	return nil
+}
+
+func (r *fakePaymentRepository) ListAttempts(paymentID string) ([]model.PaymentAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.PaymentAttempt
+	for _, a := range r.attempts {
+		if a.PaymentID == paymentID {
+			out = append(out, *a)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepository) FindAttemptBySessionID(sessionID string) (*model.PaymentAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.attempts {
+		if a.SessionID == sessionID {
+			copied := *a
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakePaymentRepository) ClaimDueRetries(now time.Time, lease time.Duration, limit int) ([]model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.Payment
+	for _, p := range r.payments {
+		if len(out) == limit {
+			break
+		}
+		if p.Status == model.PaymentRetryScheduled && p.NextRetryAt != nil && !p.NextRetryAt.After(now) {
+			out = append(out, *p)
+			next := now.Add(lease)
+			p.NextRetryAt = &next
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepository) ClaimStalePending(cutoff, now time.Time, lease time.Duration, limit int) ([]model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.Payment
+	for _, p := range r.payments {
+		if len(out) == limit {
+			break
+		}
+		leased := p.SweepLeaseUntil != nil && p.SweepLeaseUntil.After(now)
+		if p.Status == model.PaymentPending && p.UpdatedAt.Before(cutoff) && !leased {
+			until := now.Add(lease)
+			p.SweepLeaseUntil = &until
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepository) SavePaymentMethod(method *model.PaymentMethod) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owned := 0
+	for _, m := range r.methods {
+		if m.UserID == method.UserID {
+			owned++
+		}
+	}
+	if owned == 0 {
+		method.IsDefault = true
+	}
+	for _, m := range r.methods {
+		if method.IsDefault && m.UserID == method.UserID {
+			m.IsDefault = false
+		}
+	}
+	copied := *method
+	r.methods = append(r.methods, &copied)
+	return nil
+}
+
+func (r *fakePaymentRepository) FindPaymentMethod(methodID string) (*model.PaymentMethod, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.methods {
+		if m.ID == methodID {
+			copied := *m
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakePaymentRepository) ListPaymentMethods(userID string) ([]model.PaymentMethod, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.PaymentMethod
+	for i := len(r.methods) - 1; i >= 0; i-- {
+		if m := r.methods[i]; m.UserID == userID {
+			out = append(out, *m)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].IsDefault && !out[j].IsDefault })
+	return out, nil
+}
+
+func (r *fakePaymentRepository) DeletePaymentMethod(methodID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, m := range r.methods {
+		if m.ID != methodID {
+			continue
+		}
+		r.methods = append(r.methods[:i], r.methods[i+1:]...)
+		if m.IsDefault {
+			for j := len(r.methods) - 1; j >= 0; j-- {
+				if r.methods[j].UserID == m.UserID {
+					r.methods[j].IsDefault = true
+					break
+				}
+			}
+		}
+		return nil
+	}
+	return repository.ErrNotFound
+}
+
+func (r *fakePaymentRepository) SaveDispute(dispute *model.Dispute) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.disputes {
+		if d.ProviderDisputeID == dispute.ProviderDisputeID {
+			return fmt.Errorf("duplicate provider dispute %s", dispute.ProviderDisputeID)
+		}
+	}
+	copied := *dispute
+	copied.CreatedAt = time.Now()
+	r.disputes = append(r.disputes, &copied)
+	return nil
+}
+
+func (r *fakePaymentRepository) AdvanceDispute(disputeID string, to model.DisputeStatus) (*model.Dispute, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.dispute(disputeID)
+	if d == nil {
+		return nil, false, repository.ErrNotFound
+	}
+	if !model.CanAdvanceDispute(d.Status, to) {
+		copied := *d
+		return &copied, false, nil
+	}
+	d.Status = to
+	if to.Closed() {
+		now := time.Now()
+		d.ClosedAt = &now
+	}
+	copied := *d
+	return &copied, true, nil
+}
+
+func (r *fakePaymentRepository) RecordDisputeEvidence(disputeID, text string, files []model.DisputeEvidence) (*model.Dispute, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.dispute(disputeID)
+	if d == nil {
+		return nil, repository.ErrNotFound
+	}
+	if d.Status != model.DisputeNeedsResponse {
+		return nil, fmt.Errorf("%w: status is %s", repository.ErrEvidenceNotAccepted, d.Status)
+	}
+	for _, f := range files {
+		f.DisputeID = disputeID
+		d.Evidence = append(d.Evidence, f)
+	}
+	now := time.Now()
+	d.Status = model.DisputeUnderReview
+	d.EvidenceText = text
+	d.EvidenceSubmittedAt = &now
+	copied := *d
+	return &copied, nil
+}
+
+func (r *fakePaymentRepository) FindDispute(disputeID string) (*model.Dispute, error) {
+	return r.findDispute(func(d *model.Dispute) bool { return d.ID == disputeID })
+}
+
+func (r *fakePaymentRepository) FindDisputeByProviderID(providerDisputeID string) (*model.Dispute, error) {
+	return r.findDispute(func(d *model.Dispute) bool { return d.ProviderDisputeID == providerDisputeID })
+}
+
+func (r *fakePaymentRepository) ListDisputes(paymentID string, status model.DisputeStatus) ([]model.Dispute, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.Dispute
+	for i := len(r.disputes) - 1; i >= 0; i-- {
+		d := r.disputes[i]
+		if (paymentID == "" || d.PaymentID == paymentID) && (status == "" || d.Status == status) {
+			out = append(out, *d)
+		}
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepository) PostWalletTransaction(txn *model.WalletTransaction) (*model.WalletTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := txn.UserID + ":" + txn.Currency
+	for _, existing := range r.walletTxns {
+		if existing.IdempotencyKey == txn.IdempotencyKey {
+			if existing.WalletID != id || toCents(existing.Amount) != toCents(txn.Amount) {
+				return nil, repository.ErrIdempotencyKeyReused
+			}
+			copied := *existing
+			return &copied, nil
+		}
+	}
+	wallet := r.wallets[id]
+	if wallet == nil {
+		wallet = &model.Wallet{ID: id, UserID: txn.UserID, Currency: txn.Currency, CreatedAt: time.Now()}
+	}
+	balance := toCents(wallet.Balance) + toCents(txn.Amount)
+	if balance < 0 {
+		return nil, repository.ErrInsufficientCredit
+	}
+	wallet.Balance, wallet.UpdatedAt = float64(balance)/100, time.Now()
+	r.wallets[id] = wallet
+	txn.WalletID, txn.BalanceAfter, txn.CreatedAt = id, wallet.Balance, time.Now()
+	copied := *txn
+	r.walletTxns = append(r.walletTxns, &copied)
+	return txn, nil
+}
+
+func (r *fakePaymentRepository) FindWalletTransaction(idempotencyKey string) (*model.WalletTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, txn := range r.walletTxns {
+		if txn.IdempotencyKey == idempotencyKey {
+			copied := *txn
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakePaymentRepository) ListWalletTransactions(filter repository.WalletFilter, after *repository.PageCursor, limit int) ([]model.WalletTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.WalletTransaction
+	for _, txn := range r.walletTxns {
+		switch {
+		case filter.UserID != "" && txn.UserID != filter.UserID,
+			filter.Currency != "" && txn.Currency != filter.Currency,
+			filter.Reason != "" && txn.Reason != filter.Reason,
+			!filter.CreatedFrom.IsZero() && txn.CreatedAt.Before(filter.CreatedFrom),
+			!filter.CreatedTo.IsZero() && !txn.CreatedAt.Before(filter.CreatedTo):
+			continue
+		}
+		if after != nil && !newerFirst(after.CreatedAt, after.ID, txn.CreatedAt, txn.ID) {
+			continue
+		}
+		out = append(out, *txn)
+	}
+	sort.Slice(out, func(i, j int) bool { return newerFirst(out[i].CreatedAt, out[i].ID, out[j].CreatedAt, out[j].ID) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *fakePaymentRepository) ListWallets(userID string) ([]model.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.Wallet
+	for _, w := range r.wallets {
+		if w.UserID == userID {
+			out = append(out, *w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Currency < out[j].Currency })
+	return out, nil
+}
+
+// balance is the stored wallet balance of a user in a currency
+func (r *fakePaymentRepository) balance(userID, currency string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if w := r.wallets[userID+":"+currency]; w != nil {
+		return w.Balance
+	}
+	return 0
+}
+
+func (r *fakePaymentRepository) findDispute(match func(d *model.Dispute) bool) (*model.Dispute, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.disputes {
+		if match(d) {
+			copied := *d
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// dispute finds a stored dispute; the caller holds the lock
+func (r *fakePaymentRepository) dispute(disputeID string) *model.Dispute {
+	for _, d := range r.disputes {
+		if d.ID == disputeID {
+			return d
+		}
+	}
+	return nil
+}
+
+// applyFields copies the extra Transition columns onto a payment
+func applyFields(p *model.Payment, fields map[string]interface{}) {
+	for column, value := range fields {
+		switch column {
+		case "captured_amount":
+			p.CapturedAmount = value.(float64)
+		case "captured_at":
+			at := value.(time.Time)
+			p.CapturedAt = &at
+		case "fee_amount":
+			p.FeeAmount = value.(float64)
+		case "net_amount":
+			p.NetAmount = value.(float64)
+		case "attempts":
+			p.Attempts = value.(int)
+		case "decline_code":
+			p.DeclineCode = value.(string)
+		case "stripe_session_id":
+			p.StripeSessionID = value.(string)
+		case "payment_intent_id":
+			p.PaymentIntentID = value.(string)
+		case "checkout_url":
+			p.CheckoutURL = value.(string)
+		case "next_action_type":
+			p.NextActionType = value.(string)
+		case "next_action_url":
+			p.NextActionURL = value.(string)
+		case "client_secret":
+			p.ClientSecret = value.(string)
+		case "next_retry_at":
+			p.NextRetryAt = nil
+			if at, ok := value.(time.Time); ok {
+				p.NextRetryAt = &at
+			}
+		}
+	}
+}
+
+// record appends a transition; the caller holds the lock
+func (r *fakePaymentRepository) record(paymentID string, from, to model.PaymentStatus, message string) {
+	r.transitions = append(r.transitions, model.PaymentTransition{
+		ID:         uint(len(r.transitions) + 1),
+		PaymentID:  paymentID,
+		FromStatus: from,
+		ToStatus:   to,
+		Message:    message,
+		CreatedAt:  time.Now(),
+	})
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,168 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFeeSchedule = "simulator:capture:*:*=2.9%+0.30, simulator:capture:visa:EUR=1.4%+0.25, simulator:refund:*:*=0.15"
+
+func TestParseFeeSchedule(t *testing.T) {
+	t.Run("rules are parsed", func(t *testing.T) {
+		schedule, err := service.ParseFeeSchedule(testFeeSchedule)
+		require.NoError(t, err)
+		require.Len(t, schedule.Rules, 3)
+		assert.Equal(t, service.FeeRule{Provider: "simulator", Operation: service.FeeCapture, Method: "visa", Currency: "EUR", Percent: 1.4, Fixed: 0.25}, schedule.Rules[1])
+		assert.Equal(t, 0.15, schedule.Rules[2].Fixed)
+		assert.Zero(t, schedule.Rules[2].Percent)
+	})
+
+	t.Run("empty schedule charges nothing", func(t *testing.T) {
+		schedule, err := service.ParseFeeSchedule("")
+		require.NoError(t, err)
+		assert.Zero(t, schedule.Fee(provider.StripeName, service.FeeCapture, "visa", "USD", 100))
+	})
+
+	t.Run("invalid rules are rejected", func(t *testing.T) {
+		for _, s := range []string{"stripe:capture:*=1%", "stripe:payout:*:*=1%", "stripe:capture:*:*=abc", "stripe:capture:*:*=-1%", "stripe:capture:*:*"} {
+			_, err := service.ParseFeeSchedule(s)
+			assert.Error(t, err, s)
+		}
+	})
+
+	t.Run("most specific rule wins", func(t *testing.T) {
+		schedule, err := service.ParseFeeSchedule(testFeeSchedule)
+		require.NoError(t, err)
+		assert.Equal(t, 3.2, schedule.Fee(provider.SimulatorName, service.FeeCapture, "checkout", "USD", 100))
+		assert.Equal(t, 1.65, schedule.Fee(provider.SimulatorName, service.FeeCapture, "VISA", "eur", 100))
+		assert.Equal(t, 3.2, schedule.Fee(provider.SimulatorName, service.FeeCapture, "visa", "USD", 100))
+		assert.Equal(t, 0.15, schedule.Fee(provider.SimulatorName, service.FeeRefund, "visa", "EUR", 40))
+		assert.Zero(t, schedule.Fee(provider.StripeName, service.FeeCapture, "visa", "USD", 100))
+	})
+}
+
+func TestPaymentFees(t *testing.T) {
+	ctx := context.Background()
+	schedule, err := service.ParseFeeSchedule(testFeeSchedule)
+	require.NoError(t, err)
+
+	t.Run("capture and refund fees are stored and published", func(t *testing.T) {
+		svc, repo, recorder := newRetryingService(t, service.RetryPolicy{}, 3)
+		svc.Fees = schedule
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{OrderID: "order-1", UserID: "user-1", Amount: 100, Currency: "USD"})
+		require.NoError(t, err)
+		require.NoError(t, svc.UpdateStatus(ctx, payment.ID, model.PaymentPaid, "paid"))
+
+		paid, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3.2, paid.FeeAmount)
+		assert.Equal(t, 96.8, paid.NetAmount)
+		assert.Equal(t, 3.2, recorder.events[1]["fee_amount"])
+		assert.Equal(t, 96.8, recorder.events[1]["net_amount"])
+
+		refund, refunded, err := svc.RefundPayment(ctx, payment.ID, 40, "damaged", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, 0.15, refund.FeeAmount)
+		assert.Equal(t, 3.2, refunded.FeeAmount, "the capture fee is not returned")
+		assert.Equal(t, 56.65, refunded.NetAmount)
+		assert.Equal(t, 0.15, recorder.events[2]["fee_amount"])
+	})
+
+	t.Run("saved methods are charged by brand", func(t *testing.T) {
+		svc, repo, _ := newRetryingService(t, service.RetryPolicy{}, 2)
+		svc.Fees = schedule
+		method, err := svc.AttachPaymentMethod(ctx, "user-1", "", "sim_pm_visa_4242", false)
+		require.NoError(t, err)
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 100, Currency: "EUR", PaymentMethodID: method.ID,
+		})
+		require.NoError(t, err)
+
+		paid, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1.65, paid.FeeAmount)
+		assert.Equal(t, 98.35, paid.NetAmount)
+	})
+
+	t.Run("no schedule records no fees", func(t *testing.T) {
+		svc, repo, _ := newRetryingService(t, service.RetryPolicy{}, 2)
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{OrderID: "order-1", UserID: "user-1", Amount: 100, Currency: "USD"})
+		require.NoError(t, err)
+		require.NoError(t, svc.UpdateStatus(ctx, payment.ID, model.PaymentPaid, "paid"))
+
+		paid, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Zero(t, paid.FeeAmount)
+		assert.Equal(t, 100.0, paid.NetAmount)
+	})
+}
+
+func TestSettlementReport(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		require.NoError(t, err)
+		return d
+	}
+	at := func(s string) *time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return &ts
+	}
+	captured := func(id, currency, providerName string, amount, fee float64, when string) *model.Payment {
+		return &model.Payment{
+			ID: id, OrderID: "order-" + id, Amount: amount, CapturedAmount: amount, FeeAmount: fee, Currency: currency,
+			Status: model.PaymentPaid, Provider: providerName, CapturedAt: at(when),
+		}
+	}
+	newReport := func(t *testing.T) *service.PaymentService {
+		repo := newFakePaymentRepository(
+			captured("p1", "USD", provider.SimulatorName, 100, 3.2, "2024-05-01T10:00:00Z"),
+			captured("p2", "USD", provider.SimulatorName, 50, 1.75, "2024-05-01T23:59:59Z"),
+			captured("p3", "EUR", provider.SimulatorName, 80, 1.37, "2024-05-01T12:00:00Z"),
+			captured("p4", "USD", provider.StripeName, 20, 0.88, "2024-05-02T08:00:00+02:00"), // May 2 in UTC
+			captured("p5", "USD", provider.SimulatorName, 70, 2.33, "2024-05-03T00:00:00Z"),   // after the period
+		)
+		repo.refunds["r1"] = &model.Refund{ID: "r1", PaymentID: "p1", Amount: 40, FeeAmount: 0.15, Currency: "USD",
+			Status: model.RefundSucceeded, UpdatedAt: *at("2024-05-02T09:00:00Z")}
+		repo.refunds["r2"] = &model.Refund{ID: "r2", PaymentID: "p2", Amount: 10, Currency: "USD",
+			Status: model.RefundFailed, UpdatedAt: *at("2024-05-02T09:00:00Z")}
+		return service.New(repo, nil, provider.NewRegistry(provider.SimulatorName))
+	}
+
+	t.Run("days and totals per currency", func(t *testing.T) {
+		report, err := newReport(t).GetSettlementReport(day("2024-05-01"), day("2024-05-02"), "", "")
+		require.NoError(t, err)
+		assert.Equal(t, []service.SettlementLine{
+			{Date: "2024-05-01", Currency: "EUR", Gross: 80, Fees: 1.37, Net: 78.63, Captures: 1},
+			{Date: "2024-05-01", Currency: "USD", Gross: 150, Fees: 4.95, Net: 145.05, Captures: 2},
+			{Date: "2024-05-02", Currency: "USD", Gross: 20, Fees: 1.03, Refunds: 40, Net: -21.03, Captures: 1, RefundCount: 1},
+		}, report.Days)
+		assert.Equal(t, []service.SettlementLine{
+			{Currency: "EUR", Gross: 80, Fees: 1.37, Net: 78.63, Captures: 1},
+			{Currency: "USD", Gross: 170, Fees: 5.98, Refunds: 40, Net: 124.02, Captures: 3, RefundCount: 1},
+		}, report.Totals)
+	})
+
+	t.Run("provider and currency filters", func(t *testing.T) {
+		report, err := newReport(t).GetSettlementReport(day("2024-05-01"), day("2024-05-02"), provider.StripeName, "usd")
+		require.NoError(t, err)
+		require.Len(t, report.Days, 1)
+		assert.Equal(t, service.SettlementLine{Date: "2024-05-02", Currency: "USD", Gross: 20, Fees: 0.88, Net: 19.12, Captures: 1}, report.Days[0])
+	})
+
+	t.Run("invalid periods are rejected", func(t *testing.T) {
+		svc := newReport(t)
+		_, err := svc.GetSettlementReport(day("2024-05-02"), day("2024-05-01"), "", "")
+		assert.ErrorIs(t, err, service.ErrInvalidDateRange)
+		_, err = svc.GetSettlementReport(day("2024-01-01"), day("2025-01-01"), "", "")
+		assert.ErrorIs(t, err, service.ErrInvalidDateRange)
+	})
+}
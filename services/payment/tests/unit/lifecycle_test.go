@@ -0,0 +1,138 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSimulatorService returns a service using the simulator whose producer
+// expects exactly publishes messages
+func newSimulatorService(t *testing.T, publishes int) (*service.PaymentService, *fakePaymentRepository) {
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < publishes; i++ {
+		producer.ExpectSendMessageAndSucceed()
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	providers := provider.NewRegistry(provider.SimulatorName)
+	providers.Register(provider.NewSimulator())
+	repo := newFakePaymentRepository()
+	return service.New(repo, kafka.NewProducerWithClient(producer), providers), repo
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to model.PaymentStatus
+		allowed  bool
+	}{
+		{model.PaymentPending, model.PaymentAuthorized, true},
+		{model.PaymentPending, model.PaymentPaid, true},
+		{model.PaymentPending, model.PaymentCaptured, false},
+		{model.PaymentRequiresAction, model.PaymentAuthorized, true},
+		{model.PaymentAuthorized, model.PaymentCaptured, true},
+		{model.PaymentAuthorized, model.PaymentVoided, true},
+		{model.PaymentAuthorized, model.PaymentExpired, true},
+		{model.PaymentAuthorized, model.PaymentPaid, false},
+		{model.PaymentCaptured, model.PaymentVoided, false},
+		{model.PaymentCaptured, model.PaymentPartiallyRefunded, true},
+		{model.PaymentPaid, model.PaymentFailed, false},
+		{model.PaymentPartiallyRefunded, model.PaymentRefunded, true},
+		{model.PaymentPending, model.PaymentRetryScheduled, true},
+		{model.PaymentRetryScheduled, model.PaymentPending, true},
+		{model.PaymentRetryScheduled, model.PaymentPaid, false},
+		{model.PaymentFailed, model.PaymentPaid, false},
+		{model.PaymentVoided, model.PaymentCaptured, false},
+		{model.PaymentExpired, model.PaymentAuthorized, false},
+		{model.PaymentRefunded, model.PaymentPaid, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, model.CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestAuthorizeAndCapture(t *testing.T) {
+	ctx := context.Background()
+	manual := service.InitiateRequest{OrderID: "order-1", UserID: "user-1", Amount: 100, Currency: "USD", CaptureMethod: model.CaptureManual}
+
+	t.Run("partial capture limits refunds to the captured amount", func(t *testing.T) {
+		// payment-events, AUTHORIZED, CAPTURED, refund-events
+		svc, repo := newSimulatorService(t, 4)
+		payment, err := svc.InitiatePayment(ctx, manual)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPending, payment.Status)
+
+		captured, err := svc.CapturePayment(ctx, payment.ID, 60)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentCaptured, captured.Status)
+		assert.Equal(t, 60.0, captured.CapturedAmount)
+
+		refund, updated, err := svc.RefundPayment(ctx, payment.ID, 0, "", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, 60.0, refund.Amount)
+		assert.Equal(t, model.PaymentRefunded, updated.Status)
+
+		transitions, err := repo.ListTransitions(payment.ID)
+		require.NoError(t, err)
+		var path []model.PaymentStatus
+		for _, tr := range transitions {
+			path = append(path, tr.ToStatus)
+		}
+		assert.Equal(t, []model.PaymentStatus{model.PaymentPending, model.PaymentAuthorized, model.PaymentCaptured, model.PaymentRefunded}, path)
+	})
+
+	t.Run("capture above the authorization is rejected", func(t *testing.T) {
+		svc, _ := newSimulatorService(t, 2)
+		payment, err := svc.InitiatePayment(ctx, manual)
+		require.NoError(t, err)
+
+		_, err = svc.CapturePayment(ctx, payment.ID, 100.01)
+		assert.ErrorIs(t, err, service.ErrInvalidAmount)
+	})
+
+	t.Run("voided payment cannot be captured", func(t *testing.T) {
+		svc, _ := newSimulatorService(t, 2)
+		payment, err := svc.InitiatePayment(ctx, manual)
+		require.NoError(t, err)
+
+		voided, err := svc.VoidPayment(ctx, payment.ID, "order cancelled")
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentVoided, voided.Status)
+
+		_, err = svc.CapturePayment(ctx, payment.ID, 0)
+		assert.ErrorIs(t, err, service.ErrNotCapturable)
+	})
+
+	t.Run("captured payment cannot be voided", func(t *testing.T) {
+		svc, _ := newSimulatorService(t, 3)
+		payment, err := svc.InitiatePayment(ctx, manual)
+		require.NoError(t, err)
+		_, err = svc.CapturePayment(ctx, payment.ID, 0)
+		require.NoError(t, err)
+
+		_, err = svc.VoidPayment(ctx, payment.ID, "")
+		assert.ErrorIs(t, err, service.ErrNotVoidable)
+	})
+
+	t.Run("automatic payment is paid, not capturable", func(t *testing.T) {
+		svc, _ := newSimulatorService(t, 2)
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{OrderID: "order-2", UserID: "user-1", Amount: 100, Currency: "USD"})
+		require.NoError(t, err)
+
+		_, err = svc.CapturePayment(ctx, payment.ID, 0)
+		assert.ErrorIs(t, err, service.ErrNotCapturable)
+		stored, err := svc.Repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPaid, stored.Status)
+	})
+}
@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listFixture stores five payments created a minute apart, pay-1 being the oldest
+func listFixture() (*service.PaymentService, time.Time) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var payments []*model.Payment
+	for i := 1; i <= 5; i++ {
+		p := &model.Payment{
+			ID:        fmt.Sprintf("pay-%d", i),
+			OrderID:   fmt.Sprintf("order-%d", (i+1)/2),
+			UserID:    "user-1",
+			Amount:    10,
+			Currency:  "USD",
+			Status:    model.PaymentPaid,
+			Provider:  provider.SimulatorName,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		}
+		if i%2 == 0 {
+			p.UserID = "user-2"
+			p.Status = model.PaymentFailed
+			p.Provider = provider.StripeName
+		}
+		payments = append(payments, p)
+	}
+	repo := newFakePaymentRepository(payments...)
+	return service.New(repo, nil, provider.NewRegistry(provider.SimulatorName)), base
+}
+
+func ids(payments []model.Payment) []string {
+	var out []string
+	for _, p := range payments {
+		out = append(out, p.ID)
+	}
+	return out
+}
+
+func TestListPayments(t *testing.T) {
+	t.Run("pages newest first", func(t *testing.T) {
+		svc, _ := listFixture()
+		var pages [][]string
+		token := ""
+		for {
+			payments, next, err := svc.ListPayments(repository.PaymentFilter{}, 2, token)
+			require.NoError(t, err)
+			pages = append(pages, ids(payments))
+			if next == "" {
+				break
+			}
+			token = next
+		}
+		assert.Equal(t, [][]string{{"pay-5", "pay-4"}, {"pay-3", "pay-2"}, {"pay-1"}}, pages)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		svc, base := listFixture()
+		tests := []struct {
+			name   string
+			filter repository.PaymentFilter
+			want   []string
+		}{
+			{"user", repository.PaymentFilter{UserID: "user-2"}, []string{"pay-4", "pay-2"}},
+			{"order", repository.PaymentFilter{OrderID: "order-2"}, []string{"pay-4", "pay-3"}},
+			{"status", repository.PaymentFilter{Status: model.PaymentPaid}, []string{"pay-5", "pay-3", "pay-1"}},
+			{"provider", repository.PaymentFilter{Provider: provider.StripeName}, []string{"pay-4", "pay-2"}},
+			{"date range", repository.PaymentFilter{CreatedFrom: base.Add(2 * time.Minute), CreatedTo: base.Add(4 * time.Minute)}, []string{"pay-3", "pay-2"}},
+			{"combined", repository.PaymentFilter{UserID: "user-1", Status: model.PaymentFailed}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				payments, next, err := svc.ListPayments(tt.filter, 0, "")
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, ids(payments))
+				assert.Empty(t, next)
+			})
+		}
+	})
+
+	t.Run("rejects foreign page tokens", func(t *testing.T) {
+		svc, _ := listFixture()
+		for _, token := range []string{"not base64!", "bm9waXBl", "YWJjfA"} {
+			_, _, err := svc.ListPayments(repository.PaymentFilter{}, 2, token)
+			assert.ErrorIs(t, err, service.ErrInvalidPageToken, token)
+		}
+	})
+}
+
+func TestGetPaymentsForOrder(t *testing.T) {
+	first := pendingPayment()
+	first.ID, first.OrderID, first.Status, first.CreatedAt = "pay-a", "order-9", model.PaymentFailed, time.Now().Add(-time.Hour)
+	second := pendingPayment()
+	second.ID, second.OrderID, second.Status, second.CreatedAt = "pay-b", "order-9", model.PaymentPartiallyRefunded, time.Now()
+	other := pendingPayment()
+	other.ID, other.OrderID = "pay-c", "order-10"
+	repo := newFakePaymentRepository(first, second, other)
+	require.NoError(t, repo.SaveAttempt(&model.PaymentAttempt{PaymentID: "pay-a", Number: 1, Status: model.AttemptFailed, DeclineCode: provider.DeclineLostCard}))
+	require.NoError(t, repo.SaveAttempt(&model.PaymentAttempt{PaymentID: "pay-b", Number: 1, Status: model.AttemptSucceeded}))
+	repo.refunds["ref-1"] = &model.Refund{ID: "ref-1", PaymentID: "pay-b", Amount: 5, Status: model.RefundSucceeded}
+	svc := service.New(repo, nil, provider.NewRegistry(provider.SimulatorName))
+
+	payments, err := svc.GetPaymentsForOrder("order-9")
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+	assert.Equal(t, "pay-a", payments[0].Payment.ID)
+	require.Len(t, payments[0].Attempts, 1)
+	assert.Equal(t, provider.DeclineLostCard, payments[0].Attempts[0].DeclineCode)
+	assert.Empty(t, payments[0].Payment.Refunds)
+	assert.Equal(t, "pay-b", payments[1].Payment.ID)
+	require.Len(t, payments[1].Payment.Refunds, 1)
+	assert.Equal(t, "ref-1", payments[1].Payment.Refunds[0].ID)
+
+	none, err := svc.GetPaymentsForOrder("order-404")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
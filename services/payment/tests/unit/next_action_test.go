@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChallengeFlow(t *testing.T) {
+	ctx := context.Background()
+	challenged := func(t *testing.T, svc *service.PaymentService, cents int, capture model.CaptureMethod) *model.Payment {
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 10 + float64(cents)/100, Currency: "USD", CaptureMethod: capture,
+		})
+		require.NoError(t, err)
+		require.Equal(t, model.PaymentRequiresAction, payment.Status)
+		return payment
+	}
+
+	t.Run("challenge passes and the payment is paid", func(t *testing.T) {
+		svc, repo, recorder := newRetryingService(t, service.RetryPolicy{}, 2)
+		payment := challenged(t, svc, provider.SimulatorChallengeCents, "")
+		assert.Equal(t, provider.NextActionRedirect, payment.NextActionType)
+		assert.Equal(t, "https://simulator.local/3ds/"+payment.StripeSessionID, payment.NextActionURL)
+		assert.NotEmpty(t, payment.ClientSecret)
+
+		paid, err := svc.ConfirmPayment(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPaid, paid.Status)
+		assert.Empty(t, paid.NextActionType, "the next action is cleared")
+		assert.Empty(t, paid.ClientSecret)
+
+		attempts, err := repo.ListAttempts(payment.ID)
+		require.NoError(t, err)
+		require.Len(t, attempts, 1)
+		assert.Equal(t, model.AttemptSucceeded, attempts[0].Status)
+		assert.Equal(t, []string{"payment-events", "payment-status-updates"}, recorder.topics)
+	})
+
+	t.Run("challenge with manual capture authorizes", func(t *testing.T) {
+		svc, _, _ := newRetryingService(t, service.RetryPolicy{}, 2)
+		payment := challenged(t, svc, provider.SimulatorChallengeCents, model.CaptureManual)
+		authorized, err := svc.ConfirmPayment(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentAuthorized, authorized.Status)
+	})
+
+	t.Run("failed challenge fails the payment without retries", func(t *testing.T) {
+		policy := service.RetryPolicy{Schedule: []time.Duration{time.Hour}, MaxAttempts: 3}
+		svc, _, _ := newRetryingService(t, policy, 2)
+		payment := challenged(t, svc, provider.SimulatorChallengeFailCents, "")
+
+		failed, err := svc.ConfirmPayment(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentFailed, failed.Status)
+		assert.Equal(t, provider.DeclineAuthenticationFailed, failed.DeclineCode)
+		assert.Empty(t, failed.NextActionURL)
+	})
+
+	t.Run("only payments requiring action can be confirmed", func(t *testing.T) {
+		svc, _, _ := newRetryingService(t, service.RetryPolicy{}, 2)
+		payment := challenged(t, svc, provider.SimulatorChallengeCents, "")
+		_, err := svc.ConfirmPayment(ctx, payment.ID)
+		require.NoError(t, err)
+
+		_, err = svc.ConfirmPayment(ctx, payment.ID)
+		assert.ErrorIs(t, err, service.ErrNotConfirmable)
+	})
+
+	t.Run("off-session charge can require a challenge", func(t *testing.T) {
+		svc, _, _ := newRetryingService(t, service.RetryPolicy{}, 2)
+		method, err := svc.AttachPaymentMethod(ctx, "user-1", "", "sim_pm_visa_3155", false)
+		require.NoError(t, err)
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 10 + float64(provider.SimulatorChallengeCents)/100, Currency: "USD", PaymentMethodID: method.ID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentRequiresAction, payment.Status)
+		assert.NotEmpty(t, payment.ClientSecret)
+
+		paid, err := svc.ConfirmPayment(ctx, payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPaid, paid.Status)
+	})
+}
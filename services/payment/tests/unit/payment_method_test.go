@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentMethodVault(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("attach stores display data and keeps one default", func(t *testing.T) {
+		svc, repo, _ := newRetryingService(t, service.RetryPolicy{}, 0)
+		visa, err := svc.AttachPaymentMethod(ctx, "user-1", "", "sim_pm_visa_4242", false)
+		require.NoError(t, err)
+		assert.Equal(t, provider.SimulatorName, visa.Provider)
+		assert.Equal(t, "visa", visa.Brand)
+		assert.Equal(t, "4242", visa.Last4)
+		assert.Equal(t, 12, visa.ExpMonth)
+		assert.True(t, visa.IsDefault, "the first method is the default")
+
+		mc, err := svc.AttachPaymentMethod(ctx, "user-1", provider.SimulatorName, "sim_pm_mastercard_4444", true)
+		require.NoError(t, err)
+		assert.Equal(t, visa.CustomerID, mc.CustomerID, "methods share the provider customer")
+
+		methods, err := svc.ListPaymentMethods("user-1")
+		require.NoError(t, err)
+		require.Len(t, methods, 2)
+		assert.Equal(t, mc.ID, methods[0].ID)
+		assert.True(t, methods[0].IsDefault)
+		assert.False(t, methods[1].IsDefault)
+
+		require.NoError(t, svc.DetachPaymentMethod(ctx, "user-1", mc.ID))
+		methods, err = svc.ListPaymentMethods("user-1")
+		require.NoError(t, err)
+		require.Len(t, methods, 1)
+		assert.True(t, methods[0].IsDefault, "the remaining method becomes the default")
+		assert.Len(t, repo.methods, 1)
+	})
+
+	t.Run("raw card numbers are rejected", func(t *testing.T) {
+		svc, repo, _ := newRetryingService(t, service.RetryPolicy{}, 0)
+		for _, token := range []string{"4242424242424242", "4242 4242 4242 4242", "sim_pm_visa_4242-4242-4242-4242", "378282246310005"} {
+			_, err := svc.AttachPaymentMethod(ctx, "user-1", "", token, false)
+			assert.ErrorIs(t, err, service.ErrRawCardData, token)
+		}
+		assert.Empty(t, repo.methods)
+	})
+
+	t.Run("tokens the provider does not know are rejected", func(t *testing.T) {
+		svc, _, _ := newRetryingService(t, service.RetryPolicy{}, 0)
+		_, err := svc.AttachPaymentMethod(ctx, "user-1", "", "pm_card_visa", false)
+		assert.ErrorIs(t, err, service.ErrInvalidPaymentMethod)
+		_, err = svc.AttachPaymentMethod(ctx, "", "", "sim_pm_visa_4242", false)
+		assert.ErrorIs(t, err, service.ErrInvalidPaymentMethod)
+	})
+
+	t.Run("other users' methods cannot be used or detached", func(t *testing.T) {
+		svc, _, _ := newRetryingService(t, service.RetryPolicy{}, 0)
+		method, err := svc.AttachPaymentMethod(ctx, "user-1", "", "sim_pm_visa_4242", false)
+		require.NoError(t, err)
+
+		_, err = svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-2", Amount: 10, Currency: "USD", PaymentMethodID: method.ID,
+		})
+		assert.ErrorIs(t, err, service.ErrPaymentMethodNotFound)
+		assert.ErrorIs(t, svc.DetachPaymentMethod(ctx, "user-2", method.ID), service.ErrPaymentMethodNotFound)
+
+		methods, err := svc.ListPaymentMethods("user-2")
+		require.NoError(t, err)
+		assert.Empty(t, methods)
+	})
+}
+
+func TestOffSessionCharges(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("saved method is charged at once", func(t *testing.T) {
+		svc, repo, recorder := newRetryingService(t, service.RetryPolicy{}, 2)
+		method, err := svc.AttachPaymentMethod(ctx, "user-1", "", "sim_pm_visa_4242", false)
+		require.NoError(t, err)
+
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 25, Currency: "USD", PaymentMethodID: method.ID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPaid, payment.Status)
+		assert.Equal(t, method.ID, payment.PaymentMethodID)
+		assert.Empty(t, payment.CheckoutURL, "off-session charges have no hosted page")
+
+		attempts, err := repo.ListAttempts(payment.ID)
+		require.NoError(t, err)
+		require.Len(t, attempts, 1)
+		assert.Equal(t, model.AttemptSucceeded, attempts[0].Status)
+		assert.Equal(t, []string{"payment-events", "payment-status-updates"}, recorder.topics)
+	})
+
+	t.Run("method of another provider is refused", func(t *testing.T) {
+		svc, _, _ := newRetryingService(t, service.RetryPolicy{}, 0)
+		method, err := svc.AttachPaymentMethod(ctx, "user-1", "", "sim_pm_visa_4242", false)
+		require.NoError(t, err)
+		_, err = svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 25, Currency: "USD", Provider: provider.StripeName, PaymentMethodID: method.ID,
+		})
+		assert.ErrorIs(t, err, service.ErrInvalidPaymentMethod)
+	})
+
+	t.Run("soft decline is retried with the saved method", func(t *testing.T) {
+		policy := service.RetryPolicy{Schedule: []time.Duration{time.Hour}, MaxAttempts: 2}
+		svc, repo, _ := newRetryingService(t, policy, 5)
+		method, err := svc.AttachPaymentMethod(ctx, "user-1", "", "sim_pm_visa_4242", false)
+		require.NoError(t, err)
+
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 10 + float64(provider.SimulatorInsufficientFundsCents)/100, Currency: "USD", PaymentMethodID: method.ID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentRetryScheduled, payment.Status)
+		assert.Equal(t, provider.DeclineInsufficientFunds, payment.DeclineCode)
+
+		n, err := svc.RetryDuePayments(ctx, time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		paid, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPaid, paid.Status)
+		assert.Empty(t, paid.CheckoutURL)
+	})
+
+	t.Run("detached method can no longer be charged", func(t *testing.T) {
+		svc, _, _ := newRetryingService(t, service.RetryPolicy{}, 0)
+		method, err := svc.AttachPaymentMethod(ctx, "user-1", "", "sim_pm_visa_4242", false)
+		require.NoError(t, err)
+		require.NoError(t, svc.DetachPaymentMethod(ctx, "user-1", method.ID))
+
+		_, err = svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 25, Currency: "USD", PaymentMethodID: method.ID,
+		})
+		assert.ErrorIs(t, err, service.ErrPaymentMethodNotFound)
+	})
+}
@@ -0,0 +1,384 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v74"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := provider.NewRegistry(provider.SimulatorName)
+	sim := provider.NewSimulator()
+	registry.Register(sim)
+	registry.Register(decliningCheckout{provider.NewSimulator()}) // also named "simulator"
+	registry.Register(namedProvider{Simulator: sim, name: "acme"})
+
+	tests := []struct {
+		name    string
+		lookup  string
+		want    string
+		wantErr string
+	}{
+		{name: "empty name is the default", lookup: "", want: provider.SimulatorName},
+		{name: "named provider", lookup: "acme", want: "acme"},
+		{name: "unknown provider lists the configured ones", lookup: "stripe", wantErr: `"stripe" is not configured (available: [acme simulator])`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := registry.Get(tc.lookup)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, p.Name())
+		})
+	}
+
+	t.Run("registering a name again replaces the provider", func(t *testing.T) {
+		p, err := registry.Get(provider.SimulatorName)
+		require.NoError(t, err)
+		assert.IsType(t, decliningCheckout{}, p)
+	})
+
+	t.Run("names are sorted", func(t *testing.T) {
+		assert.Equal(t, []string{"acme", provider.SimulatorName}, registry.Names())
+	})
+
+	t.Run("missing default", func(t *testing.T) {
+		_, err := provider.NewRegistry("stripe").Get("")
+		assert.Error(t, err)
+	})
+}
+
+// namedProvider registers a simulator under another name
+type namedProvider struct {
+	*provider.Simulator
+	name string
+}
+
+func (p namedProvider) Name() string {
+	return p.name
+}
+
+func TestSimulatorOutcomes(t *testing.T) {
+	ctx := context.Background()
+	amount := func(cents int) float64 { return 10 + float64(cents)/100 }
+
+	tests := []struct {
+		name        string
+		cents       int
+		attempt     int
+		manual      bool
+		status      provider.Status
+		declineCode string
+		confirmed   provider.Status // status after Confirm
+	}{
+		{name: "success", cents: 0, status: provider.StatusSucceeded, confirmed: provider.StatusSucceeded},
+		{name: "manual capture is authorized", cents: 0, manual: true, status: provider.StatusRequiresCapture, confirmed: provider.StatusRequiresCapture},
+		{name: "generic decline", cents: provider.SimulatorDeclineCents, status: provider.StatusFailed, declineCode: provider.DeclineGeneric, confirmed: provider.StatusFailed},
+		{name: "generic decline on retry", cents: provider.SimulatorDeclineCents, attempt: 2, status: provider.StatusFailed, declineCode: provider.DeclineGeneric, confirmed: provider.StatusFailed},
+		{name: "lost card", cents: provider.SimulatorLostCardCents, status: provider.StatusFailed, declineCode: provider.DeclineLostCard, confirmed: provider.StatusFailed},
+		{name: "insufficient funds", cents: provider.SimulatorInsufficientFundsCents, attempt: 1, status: provider.StatusFailed, declineCode: provider.DeclineInsufficientFunds, confirmed: provider.StatusFailed},
+		{name: "insufficient funds succeeds on retry", cents: provider.SimulatorInsufficientFundsCents, attempt: 2, status: provider.StatusSucceeded, confirmed: provider.StatusSucceeded},
+		{name: "challenge", cents: provider.SimulatorChallengeCents, status: provider.StatusRequiresAction, confirmed: provider.StatusSucceeded},
+		{name: "challenge with manual capture", cents: provider.SimulatorChallengeCents, manual: true, status: provider.StatusRequiresAction, confirmed: provider.StatusRequiresCapture},
+		{name: "failed challenge", cents: provider.SimulatorChallengeFailCents, status: provider.StatusRequiresAction, confirmed: provider.StatusFailed},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sim := provider.NewSimulator()
+			sess, err := sim.CreateSession(ctx, provider.SessionRequest{
+				PaymentID: "pay-1", Amount: amount(tc.cents), Currency: "USD", Attempt: tc.attempt, ManualCapture: tc.manual,
+			})
+			require.NoError(t, err)
+			if tc.status == provider.StatusRequiresAction {
+				assert.Equal(t, tc.status, sess.Status)
+				require.NotNil(t, sess.NextAction)
+				assert.Equal(t, provider.NextActionRedirect, sess.NextAction.Type)
+			}
+
+			fetched, err := sim.FetchStatus(ctx, sess.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.status, fetched.Status)
+			assert.Equal(t, tc.declineCode, fetched.DeclineCode)
+
+			confirmed, err := sim.Confirm(ctx, sess.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.confirmed, confirmed.Status)
+			assert.Nil(t, confirmed.NextAction)
+			if tc.cents == provider.SimulatorChallengeFailCents {
+				assert.Equal(t, provider.DeclineAuthenticationFailed, confirmed.DeclineCode)
+			}
+		})
+	}
+
+	t.Run("sessions are stable per attempt", func(t *testing.T) {
+		sim := provider.NewSimulator()
+		req := provider.SessionRequest{PaymentID: "pay-1", Amount: 10, Currency: "USD"}
+		first, err := sim.CreateSession(ctx, req)
+		require.NoError(t, err)
+		again, err := sim.CreateSession(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, again.ID)
+
+		req.Attempt = 2
+		retry, err := sim.CreateSession(ctx, req)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.ID, retry.ID)
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		_, err := provider.NewSimulator().CreateSession(ctx, provider.SessionRequest{PaymentID: "pay-1", Amount: 0})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		_, err := provider.NewSimulator().FetchStatus(ctx, "sim_cs_missing")
+		assert.ErrorIs(t, err, provider.ErrNotFound)
+	})
+
+	t.Run("capture", func(t *testing.T) {
+		sim := provider.NewSimulator()
+		sess, err := sim.CreateSession(ctx, provider.SessionRequest{PaymentID: "pay-1", Amount: 10, Currency: "USD", ManualCapture: true})
+		require.NoError(t, err)
+
+		_, err = sim.Capture(ctx, sess.ID, 12)
+		assert.Error(t, err, "more than was authorized")
+
+		captured, err := sim.Capture(ctx, sess.ID, 6)
+		require.NoError(t, err)
+		assert.Equal(t, provider.StatusSucceeded, captured.Status)
+		assert.Equal(t, 6.0, captured.Amount)
+
+		_, err = sim.Capture(ctx, sess.ID, 0)
+		assert.Error(t, err, "already captured")
+	})
+
+	t.Run("refunds", func(t *testing.T) {
+		sim := provider.NewSimulator()
+		sess, err := sim.CreateSession(ctx, provider.SessionRequest{PaymentID: "pay-1", Amount: 10, Currency: "USD"})
+		require.NoError(t, err)
+
+		pending, err := sim.Refund(ctx, sess.ID, 3.07, "", "refund-1")
+		require.NoError(t, err)
+		assert.Equal(t, provider.StatusPending, pending.Status)
+		assert.Equal(t, "refund-1", pending.RefundID)
+
+		again, err := sim.Refund(ctx, sess.ID, 3.07, "", "refund-1")
+		require.NoError(t, err)
+		assert.Equal(t, pending.ID, again.ID, "same idempotency key")
+
+		fetched, err := sim.FetchRefund(ctx, sess.ID, "refund-1")
+		require.NoError(t, err)
+		assert.Equal(t, provider.StatusSucceeded, fetched.Status)
+
+		_, err = sim.FetchRefund(ctx, sess.ID, "refund-2")
+		assert.ErrorIs(t, err, provider.ErrNotFound)
+
+		_, err = sim.Refund(ctx, sess.ID, 7, "", "refund-2")
+		assert.Error(t, err, "more than remains")
+
+		rest, err := sim.Refund(ctx, sess.ID, 6.93, "", "refund-3")
+		require.NoError(t, err)
+		assert.Equal(t, provider.StatusSucceeded, rest.Status)
+		status, err := sim.FetchStatus(ctx, sess.ID)
+		require.NoError(t, err)
+		assert.Equal(t, provider.StatusRefunded, status.Status)
+	})
+}
+
+func TestDeclineMapping(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       *stripe.Error
+		code      string
+		retryable bool
+	}{
+		{name: "issuer decline code wins", err: &stripe.Error{Code: stripe.ErrorCodeCardDeclined, DeclineCode: stripe.DeclineCodeInsufficientFunds}, code: "insufficient_funds", retryable: true},
+		{name: "soft decline", err: &stripe.Error{Code: stripe.ErrorCodeCardDeclined, DeclineCode: stripe.DeclineCodeTryAgainLater}, code: "try_again_later", retryable: true},
+		{name: "lost card", err: &stripe.Error{Code: stripe.ErrorCodeCardDeclined, DeclineCode: stripe.DeclineCodeLostCard}, code: "lost_card"},
+		{name: "fraudulent", err: &stripe.Error{Code: stripe.ErrorCodeCardDeclined, DeclineCode: stripe.DeclineCodeFraudulent}, code: "fraudulent"},
+		{name: "error code without decline code", err: &stripe.Error{Code: stripe.ErrorCodeExpiredCard}, code: "expired_card"},
+		{name: "processing error", err: &stripe.Error{Code: stripe.ErrorCodeProcessingError}, code: "processing_error", retryable: true},
+		{name: "failed authentication", err: &stripe.Error{Code: stripe.ErrorCodePaymentIntentAuthenticationFailure}, code: provider.DeclineAuthenticationFailed},
+		{name: "nothing reported", err: &stripe.Error{}, code: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code := provider.DeclineCodeOf(tc.err)
+			assert.Equal(t, tc.code, code)
+			assert.Equal(t, tc.retryable, provider.IsRetryableDecline(code))
+		})
+	}
+}
+
+func TestStripeMapping(t *testing.T) {
+	t.Run("next action", func(t *testing.T) {
+		redirect := provider.NextActionOf(&stripe.PaymentIntent{
+			ClientSecret: "pi_1_secret",
+			NextAction:   &stripe.PaymentIntentNextAction{RedirectToURL: &stripe.PaymentIntentNextActionRedirectToURL{URL: "https://bank.example/3ds"}},
+		})
+		assert.Equal(t, &provider.NextAction{Type: provider.NextActionRedirect, RedirectURL: "https://bank.example/3ds", ClientSecret: "pi_1_secret"}, redirect)
+
+		sdk := provider.NextActionOf(&stripe.PaymentIntent{ClientSecret: "pi_1_secret", NextAction: &stripe.PaymentIntentNextAction{}})
+		assert.Equal(t, &provider.NextAction{Type: provider.NextActionUseClientSecret, ClientSecret: "pi_1_secret"}, sdk)
+	})
+
+	refunds := []struct {
+		name    string
+		refund  stripe.Refund
+		status  provider.Status
+		message string
+	}{
+		{name: "succeeded", refund: stripe.Refund{Status: stripe.RefundStatusSucceeded}, status: provider.StatusSucceeded},
+		{name: "pending", refund: stripe.Refund{Status: stripe.RefundStatusPending}, status: provider.StatusPending},
+		{name: "requires action", refund: stripe.Refund{Status: stripe.RefundStatusRequiresAction}, status: provider.StatusPending},
+		{name: "failed", refund: stripe.Refund{Status: stripe.RefundStatusFailed, FailureReason: stripe.RefundFailureReasonExpiredOrCanceledCard}, status: provider.StatusFailed, message: "refund failed: expired_or_canceled_card"},
+		{name: "canceled", refund: stripe.Refund{Status: stripe.RefundStatusCanceled}, status: provider.StatusFailed, message: "refund canceled"},
+	}
+	for _, tc := range refunds {
+		t.Run("refund "+tc.name, func(t *testing.T) {
+			tc.refund.ID = "re_1"
+			tc.refund.Amount = 1250
+			tc.refund.Metadata = map[string]string{"refund_id": "refund-1"}
+			got := provider.FromStripeRefund(&tc.refund)
+			assert.Equal(t, &provider.Refund{ID: "re_1", RefundID: "refund-1", Amount: 12.5, Status: tc.status, Message: tc.message}, got)
+		})
+	}
+
+	disputes := []struct {
+		status stripe.DisputeStatus
+		want   string
+	}{
+		{stripe.DisputeStatusNeedsResponse, provider.DisputeNeedsResponse},
+		{stripe.DisputeStatusWarningNeedsResponse, provider.DisputeNeedsResponse},
+		{stripe.DisputeStatusUnderReview, provider.DisputeUnderReview},
+		{stripe.DisputeStatusWarningUnderReview, provider.DisputeUnderReview},
+		{stripe.DisputeStatusWon, provider.DisputeWon},
+		{stripe.DisputeStatusWarningClosed, provider.DisputeWon},
+		{stripe.DisputeStatusChargeRefunded, provider.DisputeWon},
+		{stripe.DisputeStatusLost, provider.DisputeLost},
+	}
+	for _, tc := range disputes {
+		t.Run("dispute "+string(tc.status), func(t *testing.T) {
+			got := provider.FromStripeDispute(&stripe.Dispute{
+				ID:              "dp_1",
+				Amount:          5000,
+				Currency:        stripe.CurrencyUSD,
+				Reason:          stripe.DisputeReasonFraudulent,
+				Status:          tc.status,
+				PaymentIntent:   &stripe.PaymentIntent{ID: "pi_1", Metadata: map[string]string{"payment_id": "pay-1"}},
+				EvidenceDetails: &stripe.DisputeEvidenceDetails{DueBy: 1700000000},
+			})
+			assert.Equal(t, &provider.DisputeUpdate{
+				ID:              "dp_1",
+				PaymentIntentID: "pi_1",
+				PaymentID:       "pay-1",
+				Reason:          "fraudulent",
+				Amount:          50,
+				Currency:        "USD",
+				Status:          tc.want,
+				EvidenceDueBy:   time.Unix(1700000000, 0),
+			}, got)
+		})
+	}
+}
+
+// TestStripeSessionMapping maps Checkout sessions and payment intents served
+// by a fake Stripe API through FetchStatus
+func TestStripeSessionMapping(t *testing.T) {
+	objects := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		body, ok := objects[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			body = `{"error":{"type":"invalid_request_error","code":"resource_missing"}}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		URL:               stripe.String(server.URL),
+		MaxNetworkRetries: stripe.Int64(0),
+		LeveledLogger:     &stripe.LeveledLogger{Level: stripe.LevelNull},
+	}))
+	t.Cleanup(func() { stripe.SetBackend(stripe.APIBackend, nil) })
+	stripeProvider, err := provider.NewStripe("sk_test_123", "https://shop.example/ok", "https://shop.example/cancel")
+	require.NoError(t, err)
+
+	session := func(status, paymentStatus, intent string) string {
+		if intent == "" {
+			intent = "null"
+		}
+		return `{"id":"cs_1","object":"checkout.session","url":"https://checkout.stripe.com/cs_1","amount_total":2000,` +
+			`"status":"` + status + `","payment_status":"` + paymentStatus + `","payment_intent":` + intent + `}`
+	}
+	intent := func(status string, received int, extra string) string {
+		return `{"id":"pi_1","object":"payment_intent","amount":2000,"amount_received":` + strconv.Itoa(received) +
+			`,"status":"` + status + `","client_secret":"pi_1_secret"` + extra + `}`
+	}
+	declined := `,"last_payment_error":{"type":"card_error","code":"card_declined","decline_code":"insufficient_funds","message":"Your card has insufficient funds."}`
+
+	tests := []struct {
+		name        string
+		sessionID   string
+		object      string
+		status      provider.Status
+		amount      float64
+		declineCode string
+		message     string
+		nextAction  string
+	}{
+		{name: "open session", sessionID: "cs_1", object: session("open", "unpaid", ""), status: provider.StatusPending, amount: 20},
+		{name: "expired session", sessionID: "cs_1", object: session("expired", "unpaid", ""), status: provider.StatusCanceled, amount: 20},
+		{name: "paid session", sessionID: "cs_1", object: session("complete", "paid", ""), status: provider.StatusSucceeded, amount: 20},
+		{name: "completed but unpaid session", sessionID: "cs_1", object: session("complete", "unpaid", ""), status: provider.StatusPending, amount: 20},
+		{name: "succeeded intent uses the received amount", sessionID: "cs_1", object: session("complete", "paid", intent("succeeded", 1500, "")), status: provider.StatusSucceeded, amount: 15},
+		{name: "authorized intent", sessionID: "cs_1", object: session("complete", "unpaid", intent("requires_capture", 0, "")), status: provider.StatusRequiresCapture, amount: 20},
+		{name: "intent requiring action", sessionID: "cs_1", object: session("open", "unpaid", intent("requires_action", 0, "")), status: provider.StatusRequiresAction, amount: 20, nextAction: provider.NextActionUseClientSecret},
+		{name: "canceled intent", sessionID: "cs_1", object: session("open", "unpaid", intent("canceled", 0, "")), status: provider.StatusCanceled, amount: 20},
+		{name: "declined intent", sessionID: "cs_1", object: session("open", "unpaid", intent("requires_payment_method", 0, declined)), status: provider.StatusFailed, amount: 20, declineCode: "insufficient_funds", message: "Your card has insufficient funds."},
+		{name: "off-session intent", sessionID: "pi_1", object: intent("succeeded", 2000, ""), status: provider.StatusSucceeded, amount: 20},
+		{name: "declined off-session intent", sessionID: "pi_1", object: intent("requires_payment_method", 0, declined), status: provider.StatusFailed, amount: 20, declineCode: "insufficient_funds", message: "Your card has insufficient funds."},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			objects[tc.sessionID] = tc.object
+			t.Cleanup(func() { delete(objects, tc.sessionID) })
+
+			sess, err := stripeProvider.FetchStatus(context.Background(), tc.sessionID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.sessionID, sess.ID)
+			assert.Equal(t, tc.status, sess.Status)
+			assert.Equal(t, tc.amount, sess.Amount)
+			assert.Equal(t, tc.declineCode, sess.DeclineCode)
+			assert.Equal(t, tc.message, sess.Message)
+			if tc.nextAction == "" {
+				assert.Nil(t, sess.NextAction)
+			} else {
+				require.NotNil(t, sess.NextAction)
+				assert.Equal(t, tc.nextAction, sess.NextAction.Type)
+				assert.Equal(t, "pi_1_secret", sess.NextAction.ClientSecret)
+			}
+		})
+	}
+
+	t.Run("unknown session", func(t *testing.T) {
+		_, err := stripeProvider.FetchStatus(context.Background(), "cs_missing")
+		assert.ErrorIs(t, err, provider.ErrNotFound)
+	})
+}
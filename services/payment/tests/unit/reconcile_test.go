@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+	producer := mocks.NewSyncProducer(t, nil)
+	// 5 payment-events, 3 manual status updates and 2 automatic fixes
+	for i := 0; i < 10; i++ {
+		producer.ExpectSendMessageAndSucceed()
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	sim := provider.NewSimulator()
+	providers := provider.NewRegistry(provider.SimulatorName)
+	providers.Register(sim)
+	repo := newFakePaymentRepository()
+	svc := service.New(repo, kafka.NewProducerWithClient(producer), providers)
+
+	start := time.Now().Add(-time.Minute)
+	initiate := func(orderID string, amount float64) *model.Payment {
+		p, err := svc.InitiatePayment(ctx, service.InitiateRequest{OrderID: orderID, UserID: "user-1", Amount: amount, Currency: "USD"})
+		require.NoError(t, err)
+		return p
+	}
+
+	// the success webhook was lost
+	lostWebhook := initiate("order-1", 50)
+	// already in sync
+	inSync := initiate("order-2", 20)
+	require.NoError(t, svc.UpdateStatus(ctx, inSync.ID, model.PaymentPaid, "paid"))
+	// the decline webhook was lost
+	declined := initiate("order-3", 10+float64(provider.SimulatorDeclineCents)/100)
+	// stored amount differs from what the provider charged
+	wrongAmount := initiate("order-4", 30)
+	require.NoError(t, svc.UpdateStatus(ctx, wrongAmount.ID, model.PaymentPaid, "paid"))
+	repo.payments[wrongAmount.ID].Amount = 35
+	repo.payments[wrongAmount.ID].CapturedAmount = 35
+	// marked paid locally although the provider declined it; not safe to change
+	paidButDeclined := initiate("order-5", 40+float64(provider.SimulatorDeclineCents)/100)
+	require.NoError(t, svc.UpdateStatus(ctx, paidButDeclined.ID, model.PaymentPaid, "paid"))
+	// the provider has a session we never stored
+	_, err := sim.CreateSession(ctx, provider.SessionRequest{PaymentID: "ghost", OrderID: "order-6", Amount: 15, Currency: "USD"})
+	require.NoError(t, err)
+	// we have a session the provider never saw
+	require.NoError(t, repo.Save(&model.Payment{
+		ID:              "orphan",
+		OrderID:         "order-7",
+		Amount:          25,
+		Currency:        "USD",
+		Status:          model.PaymentPending,
+		Provider:        provider.SimulatorName,
+		StripeSessionID: "sim_cs_orphan",
+		CreatedAt:       time.Now(),
+	}))
+
+	report, err := svc.Reconcile(ctx, provider.SimulatorName, start, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Matched)
+	assert.Equal(t, 1, report.MissingLocally)
+	assert.Equal(t, 1, report.MissingAtProvider)
+	assert.Equal(t, 1, report.AmountMismatches)
+	assert.Equal(t, 3, report.StatusMismatches)
+	assert.Equal(t, 2, report.Fixed)
+
+	p, _ := repo.FindByID(lostWebhook.ID)
+	assert.Equal(t, model.PaymentPaid, p.Status)
+	p, _ = repo.FindByID(declined.ID)
+	assert.Equal(t, model.PaymentFailed, p.Status)
+	p, _ = repo.FindByID(paidButDeclined.ID)
+	assert.Equal(t, model.PaymentPaid, p.Status)
+
+	kinds := map[string]model.DiscrepancyKind{}
+	for _, item := range report.Items {
+		if item.Kind != model.DiscrepancyStatusMismatch {
+			kinds[item.PaymentID] = item.Kind
+		}
+	}
+	assert.Equal(t, model.DiscrepancyMissingLocally, kinds["ghost"])
+	assert.Equal(t, model.DiscrepancyMissingAtProvider, kinds["orphan"])
+	assert.Equal(t, model.DiscrepancyAmountMismatch, kinds[wrongAmount.ID])
+
+	stored, err := svc.GetReconciliationReport("", provider.SimulatorName)
+	require.NoError(t, err)
+	assert.Equal(t, report.ID, stored.ID)
+}
+
+func TestSimulatorListTransactionsPaging(t *testing.T) {
+	ctx := context.Background()
+	sim := provider.NewSimulator()
+	from := time.Now().Add(-time.Minute)
+	for _, id := range []string{"p1", "p2", "p3", "p4", "p5"} {
+		_, err := sim.CreateSession(ctx, provider.SessionRequest{PaymentID: id, Amount: 10, Currency: "USD"})
+		require.NoError(t, err)
+	}
+
+	seen := map[string]bool{}
+	cursor, pages := "", 0
+	for {
+		page, err := sim.ListTransactions(ctx, from, time.Now().Add(time.Minute), cursor, 2)
+		require.NoError(t, err)
+		pages++
+		for _, tx := range page.Transactions {
+			assert.False(t, seen[tx.PaymentID], "transaction %s listed twice", tx.PaymentID)
+			seen[tx.PaymentID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	assert.Len(t, seen, 5)
+	assert.Equal(t, 3, pages)
+}
@@ -0,0 +1,203 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRefundFixture returns a service backed by the simulator and a paid payment.
+// The producer expects the two setup messages followed by refundEvents refund events.
+func newRefundFixture(t *testing.T, refundEvents int) (*service.PaymentService, *model.Payment) {
+	return newRefundFixtureWith(t, refundEvents, provider.NewSimulator())
+}
+
+// newRefundFixtureWith is newRefundFixture with a provider standing in for the simulator
+func newRefundFixtureWith(t *testing.T, refundEvents int, p provider.PaymentProvider) (*service.PaymentService, *model.Payment) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed() // payment-events
+	producer.ExpectSendMessageAndSucceed() // payment-status-updates
+	for i := 0; i < refundEvents; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			if msg.Topic != "refund-events" {
+				return fmt.Errorf("unexpected topic %s", msg.Topic)
+			}
+			value, err := msg.Value.Encode()
+			if err != nil {
+				return err
+			}
+			var event map[string]interface{}
+			if err := json.Unmarshal(value, &event); err != nil {
+				return err
+			}
+			if event["order_id"] != "order-1" || event["status"] != string(model.RefundSucceeded) {
+				return fmt.Errorf("unexpected refund event %v", event)
+			}
+			return nil
+		})
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	providers := provider.NewRegistry(provider.SimulatorName)
+	providers.Register(p)
+	svc := service.New(newFakePaymentRepository(), kafka.NewProducerWithClient(producer), providers)
+
+	ctx := context.Background()
+	payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{OrderID: "order-1", UserID: "user-1", Amount: 100, Currency: "USD"})
+	require.NoError(t, err)
+	require.NoError(t, svc.UpdateStatus(ctx, payment.ID, model.PaymentPaid, "paid"))
+	return svc, payment
+}
+
+// droppedRefunds is the simulator with refund requests that fail on the way:
+// before they reach it while lost is set, after it refunded them while dropped is
+type droppedRefunds struct {
+	*provider.Simulator
+	lost, dropped bool
+}
+
+func (d *droppedRefunds) Refund(ctx context.Context, sessionID string, amount float64, reason, idempotencyKey string) (*provider.Refund, error) {
+	if d.lost {
+		return nil, errors.New("connection reset")
+	}
+	r, err := d.Simulator.Refund(ctx, sessionID, amount, reason, idempotencyKey)
+	if err == nil && d.dropped {
+		return nil, errors.New("read timeout")
+	}
+	return r, err
+}
+
+func TestRefundPayment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("partial refunds followed by the remainder", func(t *testing.T) {
+		svc, payment := newRefundFixture(t, 2)
+
+		refund, p, err := svc.RefundPayment(ctx, payment.ID, 30, "damaged item", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, model.RefundSucceeded, refund.Status)
+		assert.Equal(t, 30.0, refund.Amount)
+		assert.Equal(t, model.PaymentPartiallyRefunded, p.Status)
+		assert.Equal(t, 30.0, p.RefundedAmount)
+
+		refund, p, err = svc.RefundPayment(ctx, payment.ID, 0, "order cancelled", "key-2")
+		require.NoError(t, err)
+		assert.Equal(t, 70.0, refund.Amount)
+		assert.Equal(t, model.PaymentRefunded, p.Status)
+		assert.Equal(t, 100.0, p.RefundedAmount)
+	})
+
+	t.Run("retrying with the same key returns the original refund", func(t *testing.T) {
+		svc, payment := newRefundFixture(t, 1)
+
+		first, _, err := svc.RefundPayment(ctx, payment.ID, 25, "", "key-1")
+		require.NoError(t, err)
+		second, p, err := svc.RefundPayment(ctx, payment.ID, 25, "", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+		assert.Equal(t, 25.0, p.RefundedAmount)
+	})
+
+	t.Run("refund larger than the remainder is rejected", func(t *testing.T) {
+		svc, payment := newRefundFixture(t, 1)
+
+		_, _, err := svc.RefundPayment(ctx, payment.ID, 60, "", "key-1")
+		require.NoError(t, err)
+		_, _, err = svc.RefundPayment(ctx, payment.ID, 40.01, "", "key-2")
+		assert.ErrorIs(t, err, repository.ErrRefundExceedsPayment)
+	})
+
+	t.Run("fully refunded payment cannot be refunded again", func(t *testing.T) {
+		svc, payment := newRefundFixture(t, 1)
+
+		_, _, err := svc.RefundPayment(ctx, payment.ID, 0, "", "key-1")
+		require.NoError(t, err)
+		_, _, err = svc.RefundPayment(ctx, payment.ID, 1, "", "key-2")
+		assert.ErrorIs(t, err, repository.ErrNotRefundable)
+	})
+
+	t.Run("idempotency key is required", func(t *testing.T) {
+		svc, payment := newRefundFixture(t, 0)
+
+		_, _, err := svc.RefundPayment(ctx, payment.ID, 10, "", "")
+		assert.ErrorIs(t, err, service.ErrIdempotencyKeyRequired)
+	})
+
+	t.Run("a refund the provider has not settled stays pending until synced", func(t *testing.T) {
+		svc, payment := newRefundFixture(t, 1)
+
+		refund, p, err := svc.RefundPayment(ctx, payment.ID, 5.07, "", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, model.RefundPending, refund.Status)
+		assert.NotEmpty(t, refund.ProviderRefundID)
+		assert.Zero(t, p.RefundedAmount)
+
+		n, err := svc.SyncPendingRefunds(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		payment, err = svc.Repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 5.07, payment.RefundedAmount)
+		assert.Equal(t, model.PaymentPartiallyRefunded, payment.Status)
+
+		n, err = svc.SyncPendingRefunds(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("a refund lost on the way stays pending and is sent again", func(t *testing.T) {
+		flaky := &droppedRefunds{Simulator: provider.NewSimulator(), lost: true}
+		svc, payment := newRefundFixtureWith(t, 1, flaky)
+
+		refund, p, err := svc.RefundPayment(ctx, payment.ID, 40, "", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, model.RefundPending, refund.Status)
+		assert.Contains(t, refund.Message, "connection reset")
+		assert.Zero(t, p.RefundedAmount)
+
+		flaky.lost = false
+		_, err = svc.SyncPendingRefunds(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		payment, err = svc.Repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 40.0, payment.RefundedAmount)
+	})
+
+	t.Run("a refund whose response was lost is not refunded twice", func(t *testing.T) {
+		flaky := &droppedRefunds{Simulator: provider.NewSimulator(), dropped: true}
+		svc, payment := newRefundFixtureWith(t, 1, flaky)
+
+		refund, _, err := svc.RefundPayment(ctx, payment.ID, 0, "", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, model.RefundPending, refund.Status)
+
+		// the retry finds the provider's refund instead of making another
+		flaky.dropped = false
+		refund, p, err := svc.RefundPayment(ctx, payment.ID, 0, "", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, model.RefundSucceeded, refund.Status)
+		assert.Equal(t, model.PaymentRefunded, p.Status)
+		assert.Equal(t, 100.0, p.RefundedAmount)
+	})
+
+	t.Run("unknown payment", func(t *testing.T) {
+		svc, _ := newRefundFixture(t, 0)
+
+		_, _, err := svc.RefundPayment(ctx, "missing", 10, "", "key-1")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+	})
+}
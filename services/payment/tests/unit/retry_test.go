@@ -0,0 +1,271 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topicRecorder collects the topics a service published to and the decoded
+// events, in order
+type topicRecorder struct {
+	mu     sync.Mutex
+	topics []string
+	events []map[string]interface{}
+}
+
+func (r *topicRecorder) check(msg *sarama.ProducerMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.topics = append(r.topics, msg.Topic)
+	data, err := msg.Value.Encode()
+	if err != nil {
+		return err
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+// newRetryingService returns a simulator-backed service with policy whose
+// producer expects exactly publishes messages
+func newRetryingService(t *testing.T, policy service.RetryPolicy, publishes int) (*service.PaymentService, *fakePaymentRepository, *topicRecorder) {
+	return newRetryingServiceWith(t, policy, publishes, provider.NewSimulator())
+}
+
+// newRetryingServiceWith is newRetryingService backed by p
+func newRetryingServiceWith(t *testing.T, policy service.RetryPolicy, publishes int, p provider.PaymentProvider) (*service.PaymentService, *fakePaymentRepository, *topicRecorder) {
+	recorder := &topicRecorder{}
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < publishes; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recorder.check)
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	providers := provider.NewRegistry(provider.SimulatorName)
+	providers.Register(p)
+	repo := newFakePaymentRepository()
+	svc := service.New(repo, kafka.NewProducerWithClient(producer), providers)
+	svc.RetryPolicy = policy
+	return svc, repo, recorder
+}
+
+// decliningCheckout is a simulator whose first checkout session is declined
+// as soon as it is created
+type decliningCheckout struct {
+	*provider.Simulator
+}
+
+func (d decliningCheckout) CreateSession(ctx context.Context, req provider.SessionRequest) (*provider.Session, error) {
+	sess, err := d.Simulator.CreateSession(ctx, req)
+	if err == nil && req.Attempt == 1 {
+		sess.Status, sess.DeclineCode, sess.Message = provider.StatusFailed, provider.DeclineInsufficientFunds, "Your card has insufficient funds."
+	}
+	return sess, err
+}
+
+// decline delivers the provider's decline of the payment's current attempt
+func decline(t *testing.T, svc *service.PaymentService, payment *model.Payment, code string) {
+	require.NoError(t, svc.ApplyProviderEvent(context.Background(), service.ProviderEvent{
+		ID:          "evt_" + payment.ID,
+		Provider:    provider.SimulatorName,
+		Type:        "payment_intent.payment_failed",
+		SessionID:   payment.StripeSessionID,
+		Status:      model.PaymentFailed,
+		DeclineCode: code,
+		Attempt:     1,
+		Message:     "declined",
+	}))
+}
+
+func TestParseRetryPolicy(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		policy, err := service.ParseRetryPolicy("", "")
+		require.NoError(t, err)
+		assert.Equal(t, service.DefaultRetryPolicy(), policy)
+	})
+
+	t.Run("maximum defaults to one retry per delay", func(t *testing.T) {
+		policy, err := service.ParseRetryPolicy("30m, 2h", "")
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{30 * time.Minute, 2 * time.Hour}, policy.Schedule)
+		assert.Equal(t, 3, policy.MaxAttempts)
+	})
+
+	t.Run("last delay repeats", func(t *testing.T) {
+		policy, err := service.ParseRetryPolicy("1h", "4")
+		require.NoError(t, err)
+		for attempts := 1; attempts <= 3; attempts++ {
+			delay, ok := policy.NextDelay(attempts)
+			assert.True(t, ok)
+			assert.Equal(t, time.Hour, delay)
+		}
+		_, ok := policy.NextDelay(4)
+		assert.False(t, ok)
+	})
+
+	t.Run("one attempt disables retries", func(t *testing.T) {
+		policy, err := service.ParseRetryPolicy("", "1")
+		require.NoError(t, err)
+		_, ok := policy.NextDelay(1)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		_, err := service.ParseRetryPolicy("soon", "")
+		assert.Error(t, err)
+		_, err = service.ParseRetryPolicy("-1h", "")
+		assert.Error(t, err)
+		_, err = service.ParseRetryPolicy("", "0")
+		assert.Error(t, err)
+	})
+}
+
+func TestPaymentRetries(t *testing.T) {
+	ctx := context.Background()
+	initiate := func(t *testing.T, svc *service.PaymentService, cents int) *model.Payment {
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 10 + float64(cents)/100, Currency: "USD",
+		})
+		require.NoError(t, err)
+		return payment
+	}
+
+	t.Run("soft decline is retried and succeeds", func(t *testing.T) {
+		policy := service.RetryPolicy{Schedule: []time.Duration{time.Hour}, MaxAttempts: 3}
+		svc, repo, recorder := newRetryingService(t, policy, 5)
+		payment := initiate(t, svc, provider.SimulatorInsufficientFundsCents)
+
+		decline(t, svc, payment, provider.DeclineInsufficientFunds)
+		scheduled, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentRetryScheduled, scheduled.Status)
+		assert.Equal(t, provider.DeclineInsufficientFunds, scheduled.DeclineCode)
+		require.NotNil(t, scheduled.NextRetryAt)
+
+		// nothing is due before the scheduled time
+		n, err := svc.RetryDuePayments(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		n, err = svc.RetryDuePayments(ctx, scheduled.NextRetryAt.Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		paid, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPaid, paid.Status)
+		assert.Equal(t, 2, paid.Attempts)
+		assert.Nil(t, paid.NextRetryAt)
+		assert.NotEqual(t, payment.StripeSessionID, paid.StripeSessionID)
+
+		attempts, err := repo.ListAttempts(payment.ID)
+		require.NoError(t, err)
+		require.Len(t, attempts, 2)
+		assert.Equal(t, model.AttemptFailed, attempts[0].Status)
+		assert.Equal(t, provider.DeclineInsufficientFunds, attempts[0].DeclineCode)
+		assert.Equal(t, model.AttemptSucceeded, attempts[1].Status)
+		assert.Equal(t, paid.StripeSessionID, attempts[1].SessionID)
+
+		assert.Equal(t, []string{"payment-events", "payment-status-updates", "notification-events",
+			"payment-status-updates", "payment-status-updates"}, recorder.topics)
+	})
+
+	t.Run("decline on session creation is retried", func(t *testing.T) {
+		policy := service.RetryPolicy{Schedule: []time.Duration{time.Hour}, MaxAttempts: 3}
+		svc, repo, recorder := newRetryingServiceWith(t, policy, 3, decliningCheckout{provider.NewSimulator()})
+		payment := initiate(t, svc, 0)
+		assert.Equal(t, model.PaymentRetryScheduled, payment.Status)
+		assert.Equal(t, provider.DeclineInsufficientFunds, payment.DeclineCode)
+		require.NotNil(t, payment.NextRetryAt)
+
+		attempts, err := repo.ListAttempts(payment.ID)
+		require.NoError(t, err)
+		require.Len(t, attempts, 1)
+		assert.Equal(t, model.AttemptFailed, attempts[0].Status)
+		assert.Equal(t, []string{"payment-events", "payment-status-updates", "notification-events"}, recorder.topics)
+	})
+
+	t.Run("terminal decline fails at once", func(t *testing.T) {
+		policy := service.RetryPolicy{Schedule: []time.Duration{time.Hour}, MaxAttempts: 3}
+		svc, repo, recorder := newRetryingService(t, policy, 2)
+		payment := initiate(t, svc, provider.SimulatorLostCardCents)
+
+		decline(t, svc, payment, provider.DeclineLostCard)
+		failed, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentFailed, failed.Status)
+		assert.Equal(t, []string{"payment-events", "payment-status-updates"}, recorder.topics)
+	})
+
+	t.Run("payment fails once retries are exhausted", func(t *testing.T) {
+		policy := service.RetryPolicy{Schedule: []time.Duration{time.Hour}, MaxAttempts: 2}
+		svc, repo, recorder := newRetryingService(t, policy, 6)
+		payment := initiate(t, svc, provider.SimulatorDeclineCents)
+
+		decline(t, svc, payment, provider.DeclineGeneric)
+		n, err := svc.RetryDuePayments(ctx, time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		failed, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentFailed, failed.Status)
+		assert.Equal(t, 2, failed.Attempts)
+		attempts, err := repo.ListAttempts(payment.ID)
+		require.NoError(t, err)
+		require.Len(t, attempts, 2)
+		assert.Equal(t, model.AttemptFailed, attempts[1].Status)
+		assert.Equal(t, provider.DeclineGeneric, attempts[1].DeclineCode)
+
+		// retry scheduled notice, then the final notice after the last attempt
+		assert.Equal(t, []string{"payment-events", "payment-status-updates", "notification-events",
+			"payment-status-updates", "payment-status-updates", "notification-events"}, recorder.topics)
+	})
+
+	t.Run("without a policy declines are final", func(t *testing.T) {
+		svc, repo, _ := newRetryingService(t, service.RetryPolicy{}, 2)
+		payment := initiate(t, svc, provider.SimulatorInsufficientFundsCents)
+
+		decline(t, svc, payment, provider.DeclineInsufficientFunds)
+		failed, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentFailed, failed.Status)
+	})
+
+	t.Run("events for a superseded attempt are ignored", func(t *testing.T) {
+		payment := pendingPayment()
+		payment.Attempts = 2
+		repo := newFakePaymentRepository(payment)
+		svc := service.New(repo, nil, provider.NewRegistry(provider.SimulatorName))
+
+		require.NoError(t, svc.ApplyProviderEvent(ctx, service.ProviderEvent{
+			ID:          "evt_old",
+			PaymentID:   payment.ID,
+			Status:      model.PaymentFailed,
+			DeclineCode: provider.DeclineInsufficientFunds,
+			Attempt:     1,
+		}))
+		stored, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPending, stored.Status)
+		processed, err := repo.IsWebhookEventProcessed("evt_old")
+		require.NoError(t, err)
+		assert.True(t, processed)
+	})
+}
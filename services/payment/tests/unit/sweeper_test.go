@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingSweeper(t *testing.T) {
+	ctx := context.Background()
+	later := func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	t.Run("payment paid at the provider is marked paid", func(t *testing.T) {
+		svc, repo, recorder := newRetryingService(t, service.RetryPolicy{}, 2)
+		svc.PendingTTL = time.Hour
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{OrderID: "order-1", UserID: "user-1", Amount: 10, Currency: "USD"})
+		require.NoError(t, err)
+		require.Equal(t, model.PaymentPending, payment.Status)
+
+		// nothing is stale yet
+		n, err := svc.SweepPendingPayments(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		n, err = svc.SweepPendingPayments(ctx, later())
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		paid, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPaid, paid.Status)
+
+		require.Equal(t, []string{"payment-events", "payment-status-updates"}, recorder.topics)
+		assert.Equal(t, "order-1", recorder.events[1]["order_id"])
+		assert.Equal(t, string(model.PaymentPaid), recorder.events[1]["status"])
+	})
+
+	t.Run("payment still open at the provider expires", func(t *testing.T) {
+		svc, repo, recorder := newRetryingService(t, service.RetryPolicy{}, 2)
+		svc.PendingTTL = time.Hour
+		// a challenge the customer never completed
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-2", UserID: "user-1", Amount: 10 + float64(provider.SimulatorChallengeCents)/100, Currency: "USD",
+		})
+		require.NoError(t, err)
+		repo.payments[payment.ID].Status = model.PaymentPending
+
+		_, err = svc.SweepPendingPayments(ctx, later())
+		require.NoError(t, err)
+		expired, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentExpired, expired.Status)
+		assert.Equal(t, "order-2", recorder.events[1]["order_id"])
+		assert.Equal(t, string(model.PaymentExpired), recorder.events[1]["status"])
+
+		// the session was closed, so the customer can no longer pay it
+		_, err = svc.ConfirmPayment(ctx, payment.ID)
+		assert.ErrorIs(t, err, service.ErrNotConfirmable)
+	})
+
+	t.Run("payment unknown to the provider expires", func(t *testing.T) {
+		svc, repo, _ := newRetryingService(t, service.RetryPolicy{}, 1)
+		svc.PendingTTL = time.Hour
+		payment := pendingPayment()
+		payment.Provider = provider.SimulatorName
+		payment.UpdatedAt = time.Now().Add(-3 * time.Hour)
+		repo.payments[payment.ID] = payment
+
+		n, err := svc.SweepPendingPayments(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		expired, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentExpired, expired.Status)
+	})
+
+	t.Run("claimed payments are skipped by concurrent sweepers", func(t *testing.T) {
+		payment := pendingPayment()
+		payment.UpdatedAt = time.Now().Add(-3 * time.Hour)
+		repo := newFakePaymentRepository(payment)
+		now := time.Now()
+
+		first, err := repo.ClaimStalePending(now.Add(-time.Hour), now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Len(t, first, 1)
+		second, err := repo.ClaimStalePending(now.Add(-time.Hour), now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, second)
+
+		// an abandoned claim becomes available once its lease runs out
+		third, err := repo.ClaimStalePending(now.Add(-time.Hour), now.Add(2*time.Minute), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Len(t, third, 1)
+	})
+
+	t.Run("zero TTL disables the sweeper", func(t *testing.T) {
+		payment := pendingPayment()
+		repo := newFakePaymentRepository(payment)
+		svc := service.New(repo, nil, provider.NewRegistry(provider.SimulatorName))
+		n, err := svc.SweepPendingPayments(ctx, time.Now().Add(24*time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
@@ -0,0 +1,214 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/wallet"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWalletService returns a service with the simulator and the wallet
+// provider; the producer expects publishes messages
+func newWalletService(t *testing.T, publishes int) (*service.PaymentService, *fakePaymentRepository, *topicRecorder) {
+	recorder := &topicRecorder{}
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < publishes; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recorder.check)
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	repo := newFakePaymentRepository()
+	providers := provider.NewRegistry(provider.SimulatorName)
+	providers.Register(provider.NewSimulator())
+	providers.Register(wallet.NewProvider(repo))
+	return service.New(repo, kafka.NewProducerWithClient(producer), providers), repo, recorder
+}
+
+// grant credits user-1 with goodwill credit
+func grant(t *testing.T, svc *service.PaymentService, amount float64, key string) {
+	_, err := svc.GrantCredit(context.Background(), "user-1", "usd", amount, model.WalletGoodwill, "late delivery", "admin-1", key)
+	require.NoError(t, err)
+}
+
+func TestGrantCredit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("credit is added once per key and published", func(t *testing.T) {
+		svc, repo, recorder := newWalletService(t, 1)
+		txn, err := svc.GrantCredit(ctx, "user-1", "usd", 25, model.WalletReturn, "return of order-1", "admin-1", "rma-1")
+		require.NoError(t, err)
+		assert.Equal(t, "USD", txn.Currency)
+		assert.Equal(t, 25.0, txn.BalanceAfter)
+
+		replay, err := svc.GrantCredit(ctx, "user-1", "usd", 25, model.WalletReturn, "return of order-1", "admin-1", "rma-1")
+		require.NoError(t, err)
+		assert.Equal(t, txn.ID, replay.ID)
+		assert.Equal(t, 25.0, repo.balance("user-1", "USD"))
+
+		assert.Equal(t, "wallet-events", recorder.topics[0])
+		assert.Equal(t, "wallet.credited", recorder.events[0]["event"])
+		assert.Equal(t, "return", recorder.events[0]["reason"])
+		assert.Equal(t, 25.0, recorder.events[0]["balance"])
+
+		_, err = svc.GrantCredit(ctx, "user-1", "usd", 30, model.WalletReturn, "", "admin-1", "rma-1")
+		assert.ErrorIs(t, err, repository.ErrIdempotencyKeyReused)
+	})
+
+	t.Run("invalid grants are rejected", func(t *testing.T) {
+		svc, _, _ := newWalletService(t, 0)
+		_, err := svc.GrantCredit(ctx, "user-1", "USD", 10, model.WalletPayment, "", "admin-1", "k")
+		assert.ErrorIs(t, err, service.ErrInvalidCreditReason)
+		_, err = svc.GrantCredit(ctx, "user-1", "USD", -10, model.WalletGoodwill, "", "admin-1", "k")
+		assert.ErrorIs(t, err, service.ErrInvalidAmount)
+		_, err = svc.GrantCredit(ctx, "user-1", "USD", 10, model.WalletGoodwill, "", "admin-1", "")
+		assert.ErrorIs(t, err, service.ErrIdempotencyKeyRequired)
+	})
+
+	t.Run("balances and history", func(t *testing.T) {
+		svc, _, _ := newWalletService(t, 3)
+		grant(t, svc, 10, "g-1")
+		grant(t, svc, 5, "g-2")
+		_, err := svc.GrantCredit(ctx, "user-1", "EUR", 7, model.WalletGoodwill, "", "admin-1", "g-3")
+		require.NoError(t, err)
+
+		wallets, err := svc.GetWalletBalances("user-1", "")
+		require.NoError(t, err)
+		require.Len(t, wallets, 2)
+		assert.Equal(t, "EUR", wallets[0].Currency)
+		assert.Equal(t, 15.0, wallets[1].Balance)
+
+		empty, err := svc.GetWalletBalances("user-1", "gbp")
+		require.NoError(t, err)
+		assert.Equal(t, []model.Wallet{{UserID: "user-1", Currency: "GBP"}}, empty)
+
+		page, next, err := svc.ListWalletTransactions("user-1", "usd", 1, "")
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, 5.0, page[0].Amount)
+		require.NotEmpty(t, next)
+		page, next, err = svc.ListWalletTransactions("user-1", "usd", 1, next)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, 10.0, page[0].Amount)
+		assert.Empty(t, next)
+	})
+}
+
+func TestWalletPayments(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("store credit pays the whole amount", func(t *testing.T) {
+		svc, repo, _ := newWalletService(t, 4)
+		grant(t, svc, 50, "g-1")
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 30, Currency: "USD", Provider: wallet.ProviderName,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPaid, payment.Status)
+		assert.Equal(t, 30.0, payment.CapturedAmount)
+		assert.Equal(t, 20.0, repo.balance("user-1", "USD"))
+
+		refund, refunded, err := svc.RefundPayment(ctx, payment.ID, 10, "damaged", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, model.RefundSucceeded, refund.Status)
+		assert.Equal(t, model.PaymentPartiallyRefunded, refunded.Status)
+		assert.Equal(t, 30.0, repo.balance("user-1", "USD"))
+	})
+
+	t.Run("insufficient credit declines the payment", func(t *testing.T) {
+		svc, repo, _ := newWalletService(t, 3)
+		grant(t, svc, 5, "g-1")
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 30, Currency: "USD", Provider: wallet.ProviderName,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentFailed, payment.Status)
+		assert.Equal(t, provider.DeclineInsufficientFunds, payment.DeclineCode)
+		assert.Equal(t, 5.0, repo.balance("user-1", "USD"))
+	})
+
+	t.Run("store credit combined with a card", func(t *testing.T) {
+		svc, repo, recorder := newWalletService(t, 4)
+		grant(t, svc, 20, "g-1")
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 50, Currency: "USD", WalletAmount: 20,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.PaymentPending, payment.Status)
+		assert.Equal(t, 30.0, payment.ProviderAmount())
+		assert.Zero(t, repo.balance("user-1", "USD"))
+		assert.Equal(t, 20.0, recorder.events[1]["wallet_amount"])
+
+		require.NoError(t, svc.UpdateStatus(ctx, payment.ID, model.PaymentPaid, "paid"))
+		paid, err := repo.FindByID(payment.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 30.0, paid.CapturedAmount, "the card is charged the remainder")
+
+		// a full refund returns the card part to the card and the credit to the wallet
+		refund, refunded, err := svc.RefundPayment(ctx, payment.ID, 0, "cancelled", "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, 30.0, refund.Amount)
+		assert.Equal(t, model.PaymentRefunded, refunded.Status)
+		assert.Equal(t, 20.0, repo.balance("user-1", "USD"))
+	})
+
+	t.Run("credit is returned once when the payment does not go through", func(t *testing.T) {
+		svc, repo, _ := newWalletService(t, 3)
+		grant(t, svc, 20, "g-1")
+		payment, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 50, Currency: "USD", WalletAmount: 15, CaptureMethod: model.CaptureManual,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 5.0, repo.balance("user-1", "USD"))
+
+		_, err = svc.VoidPayment(ctx, payment.ID, "customer left")
+		require.NoError(t, err)
+		assert.Equal(t, 20.0, repo.balance("user-1", "USD"))
+		_, err = svc.VoidPayment(ctx, payment.ID, "again")
+		assert.ErrorIs(t, err, service.ErrNotVoidable)
+		assert.Equal(t, 20.0, repo.balance("user-1", "USD"))
+	})
+
+	t.Run("invalid store credit is rejected before anything is charged", func(t *testing.T) {
+		svc, repo, _ := newWalletService(t, 1)
+		grant(t, svc, 10, "g-1")
+		for name, req := range map[string]service.InitiateRequest{
+			"credit above balance": {Amount: 50, WalletAmount: 15},
+			"credit covers it all": {Amount: 10, WalletAmount: 10},
+			"negative credit":      {Amount: 10, WalletAmount: -1},
+			"wallet provider":      {Amount: 10, WalletAmount: 5, Provider: wallet.ProviderName},
+		} {
+			req.OrderID, req.UserID, req.Currency = "order-1", "user-1", "USD"
+			_, err := svc.InitiatePayment(ctx, req)
+			assert.Error(t, err, name)
+		}
+		_, err := svc.InitiatePayment(ctx, service.InitiateRequest{OrderID: "order-1", UserID: "user-1", Amount: 50, Currency: "USD", WalletAmount: 15})
+		assert.ErrorIs(t, err, repository.ErrInsufficientCredit)
+		assert.Equal(t, 10.0, repo.balance("user-1", "USD"))
+		assert.Empty(t, repo.payments)
+	})
+
+	t.Run("wallet payments reconcile against their debits", func(t *testing.T) {
+		svc, _, _ := newWalletService(t, 3)
+		grant(t, svc, 50, "g-1")
+		_, err := svc.InitiatePayment(ctx, service.InitiateRequest{
+			OrderID: "order-1", UserID: "user-1", Amount: 30, Currency: "USD", Provider: wallet.ProviderName,
+		})
+		require.NoError(t, err)
+
+		report, err := svc.Reconcile(ctx, wallet.ProviderName, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Matched)
+		assert.Empty(t, report.Items)
+	})
+}
@@ -0,0 +1,204 @@
+package unit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/handler"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/service"
+
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v74/webhook"
+)
+
+const testWebhookSecret = "whsec_test_secret"
+
+// newWebhookServer wires the webhook handler to a fake repository and a mock
+// producer that expects exactly that many published messages
+func newWebhookServer(t *testing.T, repo *fakePaymentRepository, publishes int) http.Handler {
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < publishes; i++ {
+		producer.ExpectSendMessageAndSucceed()
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	svc := service.New(repo, kafka.NewProducerWithClient(producer), provider.NewRegistry(provider.SimulatorName))
+	return handler.NewStripeWebhookHandler(svc, testWebhookSecret)
+}
+
+// deliver posts a fixture signed with secret, the way Stripe would
+func deliver(t *testing.T, h http.Handler, fixture, secret string) int {
+	payload, err := os.ReadFile(filepath.Join("testdata", fixture))
+	require.NoError(t, err)
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: secret})
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", bytes.NewReader(signed.Payload))
+	req.Header.Set("Stripe-Signature", signed.Header)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func pendingPayment() *model.Payment {
+	return &model.Payment{
+		ID:              "pay-1",
+		OrderID:         "order-1",
+		UserID:          "user-1",
+		Amount:          49.99,
+		Currency:        "USD",
+		Status:          model.PaymentPending,
+		Provider:        provider.StripeName,
+		StripeSessionID: "cs_test_123",
+	}
+}
+
+func TestStripeWebhook(t *testing.T) {
+	t.Run("checkout completed marks the payment paid", func(t *testing.T) {
+		repo := newFakePaymentRepository(pendingPayment())
+		h := newWebhookServer(t, repo, 1)
+
+		assert.Equal(t, http.StatusOK, deliver(t, h, "checkout_session_completed.json", testWebhookSecret))
+		p, _ := repo.FindByID("pay-1")
+		assert.Equal(t, model.PaymentPaid, p.Status)
+		assert.Equal(t, "pi_test_123", p.PaymentIntentID)
+	})
+
+	t.Run("redelivered event is applied once", func(t *testing.T) {
+		repo := newFakePaymentRepository(pendingPayment())
+		h := newWebhookServer(t, repo, 1)
+
+		assert.Equal(t, http.StatusOK, deliver(t, h, "checkout_session_completed.json", testWebhookSecret))
+		assert.Equal(t, http.StatusOK, deliver(t, h, "checkout_session_completed.json", testWebhookSecret))
+		assert.Len(t, repo.events, 1)
+	})
+
+	t.Run("intent succeeded after checkout completed publishes nothing", func(t *testing.T) {
+		repo := newFakePaymentRepository(pendingPayment())
+		h := newWebhookServer(t, repo, 1)
+
+		assert.Equal(t, http.StatusOK, deliver(t, h, "checkout_session_completed.json", testWebhookSecret))
+		assert.Equal(t, http.StatusOK, deliver(t, h, "payment_intent_succeeded.json", testWebhookSecret))
+		p, _ := repo.FindByID("pay-1")
+		assert.Equal(t, model.PaymentPaid, p.Status)
+	})
+
+	t.Run("payment failed is matched through metadata", func(t *testing.T) {
+		repo := newFakePaymentRepository(pendingPayment())
+		h := newWebhookServer(t, repo, 1)
+
+		assert.Equal(t, http.StatusOK, deliver(t, h, "payment_intent_payment_failed.json", testWebhookSecret))
+		p, _ := repo.FindByID("pay-1")
+		assert.Equal(t, model.PaymentFailed, p.Status)
+		assert.Equal(t, "Your card was declined.", p.Message)
+	})
+
+	t.Run("late failure does not overwrite a paid payment", func(t *testing.T) {
+		paid := pendingPayment()
+		paid.Status = model.PaymentPaid
+		paid.PaymentIntentID = "pi_test_123"
+		repo := newFakePaymentRepository(paid)
+		h := newWebhookServer(t, repo, 0)
+
+		assert.Equal(t, http.StatusOK, deliver(t, h, "payment_intent_payment_failed.json", testWebhookSecret))
+		p, _ := repo.FindByID("pay-1")
+		assert.Equal(t, model.PaymentPaid, p.Status)
+	})
+
+	t.Run("charge refunded records the dashboard refund", func(t *testing.T) {
+		paid := pendingPayment()
+		paid.Status = model.PaymentPaid
+		paid.PaymentIntentID = "pi_test_123"
+		repo := newFakePaymentRepository(paid)
+		h := newWebhookServer(t, repo, 1)
+
+		assert.Equal(t, http.StatusOK, deliver(t, h, "charge_refunded.json", testWebhookSecret))
+		p, _ := repo.FindByID("pay-1")
+		assert.Equal(t, model.PaymentRefunded, p.Status)
+		assert.Equal(t, 49.99, p.RefundedAmount)
+		require.Len(t, repo.refunds, 1)
+		for _, refund := range repo.refunds {
+			assert.Equal(t, model.RefundSucceeded, refund.Status)
+			assert.Equal(t, "re_test_123", refund.ProviderRefundID)
+			assert.Equal(t, "provider_re_test_123", refund.IdempotencyKey)
+		}
+	})
+
+	t.Run("refund updated completes a pending refund", func(t *testing.T) {
+		paid := pendingPayment()
+		paid.Status = model.PaymentPaid
+		paid.PaymentIntentID = "pi_test_123"
+		repo := newFakePaymentRepository(paid)
+		_, err := repo.CreateRefund(&model.Refund{ID: "refund-1", PaymentID: "pay-1", IdempotencyKey: "key-1", Amount: 10, Status: model.RefundPending})
+		require.NoError(t, err)
+		h := newWebhookServer(t, repo, 1)
+
+		assert.Equal(t, http.StatusOK, deliver(t, h, "refund_updated.json", testWebhookSecret))
+		refund, err := repo.FindRefund("refund-1")
+		require.NoError(t, err)
+		assert.Equal(t, model.RefundSucceeded, refund.Status)
+		assert.Equal(t, "re_test_456", refund.ProviderRefundID)
+		p, _ := repo.FindByID("pay-1")
+		assert.Equal(t, model.PaymentPartiallyRefunded, p.Status)
+		assert.Equal(t, 10.0, p.RefundedAmount)
+		assert.Len(t, repo.refunds, 1, "the refund is not recorded twice")
+	})
+
+	t.Run("dispute events create and close a dispute", func(t *testing.T) {
+		paid := pendingPayment()
+		paid.Status = model.PaymentPaid
+		paid.PaymentIntentID = "pi_test_123"
+		repo := newFakePaymentRepository(paid)
+		h := newWebhookServer(t, repo, 2)
+
+		assert.Equal(t, http.StatusOK, deliver(t, h, "charge_dispute_created.json", testWebhookSecret))
+		assert.Equal(t, http.StatusOK, deliver(t, h, "charge_dispute_closed.json", testWebhookSecret))
+		assert.Equal(t, http.StatusOK, deliver(t, h, "charge_dispute_created.json", testWebhookSecret))
+
+		d, err := repo.FindDisputeByProviderID("dp_test_123")
+		require.NoError(t, err)
+		assert.Equal(t, "pay-1", d.PaymentID)
+		assert.Equal(t, "fraudulent", d.Reason)
+		assert.Equal(t, 49.99, d.Amount)
+		assert.Equal(t, "USD", d.Currency)
+		assert.Equal(t, model.DisputeLost, d.Status)
+		require.NotNil(t, d.EvidenceDueBy)
+		assert.Equal(t, int64(1767225600), d.EvidenceDueBy.Unix())
+		p, _ := repo.FindByID("pay-1")
+		assert.Equal(t, model.PaymentPaid, p.Status, "a dispute does not change the payment status")
+	})
+
+	t.Run("invalid signature is rejected", func(t *testing.T) {
+		repo := newFakePaymentRepository(pendingPayment())
+		h := newWebhookServer(t, repo, 0)
+
+		assert.Equal(t, http.StatusBadRequest, deliver(t, h, "checkout_session_completed.json", "whsec_wrong"))
+		p, _ := repo.FindByID("pay-1")
+		assert.Equal(t, model.PaymentPending, p.Status)
+		assert.Empty(t, repo.events)
+	})
+
+	t.Run("unknown payment asks stripe to retry", func(t *testing.T) {
+		repo := newFakePaymentRepository()
+		h := newWebhookServer(t, repo, 0)
+
+		assert.Equal(t, http.StatusNotFound, deliver(t, h, "checkout_session_completed.json", testWebhookSecret))
+		assert.Empty(t, repo.events)
+	})
+
+	t.Run("unhandled event types are acknowledged", func(t *testing.T) {
+		repo := newFakePaymentRepository(pendingPayment())
+		h := newWebhookServer(t, repo, 0)
+
+		assert.Equal(t, http.StatusOK, deliver(t, h, "customer_created.json", testWebhookSecret))
+		assert.Empty(t, repo.events)
+	})
+}
@@ -0,0 +1,219 @@
+// Package wallet lets customers pay with store credit through the same
+// provider interface as card processors
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SabinGhost19/go-micro-payment/configs/utils"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/model"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/provider"
+	"github.com/SabinGhost19/go-micro-payment/services/payment/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProviderName is the provider name of payments made entirely with store credit
+const ProviderName = "wallet"
+
+// sessionPrefix starts the session IDs, which double as the idempotency keys
+// of the debits; credit applied next to a card is debited under other keys
+const sessionPrefix = "wallet_"
+
+// Store is the part of the payment repository that holds wallets
+type Store interface {
+	PostWalletTransaction(txn *model.WalletTransaction) (*model.WalletTransaction, error)
+	FindWalletTransaction(idempotencyKey string) (*model.WalletTransaction, error)
+	ListWalletTransactions(filter repository.WalletFilter, after *repository.PageCursor, limit int) ([]model.WalletTransaction, error)
+}
+
+// Provider charges payments to the customer's wallet. A session is the debit
+// of one attempt and settles at once: it exists when the debit went through,
+// and an attempt without a debit was declined for insufficient credit.
+type Provider struct {
+	store Store
+}
+
+func NewProvider(store Store) *Provider {
+	return &Provider{store: store}
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// CreateSession debits the amount from the user's wallet
+func (p *Provider) CreateSession(ctx context.Context, req provider.SessionRequest) (*provider.Session, error) {
+	if req.ManualCapture {
+		return nil, errors.New("wallet: store credit cannot be authorized for a later capture")
+	}
+	if req.PaymentMethod != "" {
+		return nil, errors.New("wallet: saved payment methods are not supported")
+	}
+	key := fmt.Sprintf("%s%s_%d", sessionPrefix, req.PaymentID, req.Attempt)
+	txn, err := p.store.PostWalletTransaction(&model.WalletTransaction{
+		ID:             utils.GenerateUUID(),
+		UserID:         req.UserID,
+		Currency:       strings.ToUpper(req.Currency),
+		Amount:         -req.Amount,
+		Reason:         model.WalletPayment,
+		Note:           "order " + req.OrderID,
+		PaymentID:      req.PaymentID,
+		IdempotencyKey: key,
+	})
+	if errors.Is(err, repository.ErrInsufficientCredit) {
+		return declined(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("wallet: %w", err)
+	}
+	return paid(txn), nil
+}
+
+// Capture is not supported: wallet payments are charged when created
+func (p *Provider) Capture(ctx context.Context, sessionID string, amount float64) (*provider.Session, error) {
+	return nil, errors.New("wallet: payments are charged when created")
+}
+
+// Cancel cancels a declined attempt; paid ones must be refunded
+func (p *Provider) Cancel(ctx context.Context, sessionID string) (*provider.Session, error) {
+	sess, err := p.FetchStatus(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if sess.Status == provider.StatusSucceeded {
+		return nil, errors.New("wallet: cannot cancel a paid session")
+	}
+	sess.Status = provider.StatusCanceled
+	return sess, nil
+}
+
+// Refund returns part or all of a debit to the wallet it came from
+func (p *Provider) Refund(ctx context.Context, sessionID string, amount float64, reason, idempotencyKey string) (*provider.Refund, error) {
+	debit, err := p.debit(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 || amount > -debit.Amount {
+		return nil, fmt.Errorf("wallet: refund %.2f exceeds the payment of %.2f", amount, -debit.Amount)
+	}
+	txn, err := p.store.PostWalletTransaction(&model.WalletTransaction{
+		ID:             utils.GenerateUUID(),
+		UserID:         debit.UserID,
+		Currency:       debit.Currency,
+		Amount:         amount,
+		Reason:         model.WalletRefund,
+		Note:           reason,
+		PaymentID:      debit.PaymentID,
+		IdempotencyKey: "refund_" + idempotencyKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("wallet: %w", err)
+	}
+	return &provider.Refund{ID: txn.ID, RefundID: idempotencyKey, Amount: txn.Amount, Status: provider.StatusSucceeded}, nil
+}
+
+// FetchRefund returns the credit a refund posted back to the wallet
+func (p *Provider) FetchRefund(ctx context.Context, sessionID, idempotencyKey string) (*provider.Refund, error) {
+	txn, err := p.store.FindWalletTransaction("refund_" + idempotencyKey)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, provider.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("wallet: %w", err)
+	}
+	return &provider.Refund{ID: txn.ID, RefundID: idempotencyKey, Amount: txn.Amount, Status: provider.StatusSucceeded}, nil
+}
+
+// FetchStatus reports a session as paid when its debit exists, and as
+// declined otherwise
+func (p *Provider) FetchStatus(ctx context.Context, sessionID string) (*provider.Session, error) {
+	debit, err := p.debit(sessionID)
+	if errors.Is(err, provider.ErrNotFound) {
+		return declined(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return paid(debit), nil
+}
+
+// Confirm is FetchStatus: wallet payments never require action
+func (p *Provider) Confirm(ctx context.Context, sessionID string) (*provider.Session, error) {
+	return p.FetchStatus(ctx, sessionID)
+}
+
+// ListTransactions pages through the payment debits created in [from, to),
+// newest first
+func (p *Provider) ListTransactions(ctx context.Context, from, to time.Time, cursor string, limit int) (*provider.TransactionPage, error) {
+	var after *repository.PageCursor
+	if cursor != "" {
+		nanos, id, ok := strings.Cut(cursor, "|")
+		n, err := strconv.ParseInt(nanos, 10, 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("wallet: invalid cursor %q", cursor)
+		}
+		after = &repository.PageCursor{CreatedAt: time.Unix(0, n), ID: id}
+	}
+	filter := repository.WalletFilter{Reason: model.WalletPayment, CreatedFrom: from, CreatedTo: to}
+	txns, err := p.store.ListWalletTransactions(filter, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("wallet: %w", err)
+	}
+	page := &provider.TransactionPage{}
+	if len(txns) > limit {
+		txns = txns[:limit]
+		last := txns[limit-1]
+		page.NextCursor = strconv.FormatInt(last.CreatedAt.UnixNano(), 10) + "|" + last.ID
+	}
+	for _, txn := range txns {
+		if !strings.HasPrefix(txn.IdempotencyKey, sessionPrefix) {
+			// credit combined with a card belongs to that card's payment
+			continue
+		}
+		page.Transactions = append(page.Transactions, provider.Transaction{
+			SessionID: txn.IdempotencyKey,
+			PaymentID: txn.PaymentID,
+			Status:    provider.StatusSucceeded,
+			Amount:    -txn.Amount,
+			Currency:  txn.Currency,
+			CreatedAt: txn.CreatedAt,
+		})
+	}
+	return page, nil
+}
+
+// debit returns the payment debit of a session
+func (p *Provider) debit(sessionID string) (*model.WalletTransaction, error) {
+	if !strings.HasPrefix(sessionID, sessionPrefix) {
+		return nil, provider.ErrNotFound
+	}
+	txn, err := p.store.FindWalletTransaction(sessionID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && txn.Reason != model.WalletPayment) {
+		return nil, provider.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("wallet: %w", err)
+	}
+	return txn, nil
+}
+
+// paid is the session of a debit
+func paid(debit *model.WalletTransaction) *provider.Session {
+	return &provider.Session{
+		ID:      debit.IdempotencyKey,
+		Status:  provider.StatusSucceeded,
+		Amount:  -debit.Amount,
+		Message: "paid with store credit",
+	}
+}
+
+// declined is the session of an attempt the wallet could not cover; it has no
+// ID, as nothing was recorded for it
+func declined() *provider.Session {
+	return &provider.Session{
+		Status:      provider.StatusFailed,
+		Message:     "insufficient store credit",
+		DeclineCode: provider.DeclineInsufficientFunds,
+	}
+}
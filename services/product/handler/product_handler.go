@@ -34,3 +34,7 @@ func (h *ProductHandler) UpdateProduct(ctx context.Context, req *productpb.Updat
 func (h *ProductHandler) DeleteProduct(ctx context.Context, req *productpb.DeleteProductRequest) (*productpb.DeleteProductResponse, error) {
 	return h.svc.DeleteProduct(ctx, req)
 }
+
+func (h *ProductHandler) ReconcileStock(ctx context.Context, req *productpb.ReconcileStockRequest) (*productpb.ReconcileStockResponse, error) {
+	return h.svc.ReconcileStock(ctx, req)
+}
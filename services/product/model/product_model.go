@@ -7,7 +7,9 @@ type Product struct {
 	Name        string    `gorm:"type:varchar(255);not null"`
 	Description string    `gorm:"type:text"`
 	Price       float64   `gorm:"type:decimal(10,2);not null"`
-	Stock       int32     `gorm:"type:integer;not null"`
+	Stock       int32     `gorm:"type:integer;not null"` // cached from the Inventory Service, which owns stock
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
+
+	StockVersion int64 `gorm:"not null;default:0"` // inventory version of the cached stock
 }
@@ -11,8 +11,10 @@ type ProductRepository interface {
 	Create(ctx context.Context, p *model.Product) error
 	GetByID(ctx context.Context, id string) (*model.Product, error)
 	List(ctx context.Context, limit, offset int) ([]*model.Product, error)
+	ListAfter(ctx context.Context, afterID string, limit int) ([]*model.Product, error)
 	Update(ctx context.Context, p *model.Product) error
 	Delete(ctx context.Context, id string) error
+	SetStock(ctx context.Context, id string, stock int32, version int64) (bool, error)
 }
 
 type productRepo struct {
@@ -42,6 +44,18 @@ func (r *productRepo) List(ctx context.Context, limit, offset int) ([]*model.Pro
 	return products, err
 }
 
+// ListAfter returns up to limit products ordered by ID, starting after afterID
+func (r *productRepo) ListAfter(ctx context.Context, afterID string, limit int) ([]*model.Product, error) {
+	var products []*model.Product
+	query := r.db.WithContext(ctx).Order("id").Limit(limit)
+	if afterID != "" {
+		query = query.Where("id > ?", afterID)
+	}
+	err := query.Find(&products).Error
+	return products, err
+}
+
+// Update saves a product's details; its stock is only written by SetStock
 func (r *productRepo) Update(ctx context.Context, p *model.Product) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var existing model.Product
@@ -51,10 +65,20 @@ func (r *productRepo) Update(ctx context.Context, p *model.Product) error {
 			}
 			return err
 		}
-		return tx.Save(p).Error
+		return tx.Omit("stock", "stock_version").Save(p).Error
 	})
 }
 
 func (r *productRepo) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Product{}).Error
 }
+
+// SetStock caches the stock the Inventory Service reported for a product at
+// version, unless a later version is cached already. It reports whether the
+// cache changed.
+func (r *productRepo) SetStock(ctx context.Context, id string, stock int32, version int64) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&model.Product{}).
+		Where("id = ? AND stock_version <= ?", id, version).
+		Updates(map[string]interface{}{"stock": stock, "stock_version": version})
+	return res.RowsAffected == 1, res.Error
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/SabinGhost19/go-micro-payment/configs/utils"
 	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
 	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
 	"github.com/SabinGhost19/go-micro-payment/services/product/model"
 	"github.com/SabinGhost19/go-micro-payment/services/product/repository"
@@ -15,7 +16,7 @@ import (
 
 // InventoryGrpcClient defines the gRPC client interface for Inventory Service
 type InventoryGrpcClient interface {
-	UpdateStock(ctx context.Context, productID string, stockDelta int32) (int32, error)
+	CheckStock(ctx context.Context, productID string) (*inventorypb.CheckStockResponse, error)
 }
 
 // ProductService handles product-related business logic
@@ -31,7 +32,8 @@ func NewProductService(repo repository.ProductRepository, kafka *kafka.Producer,
 	return &ProductService{repo: repo, kafka: kafka, inventoryGrpc: inventoryGrpc}
 }
 
-// CreateProduct creates a new product
+// CreateProduct creates a new product. Its stock is handed to the Inventory
+// Service with the product.created event and owned by it from then on.
 func (s *ProductService) CreateProduct(ctx context.Context, req *productpb.CreateProductRequest) (*productpb.ProductResponse, error) {
 	if req.Name == "" || req.Price <= 0 {
 		return nil, status.Errorf(codes.InvalidArgument, "name and price are required")
@@ -142,13 +144,9 @@ func (s *ProductService) UpdateProduct(ctx context.Context, req *productpb.Updat
 	if req.Price > 0 {
 		p.Price = req.Price
 	}
-	if req.Stock != p.Stock {
-		// call Inventory Service to update stock
-		newStock, err := s.inventoryGrpc.UpdateStock(ctx, req.ProductId, req.Stock-p.Stock)
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to update stock: %v", err)
-		}
-		p.Stock = newStock
+	// stock is owned by the Inventory Service and only cached here
+	if req.Stock != 0 && req.Stock != p.Stock {
+		return nil, status.Errorf(codes.InvalidArgument, "stock is read-only; use the Inventory Service's UpdateStock")
 	}
 
 	if err := s.repo.Update(ctx, p); err != nil {
@@ -160,7 +158,6 @@ func (s *ProductService) UpdateProduct(ctx context.Context, req *productpb.Updat
 		"product_id": p.ID,
 		"name":       p.Name,
 		"price":      p.Price,
-		"stock":      p.Stock,
 		"status":     "updated",
 	}
 	if err := s.kafka.SendMessage(ctx, "product-events", p.ID, event); err != nil {
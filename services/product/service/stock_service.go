@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/IBM/sarama"
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
+	"github.com/SabinGhost19/go-micro-payment/services/product/model"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+)
+
+// reconcileBatchSize is how many products a reconciliation reads at a time
+const reconcileBatchSize = 100
+
+// ConsumeEvents listens for stock events to cache the stock the Inventory
+// Service owns
+func (s *ProductService) ConsumeEvents(ctx context.Context) error {
+	consumer, err := kafka.NewConsumer([]string{"kafka:9092"}, "product-service-group")
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	handler := &productEventHandler{service: s}
+	return consumer.Consume(ctx, []string{"stock-events"}, handler)
+}
+
+// productEventHandler implements Sarama ConsumerGroupHandler for stock events
+type productEventHandler struct {
+	service *ProductService
+}
+
+// Setup is called when the consumer group session starts
+func (h *productEventHandler) Setup(_ sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup is called when the consumer group session ends
+func (h *productEventHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim processes stock event messages
+func (h *productEventHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		h.service.HandleStockEvent(context.Background(), msg.Value)
+		session.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+// HandleStockEvent caches the new stock of a stock.updated event. Events of
+// a version older than the cached one arrived late and are ignored.
+func (s *ProductService) HandleStockEvent(ctx context.Context, value []byte) {
+	var event struct {
+		Event     string `json:"event"`
+		ProductID string `json:"product_id"`
+		NewStock  int32  `json:"new_stock"`
+		Version   int64  `json:"version"`
+	}
+	if err := json.Unmarshal(value, &event); err != nil {
+		log.Printf("failed to unmarshal stock event: %v", err)
+		return
+	}
+	if event.Event != "stock.updated" {
+		return
+	}
+	if _, err := s.repo.SetStock(ctx, event.ProductID, event.NewStock, event.Version); err != nil {
+		log.Printf("failed to cache stock of product %s: %v", event.ProductID, err)
+	}
+}
+
+// ReconcileStock compares the cached stock of one or every product with the
+// Inventory Service and repairs what diverged: a different stock is replaced
+// by the inventory's, and a product the inventory does not know or has
+// archived is published again so the inventory syncs it
+func (s *ProductService) ReconcileStock(ctx context.Context, req *productpb.ReconcileStockRequest) (*productpb.ReconcileStockResponse, error) {
+	resp := &productpb.ReconcileStockResponse{}
+	reconcile := func(products ...*model.Product) error {
+		for _, p := range products {
+			divergence, err := s.reconcileStock(ctx, p, req.DryRun)
+			if err != nil {
+				return err
+			}
+			resp.Checked++
+			if divergence != nil {
+				resp.Divergences = append(resp.Divergences, divergence)
+			}
+		}
+		return nil
+	}
+
+	if req.ProductId != "" {
+		p, err := s.repo.GetByID(ctx, req.ProductId)
+		if err != nil {
+			return nil, status.Errorf(codes.NotFound, "product not found: %v", err)
+		}
+		if err := reconcile(p); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+	after := ""
+	for {
+		products, err := s.repo.ListAfter(ctx, after, reconcileBatchSize)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to list products: %v", err)
+		}
+		if err := reconcile(products...); err != nil {
+			return nil, err
+		}
+		if len(products) < reconcileBatchSize {
+			return resp, nil
+		}
+		after = products[len(products)-1].ID
+	}
+}
+
+// reconcileStock returns how a product diverged from the Inventory Service,
+// repaired unless dryRun, or nil when it did not
+func (s *ProductService) reconcileStock(ctx context.Context, p *model.Product, dryRun bool) (*productpb.StockDivergence, error) {
+	stock, err := s.inventoryGrpc.CheckStock(ctx, p.ID)
+	divergence := &productpb.StockDivergence{ProductId: p.ID, CachedStock: p.Stock}
+	switch {
+	case status.Code(err) == codes.NotFound:
+		divergence.Kind = "missing"
+	case err != nil:
+		return nil, status.Errorf(codes.Unavailable, "failed to check stock of product %s: %v", p.ID, err)
+	case stock.Archived:
+		divergence.Kind, divergence.InventoryStock = "archived", stock.OnHand
+	case stock.OnHand != p.Stock:
+		divergence.Kind, divergence.InventoryStock = "stock", stock.OnHand
+	default:
+		return nil, nil
+	}
+	if dryRun {
+		return divergence, nil
+	}
+
+	if divergence.Kind == "stock" {
+		divergence.Repaired, err = s.repo.SetStock(ctx, p.ID, stock.OnHand, stock.Version)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to cache stock of product %s: %v", p.ID, err)
+		}
+		return divergence, nil
+	}
+	// the inventory missed the product's events; publish product.created
+	// again, which seeds a missing product with the cached stock and restores
+	// an archived one
+	event := map[string]interface{}{
+		"product_id": p.ID,
+		"name":       p.Name,
+		"price":      p.Price,
+		"stock":      p.Stock,
+		"status":     "created",
+	}
+	if err := s.kafka.SendMessage(ctx, "product-events", p.ID, event); err != nil {
+		log.Printf("failed to publish product.created event: %v", err)
+		return divergence, nil
+	}
+	divergence.Repaired = true
+	return divergence, nil
+}
@@ -0,0 +1,190 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/SabinGhost19/go-micro-payment/internal/kafka"
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	"github.com/SabinGhost19/go-micro-payment/services/product/model"
+	"github.com/SabinGhost19/go-micro-payment/services/product/service"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeProductRepository is an in-memory ProductRepository shared by the
+// product tests
+type fakeProductRepository struct {
+	mu       sync.Mutex
+	products map[string]*model.Product
+	pages    []string // the afterID of every ListAfter call
+}
+
+func newFakeProductRepository(products ...model.Product) *fakeProductRepository {
+	r := &fakeProductRepository{products: make(map[string]*model.Product)}
+	for i := range products {
+		p := products[i]
+		r.products[p.ID] = &p
+	}
+	return r
+}
+
+func (r *fakeProductRepository) Create(_ context.Context, p *model.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *p
+	r.products[p.ID] = &stored
+	return nil
+}
+
+func (r *fakeProductRepository) GetByID(_ context.Context, id string) (*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok {
+		return nil, errors.New("product not found")
+	}
+	out := *p
+	return &out, nil
+}
+
+func (r *fakeProductRepository) List(_ context.Context, limit, offset int) ([]*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.products))
+	for id := range r.products {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var out []*model.Product
+	for i := offset; i < len(ids) && len(out) < limit; i++ {
+		p := *r.products[ids[i]]
+		out = append(out, &p)
+	}
+	return out, nil
+}
+
+func (r *fakeProductRepository) ListAfter(_ context.Context, afterID string, limit int) ([]*model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pages = append(r.pages, afterID)
+	ids := make([]string, 0, len(r.products))
+	for id := range r.products {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	var out []*model.Product
+	for _, id := range ids {
+		if len(out) == limit {
+			break
+		}
+		p := *r.products[id]
+		out = append(out, &p)
+	}
+	return out, nil
+}
+
+func (r *fakeProductRepository) Update(_ context.Context, p *model.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.products[p.ID]
+	if !ok {
+		return errors.New("product not found")
+	}
+	stored := *p
+	stored.Stock, stored.StockVersion = existing.Stock, existing.StockVersion
+	r.products[p.ID] = &stored
+	return nil
+}
+
+func (r *fakeProductRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.products, id)
+	return nil
+}
+
+func (r *fakeProductRepository) SetStock(_ context.Context, id string, stock int32, version int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok || p.StockVersion > version {
+		return false, nil
+	}
+	p.Stock, p.StockVersion = stock, version
+	return true, nil
+}
+
+// stock returns the cached stock and its version
+func (r *fakeProductRepository) stock(id string) (int32, int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := r.products[id]
+	return p.Stock, p.StockVersion
+}
+
+// fakeInventoryClient answers CheckStock from a fixed set of inventory
+// products; unknown products are NotFound
+type fakeInventoryClient struct {
+	stocks map[string]*inventorypb.CheckStockResponse
+	err    error // returned for every product when set
+}
+
+func (c fakeInventoryClient) CheckStock(_ context.Context, productID string) (*inventorypb.CheckStockResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	stock, ok := c.stocks[productID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "product %s not found", productID)
+	}
+	return stock, nil
+}
+
+// eventRecorder collects the decoded events a service published, in order
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []map[string]interface{}
+}
+
+func (r *eventRecorder) check(msg *sarama.ProducerMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if msg.Topic != "product-events" {
+		return errors.New("unexpected topic " + msg.Topic)
+	}
+	data, err := msg.Value.Encode()
+	if err != nil {
+		return err
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+// newProductService returns a service over products whose producer expects
+// exactly publishes messages
+func newProductService(t *testing.T, inventory fakeInventoryClient, publishes int, products ...model.Product) (*service.ProductService, *fakeProductRepository, *eventRecorder) {
+	recorder := &eventRecorder{}
+	producer := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < publishes; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(recorder.check)
+	}
+	t.Cleanup(func() { require.NoError(t, producer.Close()) })
+
+	repo := newFakeProductRepository(products...)
+	return service.NewProductService(repo, kafka.NewProducerWithClient(producer), inventory), repo, recorder
+}
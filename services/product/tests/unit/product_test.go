@@ -0,0 +1,57 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
+	"github.com/SabinGhost19/go-micro-payment/services/product/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const laptop = "prod-laptop"
+
+func TestUpdateProduct(t *testing.T) {
+	ctx := context.Background()
+	product := model.Product{ID: laptop, Name: "Laptop", Price: 999, Stock: 10, StockVersion: 3}
+
+	t.Run("stock change is rejected", func(t *testing.T) {
+		svc, repo, recorder := newProductService(t, fakeInventoryClient{}, 0, product)
+		_, err := svc.UpdateProduct(ctx, &productpb.UpdateProductRequest{ProductId: laptop, Name: "Gaming laptop", Stock: 12})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		stored, err := repo.GetByID(ctx, laptop)
+		require.NoError(t, err)
+		assert.Equal(t, "Laptop", stored.Name, "nothing was saved")
+		assert.Empty(t, recorder.events)
+	})
+
+	t.Run("details are updated and the cached stock is kept", func(t *testing.T) {
+		svc, repo, recorder := newProductService(t, fakeInventoryClient{}, 2, product)
+		// an omitted or unchanged stock is not a stock change
+		resp, err := svc.UpdateProduct(ctx, &productpb.UpdateProductRequest{ProductId: laptop, Price: 899})
+		require.NoError(t, err)
+		assert.Equal(t, 899.0, resp.Price)
+		resp, err = svc.UpdateProduct(ctx, &productpb.UpdateProductRequest{ProductId: laptop, Name: "Gaming laptop", Stock: 10})
+		require.NoError(t, err)
+		assert.Equal(t, "Gaming laptop", resp.Name)
+		assert.Equal(t, int32(10), resp.Stock)
+
+		stock, version := repo.stock(laptop)
+		assert.Equal(t, int32(10), stock)
+		assert.Equal(t, int64(3), version)
+		require.Len(t, recorder.events, 2)
+		assert.Equal(t, "updated", recorder.events[1]["status"])
+		assert.NotContains(t, recorder.events[1], "stock")
+	})
+
+	t.Run("unknown product", func(t *testing.T) {
+		svc, _, _ := newProductService(t, fakeInventoryClient{}, 0)
+		_, err := svc.UpdateProduct(ctx, &productpb.UpdateProductRequest{ProductId: laptop, Name: "Laptop"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,176 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	inventorypb "github.com/SabinGhost19/go-micro-payment/proto/inventory"
+	productpb "github.com/SabinGhost19/go-micro-payment/proto/product"
+	"github.com/SabinGhost19/go-micro-payment/services/product/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHandleStockEvent(t *testing.T) {
+	ctx := context.Background()
+	event := func(name string, stock int32, version int64) []byte {
+		return []byte(fmt.Sprintf(`{"event":%q,"product_id":%q,"new_stock":%d,"version":%d}`, name, laptop, stock, version))
+	}
+
+	tests := []struct {
+		name    string
+		value   []byte
+		stock   int32
+		version int64
+	}{
+		{name: "newer version is cached", value: event("stock.updated", 7, 4), stock: 7, version: 4},
+		{name: "same version is cached", value: event("stock.updated", 7, 3), stock: 7, version: 3},
+		{name: "out-of-order version is ignored", value: event("stock.updated", 12, 2), stock: 10, version: 3},
+		{name: "other events are ignored", value: event("stock.reserved", 7, 4), stock: 10, version: 3},
+		{name: "malformed event is ignored", value: []byte(`{"event":`), stock: 10, version: 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, repo, _ := newProductService(t, fakeInventoryClient{}, 0, model.Product{ID: laptop, Stock: 10, StockVersion: 3})
+			svc.HandleStockEvent(ctx, tc.value)
+			stock, version := repo.stock(laptop)
+			assert.Equal(t, tc.stock, stock)
+			assert.Equal(t, tc.version, version)
+		})
+	}
+
+	t.Run("late event after a newer one", func(t *testing.T) {
+		svc, repo, _ := newProductService(t, fakeInventoryClient{}, 0, model.Product{ID: laptop, Stock: 10, StockVersion: 3})
+		svc.HandleStockEvent(ctx, event("stock.updated", 5, 5))
+		svc.HandleStockEvent(ctx, event("stock.updated", 8, 4))
+		stock, version := repo.stock(laptop)
+		assert.Equal(t, int32(5), stock)
+		assert.Equal(t, int64(5), version)
+	})
+}
+
+func TestReconcileStock(t *testing.T) {
+	ctx := context.Background()
+	const (
+		mouse    = "prod-mouse"
+		keyboard = "prod-keyboard"
+		monitor  = "prod-monitor"
+	)
+	products := []model.Product{
+		{ID: laptop, Name: "Laptop", Price: 999, Stock: 10, StockVersion: 3},
+		{ID: mouse, Name: "Mouse", Price: 25, Stock: 40, StockVersion: 2},
+		{ID: keyboard, Name: "Keyboard", Price: 60, Stock: 5, StockVersion: 1},
+		{ID: monitor, Name: "Monitor", Price: 300, Stock: 8, StockVersion: 6},
+	}
+	inventory := fakeInventoryClient{stocks: map[string]*inventorypb.CheckStockResponse{
+		laptop:   {ProductId: laptop, OnHand: 10, Version: 3},
+		mouse:    {ProductId: mouse, OnHand: 35, Version: 4},
+		keyboard: {ProductId: keyboard, OnHand: 5, Version: 1, Archived: true},
+		// the monitor is missing
+	}}
+	kinds := func(resp *productpb.ReconcileStockResponse) map[string]*productpb.StockDivergence {
+		out := map[string]*productpb.StockDivergence{}
+		for _, d := range resp.Divergences {
+			out[d.ProductId] = d
+		}
+		return out
+	}
+
+	t.Run("dry run reports divergences without repairing them", func(t *testing.T) {
+		svc, repo, recorder := newProductService(t, inventory, 0, products...)
+		resp, err := svc.ReconcileStock(ctx, &productpb.ReconcileStockRequest{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, int32(4), resp.Checked)
+
+		got := kinds(resp)
+		require.Len(t, got, 3)
+		assert.Equal(t, &productpb.StockDivergence{ProductId: mouse, Kind: "stock", CachedStock: 40, InventoryStock: 35}, got[mouse])
+		assert.Equal(t, &productpb.StockDivergence{ProductId: keyboard, Kind: "archived", CachedStock: 5, InventoryStock: 5}, got[keyboard])
+		assert.Equal(t, &productpb.StockDivergence{ProductId: monitor, Kind: "missing", CachedStock: 8}, got[monitor])
+
+		stock, _ := repo.stock(mouse)
+		assert.Equal(t, int32(40), stock)
+		assert.Empty(t, recorder.events)
+	})
+
+	t.Run("repair caches the inventory stock and publishes missing and archived products again", func(t *testing.T) {
+		svc, repo, recorder := newProductService(t, inventory, 2, products...)
+		resp, err := svc.ReconcileStock(ctx, &productpb.ReconcileStockRequest{})
+		require.NoError(t, err)
+
+		got := kinds(resp)
+		require.Len(t, got, 3)
+		for _, d := range got {
+			assert.True(t, d.Repaired, d.ProductId)
+		}
+		stock, version := repo.stock(mouse)
+		assert.Equal(t, int32(35), stock)
+		assert.Equal(t, int64(4), version)
+
+		require.Len(t, recorder.events, 2)
+		published := map[string]map[string]interface{}{}
+		for _, event := range recorder.events {
+			published[event["product_id"].(string)] = event
+		}
+		require.Contains(t, published, keyboard)
+		require.Contains(t, published, monitor)
+		assert.Equal(t, "created", published[monitor]["status"])
+		assert.Equal(t, float64(8), published[monitor]["stock"])
+	})
+
+	t.Run("a newer cached stock is not overwritten", func(t *testing.T) {
+		stale := fakeInventoryClient{stocks: map[string]*inventorypb.CheckStockResponse{
+			laptop: {ProductId: laptop, OnHand: 12, Version: 2},
+		}}
+		svc, repo, _ := newProductService(t, stale, 0, products[0])
+		resp, err := svc.ReconcileStock(ctx, &productpb.ReconcileStockRequest{})
+		require.NoError(t, err)
+		require.Len(t, resp.Divergences, 1)
+		assert.False(t, resp.Divergences[0].Repaired)
+		stock, _ := repo.stock(laptop)
+		assert.Equal(t, int32(10), stock)
+	})
+
+	t.Run("one product", func(t *testing.T) {
+		svc, repo, _ := newProductService(t, inventory, 0, products...)
+		resp, err := svc.ReconcileStock(ctx, &productpb.ReconcileStockRequest{ProductId: mouse})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), resp.Checked)
+		require.Len(t, resp.Divergences, 1)
+		assert.Equal(t, "stock", resp.Divergences[0].Kind)
+		assert.Empty(t, repo.pages, "nothing was listed")
+
+		_, err = svc.ReconcileStock(ctx, &productpb.ReconcileStockRequest{ProductId: "prod-unknown"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("every page is checked", func(t *testing.T) {
+		var many []model.Product
+		stocks := map[string]*inventorypb.CheckStockResponse{}
+		for i := 0; i < 250; i++ {
+			id := fmt.Sprintf("prod-%03d", i)
+			many = append(many, model.Product{ID: id, Stock: 1})
+			stocks[id] = &inventorypb.CheckStockResponse{ProductId: id, OnHand: 1}
+		}
+		stocks["prod-249"].OnHand = 2
+		svc, repo, _ := newProductService(t, fakeInventoryClient{stocks: stocks}, 0, many...)
+
+		resp, err := svc.ReconcileStock(ctx, &productpb.ReconcileStockRequest{DryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, int32(250), resp.Checked)
+		require.Len(t, resp.Divergences, 1)
+		assert.Equal(t, "prod-249", resp.Divergences[0].ProductId)
+		assert.Equal(t, []string{"", "prod-099", "prod-199"}, repo.pages)
+	})
+
+	t.Run("inventory outage", func(t *testing.T) {
+		svc, _, _ := newProductService(t, fakeInventoryClient{err: errors.New("connection refused")}, 0, products...)
+		_, err := svc.ReconcileStock(ctx, &productpb.ReconcileStockRequest{})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}